@@ -8,7 +8,13 @@ import (
 	"monitoring/server"
 )
 
-var cfg = config.Load()
+var cfg = loadConfig()
+
+func loadConfig() config.Config {
+	cfg := config.Load()
+	cfg.Serverless = true
+	return cfg
+}
 
 func Handler(w http.ResponseWriter, r *http.Request) {
 	db, client := db.New(cfg)
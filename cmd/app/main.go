@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"log"
 	"monitoring/config"
 	"monitoring/db"
+	"monitoring/internal/persistence"
 	"monitoring/server"
 
 	_ "monitoring/docs"
@@ -32,6 +34,18 @@ func main() {
 	cfg := config.Load()
 	db, client := db.New(cfg)
 	defer client.Disconnect(context.Background())
+	if err := persistence.EnsureIndexes(context.Background(), db); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.StatsDAddr != "" {
+		go func() {
+			if err := server.ListenStatsD(context.Background(), cfg, db); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+	go server.EvaluateLogMetricAlerts(context.Background(), cfg, db)
+	go server.FailAbandonedExportJobs(context.Background(), db)
 	router := server.New(cfg, db)
 	router.Run(":" + cfg.APIPort)
 }
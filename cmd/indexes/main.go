@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"monitoring/config"
+	"monitoring/db"
+	"monitoring/internal/persistence"
+)
+
+// Creates the database indexes. The server does it on start, this command
+// is for deployments where it does not run as a long-lived process.
+func main() {
+	cfg := config.Load()
+	db, client := db.New(cfg)
+	defer client.Disconnect(context.Background())
+
+	if err := persistence.EnsureIndexes(context.Background(), db); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("indexes created")
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"monitoring/config"
+	"monitoring/db"
+	"monitoring/internal/persistence"
+)
+
+// Merges the log patterns of an app learned twice with the same template,
+// so the unique index of templates can be created.
+func main() {
+	cfg := config.Load()
+	db, client := db.New(cfg)
+	defer client.Disconnect(context.Background())
+
+	if err := persistence.MergeDuplicateLogPatterns(context.Background(), db); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("log patterns merged")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"monitoring/config"
+	"monitoring/db"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// Rebuilds the schema catalog of an app, or of all apps, from its stored
+// logs, like after changing how fields are walked.
+func main() {
+	appID := flag.String("app", "", "id of the app, all apps when empty")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, client := db.New(cfg)
+	defer client.Disconnect(context.Background())
+
+	script := scripts.NewRebuildLogSchemaScript(
+		persistence.NewAppRepo(db),
+		persistence.NewLogRepo(db),
+		persistence.NewLogSchemaRepo(db),
+	)
+	resp, err := script.Exec(context.Background(), scripts.RebuildLogSchemaReq{AppID: *appID})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("schema rebuilt for %d apps from %d logs", resp.Apps, resp.Logs)
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
+
+	"monitoring/internal/domain"
 )
 
 type Config struct {
@@ -21,6 +24,24 @@ type Config struct {
 	GithubClientSecret string
 	GoogleClientID     string
 	GoogleClientSecret string
+	// LogBroker delivers the logs to live tails: "memory" within the instance,
+	// or "mongo" across instances through change streams.
+	LogBroker string
+	// OpenAIModel writes the queries of the natural language search. The
+	// key is read from OPENAI_API_KEY.
+	OpenAIModel string
+	// QueryLimits bound the searches of the logs of every account, which
+	// the query policy of an account can narrow.
+	QueryLimits domain.QueryLimits
+	// StatsDAddr is the UDP address StatsD metrics are received on, like
+	// :8125, none when empty.
+	StatsDAddr string
+	// CronSecret authorizes the cron jobs, like the evaluation of the log
+	// metric alerts, which are refused when it is empty.
+	CronSecret string
+	// Serverless is set by the serverless entry, where nothing runs once a
+	// request is answered, so export jobs are not accepted.
+	Serverless bool
 }
 
 func Load() Config {
@@ -86,6 +107,33 @@ func Load() Config {
 		log.Fatal("GOOGLE_CLIENT_SECRET not configured")
 	}
 
+	logBroker, ok := os.LookupEnv("LOG_BROKER")
+	if !ok {
+		logBroker = "memory"
+	}
+
+	openAIModel, ok := os.LookupEnv("OPENAI_MODEL")
+	if !ok {
+		openAIModel = "gpt-4o-mini"
+	}
+
+	// The longest time range of a search, like 30d, is not limited by
+	// default.
+	queryMaxRange, _ := os.LookupEnv("QUERY_MAX_RANGE")
+
+	queryLimits, err := domain.NewQueryLimits(
+		queryMaxRange,
+		int64Env("QUERY_MAX_SCAN", 0),
+		int64Env("QUERY_MAX_TIME_MS", 60000),
+	)
+	if err != nil {
+		log.Fatal("invalid query limits: ", err)
+	}
+
+	statsDAddr, _ := os.LookupEnv("STATSD_ADDR")
+
+	cronSecret, _ := os.LookupEnv("CRON_SECRET")
+
 	return Config{
 		APIBaseURI:         APIBaseURI,
 		WebBaseURI:         webBaseURI,
@@ -99,5 +147,23 @@ func Load() Config {
 		GithubClientSecret: githubClientSecret,
 		GoogleClientID:     googleClientID,
 		GoogleClientSecret: googleClientSecret,
+		LogBroker:          logBroker,
+		OpenAIModel:        openAIModel,
+		QueryLimits:        queryLimits,
+		StatsDAddr:         statsDAddr,
+		CronSecret:         cronSecret,
+	}
+}
+
+func int64Env(key string, fallback int64) int64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalf("%s must be a number", key)
 	}
+	return number
 }
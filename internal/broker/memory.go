@@ -0,0 +1,148 @@
+// Package broker delivers newly ingested logs to live tails within a single
+// instance.
+package broker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.LogBroker = &MemoryLogBroker{}
+
+// pollWait is how long Next waits for a log before returning nil.
+const pollWait = time.Second
+
+// MemoryLogBroker keeps the last published logs in a ring buffer that every
+// subscriber reads at its own pace, so slow subscribers never hold back
+// ingestion. A subscriber that falls behind the whole buffer gets a gap.
+type MemoryLogBroker struct {
+	mu sync.Mutex
+	// epoch tells apart the cursors of this process from those of a previous
+	// one, whose sequence numbers mean nothing here.
+	epoch  string
+	logs   []domain.Log
+	next   uint64
+	notify chan struct{}
+}
+
+func NewMemoryLogBroker(capacity int) *MemoryLogBroker {
+	return &MemoryLogBroker{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		logs:   make([]domain.Log, capacity),
+		notify: make(chan struct{}),
+	}
+}
+
+func (b *MemoryLogBroker) Publish(logs []domain.Log) {
+	if len(logs) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, log := range logs {
+		b.logs[b.next%uint64(len(b.logs))] = log
+		b.next++
+	}
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+}
+
+// oldest is the sequence number of the oldest log still in the buffer.
+func (b *MemoryLogBroker) oldest() uint64 {
+	return b.next - min(b.next, uint64(len(b.logs)))
+}
+
+func (b *MemoryLogBroker) Subscribe(ctx context.Context, appIDs []domain.ID, cursor string) (domain.LogSubscription, error) {
+	apps := make(map[domain.ID]bool, len(appIDs))
+	for _, appID := range appIDs {
+		apps[appID] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &memoryLogSubscription{broker: b, apps: apps, seq: b.next}
+	if cursor == "" {
+		return sub, nil
+	}
+
+	epoch, rawSeq, ok := strings.Cut(cursor, ".")
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrLogBrokerInvalidCursor, cursor)
+	}
+
+	switch {
+	case epoch != b.epoch || seq > b.next:
+		sub.gap = true
+	case seq < b.oldest():
+		sub.seq = b.oldest()
+		sub.gap = true
+	default:
+		sub.seq = seq
+	}
+	return sub, nil
+}
+
+type memoryLogSubscription struct {
+	broker *MemoryLogBroker
+	apps   map[domain.ID]bool
+	// seq is the sequence number of the next log to read.
+	seq uint64
+	gap bool
+}
+
+func (s *memoryLogSubscription) cursor() string {
+	return fmt.Sprintf("%s.%d", s.broker.epoch, s.seq)
+}
+
+func (s *memoryLogSubscription) Next(ctx context.Context) (*domain.LogEvent, error) {
+	timer := time.NewTimer(pollWait)
+	defer timer.Stop()
+
+	for {
+		b := s.broker
+		b.mu.Lock()
+		if s.seq < b.oldest() {
+			s.seq = b.oldest()
+			s.gap = true
+		}
+		if s.gap {
+			s.gap = false
+			b.mu.Unlock()
+			return &domain.LogEvent{Cursor: s.cursor(), Gap: true}, nil
+		}
+
+		for s.seq < b.next {
+			log := b.logs[s.seq%uint64(len(b.logs))]
+			s.seq++
+			if s.apps[log.AppID()] {
+				b.mu.Unlock()
+				return &domain.LogEvent{Log: &log, Cursor: s.cursor()}, nil
+			}
+		}
+		notify := b.notify
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, nil
+		case <-notify:
+		}
+	}
+}
+
+func (s *memoryLogSubscription) Close(ctx context.Context) error {
+	return nil
+}
@@ -12,32 +12,35 @@ var (
 )
 
 type App struct {
-	id        ID
-	name      string
-	appKey    string
-	userID    ID
-	createdAt time.Time
+	id               ID
+	name             string
+	userID           ID
+	createdAt        time.Time
+	signingSecret    string
+	requireSignature bool
 }
 
 func NewApp(
 	id ID,
 	name string,
-	appKey string,
 	userID ID,
 	createdAt time.Time,
+	signingSecret string,
+	requireSignature bool,
 ) (*App, error) {
 	app := &App{
-		id:        id,
-		userID:    userID,
-		createdAt: createdAt,
+		id:            id,
+		userID:        userID,
+		createdAt:     createdAt,
+		signingSecret: signingSecret,
 	}
 
 	if err := app.ChangeName(name); err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrApp, err)
 	}
 
-	if err := app.ChangeAppKey(appKey); err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrApp, err)
+	if err := app.ChangeRequireSignature(requireSignature); err != nil {
+		return nil, err
 	}
 	return app, nil
 }
@@ -50,10 +53,6 @@ func (a *App) Name() string {
 	return a.name
 }
 
-func (a *App) AppKey() string {
-	return a.appKey
-}
-
 func (a *App) UserID() ID {
 	return a.userID
 }
@@ -62,6 +61,15 @@ func (a *App) CreatedAt() time.Time {
 	return a.createdAt
 }
 
+// SigningSecret is the secret shared with the app to sign ingestion requests.
+func (a *App) SigningSecret() string {
+	return a.signingSecret
+}
+
+func (a *App) RequireSignature() bool {
+	return a.requireSignature
+}
+
 func (a *App) ChangeName(name string) error {
 	if strings.TrimSpace(name) == "" {
 		return fmt.Errorf("%w: name cannot be empty", ErrApp)
@@ -71,21 +79,31 @@ func (a *App) ChangeName(name string) error {
 	return nil
 }
 
-func (a *App) ChangeAppKey(appKey string) error {
-	if strings.TrimSpace(appKey) == "" {
-		return fmt.Errorf("%w: appKey cannot be empty", ErrApp)
+func (a *App) ChangeSigningSecret(signingSecret string) error {
+	if strings.TrimSpace(signingSecret) == "" {
+		return fmt.Errorf("%w: signingSecret cannot be empty", ErrApp)
+	}
+
+	a.signingSecret = signingSecret
+	return nil
+}
+
+func (a *App) ChangeRequireSignature(requireSignature bool) error {
+	if requireSignature && a.signingSecret == "" {
+		return fmt.Errorf("%w: a signing secret is required to require signatures", ErrApp)
 	}
 
-	a.appKey = appKey
+	a.requireSignature = requireSignature
 	return nil
 }
 
 func (a App) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]any{
-		"id":        a.id,
-		"name":      a.name,
-		"appKey":    a.appKey,
-		"userId":    a.userID,
-		"createdAt": a.createdAt,
+		"id":               a.id,
+		"name":             a.name,
+		"userId":           a.userID,
+		"createdAt":        a.createdAt,
+		"hasSigningSecret": a.signingSecret != "",
+		"requireSignature": a.requireSignature,
 	})
 }
@@ -0,0 +1,249 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrAppKey        = fmt.Errorf("error in app key")
+	ErrAppKeyRevoked = fmt.Errorf("%w: key is revoked", ErrAppKey)
+)
+
+// AppKeyScope limits what an app key is allowed to do.
+type AppKeyScope string
+
+const (
+	AppKeyScopeIngest AppKeyScope = "ingest"
+	AppKeyScopeRead   AppKeyScope = "read"
+	// AppKeyScopeBrowser is the scope of public keys embedded in web pages.
+	// It only allows reporting browser errors from the allowed origins.
+	AppKeyScopeBrowser AppKeyScope = "browser"
+)
+
+// AppKey is a credential of an app. Only the hash of the secret is kept,
+// the secret itself is shown once when the key is created.
+type AppKey struct {
+	id             ID
+	appID          ID
+	name           string
+	prefix         string
+	hash           string
+	scopes         []AppKeyScope
+	allowedOrigins []string
+	expiresAt      *time.Time
+	lastUsedAt     *time.Time
+	revokedAt      *time.Time
+	createdAt      time.Time
+}
+
+func NewAppKey(
+	id ID,
+	appID ID,
+	name string,
+	prefix string,
+	hash string,
+	scopes []AppKeyScope,
+	allowedOrigins []string,
+	expiresAt *time.Time,
+	lastUsedAt *time.Time,
+	revokedAt *time.Time,
+	createdAt time.Time,
+) (*AppKey, error) {
+	if strings.TrimSpace(hash) == "" {
+		return nil, fmt.Errorf("%w: hash cannot be empty", ErrAppKey)
+	}
+
+	key := &AppKey{
+		id:         id,
+		appID:      appID,
+		prefix:     prefix,
+		hash:       hash,
+		expiresAt:  expiresAt,
+		lastUsedAt: lastUsedAt,
+		revokedAt:  revokedAt,
+		createdAt:  createdAt,
+	}
+
+	if err := key.ChangeName(name); err != nil {
+		return nil, err
+	}
+
+	if err := key.ChangeAccess(scopes, allowedOrigins); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (k *AppKey) ID() ID {
+	return k.id
+}
+
+func (k *AppKey) AppID() ID {
+	return k.appID
+}
+
+func (k *AppKey) Name() string {
+	return k.name
+}
+
+func (k *AppKey) Prefix() string {
+	return k.prefix
+}
+
+func (k *AppKey) Hash() string {
+	return k.hash
+}
+
+func (k *AppKey) Scopes() []AppKeyScope {
+	return k.scopes
+}
+
+func (k *AppKey) AllowedOrigins() []string {
+	return k.allowedOrigins
+}
+
+func (k *AppKey) IsPublic() bool {
+	return k.HasScope(AppKeyScopeBrowser)
+}
+
+// IsOriginAllowed reports whether a browser origin such as
+// "https://app.example.com" may use the key. Allowed origins can use a
+// wildcard subdomain, like "https://*.example.com".
+func (k *AppKey) IsOriginAllowed(origin string) bool {
+	origin = strings.ToLower(strings.TrimRight(origin, "/"))
+	if origin == "" {
+		return false
+	}
+
+	for _, allowed := range k.allowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == origin {
+			return true
+		}
+
+		scheme, host, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *AppKey) ExpiresAt() *time.Time {
+	return k.expiresAt
+}
+
+func (k *AppKey) LastUsedAt() *time.Time {
+	return k.lastUsedAt
+}
+
+func (k *AppKey) RevokedAt() *time.Time {
+	return k.revokedAt
+}
+
+func (k *AppKey) CreatedAt() time.Time {
+	return k.createdAt
+}
+
+func (k *AppKey) HasScope(scope AppKeyScope) bool {
+	return slices.Contains(k.scopes, scope)
+}
+
+// IsActive reports whether the key can be used at the given time.
+func (k *AppKey) IsActive(at time.Time) bool {
+	if k.revokedAt != nil {
+		return false
+	}
+	return k.expiresAt == nil || at.Before(*k.expiresAt)
+}
+
+func (k *AppKey) ChangeName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrAppKey)
+	}
+
+	k.name = name
+	return nil
+}
+
+// ChangeAccess sets the scopes of the key and the origins allowed to use it.
+// Public browser keys cannot have other scopes and need at least one origin.
+func (k *AppKey) ChangeAccess(scopes []AppKeyScope, allowedOrigins []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrAppKey)
+	}
+
+	for _, scope := range scopes {
+		if scope != AppKeyScopeIngest && scope != AppKeyScopeRead && scope != AppKeyScopeBrowser {
+			return fmt.Errorf("%w: invalid scope %s", ErrAppKey, scope)
+		}
+	}
+
+	isPublic := slices.Contains(scopes, AppKeyScopeBrowser)
+	if isPublic && len(scopes) > 1 {
+		return fmt.Errorf("%w: browser keys cannot have other scopes", ErrAppKey)
+	}
+
+	if isPublic && len(allowedOrigins) == 0 {
+		return fmt.Errorf("%w: browser keys need at least one allowed origin", ErrAppKey)
+	}
+
+	if !isPublic && len(allowedOrigins) > 0 {
+		return fmt.Errorf("%w: only browser keys can have allowed origins", ErrAppKey)
+	}
+
+	origins := make([]string, len(allowedOrigins))
+	for i, origin := range allowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("%w: invalid origin %s", ErrAppKey, origin)
+		}
+		origins[i] = origin
+	}
+
+	k.scopes = scopes
+	k.allowedOrigins = origins
+	return nil
+}
+
+func (k *AppKey) ChangeExpiresAt(expiresAt *time.Time) error {
+	if k.revokedAt != nil {
+		return ErrAppKeyRevoked
+	}
+
+	k.expiresAt = expiresAt
+	return nil
+}
+
+func (k *AppKey) MarkUsed(at time.Time) {
+	k.lastUsedAt = &at
+}
+
+func (k *AppKey) Revoke(at time.Time) error {
+	if k.revokedAt != nil {
+		return fmt.Errorf("%w: key is already revoked", ErrAppKey)
+	}
+
+	k.revokedAt = &at
+	return nil
+}
+
+func (k AppKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":             k.id,
+		"appId":          k.appID,
+		"name":           k.name,
+		"prefix":         k.prefix,
+		"scopes":         k.scopes,
+		"allowedOrigins": k.allowedOrigins,
+		"expiresAt":      k.expiresAt,
+		"lastUsedAt":     k.lastUsedAt,
+		"revokedAt":      k.revokedAt,
+		"createdAt":      k.createdAt,
+	})
+}
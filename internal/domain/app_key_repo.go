@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type AppKeyRepo interface {
+	SaveAppKey(ctx context.Context, appKey AppKey) error
+	UpdateAppKey(ctx context.Context, appKey AppKey) error
+	// ExpireAppKey makes the key expire at the given time, unless it expires
+	// sooner, and returns it. It fails with ErrAppKeyRevoked when the key is
+	// revoked.
+	ExpireAppKey(ctx context.Context, id ID, at time.Time) (*AppKey, error)
+	TouchAppKey(ctx context.Context, id ID, at time.Time) error
+	GetAppKeyByID(ctx context.Context, id ID) (*AppKey, error)
+	GetAppKeyByHash(ctx context.Context, hash string) (*AppKey, error)
+	ListAppKeys(ctx context.Context, criteria Criteria) ([]AppKey, error)
+	DeleteAppKeysByAppID(ctx context.Context, appID ID) error
+}
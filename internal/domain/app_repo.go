@@ -8,7 +8,10 @@ type AppRepo interface {
 	SaveApp(ctx context.Context, app App) error
 	UpdateApp(ctx context.Context, app App) error
 	GetAppByID(ctx context.Context, appID ID) (*App, error)
-	GetAppByKey(ctx context.Context, appKey string) (*App, error)
+	// GetAppByLegacyKey finds an app by the plain key apps had before
+	// AppKey existed, so it can be migrated on first use.
+	GetAppByLegacyKey(ctx context.Context, appKey string) (*App, error)
+	RemoveLegacyAppKey(ctx context.Context, appID ID) error
 	DeleteApp(ctx context.Context, appID ID) error
 	ListApps(ctx context.Context, criteria Criteria) ([]App, error)
 }
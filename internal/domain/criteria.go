@@ -1,15 +1,45 @@
 package domain
 
-// FilterType represents the type of filter (equals or not equals).
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrCriteria = fmt.Errorf("error in criteria")
+)
+
+// FilterType represents the comparison a filter makes, or how a group
+// combines its filters.
 type FilterType string
 
 const (
-	Equals             FilterType = "eq"
-	NotEquals          FilterType = "ne"
+	Equals    FilterType = "eq"
+	NotEquals FilterType = "ne"
+	// Like matches the value as a literal substring, ignoring case.
 	Like               FilterType = "like"
 	In                 FilterType = "in"
+	NotIn              FilterType = "nin"
+	GreaterThan        FilterType = "gt"
 	GreaterThanOrEqual FilterType = "gte"
+	LessThan           FilterType = "lt"
 	LessThanOrEqual    FilterType = "lte"
+	// Exists takes a bool value: whether the field must be present or not.
+	Exists FilterType = "exists"
+	// Regex takes a regular expression that is used as is.
+	Regex      FilterType = "regex"
+	StartsWith FilterType = "startsWith"
+	// Between takes a [2]any value with the inclusive lower and upper bounds.
+	Between FilterType = "between"
+	// Text is a full-text search of the value over the fields of the text
+	// index of the collection, the filter has no field.
+	Text FilterType = "text"
+
+	// And, Or and Not are groups, their value is a []Filter. Not matches
+	// when none of its filters match.
+	And FilterType = "and"
+	Or  FilterType = "or"
+	Not FilterType = "not"
 )
 
 var (
@@ -29,6 +59,37 @@ func NewFilter(field string, filterType FilterType, value any) Filter {
 	return Filter{Field: field, Type: filterType, Value: value}
 }
 
+// NewAndFilter creates a group that matches when all the filters match.
+func NewAndFilter(filters ...Filter) Filter {
+	return Filter{Type: And, Value: filters}
+}
+
+// NewOrFilter creates a group that matches when any of the filters match.
+func NewOrFilter(filters ...Filter) Filter {
+	return Filter{Type: Or, Value: filters}
+}
+
+// NewNotFilter creates a group that matches when none of the filters match.
+func NewNotFilter(filters ...Filter) Filter {
+	return Filter{Type: Not, Value: filters}
+}
+
+// NewBetweenFilter creates a filter on a field between two inclusive bounds.
+func NewBetweenFilter(field string, from any, to any) Filter {
+	return Filter{Field: field, Type: Between, Value: [2]any{from, to}}
+}
+
+// IsGroup reports whether the filter combines other filters.
+func (f Filter) IsGroup() bool {
+	return f.Type == And || f.Type == Or || f.Type == Not
+}
+
+// Filters returns the filters of a group.
+func (f Filter) Filters() []Filter {
+	filters, _ := f.Value.([]Filter)
+	return filters
+}
+
 // Pagination represents pagination settings with limit and offset.
 type Pagination struct {
 	Limit  int
@@ -48,10 +109,15 @@ const (
 	Desc SortOrder = "desc"
 )
 
-// Sort represents sorting by a specific field.
+// SortByRelevance is the sort field of the relevance of a Text filter.
+const SortByRelevance = "$relevance"
+
+// Sort represents sorting by a specific field, then by the fields of Then
+// when it ties.
 type Sort struct {
 	Field string
 	Order SortOrder
+	Then  []Sort
 }
 
 // NewSort creates a new sorting configuration.
@@ -59,11 +125,38 @@ func NewSort(field string, order SortOrder) Sort {
 	return Sort{Field: field, Order: order}
 }
 
+// ThenBy adds a field used to sort the ties of the previous ones.
+func (s Sort) ThenBy(field string, order SortOrder) Sort {
+	then := make([]Sort, len(s.Then), len(s.Then)+1)
+	copy(then, s.Then)
+	s.Then = append(then, Sort{Field: field, Order: order})
+	return s
+}
+
+// Fields returns the sort followed by its tie breakers.
+func (s Sort) Fields() []Sort {
+	if s.Field == "" {
+		return nil
+	}
+
+	fields := []Sort{{Field: s.Field, Order: s.Order}}
+	for _, then := range s.Then {
+		if then.Field != "" {
+			fields = append(fields, Sort{Field: then.Field, Order: then.Order})
+		}
+	}
+	return fields
+}
+
 // Criteria represents all search criteria.
 type Criteria struct {
-	Filters    []Filter
+	Filters []Filter
+	// Query is an optional expression tree that must also match.
+	Query      QueryNode
 	Pagination Pagination
 	Sort       Sort
+	// MaxTime bounds how long the search can run, zero means no limit.
+	MaxTime time.Duration
 }
 
 // NewCriteria creates a new set of search criteria.
@@ -15,5 +15,8 @@ type DashboardRepo interface {
 		ctx context.Context,
 		userID ID,
 		optionalRange *Range,
+		// maxTime bounds how long the aggregation can run, zero means no
+		// limit.
+		maxTime time.Duration,
 	) (DashboardOverviewKPIs, error)
 }
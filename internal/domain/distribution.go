@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The histogram of a distribution has logarithmic buckets: each one holds the
+// values up to histogramGamma times the values of the previous one, so a
+// percentile is off by 1% of its value at most. Values closer to zero than
+// histogramMinValue count as zero and those above histogramMaxValue as
+// histogramMaxValue, the minimum and maximum being kept exactly.
+const (
+	histogramGamma    = 1.02
+	histogramMinValue = 1e-9
+	histogramMaxValue = 1e18
+)
+
+var histogramMinIndex = int(math.Floor(math.Log(histogramMinValue) / math.Log(histogramGamma)))
+
+// Distribution adds up values so their statistics, percentiles included, can
+// be computed from distributions merged over time or groups.
+type Distribution struct {
+	Count int64
+	Sum   float64
+	Min   float64
+	Max   float64
+	// Histogram counts the values by bucket: zero for zero, positive for the
+	// positive values, negative for the negative ones, so the buckets sort
+	// like their values. Distributions that only count have none.
+	Histogram map[int]int64
+}
+
+// Add counts a value.
+func (d *Distribution) Add(value float64) {
+	d.AddN(value, 1)
+}
+
+// AddN counts a value n times.
+func (d *Distribution) AddN(value float64, n int64) {
+	if n <= 0 {
+		return
+	}
+	d.Merge(Distribution{Count: n, Sum: value * float64(n), Min: value, Max: value, Histogram: map[int]int64{histogramBucket(value): n}})
+}
+
+// Merge adds up another distribution, of another time or group.
+func (d *Distribution) Merge(other Distribution) {
+	if other.Count == 0 {
+		return
+	}
+
+	if d.Count == 0 {
+		d.Min, d.Max = other.Min, other.Max
+	} else {
+		d.Min, d.Max = min(d.Min, other.Min), max(d.Max, other.Max)
+	}
+	d.Count += other.Count
+	d.Sum += other.Sum
+
+	if len(other.Histogram) > 0 && d.Histogram == nil {
+		d.Histogram = make(map[int]int64, len(other.Histogram))
+	}
+	for bucket, count := range other.Histogram {
+		d.Histogram[bucket] += count
+	}
+}
+
+// Sub returns the values added to the distribution since a previous state of
+// it, false when it has fewer values than before, which means it was reset.
+// The minimum and maximum of the difference are estimated from its
+// histogram.
+func (d Distribution) Sub(previous Distribution) (Distribution, bool) {
+	if d.Count < previous.Count {
+		return Distribution{}, false
+	}
+
+	diff := Distribution{Count: d.Count - previous.Count, Sum: d.Sum - previous.Sum}
+	for bucket, count := range d.Histogram {
+		count -= previous.Histogram[bucket]
+		if count < 0 {
+			return Distribution{}, false
+		}
+		if count == 0 {
+			continue
+		}
+		if diff.Histogram == nil {
+			diff.Histogram = map[int]int64{}
+		}
+		diff.Histogram[bucket] = count
+	}
+
+	if len(diff.Histogram) > 0 {
+		buckets := slices.Sorted(maps.Keys(diff.Histogram))
+		diff.Min = max(histogramValue(buckets[0]), d.Min)
+		diff.Max = min(histogramValue(buckets[len(buckets)-1]), d.Max)
+	}
+	return diff, true
+}
+
+// Stat returns count, sum, avg, min, max or a percentile like p99, false
+// when the distribution has no values for it.
+func (d *Distribution) Stat(stat string) (float64, bool) {
+	if stat == "count" {
+		return float64(d.Count), true
+	}
+	if d.Count == 0 {
+		return 0, false
+	}
+
+	switch stat {
+	case "sum":
+		return d.Sum, true
+	case "avg":
+		return d.Sum / float64(d.Count), true
+	case "min":
+		return d.Min, true
+	case "max":
+		return d.Max, true
+	}
+
+	percentile, err := strconv.ParseFloat(strings.TrimPrefix(stat, "p"), 64)
+	if !strings.HasPrefix(stat, "p") || err != nil {
+		return 0, false
+	}
+	return d.Percentile(percentile)
+}
+
+// Percentile estimates the value below which the percentage of the values
+// fall, from the histogram.
+func (d *Distribution) Percentile(percentile float64) (float64, bool) {
+	var total int64
+	for _, count := range d.Histogram {
+		total += count
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	rank := int64(math.Floor(percentile / 100 * float64(total-1)))
+	var seen int64
+	for _, bucket := range slices.Sorted(maps.Keys(d.Histogram)) {
+		seen += d.Histogram[bucket]
+		if seen > rank {
+			return min(max(histogramValue(bucket), d.Min), d.Max), true
+		}
+	}
+	return d.Max, true
+}
+
+func histogramBucket(value float64) int {
+	magnitude := math.Abs(value)
+	if magnitude < histogramMinValue || math.IsNaN(value) {
+		return 0
+	}
+
+	index := int(math.Ceil(math.Log(min(magnitude, histogramMaxValue)) / math.Log(histogramGamma)))
+	bucket := index - histogramMinIndex + 1
+	if value < 0 {
+		return -bucket
+	}
+	return bucket
+}
+
+// histogramValue is the middle of a bucket, as far from its values as
+// possible relative to them.
+func histogramValue(bucket int) float64 {
+	if bucket == 0 {
+		return 0
+	}
+
+	if bucket < 0 {
+		return -histogramValue(-bucket)
+	}
+
+	index := bucket - 1 + histogramMinIndex
+	return 2 * math.Pow(histogramGamma, float64(index)) / (1 + histogramGamma)
+}
@@ -0,0 +1,200 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrExportJob = fmt.Errorf("error in export job")
+)
+
+// ExportJobStatus is the progress state of an export job.
+type ExportJobStatus string
+
+const (
+	ExportJobStatusPending   ExportJobStatus = "pending"
+	ExportJobStatusRunning   ExportJobStatus = "running"
+	ExportJobStatusCompleted ExportJobStatus = "completed"
+	ExportJobStatusFailed    ExportJobStatus = "failed"
+)
+
+// ExportJobLease is how long an unfinished job is kept once the server
+// writing it stops renewing it, which it does as it makes progress and at
+// least every ExportJobLease/5.
+const ExportJobLease = 5 * time.Minute
+
+var (
+	ErrExportJobFinished = fmt.Errorf("%w: the job is already finished", ErrExportJob)
+)
+
+// ExportJob writes the logs of a search to a file in the background, to be
+// downloaded once completed.
+type ExportJob struct {
+	id           ID
+	userID       ID
+	format       string
+	columns      []string
+	status       ExportJobStatus
+	processed    int64
+	total        int64
+	errorMessage string
+	fileSize     int64
+	createdAt    time.Time
+	updatedAt    time.Time
+	finishedAt   *time.Time
+}
+
+func NewExportJob(
+	id ID,
+	userID ID,
+	format string,
+	columns []string,
+	status ExportJobStatus,
+	processed int64,
+	total int64,
+	errorMessage string,
+	fileSize int64,
+	createdAt time.Time,
+	updatedAt time.Time,
+	finishedAt *time.Time,
+) (*ExportJob, error) {
+	if strings.TrimSpace(format) == "" {
+		return nil, fmt.Errorf("%w: format cannot be empty", ErrExportJob)
+	}
+
+	if !slices.Contains([]ExportJobStatus{ExportJobStatusPending, ExportJobStatusRunning, ExportJobStatusCompleted, ExportJobStatusFailed}, status) {
+		return nil, fmt.Errorf("%w: invalid status %s", ErrExportJob, status)
+	}
+
+	return &ExportJob{
+		id:           id,
+		userID:       userID,
+		format:       format,
+		columns:      columns,
+		status:       status,
+		processed:    processed,
+		total:        total,
+		errorMessage: errorMessage,
+		fileSize:     fileSize,
+		createdAt:    createdAt,
+		updatedAt:    updatedAt,
+		finishedAt:   finishedAt,
+	}, nil
+}
+
+func (j *ExportJob) ID() ID {
+	return j.id
+}
+
+func (j *ExportJob) UserID() ID {
+	return j.userID
+}
+
+func (j *ExportJob) Format() string {
+	return j.format
+}
+
+// Columns are the log fields written to csv and parquet files.
+func (j *ExportJob) Columns() []string {
+	return j.columns
+}
+
+func (j *ExportJob) Status() ExportJobStatus {
+	return j.status
+}
+
+// Processed is how many logs have been written to the file.
+func (j *ExportJob) Processed() int64 {
+	return j.processed
+}
+
+// Total is how many logs matched the search when the job was created.
+func (j *ExportJob) Total() int64 {
+	return j.total
+}
+
+func (j *ExportJob) ErrorMessage() string {
+	return j.errorMessage
+}
+
+func (j *ExportJob) FileSize() int64 {
+	return j.fileSize
+}
+
+func (j *ExportJob) FileName() string {
+	return fmt.Sprintf("logs-%s.%s", j.id.Hex(), j.format)
+}
+
+func (j *ExportJob) CreatedAt() time.Time {
+	return j.createdAt
+}
+
+// UpdatedAt is when the server writing the job last renewed its lease.
+func (j *ExportJob) UpdatedAt() time.Time {
+	return j.updatedAt
+}
+
+func (j *ExportJob) FinishedAt() *time.Time {
+	return j.finishedAt
+}
+
+func (j *ExportJob) Start(at time.Time) {
+	j.status = ExportJobStatusRunning
+	j.updatedAt = at
+}
+
+func (j *ExportJob) ChangeProcessed(processed int64, at time.Time) {
+	j.processed = processed
+	j.updatedAt = at
+}
+
+func (j *ExportJob) Complete(fileSize int64, at time.Time) {
+	j.status = ExportJobStatusCompleted
+	j.fileSize = fileSize
+	j.updatedAt = at
+	j.finishedAt = &at
+}
+
+func (j *ExportJob) Fail(err error, at time.Time) {
+	j.status = ExportJobStatusFailed
+	j.errorMessage = err.Error()
+	j.updatedAt = at
+	j.finishedAt = &at
+}
+
+func (j *ExportJob) IsFinished() bool {
+	return j.status == ExportJobStatusCompleted || j.status == ExportJobStatusFailed
+}
+
+// IsAbandoned reports whether the job is unfinished and its lease expired,
+// like when the server writing it stopped.
+func (j *ExportJob) IsAbandoned(now time.Time) bool {
+	return !j.IsFinished() && now.Sub(j.updatedAt) > ExportJobLease
+}
+
+func (j ExportJob) MarshalJSON() ([]byte, error) {
+	var progress float64 = 1
+	if j.total > 0 {
+		progress = min(float64(j.processed)/float64(j.total), 1)
+	}
+
+	return json.Marshal(map[string]any{
+		"id":         j.id,
+		"userId":     j.userID,
+		"format":     j.format,
+		"columns":    j.columns,
+		"status":     j.status,
+		"processed":  j.processed,
+		"total":      j.total,
+		"progress":   progress,
+		"error":      j.errorMessage,
+		"fileName":   j.FileName(),
+		"fileSize":   j.fileSize,
+		"createdAt":  j.createdAt,
+		"finishedAt": j.finishedAt,
+	})
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+type ExportJobRepo interface {
+	SaveExportJob(ctx context.Context, job ExportJob) error
+	// UpdateExportJob saves the job unless it is already finished, like when
+	// another server failed it as abandoned, returning ErrExportJobFinished.
+	UpdateExportJob(ctx context.Context, job ExportJob) error
+	// RenewExportJob renews the lease of an unfinished job, returning
+	// ErrExportJobFinished when it is already finished.
+	RenewExportJob(ctx context.Context, id ID, at time.Time) error
+	// FailAbandonedExportJob saves the failed job unless it was renewed
+	// since before or finished, and reports whether it was saved.
+	FailAbandonedExportJob(ctx context.Context, job ExportJob, before time.Time) (bool, error)
+	GetExportJobByID(ctx context.Context, id ID) (*ExportJob, error)
+	ListExportJobs(ctx context.Context, criteria Criteria) ([]ExportJob, error)
+	DeleteExportJob(ctx context.Context, id ID) error
+}
+
+// ExportFileRepo stores the files written by export jobs.
+type ExportFileRepo interface {
+	// CreateExportFile returns a writer of the file, which is stored once
+	// closed.
+	CreateExportFile(ctx context.Context, id ID, name string) (io.WriteCloser, error)
+	// OpenExportFile returns a reader of the file and its size.
+	OpenExportFile(ctx context.Context, id ID) (io.ReadCloser, int64, error)
+	DeleteExportFile(ctx context.Context, id ID) error
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -19,3 +20,9 @@ func NewID(id string) (ID, error) {
 func NewAutoID() ID {
 	return primitive.NewObjectID()
 }
+
+// NewIDFromTime returns the smallest ID created at the second of t, so the
+// IDs created before that second are lower.
+func NewIDFromTime(t time.Time) ID {
+	return primitive.NewObjectIDFromTimestamp(t)
+}
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInterval = fmt.Errorf("error in interval")
+)
+
+// TimeUnit is the unit of an interval, named like the units of MongoDB's
+// $dateTrunc.
+type TimeUnit string
+
+const (
+	TimeUnitSecond TimeUnit = "second"
+	TimeUnitMinute TimeUnit = "minute"
+	TimeUnitHour   TimeUnit = "hour"
+	TimeUnitDay    TimeUnit = "day"
+	TimeUnitWeek   TimeUnit = "week"
+	TimeUnitMonth  TimeUnit = "month"
+)
+
+var (
+	intervalRegex = regexp.MustCompile(`^([1-9][0-9]*)(s|m|h|d|w|M)$`)
+	intervalUnits = map[string]TimeUnit{
+		"s": TimeUnitSecond,
+		"m": TimeUnitMinute,
+		"h": TimeUnitHour,
+		"d": TimeUnitDay,
+		"w": TimeUnitWeek,
+		"M": TimeUnitMonth,
+	}
+	unitDurations = map[TimeUnit]time.Duration{
+		TimeUnitSecond: time.Second,
+		TimeUnitMinute: time.Minute,
+		TimeUnitHour:   time.Hour,
+		TimeUnitDay:    24 * time.Hour,
+		TimeUnitWeek:   7 * 24 * time.Hour,
+		TimeUnitMonth:  30 * 24 * time.Hour,
+	}
+)
+
+// MaxInterval is the longest interval of a time bucket.
+var MaxInterval = Interval{Unit: TimeUnitMonth, Size: 1}
+
+// Interval is the length of a time bucket, like 5 minutes or 1 month. Days,
+// weeks and months are calendar ones, so they follow daylight saving time.
+type Interval struct {
+	Unit TimeUnit
+	Size int
+}
+
+// ParseInterval parses intervals like 30s, 5m, 1h, 1d, 1w or 1M (a month),
+// from 1 second to 1 month.
+func ParseInterval(value string) (Interval, error) {
+	matches := intervalRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return Interval{}, fmt.Errorf("%w: %q must be a number followed by s, m, h, d, w or M", ErrInterval, value)
+	}
+
+	size, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return Interval{}, fmt.Errorf("%w: %q is too large", ErrInterval, value)
+	}
+
+	interval := Interval{Unit: intervalUnits[matches[2]], Size: size}
+	if interval.Duration() > MaxInterval.Duration() {
+		return Interval{}, fmt.Errorf("%w: %q is longer than a month", ErrInterval, value)
+	}
+	return interval, nil
+}
+
+func (i Interval) String() string {
+	for symbol, unit := range intervalUnits {
+		if unit == i.Unit {
+			return fmt.Sprintf("%d%s", i.Size, symbol)
+		}
+	}
+	return ""
+}
+
+// Duration is the length of the interval, taking months as 30 days.
+func (i Interval) Duration() time.Duration {
+	return time.Duration(i.Size) * unitDurations[i.Unit]
+}
+
+// Add moves t by n intervals in the location of t.
+func (i Interval) Add(t time.Time, n int) time.Time {
+	switch i.Unit {
+	case TimeUnitDay:
+		return t.AddDate(0, 0, n*i.Size)
+	case TimeUnitWeek:
+		return t.AddDate(0, 0, 7*n*i.Size)
+	case TimeUnitMonth:
+		return t.AddDate(0, n*i.Size, 0)
+	}
+	return t.Add(time.Duration(n) * i.Duration())
+}
+
+// Buckets returns at most how many intervals cover from to to, including the
+// partial ones at both ends. Calendar intervals of a single unit start with
+// the unit in the location of from, the others start up to an interval
+// before from.
+func (i Interval) Buckets(from time.Time, to time.Time) int {
+	if to.Before(from) {
+		return 0
+	}
+
+	start, calendar := from, false
+	switch i.Unit {
+	case TimeUnitDay, TimeUnitWeek, TimeUnitMonth:
+		start, calendar = i.Truncate(from), true
+	}
+
+	// n is the number of whole intervals from start to to, estimated with
+	// Duration and corrected for the calendar ones.
+	n := int(to.Sub(start) / i.Duration())
+	for n > 0 && i.Add(start, n).After(to) {
+		n--
+	}
+	for !i.Add(start, n+1).After(to) {
+		n++
+	}
+
+	if calendar && i.Size == 1 || !calendar && i.Add(start, n).Equal(to) {
+		return n + 1
+	}
+	return n + 2
+}
+
+// Truncate returns the start of the unit of t in the location of t, weeks
+// starting on Monday.
+func (i Interval) Truncate(t time.Time) time.Time {
+	switch i.Unit {
+	case TimeUnitDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case TimeUnitWeek:
+		weekday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-weekday, 0, 0, 0, 0, t.Location())
+	case TimeUnitMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return t.Truncate(unitDurations[i.Unit])
+}
+
+// ParseLength parses lengths of time written like intervals, like 12h, 7d
+// or 3M, with no upper bound. Months are taken as 30 days.
+func ParseLength(value string) (time.Duration, error) {
+	matches := intervalRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, fmt.Errorf("%w: %q must be a number followed by s, m, h, d, w or M", ErrInterval, value)
+	}
+
+	size, err := strconv.Atoi(matches[1])
+	unit := unitDurations[intervalUnits[matches[2]]]
+	if err != nil || size > int(math.MaxInt64/unit) {
+		return 0, fmt.Errorf("%w: %q is too large", ErrInterval, value)
+	}
+	return time.Duration(size) * unit, nil
+}
+
+// FormatLength writes a length of time in the largest unit of intervals that
+// divides it, the opposite of ParseLength.
+func FormatLength(length time.Duration) string {
+	for _, symbol := range []string{"M", "w", "d", "h", "m", "s"} {
+		unit := unitDurations[intervalUnits[symbol]]
+		if length > 0 && length%unit == 0 {
+			return fmt.Sprintf("%d%s", length/unit, symbol)
+		}
+	}
+	return length.String()
+}
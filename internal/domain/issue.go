@@ -0,0 +1,185 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrIssue = fmt.Errorf("error in issue")
+)
+
+// IssueStatus is the triage state of an issue.
+type IssueStatus string
+
+const (
+	IssueStatusOpen     IssueStatus = "open"
+	IssueStatusResolved IssueStatus = "resolved"
+	IssueStatusIgnored  IssueStatus = "ignored"
+	// IssueStatusRegressed is set when a resolved issue happens again.
+	IssueStatusRegressed IssueStatus = "regressed"
+)
+
+// Issue groups the error events of a user's apps that share a fingerprint.
+// Merged issues keep the fingerprints of all of them.
+type Issue struct {
+	id           ID
+	userID       ID
+	fingerprints []string
+	title        string
+	level        string
+	status       IssueStatus
+	appIDs       []ID
+	count        int64
+	firstSeen    time.Time
+	lastSeen     time.Time
+	resolvedAt   *time.Time
+}
+
+func NewIssue(
+	id ID,
+	userID ID,
+	fingerprints []string,
+	title string,
+	level string,
+	status IssueStatus,
+	appIDs []ID,
+	count int64,
+	firstSeen time.Time,
+	lastSeen time.Time,
+	resolvedAt *time.Time,
+) (*Issue, error) {
+	if len(fingerprints) == 0 {
+		return nil, fmt.Errorf("%w: at least one fingerprint is required", ErrIssue)
+	}
+
+	if strings.TrimSpace(title) == "" {
+		return nil, fmt.Errorf("%w: title cannot be empty", ErrIssue)
+	}
+
+	if !slices.Contains([]IssueStatus{IssueStatusOpen, IssueStatusResolved, IssueStatusIgnored, IssueStatusRegressed}, status) {
+		return nil, fmt.Errorf("%w: invalid status %s", ErrIssue, status)
+	}
+
+	return &Issue{
+		id:           id,
+		userID:       userID,
+		fingerprints: fingerprints,
+		title:        title,
+		level:        level,
+		status:       status,
+		appIDs:       appIDs,
+		count:        count,
+		firstSeen:    firstSeen,
+		lastSeen:     lastSeen,
+		resolvedAt:   resolvedAt,
+	}, nil
+}
+
+func (i *Issue) ID() ID {
+	return i.id
+}
+
+func (i *Issue) UserID() ID {
+	return i.userID
+}
+
+func (i *Issue) Fingerprints() []string {
+	return i.fingerprints
+}
+
+func (i *Issue) Title() string {
+	return i.title
+}
+
+// Level is the level of the latest event.
+func (i *Issue) Level() string {
+	return i.level
+}
+
+func (i *Issue) Status() IssueStatus {
+	return i.status
+}
+
+// AppIDs are the apps where the issue happened.
+func (i *Issue) AppIDs() []ID {
+	return i.appIDs
+}
+
+func (i *Issue) Count() int64 {
+	return i.count
+}
+
+func (i *Issue) FirstSeen() time.Time {
+	return i.firstSeen
+}
+
+func (i *Issue) LastSeen() time.Time {
+	return i.lastSeen
+}
+
+func (i *Issue) ResolvedAt() *time.Time {
+	return i.resolvedAt
+}
+
+// IssueEvents are error events of an app that share a fingerprint. They are
+// added to the issue of the fingerprint, which is opened by the first ones: the
+// count and apps grow, the level is that of the latest event, and a resolved
+// issue that happens after being resolved becomes regressed.
+type IssueEvents struct {
+	AppID       ID
+	Fingerprint string
+	// Title is the title of the issue they open.
+	Title     string
+	Level     string
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// ChangeStatus sets the status chosen while triaging. Regressed is only set
+// when events are recorded.
+func (i *Issue) ChangeStatus(status IssueStatus, at time.Time) error {
+	if !slices.Contains([]IssueStatus{IssueStatusOpen, IssueStatusResolved, IssueStatusIgnored}, status) {
+		return fmt.Errorf("%w: status must be open, resolved or ignored", ErrIssue)
+	}
+
+	i.status = status
+	i.resolvedAt = nil
+	if status == IssueStatusResolved {
+		i.resolvedAt = &at
+	}
+	return nil
+}
+
+// CheckMerge tells whether other can be merged into the issue, moving its
+// fingerprints and events here.
+func (i *Issue) CheckMerge(other Issue) error {
+	if other.id == i.id {
+		return fmt.Errorf("%w: an issue cannot be merged into itself", ErrIssue)
+	}
+
+	if other.userID != i.userID {
+		return fmt.Errorf("%w: issues of different users cannot be merged", ErrIssue)
+	}
+	return nil
+}
+
+func (i Issue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":           i.id,
+		"userId":       i.userID,
+		"fingerprints": i.fingerprints,
+		"title":        i.title,
+		"level":        i.level,
+		"status":       i.status,
+		"appIds":       i.appIDs,
+		"count":        i.count,
+		"firstSeen":    i.firstSeen,
+		"lastSeen":     i.lastSeen,
+		"resolvedAt":   i.resolvedAt,
+	})
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+)
+
+type IssueRepo interface {
+	// ChangeIssueStatus saves the status of the issue only, leaving the
+	// events recorded meanwhile.
+	ChangeIssueStatus(ctx context.Context, issue Issue) (*Issue, error)
+	// MergeIssue moves the fingerprints and events of other into the target
+	// issue, deletes other and returns the target.
+	MergeIssue(ctx context.Context, targetID ID, other Issue) (*Issue, error)
+	GetIssueByID(ctx context.Context, id ID) (*Issue, error)
+	// RecordIssueEvents adds the events to the issue of their fingerprint
+	// among those of the user at once, opening it when there is none.
+	RecordIssueEvents(ctx context.Context, userID ID, events IssueEvents) (*Issue, error)
+	ListIssues(ctx context.Context, criteria Criteria) ([]Issue, error)
+	DeleteIssue(ctx context.Context, id ID) error
+}
@@ -2,16 +2,23 @@ package domain
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
 type Log struct {
-	id        ID
-	appID     ID
-	timestamp time.Time
-	data      map[string]any
-	raw       string
-	level     string
+	id         ID
+	appID      ID
+	timestamp  time.Time
+	data       map[string]any
+	raw        string
+	level      string
+	stackTrace *StackTrace
+	// fingerprint groups error logs into issues, it is empty for other levels.
+	fingerprint string
+	// patternID is the hex ID of the LogPattern of the message, empty when
+	// the log has none.
+	patternID string
 }
 
 func NewLog(
@@ -21,14 +28,20 @@ func NewLog(
 	data map[string]any,
 	raw string,
 	level string,
+	stackTrace *StackTrace,
+	fingerprint string,
+	patternID string,
 ) (*Log, error) {
 	return &Log{
-		id:        id,
-		appID:     appID,
-		timestamp: timestamp,
-		data:      data,
-		raw:       raw,
-		level:     level,
+		id:          id,
+		appID:       appID,
+		timestamp:   timestamp,
+		data:        data,
+		raw:         raw,
+		level:       level,
+		stackTrace:  stackTrace,
+		fingerprint: fingerprint,
+		patternID:   patternID,
 	}, nil
 }
 
@@ -56,13 +69,74 @@ func (l *Log) Level() string {
 	return l.level
 }
 
+func (l *Log) StackTrace() *StackTrace {
+	return l.stackTrace
+}
+
+func (l *Log) Fingerprint() string {
+	return l.fingerprint
+}
+
+func (l *Log) PatternID() string {
+	return l.patternID
+}
+
+func (l *Log) ChangePatternID(patternID string) {
+	l.patternID = patternID
+}
+
+// Field returns the value of a field of the log, like level or
+// data.request.path, and whether the log has it.
+func (l *Log) Field(field string) (any, bool) {
+	switch field {
+	case "id", "_id":
+		return l.id, true
+	case "appId":
+		return l.appID, true
+	case "level":
+		return l.level, true
+	case "raw":
+		return l.raw, true
+	case "timestamp":
+		return l.timestamp, true
+	case "fingerprint":
+		return l.fingerprint, l.fingerprint != ""
+	case "patternId":
+		return l.patternID, l.patternID != ""
+	}
+
+	var value any
+	path, ok := strings.CutPrefix(field, "data.")
+	if ok {
+		value = l.data
+	} else if path, ok = strings.CutPrefix(field, "stackTrace."); ok && l.stackTrace != nil {
+		value = stackTraceValue(l.stackTrace)
+	} else {
+		return nil, false
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
 func (a Log) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]any{
-		"id":        a.id,
-		"appId":     a.appID,
-		"timestamp": a.timestamp,
-		"data":      a.data,
-		"raw":       a.raw,
-		"level":     a.level,
+		"id":          a.id,
+		"appId":       a.appID,
+		"timestamp":   a.timestamp,
+		"data":        a.data,
+		"raw":         a.raw,
+		"level":       a.level,
+		"stackTrace":  a.stackTrace,
+		"fingerprint": a.fingerprint,
+		"patternId":   a.patternID,
 	})
 }
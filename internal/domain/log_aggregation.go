@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// AggregationOp is how a metric adds up the values of a field.
+type AggregationOp string
+
+const (
+	AggregationCount    AggregationOp = "count"
+	AggregationDistinct AggregationOp = "distinct"
+	AggregationSum      AggregationOp = "sum"
+	AggregationAvg      AggregationOp = "avg"
+	AggregationMin      AggregationOp = "min"
+	AggregationMax      AggregationOp = "max"
+	// AggregationPercentile is approximate and needs MongoDB 7.0.
+	AggregationPercentile AggregationOp = "percentile"
+)
+
+// AggregationGroup groups logs by the value of a field, or by the time
+// bucket of their timestamp when Interval is set.
+type AggregationGroup struct {
+	Field    string
+	Interval *Interval
+	// Size is how many groups with the most logs are kept, for groups by
+	// field.
+	Size int
+}
+
+// Name is the column of the group in tabular results.
+func (g AggregationGroup) Name() string {
+	if g.Interval != nil {
+		return "timestamp"
+	}
+	return g.Field
+}
+
+// AggregationMetric is computed for every group. Sum, avg, min, max and
+// percentiles only take the numeric values of the field.
+type AggregationMetric struct {
+	Op    AggregationOp
+	Field string
+	// Percentile is between 0 and 100, for AggregationPercentile.
+	Percentile float64
+}
+
+// Name is the column of the metric in tabular results, like count or
+// p95(data.duration).
+func (m AggregationMetric) Name() string {
+	switch m.Op {
+	case AggregationCount:
+		return string(m.Op)
+	case AggregationPercentile:
+		return fmt.Sprintf("p%s(%s)", strconv.FormatFloat(m.Percentile, 'f', -1, 64), m.Field)
+	}
+	return fmt.Sprintf("%s(%s)", m.Op, m.Field)
+}
+
+// LogAggregation groups logs by each of its groups in turn and computes the
+// metrics of the innermost groups.
+type LogAggregation struct {
+	Groups  []AggregationGroup
+	Metrics []AggregationMetric
+	// Location is where time buckets start.
+	Location *time.Location
+	// MaxRows is the most groups read, past it the result is truncated.
+	MaxRows int
+}
+
+// LogAggregationRow is a group of the innermost level.
+type LogAggregationRow struct {
+	// Keys are the values of the groups, time buckets are time.Time.
+	Keys []any
+	// Count is how many logs are in the group.
+	Count int64
+	// Values are the values of the metrics, nil when there were no values.
+	Values []any
+}
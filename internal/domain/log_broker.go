@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrLogBrokerInvalidCursor = errors.New("invalid live tail cursor")
+)
+
+// LogEvent is a log delivered to a live tail. Cursor resumes the tail right
+// after the event.
+type LogEvent struct {
+	// Log is nil when the event reports a gap.
+	Log    *Log
+	Cursor string
+	// Gap is set when logs were missed, because the subscriber fell too far
+	// behind or its cursor expired.
+	Gap bool
+}
+
+// LogBroker delivers the logs saved at ingestion to live tails.
+type LogBroker interface {
+	// Publish announces logs that were just saved.
+	Publish(logs []Log)
+	// Subscribe follows the logs of the apps published after cursor, or from
+	// now when the cursor is empty.
+	Subscribe(ctx context.Context, appIDs []ID, cursor string) (LogSubscription, error)
+}
+
+type LogSubscription interface {
+	// Next returns the next event, or nil when none arrived in about a
+	// second, so the caller can keep the connection alive.
+	Next(ctx context.Context) (*LogEvent, error)
+	Close(ctx context.Context) error
+}
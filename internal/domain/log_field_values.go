@@ -0,0 +1,22 @@
+package domain
+
+// LogFieldValue is a value of a field and how many times it was found.
+type LogFieldValue struct {
+	Value any
+	Count int64
+}
+
+// LogFieldValues are the values of a field among the logs scanned. The
+// values of arrays are counted one by one.
+type LogFieldValues struct {
+	// Values are the most frequent values, most frequent first.
+	Values []LogFieldValue
+	// Distinct is how many different values were found.
+	Distinct int64
+	// Scanned is how many logs with the field were read.
+	Scanned int64
+	// Min and Max are the range of the numeric values, nil when there were
+	// none.
+	Min *float64
+	Max *float64
+}
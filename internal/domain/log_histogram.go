@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// LogHistogramBucket counts the logs of a time bucket, or of one series of
+// it when they are split by a field.
+type LogHistogramBucket struct {
+	Start time.Time
+	// Series is the value of the split field, formatted as text.
+	Series string
+	Count  int64
+}
@@ -0,0 +1,267 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLogMetric = fmt.Errorf("error in log metric")
+)
+
+// LogMetricType is what a metric adds up from the logs it matches.
+type LogMetricType string
+
+const (
+	// LogMetricCount counts the logs.
+	LogMetricCount LogMetricType = "count"
+	// LogMetricDistribution adds up the values of a numeric field, with a
+	// histogram for percentiles.
+	LogMetricDistribution LogMetricType = "distribution"
+)
+
+const maxLogMetricGroups = 3
+
+// LogMetric is a time series computed from the logs of a user as they are
+// received, like the count of level:ERROR by app or the distribution of
+// data.duration_ms by data.route.
+type LogMetric struct {
+	id         ID
+	userID     ID
+	name       string
+	metricType LogMetricType
+	query      string
+	node       QueryNode
+	// appIDs limits the metric to some apps, all apps when empty.
+	appIDs []ID
+	// field is the numeric field of distributions.
+	field string
+	// groupBy are the fields that split the series, like appId or
+	// data.route.
+	groupBy   []string
+	alert     *LogMetricAlert
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func NewLogMetric(
+	id ID,
+	userID ID,
+	name string,
+	metricType LogMetricType,
+	query string,
+	appIDs []ID,
+	field string,
+	groupBy []string,
+	alert *LogMetricAlert,
+	createdAt time.Time,
+	updatedAt time.Time,
+) (*LogMetric, error) {
+	metric := &LogMetric{
+		id:        id,
+		userID:    userID,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+
+	if err := metric.ChangeName(name); err != nil {
+		return nil, err
+	}
+
+	if err := metric.ChangeDefinition(metricType, query, appIDs, field, groupBy); err != nil {
+		return nil, err
+	}
+
+	if err := metric.ChangeAlert(alert); err != nil {
+		return nil, err
+	}
+
+	return metric, nil
+}
+
+func (m *LogMetric) ID() ID {
+	return m.id
+}
+
+func (m *LogMetric) UserID() ID {
+	return m.userID
+}
+
+func (m *LogMetric) Name() string {
+	return m.name
+}
+
+func (m *LogMetric) Type() LogMetricType {
+	return m.metricType
+}
+
+func (m *LogMetric) Query() string {
+	return m.query
+}
+
+func (m *LogMetric) AppIDs() []ID {
+	return m.appIDs
+}
+
+func (m *LogMetric) Field() string {
+	return m.field
+}
+
+func (m *LogMetric) GroupBy() []string {
+	return m.groupBy
+}
+
+func (m *LogMetric) Alert() *LogMetricAlert {
+	return m.alert
+}
+
+func (m *LogMetric) CreatedAt() time.Time {
+	return m.createdAt
+}
+
+func (m *LogMetric) UpdatedAt() time.Time {
+	return m.updatedAt
+}
+
+func (m *LogMetric) ChangeName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrLogMetric)
+	}
+	m.name = name
+	return nil
+}
+
+// ChangeDefinition changes what the metric adds up. The rollups recorded
+// with the previous definition no longer match it.
+func (m *LogMetric) ChangeDefinition(metricType LogMetricType, query string, appIDs []ID, field string, groupBy []string) error {
+	switch metricType {
+	case LogMetricCount:
+		field = ""
+	case LogMetricDistribution:
+		if !IsQueryField(field) {
+			return fmt.Errorf("%w: a distribution needs a numeric field, like data.duration_ms", ErrLogMetric)
+		}
+	default:
+		return fmt.Errorf("%w: type must be count or distribution", ErrLogMetric)
+	}
+
+	node, err := ParseQuery(query)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLogMetric, err)
+	}
+
+	if len(groupBy) > maxLogMetricGroups {
+		return fmt.Errorf("%w: at most %d groups", ErrLogMetric, maxLogMetricGroups)
+	}
+	for i, group := range groupBy {
+		if group != "appId" && !IsQueryField(group) {
+			return fmt.Errorf("%w: cannot group by %q, use appId, level or a data field", ErrLogMetric, group)
+		}
+		if slices.Contains(groupBy[:i], group) {
+			return fmt.Errorf("%w: duplicated group %q", ErrLogMetric, group)
+		}
+	}
+
+	if m.alert != nil && !m.alert.isValidFor(metricType) {
+		return fmt.Errorf("%w: the alert of a count can only compare the count", ErrLogMetric)
+	}
+
+	m.metricType = metricType
+	m.query = strings.TrimSpace(query)
+	m.node = node
+	m.appIDs = appIDs
+	m.field = field
+	m.groupBy = groupBy
+	return nil
+}
+
+// ChangeAlert changes the alert of the metric, nil removing it.
+func (m *LogMetric) ChangeAlert(alert *LogMetricAlert) error {
+	if alert != nil && !alert.isValidFor(m.metricType) {
+		return fmt.Errorf("%w: the alert of a count can only compare the count", ErrLogMetric)
+	}
+	m.alert = alert
+	return nil
+}
+
+func (m *LogMetric) MarkUpdated(at time.Time) {
+	m.updatedAt = at
+}
+
+// Observe returns the values of the groups of a log and the value it adds
+// to a distribution. ok is false when the metric does not count the log: it
+// is of another app, does not match the query, or has no numeric value.
+func (m *LogMetric) Observe(log Log) (keys []string, value float64, ok bool) {
+	if len(m.appIDs) > 0 && !slices.Contains(m.appIDs, log.AppID()) {
+		return nil, 0, false
+	}
+
+	if !MatchQuery(m.node, log) {
+		return nil, 0, false
+	}
+
+	if m.metricType == LogMetricDistribution {
+		value, ok = logNumber(log, m.field)
+		if !ok {
+			return nil, 0, false
+		}
+	}
+
+	keys = make([]string, len(m.groupBy))
+	for i, group := range m.groupBy {
+		keys[i] = logGroupValue(log, group)
+	}
+	return keys, value, true
+}
+
+func logNumber(log Log, field string) (float64, bool) {
+	for _, value := range logFieldValues(log, field) {
+		if number, ok := queryNumber(value); ok && !math.IsNaN(number) && !math.IsInf(number, 0) {
+			return number, true
+		}
+	}
+	return 0, false
+}
+
+// logGroupValue is the first value of the field as text, empty when the log
+// does not have it.
+func logGroupValue(log Log, field string) string {
+	if field == "appId" {
+		return log.AppID().Hex()
+	}
+
+	values := logFieldValues(log, field)
+	if len(values) == 0 || values[0] == nil {
+		return ""
+	}
+	if number, ok := queryNumber(values[0]); ok {
+		return fmt.Sprint(number)
+	}
+	return fmt.Sprint(schemaSample(values[0]))
+}
+
+func (m LogMetric) MarshalJSON() ([]byte, error) {
+	appIDs := make([]string, len(m.appIDs))
+	for i, id := range m.appIDs {
+		appIDs[i] = id.Hex()
+	}
+
+	return json.Marshal(map[string]any{
+		"id":        m.id.Hex(),
+		"userId":    m.userID.Hex(),
+		"name":      m.name,
+		"type":      m.metricType,
+		"query":     m.query,
+		"appIds":    appIDs,
+		"field":     m.field,
+		"groupBy":   m.groupBy,
+		"alert":     m.alert,
+		"createdAt": m.createdAt,
+		"updatedAt": m.updatedAt,
+	})
+}
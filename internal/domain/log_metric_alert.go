@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// LogMetricAlertOp is how an alert compares a statistic with its threshold.
+type LogMetricAlertOp string
+
+const (
+	LogMetricAbove LogMetricAlertOp = "above"
+	LogMetricBelow LogMetricAlertOp = "below"
+)
+
+const maxLogMetricAlertWindow = 24 * time.Hour
+
+// LogMetricAlert fires when a statistic of a group of a metric over the last
+// window crosses a threshold, and resolves when it no longer does.
+type LogMetricAlert struct {
+	// Stat is one of LogMetricStats, only count for the count metrics.
+	Stat      string
+	Op        LogMetricAlertOp
+	Threshold float64
+	Window    time.Duration
+}
+
+// NewLogMetricAlert validates an alert written like in requests, with a
+// window like 5m.
+func NewLogMetricAlert(stat string, op LogMetricAlertOp, threshold float64, window string) (*LogMetricAlert, error) {
+	if !slices.Contains(LogMetricStats, stat) {
+		return nil, fmt.Errorf("%w: invalid alert stat %q", ErrLogMetric, stat)
+	}
+
+	if op != LogMetricAbove && op != LogMetricBelow {
+		return nil, fmt.Errorf("%w: alert op must be above or below", ErrLogMetric)
+	}
+
+	length, err := ParseLength(window)
+	if err != nil {
+		return nil, fmt.Errorf("%w: alert window: %w", ErrLogMetric, err)
+	}
+	if length < LogMetricResolution || length > maxLogMetricAlertWindow {
+		return nil, fmt.Errorf("%w: alert window must be between 1m and 1d", ErrLogMetric)
+	}
+
+	return &LogMetricAlert{Stat: stat, Op: op, Threshold: threshold, Window: length}, nil
+}
+
+func (a *LogMetricAlert) isValidFor(metricType LogMetricType) bool {
+	return metricType == LogMetricDistribution || a.Stat == "count"
+}
+
+// Exceeded tells whether the value crosses the threshold.
+func (a *LogMetricAlert) Exceeded(value float64) bool {
+	if a.Op == LogMetricBelow {
+		return value < a.Threshold
+	}
+	return value > a.Threshold
+}
+
+func (a LogMetricAlert) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"stat":      a.Stat,
+		"op":        a.Op,
+		"threshold": a.Threshold,
+		"window":    FormatLength(a.Window),
+	})
+}
+
+// LogMetricAlertState is whether the alert of a metric fires for a group, so
+// the channels are only notified when it changes.
+type LogMetricAlertState struct {
+	MetricID ID
+	Keys     []string
+	Firing   bool
+	// Value is the statistic when the state last changed.
+	Value     float64
+	ChangedAt time.Time
+}
+
+func (s LogMetricAlertState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"metricId":  s.MetricID.Hex(),
+		"keys":      s.Keys,
+		"firing":    s.Firing,
+		"value":     s.Value,
+		"changedAt": s.ChangedAt,
+	})
+}
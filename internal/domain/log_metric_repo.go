@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+type LogMetricRepo interface {
+	SaveLogMetric(ctx context.Context, metric LogMetric) error
+	UpdateLogMetric(ctx context.Context, metric LogMetric) error
+	DeleteLogMetric(ctx context.Context, id ID) error
+	GetLogMetricByID(ctx context.Context, id ID) (*LogMetric, error)
+	ListLogMetrics(ctx context.Context, criteria Criteria) ([]LogMetric, error)
+	// ClaimAlertEvaluation marks the alert of the metric as evaluated at the
+	// time, false when it already was after since, so concurrent batches
+	// evaluate it once.
+	ClaimAlertEvaluation(ctx context.Context, id ID, at time.Time, since time.Time) (bool, error)
+}
+
+type LogMetricRollupRepo interface {
+	// RecordRollups adds the rollups to those of their metric, group and
+	// minute.
+	RecordRollups(ctx context.Context, rollups []LogMetricRollup) error
+	// ListRollups merges the rollups of the metric in the range by group and
+	// interval, sorted by start.
+	ListRollups(ctx context.Context, metricID ID, dateRange Range, interval Interval, location *time.Location) ([]LogMetricRollup, error)
+	// ClaimGroups records the groups of the keys the metric did not have, as
+	// long as it has fewer than limit, and returns those of the keys it has.
+	ClaimGroups(ctx context.Context, metricID ID, keys []string, limit int) (map[string]bool, error)
+	// DeleteRollups deletes the rollups of the metric and its groups.
+	DeleteRollups(ctx context.Context, metricID ID) error
+}
+
+type LogMetricAlertStateRepo interface {
+	SaveAlertState(ctx context.Context, state LogMetricAlertState) error
+	ListAlertStates(ctx context.Context, metricID ID) ([]LogMetricAlertState, error)
+	DeleteAlertStates(ctx context.Context, metricID ID) error
+}
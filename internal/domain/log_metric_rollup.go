@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// LogMetricResolution is the length of the rollups recorded at ingestion,
+// the shortest interval of the series of a metric.
+const LogMetricResolution = time.Minute
+
+// LogMetricRollupRetention is how long the rollups of the metrics are kept.
+const LogMetricRollupRetention = 30 * 24 * time.Hour
+
+// LogMetricGroupLimit bounds the groups of a metric within the retention,
+// since fields with unbounded values, like IDs, make a group per value. The
+// logs of the groups past it are counted in LogMetricOtherGroup.
+const LogMetricGroupLimit = 500
+
+// LogMetricOtherGroup is the value of each group of the logs of the groups
+// past the limit.
+const LogMetricOtherGroup = "(other)"
+
+// LogMetricStats are the statistics of a distribution, count being the only
+// one of a count.
+var LogMetricStats = []string{"count", "sum", "avg", "min", "max", "p50", "p90", "p95", "p99"}
+
+// LogMetricRollup adds up the logs of a group of a metric over a time bucket.
+type LogMetricRollup struct {
+	MetricID ID
+	Start    time.Time
+	// Keys are the values of the groups of the metric, in its order, empty
+	// for the logs without them.
+	Keys []string
+	// Distribution only counts the logs of a count.
+	Distribution
+}
+
+// LogMetricGroupKey identifies the group of the keys, which can hold any
+// text.
+func LogMetricGroupKey(keys []string) string {
+	key, _ := json.Marshal(keys)
+	return string(key)
+}
+
+// RollUpLogs adds up the logs the metric counts by group and minute.
+func RollUpLogs(metric LogMetric, logs []Log) []LogMetricRollup {
+	rollups := []LogMetricRollup{}
+	index := map[string]int{}
+	for _, log := range logs {
+		keys, value, ok := metric.Observe(log)
+		if !ok {
+			continue
+		}
+
+		start := log.Timestamp().UTC().Truncate(LogMetricResolution)
+		key := strconv.FormatInt(start.Unix(), 10) + LogMetricGroupKey(keys)
+		i, ok := index[key]
+		if !ok {
+			i = len(rollups)
+			index[key] = i
+			rollups = append(rollups, LogMetricRollup{MetricID: metric.ID(), Start: start, Keys: keys})
+		}
+
+		if metric.Type() == LogMetricDistribution {
+			rollups[i].Add(value)
+		} else {
+			rollups[i].Count++
+		}
+	}
+	return rollups
+}
+
+// LimitLogMetricGroups moves the rollups of the groups that are not allowed
+// into the other group, merging those of the same minute.
+func LimitLogMetricGroups(rollups []LogMetricRollup, allowed map[string]bool) []LogMetricRollup {
+	limited := []LogMetricRollup{}
+	others := map[int64]int{}
+	for _, rollup := range rollups {
+		if allowed[LogMetricGroupKey(rollup.Keys)] {
+			limited = append(limited, rollup)
+			continue
+		}
+
+		if i, ok := others[rollup.Start.Unix()]; ok {
+			limited[i].Merge(rollup.Distribution)
+			continue
+		}
+		keys := make([]string, len(rollup.Keys))
+		for i := range keys {
+			keys[i] = LogMetricOtherGroup
+		}
+		others[rollup.Start.Unix()] = len(limited)
+		rollup.Keys = keys
+		limited = append(limited, rollup)
+	}
+	return limited
+}
@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLogPattern = fmt.Errorf("error in log pattern")
+)
+
+// LogPattern is a message template learned from the logs of an app, like
+// "user <num> logged in from <*>". Logs keep the ID of their pattern.
+type LogPattern struct {
+	id        ID
+	appID     ID
+	template  string
+	count     int64
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+func NewLogPattern(
+	id ID,
+	appID ID,
+	template string,
+	count int64,
+	firstSeen time.Time,
+	lastSeen time.Time,
+) (*LogPattern, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("%w: template cannot be empty", ErrLogPattern)
+	}
+
+	return &LogPattern{
+		id:        id,
+		appID:     appID,
+		template:  template,
+		count:     count,
+		firstSeen: firstSeen,
+		lastSeen:  lastSeen,
+	}, nil
+}
+
+func (p *LogPattern) ID() ID {
+	return p.id
+}
+
+func (p *LogPattern) AppID() ID {
+	return p.appID
+}
+
+func (p *LogPattern) Template() string {
+	return p.template
+}
+
+// Count is how many logs had the pattern since it was learned.
+func (p *LogPattern) Count() int64 {
+	return p.count
+}
+
+func (p *LogPattern) FirstSeen() time.Time {
+	return p.firstSeen
+}
+
+func (p *LogPattern) LastSeen() time.Time {
+	return p.lastSeen
+}
+
+// LogPatternLogs are logs of an app that fit a pattern, whose template may
+// have become more general to fit them.
+type LogPatternLogs struct {
+	PatternID ID
+	AppID     ID
+	// New tells whether the pattern was learned from the logs.
+	New       bool
+	Template  string
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+func (p LogPattern) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":        p.id,
+		"appId":     p.appID,
+		"template":  p.template,
+		"count":     p.count,
+		"firstSeen": p.firstSeen,
+		"lastSeen":  p.lastSeen,
+	})
+}
@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+)
+
+type LogPatternRepo interface {
+	// RecordLogPattern saves the template of the pattern of the logs,
+	// creating the pattern when it is new and the app has no pattern with
+	// its template, and returns the pattern. The logs are not counted.
+	RecordLogPattern(ctx context.Context, logs LogPatternLogs) (*LogPattern, error)
+	// AddLogPatternLogs adds the logs to their patterns, once they are saved.
+	AddLogPatternLogs(ctx context.Context, logs []LogPatternLogs) error
+	GetLogPatternByID(ctx context.Context, id ID) (*LogPattern, error)
+	ListLogPatterns(ctx context.Context, criteria Criteria) ([]LogPattern, error)
+}
@@ -0,0 +1,11 @@
+package domain
+
+// LogQueryPlan is how the database would run a search of the logs.
+type LogQueryPlan struct {
+	// Plan is the winning plan, as the database reports it.
+	Plan any
+	// Indexes are the names of the indexes the plan reads.
+	Indexes []string
+	// CollectionScan is set when the plan reads every log of the collection.
+	CollectionScan bool
+}
@@ -2,9 +2,33 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 type LogRepo interface {
 	SaveLogs(ctx context.Context, logs []Log) error
+	GetLogByID(ctx context.Context, id ID) (*Log, error)
 	ListLogs(ctx context.Context, criteria Criteria) ([]Log, error)
+	// CountLogs counts the logs that match the filters of the criteria, up
+	// to max.
+	CountLogs(ctx context.Context, criteria Criteria, max int64) (int64, error)
+	// HistogramLogs counts the logs that match the criteria per interval,
+	// truncating their timestamps in the location, and per value of the
+	// splitBy field unless it is empty.
+	HistogramLogs(ctx context.Context, criteria Criteria, interval Interval, location *time.Location, splitBy string) ([]LogHistogramBucket, error)
+	// AggregateLogs groups the logs that match the criteria and computes the
+	// metrics of each group. truncated is set when there were more than
+	// MaxRows groups, those with fewer logs being left out.
+	AggregateLogs(ctx context.Context, criteria Criteria, aggregation LogAggregation) (rows []LogAggregationRow, truncated bool, err error)
+	// FieldValues reads the logs that match the criteria in its sort order,
+	// up to the limit of its pagination, and counts the values of the field
+	// that start with the prefix, ignoring case, keeping the size most
+	// frequent.
+	FieldValues(ctx context.Context, criteria Criteria, field string, prefix string, size int) (LogFieldValues, error)
+	// StreamLogs calls fn with each log that matches the criteria, without
+	// loading them all in memory, and stops at the first error.
+	StreamLogs(ctx context.Context, criteria Criteria, fn func(Log) error) error
+	// ExplainLogs returns the plan the database picks to list the logs of
+	// the criteria, without running it.
+	ExplainLogs(ctx context.Context, criteria Criteria) (LogQueryPlan, error)
 }
@@ -1,6 +1,296 @@
 package domain
 
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// maxSchemaFields keeps data with dynamic keys, like maps of ids, from
+	// growing the schema without bounds.
+	maxSchemaFields = 2000
+	maxSchemaDepth  = 20
+	// maxSchemaSamples is how many different sample values a field keeps.
+	maxSchemaSamples      = 5
+	maxSchemaSampleLength = 100
+)
+
 type LogSchema struct {
-	Total  int            `json:"total"`
+	Total int `json:"total"`
+	// LastSeen is the timestamp of the latest log.
+	LastSeen time.Time `json:"lastSeen"`
+	// Schema counts the logs that have each field of the data, by its path
+	// under data, leaving out the fields that are only objects.
 	Schema map[string]int `json:"schema"`
+	// Fields are all the fields of the data, sorted by path.
+	Fields []LogSchemaField `json:"fields"`
+	// Rebuilding is whether a catalog is being rebuilt from the stored
+	// logs, so it misses fields and counts.
+	Rebuilding bool `json:"rebuilding"`
+}
+
+// LogSchemaField is a field of the data of the logs. Arrays are walked like
+// queries do: the fields of the objects in an array are under the path of
+// the array, and the types of its elements are counted with the array type.
+type LogSchemaField struct {
+	// Path is the path under data, like request.headers.host.
+	Path string `json:"path"`
+	// Count is how many logs have the field.
+	Count int `json:"count"`
+	// Types counts the values of the field by BSON type, like string, double
+	// or array.
+	Types     map[string]int `json:"types"`
+	FirstSeen time.Time      `json:"firstSeen"`
+	LastSeen  time.Time      `json:"lastSeen"`
+	// Samples are a few different values of the field, objects and arrays
+	// left out.
+	Samples []any `json:"samples"`
+}
+
+// LogSchemaBuilder adds up the fields of logs into a schema.
+type LogSchemaBuilder struct {
+	total    int
+	lastSeen time.Time
+	fields   map[string]*LogSchemaField
+}
+
+func NewLogSchemaBuilder() *LogSchemaBuilder {
+	return &LogSchemaBuilder{fields: map[string]*LogSchemaField{}}
+}
+
+// Add walks the data of a log seen at the timestamp.
+func (b *LogSchemaBuilder) Add(timestamp time.Time, data map[string]any) {
+	b.total++
+	if timestamp.After(b.lastSeen) {
+		b.lastSeen = timestamp
+	}
+	seen := map[string]bool{}
+	b.walkObject("", data, timestamp, seen, 0)
+}
+
+// Build returns the schema of the logs added so far.
+func (b *LogSchemaBuilder) Build() LogSchema {
+	fields := make([]LogSchemaField, 0, len(b.fields))
+	for _, field := range b.fields {
+		field := *field
+		field.Types = maps.Clone(field.Types)
+		field.Samples = slices.Clone(field.Samples)
+		fields = append(fields, field)
+	}
+	return NewLogSchema(b.total, b.lastSeen, fields)
+}
+
+// NewLogSchema sorts the fields by path and counts the logs per path of the
+// fields that are not only objects.
+func NewLogSchema(total int, lastSeen time.Time, fields []LogSchemaField) LogSchema {
+	slices.SortFunc(fields, func(a, b LogSchemaField) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	schema := make(map[string]int, len(fields))
+	for _, field := range fields {
+		if !field.IsOnlyObject() {
+			schema[field.Path] = field.Count
+		}
+	}
+	return LogSchema{Total: total, LastSeen: lastSeen, Schema: schema, Fields: fields}
+}
+
+// IsOnlyObject tells the fields whose values were all objects, which only
+// hold other fields.
+func (f *LogSchemaField) IsOnlyObject() bool {
+	return len(f.Types) == 1 && f.Types["object"] > 0
+}
+
+// Merge adds up the field as seen by other logs, like those of another app.
+func (f *LogSchemaField) Merge(other LogSchemaField) {
+	f.Count += other.Count
+	if f.Types == nil {
+		f.Types = map[string]int{}
+	}
+	for valueType, count := range other.Types {
+		f.Types[valueType] += count
+	}
+	if f.FirstSeen.IsZero() || other.FirstSeen.Before(f.FirstSeen) {
+		f.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(f.LastSeen) {
+		f.LastSeen = other.LastSeen
+	}
+	for _, sample := range other.Samples {
+		addSchemaSample(f, sample)
+	}
+}
+
+func (b *LogSchemaBuilder) walkObject(prefix string, object map[string]any, timestamp time.Time, seen map[string]bool, depth int) {
+	for key, value := range object {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		b.walkValue(path, value, timestamp, seen, depth+1)
+	}
+}
+
+func (b *LogSchemaBuilder) walkValue(path string, value any, timestamp time.Time, seen map[string]bool, depth int) {
+	if depth > maxSchemaDepth || strings.ContainsAny(path, "$\x00") {
+		return
+	}
+
+	field, ok := b.fields[path]
+	if !ok {
+		if len(b.fields) >= maxSchemaFields {
+			return
+		}
+		field = &LogSchemaField{Path: path, Types: map[string]int{}, FirstSeen: timestamp, LastSeen: timestamp, Samples: []any{}}
+		b.fields[path] = field
+	}
+
+	if !seen[path] {
+		seen[path] = true
+		field.Count++
+		if timestamp.Before(field.FirstSeen) {
+			field.FirstSeen = timestamp
+		}
+		if timestamp.After(field.LastSeen) {
+			field.LastSeen = timestamp
+		}
+	}
+
+	valueType := BSONType(value)
+	field.Types[valueType]++
+
+	switch valueType {
+	case "object":
+		if object, ok := schemaObject(value); ok {
+			b.walkObject(path, object, timestamp, seen, depth)
+		}
+	case "array":
+		for _, element := range schemaArray(value) {
+			// The elements are counted with the array, and their fields under
+			// its path.
+			if BSONType(element) == "object" {
+				if object, ok := schemaObject(element); ok {
+					b.walkObject(path, object, timestamp, seen, depth)
+				}
+				continue
+			}
+			field.Types[BSONType(element)]++
+			addSchemaSample(field, element)
+		}
+	default:
+		addSchemaSample(field, value)
+	}
+}
+
+func addSchemaSample(field *LogSchemaField, value any) {
+	if len(field.Samples) >= maxSchemaSamples || value == nil {
+		return
+	}
+
+	sample := schemaSample(value)
+	for _, existing := range field.Samples {
+		if existing == sample {
+			return
+		}
+	}
+	field.Samples = append(field.Samples, sample)
+}
+
+// schemaSample turns a value into a comparable one that reads well in JSON.
+func schemaSample(value any) any {
+	switch v := value.(type) {
+	case string:
+		if len(v) > maxSchemaSampleLength {
+			return v[:maxSchemaSampleLength]
+		}
+		return v
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case time.Time:
+		return v.UTC()
+	case primitive.ObjectID:
+		return v.Hex()
+	case int32, int64, float64, bool:
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+func schemaObject(value any) (map[string]any, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		return v, true
+	case primitive.M:
+		return v, true
+	case primitive.D:
+		object := make(map[string]any, len(v))
+		for _, element := range v {
+			object[element.Key] = element.Value
+		}
+		return object, true
+	case map[string]string:
+		object := make(map[string]any, len(v))
+		for key, value := range v {
+			object[key] = value
+		}
+		return object, true
+	}
+	return nil, false
+}
+
+func schemaArray(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		return v
+	case primitive.A:
+		return v
+	case []map[string]any:
+		array := make([]any, len(v))
+		for i, element := range v {
+			array[i] = element
+		}
+		return array
+	}
+	return nil
+}
+
+// BSONType is the name of the BSON type of a value, like $type returns.
+func BSONType(value any) string {
+	switch value.(type) {
+	case nil, primitive.Null:
+		return "null"
+	case string:
+		return "string"
+	case float64, float32:
+		return "double"
+	case int32, int8, int16:
+		return "int"
+	case int64, int:
+		return "long"
+	case bool:
+		return "bool"
+	case time.Time, primitive.DateTime:
+		return "date"
+	case primitive.ObjectID:
+		return "objectId"
+	case primitive.Decimal128:
+		return "decimal"
+	case primitive.Binary, []byte:
+		return "binData"
+	case primitive.Regex:
+		return "regex"
+	case primitive.Timestamp:
+		return "timestamp"
+	case map[string]any, primitive.M, primitive.D, map[string]string:
+		return "object"
+	case []any, primitive.A, []map[string]any:
+		return "array"
+	}
+	return "unknown"
 }
@@ -4,6 +4,23 @@ import (
 	"context"
 )
 
+// LogSchemaRepo keeps a catalog of the fields of the logs of each app.
 type LogSchemaRepo interface {
+	// Get merges the catalogs of the apps of the user, or of the given apps
+	// of the user. With a range, only the fields seen in it are listed, with
+	// the counts of all time.
 	Get(ctx context.Context, userID ID, appIDs []ID, optionalRange *Range) (LogSchema, error)
+	// GetFields returns the catalog of the app with only the fields of the
+	// given paths.
+	GetFields(ctx context.Context, appID ID, paths []string) (LogSchema, error)
+	// ListFieldsLastSeen lists the fields of the catalog of the app last seen
+	// in the range, from included and to excluded.
+	ListFieldsLastSeen(ctx context.Context, appID ID, dateRange Range) ([]LogSchemaField, error)
+	// Record adds the schema of new logs of the app to its catalog.
+	Record(ctx context.Context, appID ID, schema LogSchema) error
+	// StartRebuild empties the catalog of the app and marks it as being
+	// rebuilt until FinishRebuild, which the catalogs read tell.
+	StartRebuild(ctx context.Context, appID ID) error
+	// FinishRebuild marks the catalog of the app as rebuilt.
+	FinishRebuild(ctx context.Context, appID ID) error
 }
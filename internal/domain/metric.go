@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMetric = fmt.Errorf("error in metric")
+)
+
+// MetricKind is how the values of a metric add up over time.
+type MetricKind string
+
+const (
+	// MetricGauge is a value at a time, like the size of a queue.
+	MetricGauge MetricKind = "gauge"
+	// MetricCounter counts events, each sample holding how many happened
+	// since the previous one.
+	MetricCounter MetricKind = "counter"
+	// MetricHistogram observes values, like latencies, each sample holding
+	// the distribution of those observed since the previous one.
+	MetricHistogram MetricKind = "histogram"
+)
+
+const (
+	maxMetricNameLength       = 200
+	maxMetricLabels           = 30
+	maxMetricLabelNameLength  = 100
+	maxMetricLabelValueLength = 1024
+)
+
+var (
+	// Metric names are those of Prometheus, dots of OpenTelemetry allowed.
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:.]*$`)
+	// Label names are those of Prometheus, so they are field names that can
+	// be queried.
+	metricLabelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// MetricStats are the statistics of the points of a series by kind. Rate is
+// per second: what a counter counted, or how many values a histogram
+// observed.
+var MetricStats = map[MetricKind][]string{
+	MetricGauge:     {"count", "avg", "min", "max", "last"},
+	MetricCounter:   {"sum", "rate"},
+	MetricHistogram: {"count", "sum", "avg", "min", "max", "rate", "p50", "p90", "p95", "p99"},
+}
+
+// MetricSample is a value of a series, the metric of an app with some labels,
+// at a time.
+type MetricSample struct {
+	AppID     ID
+	Name      string
+	Kind      MetricKind
+	Labels    map[string]string
+	Timestamp time.Time
+	// Value is the value of a gauge, or what a counter counted.
+	Value float64
+	// Distribution holds the values a histogram observed.
+	Distribution *Distribution
+	// Cumulative samples of counters and histograms hold what they counted
+	// since StartTime, or since they started when it is zero, rather than
+	// since their previous sample.
+	Cumulative bool
+	StartTime  time.Time
+}
+
+// NewMetricSample checks the name and labels of a sample. The value is set
+// after.
+func NewMetricSample(name string, kind MetricKind, labels map[string]string, timestamp time.Time) (*MetricSample, error) {
+	if len(name) > maxMetricNameLength || !metricNameRegex.MatchString(name) {
+		return nil, fmt.Errorf("%w: invalid name %q", ErrMetric, name)
+	}
+
+	if _, ok := MetricStats[kind]; !ok {
+		return nil, fmt.Errorf("%w: %s must be a gauge, counter or histogram", ErrMetric, name)
+	}
+
+	if len(labels) > maxMetricLabels {
+		return nil, fmt.Errorf("%w: %s has more than %d labels", ErrMetric, name, maxMetricLabels)
+	}
+	for label, value := range labels {
+		if err := ValidateMetricLabel(label); err != nil {
+			return nil, err
+		}
+		if len(value) > maxMetricLabelValueLength {
+			return nil, fmt.Errorf("%w: the value of label %s is longer than %d", ErrMetric, label, maxMetricLabelValueLength)
+		}
+	}
+
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	return &MetricSample{Name: name, Kind: kind, Labels: labels, Timestamp: timestamp.UTC()}, nil
+}
+
+// ValidateMetricLabel checks the name of a label.
+func ValidateMetricLabel(label string) error {
+	if len(label) > maxMetricLabelNameLength || !metricLabelNameRegex.MatchString(label) {
+		return fmt.Errorf("%w: invalid label name %q", ErrMetric, label)
+	}
+	return nil
+}
+
+// SanitizeMetricLabel turns a name into a label name, replacing the
+// characters label names cannot have with underscores, like the dots of the
+// attributes of OpenTelemetry.
+func SanitizeMetricLabel(name string) string {
+	label := []byte(name)
+	for i, c := range label {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9' && i > 0) {
+			label[i] = '_'
+		}
+	}
+	return string(label)
+}
+
+// Series identifies the series of the sample within its app.
+func (s MetricSample) Series() string {
+	return MetricSeriesKey(s.Name, s.Labels)
+}
+
+// MetricSeriesKey writes a series like Prometheus does, as the metric name
+// followed by the labels sorted by name, like requests{method="GET"}.
+func MetricSeriesKey(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	pairs := make([]string, 0, len(labels))
+	for _, label := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, label+"="+strconv.Quote(labels[label]))
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// MetricName sums up the series of a metric.
+type MetricName struct {
+	Name string `json:"name"`
+	// Kinds are usually one, unless apps report the metric differently.
+	Kinds []MetricKind `json:"kinds"`
+	// Labels are the names of the labels of its series.
+	Labels     []string  `json:"labels"`
+	Series     int       `json:"series"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MetricQuery selects the series of a metric in some apps, by the values of
+// some of their labels, and merges their samples by interval over a range.
+type MetricQuery struct {
+	AppIDs   []ID
+	Name     string
+	Labels   map[string]string
+	Range    Range
+	Interval Interval
+	Location *time.Location
+}
+
+type MetricRepo interface {
+	SaveMetricSamples(ctx context.Context, samples []MetricSample) error
+	// RecordMetricRollups adds the rollups to those of their series and
+	// bucket of the resolution.
+	RecordMetricRollups(ctx context.Context, resolution time.Duration, rollups []MetricRollup) error
+	// ListMetricRollups merges the samples of the series of the query by
+	// series and interval, sorted by start.
+	ListMetricRollups(ctx context.Context, query MetricQuery) ([]MetricRollup, error)
+}
+
+type MetricSeriesRepo interface {
+	ListMetricSeriesByKeys(ctx context.Context, appID ID, keys []string) ([]MetricSeries, error)
+	CountMetricSeries(ctx context.Context, appID ID) (int64, error)
+	// SaveMetricSeries creates the new series and updates when the others
+	// were last seen and their totals.
+	SaveMetricSeries(ctx context.Context, series []MetricSeries) error
+	// ListMetricNames sums up the series of the apps by metric, sorted by
+	// name, those whose name contains the search term when it is not empty.
+	ListMetricNames(ctx context.Context, appIDs []ID, searchTerm string, limit int) ([]MetricName, error)
+}
@@ -0,0 +1,94 @@
+package domain
+
+import "time"
+
+// MetricSampleRetention is how long the samples are kept, the series of
+// longer ranges being read from the rollups.
+const MetricSampleRetention = 7 * 24 * time.Hour
+
+// MetricRollupRetentions are the lengths of the rollups recorded at
+// ingestion, and how long they are kept.
+var MetricRollupRetentions = map[time.Duration]time.Duration{
+	5 * time.Minute: 90 * 24 * time.Hour,
+	time.Hour:       2 * 365 * 24 * time.Hour,
+}
+
+// MetricRollup adds up the samples of a series over a time bucket.
+type MetricRollup struct {
+	AppID  ID
+	Name   string
+	Kind   MetricKind
+	Labels map[string]string
+	Start  time.Time
+	// Distribution adds up the values of a gauge or counter, without a
+	// histogram, or the distributions of a histogram.
+	Distribution
+	// Last is the latest value of a gauge or counter, at LastAt.
+	Last   float64
+	LastAt time.Time
+}
+
+// RollUpMetricSamples adds up the samples by series and bucket of the
+// resolution.
+func RollUpMetricSamples(samples []MetricSample, resolution time.Duration) []MetricRollup {
+	rollups := []MetricRollup{}
+	index := map[string]int{}
+	for _, sample := range samples {
+		rollup := sample.Rollup(resolution)
+		key := rollup.AppID.Hex() + rollup.Start.Format(time.RFC3339) + sample.Series()
+		if i, ok := index[key]; ok {
+			rollups[i].Merge(rollup)
+			continue
+		}
+		index[key] = len(rollups)
+		rollups = append(rollups, rollup)
+	}
+	return rollups
+}
+
+// Rollup is the rollup of the bucket of the resolution the sample is in,
+// with only the sample.
+func (s MetricSample) Rollup(resolution time.Duration) MetricRollup {
+	rollup := MetricRollup{
+		AppID:  s.AppID,
+		Name:   s.Name,
+		Kind:   s.Kind,
+		Labels: s.Labels,
+		Start:  s.Timestamp.Truncate(resolution),
+	}
+	if s.Distribution != nil {
+		rollup.Distribution.Merge(*s.Distribution)
+		return rollup
+	}
+
+	rollup.Distribution = Distribution{Count: 1, Sum: s.Value, Min: s.Value, Max: s.Value}
+	rollup.Last, rollup.LastAt = s.Value, s.Timestamp
+	return rollup
+}
+
+// Merge adds up another rollup of the series, of another bucket or of the
+// same one.
+func (r *MetricRollup) Merge(other MetricRollup) {
+	r.Distribution.Merge(other.Distribution)
+	if other.LastAt.After(r.LastAt) {
+		r.Last, r.LastAt = other.Last, other.LastAt
+	}
+}
+
+// Stat returns one of the MetricStats of the kind of the rollup, over a
+// bucket of the length, false when the rollup has no values for it.
+func (r *MetricRollup) Stat(stat string, length time.Duration) (float64, bool) {
+	switch stat {
+	case "last":
+		return r.Last, !r.LastAt.IsZero()
+	case "rate":
+		if length <= 0 || r.Count == 0 {
+			return 0, false
+		}
+		if r.Kind == MetricHistogram {
+			return float64(r.Count) / length.Seconds(), true
+		}
+		return r.Sum / length.Seconds(), true
+	}
+	return r.Distribution.Stat(stat)
+}
@@ -0,0 +1,84 @@
+package domain
+
+import "time"
+
+// MetricSeries is a series of an app, as the catalog of the metrics knows
+// it.
+type MetricSeries struct {
+	AppID       ID
+	Key         string
+	Name        string
+	Kind        MetricKind
+	Labels      map[string]string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	// Total is the latest total of a cumulative counter or histogram, which
+	// its next total is counted from.
+	Total *MetricTotal
+}
+
+// MetricTotal is what a cumulative counter or histogram counted since its
+// start time.
+type MetricTotal struct {
+	StartTime    time.Time
+	Timestamp    time.Time
+	Value        float64
+	Distribution *Distribution
+}
+
+// NewMetricSeries starts the series of a sample.
+func NewMetricSeries(sample MetricSample) MetricSeries {
+	return MetricSeries{
+		AppID:       sample.AppID,
+		Key:         sample.Series(),
+		Name:        sample.Name,
+		Kind:        sample.Kind,
+		Labels:      sample.Labels,
+		FirstSeenAt: sample.Timestamp,
+		LastSeenAt:  sample.Timestamp,
+	}
+}
+
+// Accumulate turns a cumulative sample into what it counted since the
+// previous total of the series, which it replaces. It returns false for the
+// first total, which only starts the count, and for totals older than the
+// latest one. A total lower than the previous one, or with another start
+// time, means the counter restarted, so all of it is counted.
+func (s *MetricSeries) Accumulate(sample MetricSample) (MetricSample, bool) {
+	if sample.Timestamp.After(s.LastSeenAt) {
+		s.LastSeenAt = sample.Timestamp
+	}
+	if !sample.Cumulative {
+		return sample, true
+	}
+
+	previous := s.Total
+	if previous != nil && !sample.Timestamp.After(previous.Timestamp) {
+		return MetricSample{}, false
+	}
+	s.Total = &MetricTotal{
+		StartTime:    sample.StartTime,
+		Timestamp:    sample.Timestamp,
+		Value:        sample.Value,
+		Distribution: sample.Distribution,
+	}
+	if previous == nil {
+		return MetricSample{}, false
+	}
+
+	delta := sample
+	delta.Cumulative = false
+	delta.StartTime = time.Time{}
+	if !sample.StartTime.Equal(previous.StartTime) {
+		return delta, true
+	}
+
+	if sample.Distribution != nil && previous.Distribution != nil {
+		if diff, ok := sample.Distribution.Sub(*previous.Distribution); ok {
+			delta.Distribution = &diff
+		}
+	} else if sample.Value >= previous.Value {
+		delta.Value = sample.Value - previous.Value
+	}
+	return delta, true
+}
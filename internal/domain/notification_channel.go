@@ -0,0 +1,211 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotificationChannel = fmt.Errorf("error in notification channel")
+)
+
+// NotificationChannelType is where a channel sends its notifications.
+type NotificationChannelType string
+
+const (
+	// NotificationWebhook posts the notifications as JSON to a URL, with a
+	// text field that chat webhooks, like the incoming webhooks of Slack,
+	// show as a message.
+	NotificationWebhook NotificationChannelType = "webhook"
+	NotificationEmail   NotificationChannelType = "email"
+)
+
+// NotificationEvent is a kind of notification a channel subscribes to.
+type NotificationEvent string
+
+const (
+	NotificationSchemaDrift NotificationEvent = "schemaDrift"
+	// NotificationLogMetricAlert is sent when the alert of a log metric fires
+	// or resolves.
+	NotificationLogMetricAlert NotificationEvent = "logMetricAlert"
+)
+
+var notificationEvents = []NotificationEvent{NotificationSchemaDrift, NotificationLogMetricAlert}
+
+// NotificationChannel sends notifications of some events of the apps of a
+// user to a webhook or an email address.
+type NotificationChannel struct {
+	id          ID
+	userID      ID
+	name        string
+	channelType NotificationChannelType
+	target      string
+	events      []NotificationEvent
+	// appIDs limits the channel to some apps, all apps when empty.
+	appIDs    []ID
+	createdAt time.Time
+}
+
+func NewNotificationChannel(
+	id ID,
+	userID ID,
+	name string,
+	channelType NotificationChannelType,
+	target string,
+	events []NotificationEvent,
+	appIDs []ID,
+	createdAt time.Time,
+) (*NotificationChannel, error) {
+	channel := &NotificationChannel{
+		id:        id,
+		userID:    userID,
+		createdAt: createdAt,
+	}
+
+	if err := channel.ChangeName(name); err != nil {
+		return nil, err
+	}
+
+	if err := channel.ChangeTarget(channelType, target); err != nil {
+		return nil, err
+	}
+
+	if err := channel.ChangeSubscription(events, appIDs); err != nil {
+		return nil, err
+	}
+
+	return channel, nil
+}
+
+func (c *NotificationChannel) ID() ID {
+	return c.id
+}
+
+func (c *NotificationChannel) UserID() ID {
+	return c.userID
+}
+
+func (c *NotificationChannel) Name() string {
+	return c.name
+}
+
+func (c *NotificationChannel) Type() NotificationChannelType {
+	return c.channelType
+}
+
+// Target is the URL of a webhook or the address of an email channel.
+func (c *NotificationChannel) Target() string {
+	return c.target
+}
+
+func (c *NotificationChannel) Events() []NotificationEvent {
+	return c.events
+}
+
+func (c *NotificationChannel) AppIDs() []ID {
+	return c.appIDs
+}
+
+func (c *NotificationChannel) CreatedAt() time.Time {
+	return c.createdAt
+}
+
+// IsSubscribed tells whether the channel sends the event of the app.
+func (c *NotificationChannel) IsSubscribed(event NotificationEvent, appID ID) bool {
+	if !slices.Contains(c.events, event) {
+		return false
+	}
+	return len(c.appIDs) == 0 || slices.Contains(c.appIDs, appID)
+}
+
+func (c *NotificationChannel) ChangeName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrNotificationChannel)
+	}
+
+	c.name = name
+	return nil
+}
+
+// ChangeTarget sets where the channel sends: a public http or https URL for a
+// webhook, an address for an email.
+func (c *NotificationChannel) ChangeTarget(channelType NotificationChannelType, target string) error {
+	target = strings.TrimSpace(target)
+
+	switch channelType {
+	case NotificationWebhook:
+		parsed, err := url.Parse(target)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: target must be an http or https URL", ErrNotificationChannel)
+		}
+		// The addresses names resolve to are checked when sending.
+		addr, err := netip.ParseAddr(parsed.Hostname())
+		if strings.EqualFold(parsed.Hostname(), "localhost") || (err == nil && !IsPublicAddr(addr)) {
+			return fmt.Errorf("%w: target must be a public URL", ErrNotificationChannel)
+		}
+	case NotificationEmail:
+		address, err := mail.ParseAddress(target)
+		if err != nil {
+			return fmt.Errorf("%w: target must be an email address", ErrNotificationChannel)
+		}
+		target = address.Address
+	default:
+		return fmt.Errorf("%w: invalid type %s, use webhook or email", ErrNotificationChannel, channelType)
+	}
+
+	c.channelType = channelType
+	c.target = target
+	return nil
+}
+
+func (c *NotificationChannel) ChangeSubscription(events []NotificationEvent, appIDs []ID) error {
+	if len(events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrNotificationChannel)
+	}
+
+	for _, event := range events {
+		if !slices.Contains(notificationEvents, event) {
+			return fmt.Errorf("%w: invalid event %s", ErrNotificationChannel, event)
+		}
+	}
+
+	if appIDs == nil {
+		appIDs = []ID{}
+	}
+
+	c.events = events
+	c.appIDs = appIDs
+	return nil
+}
+
+func (c NotificationChannel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":        c.id,
+		"userId":    c.userID,
+		"name":      c.name,
+		"type":      c.channelType,
+		"target":    c.target,
+		"events":    c.events,
+		"appIds":    c.appIDs,
+		"createdAt": c.createdAt,
+	})
+}
+
+// sharedAddressSpace is the range of carrier-grade NAT, which is not routed
+// on the internet either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublicAddr tells whether the address is reachable on the internet, so
+// webhooks cannot reach the services of the network of the server.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+)
+
+type NotificationChannelRepo interface {
+	SaveNotificationChannel(ctx context.Context, channel NotificationChannel) error
+	UpdateNotificationChannel(ctx context.Context, channel NotificationChannel) error
+	DeleteNotificationChannel(ctx context.Context, id ID) error
+	GetNotificationChannelByID(ctx context.Context, id ID) (*NotificationChannel, error)
+	ListNotificationChannels(ctx context.Context, criteria Criteria) ([]NotificationChannel, error)
+}
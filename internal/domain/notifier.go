@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"context"
+)
+
+// Notification is a message about an event of an app.
+type Notification struct {
+	Event   NotificationEvent `json:"event"`
+	AppID   ID                `json:"appId"`
+	Subject string            `json:"subject"`
+	Text    string            `json:"text"`
+	// Data holds the details of the event, like the schema drift events.
+	Data any `json:"data"`
+}
+
+// Notifier sends notifications through a channel.
+type Notifier interface {
+	Notify(ctx context.Context, channel NotificationChannel, notification Notification) error
+}
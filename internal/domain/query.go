@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryNode is a node of the expression tree of a search query. It is either
+// a QueryGroup, a QueryNot or a QueryPredicate.
+type QueryNode interface {
+	isQueryNode()
+}
+
+// QueryGroupType is the boolean operator that joins the nodes of a group.
+type QueryGroupType string
+
+const (
+	QueryAnd QueryGroupType = "and"
+	QueryOr  QueryGroupType = "or"
+)
+
+// QueryGroup matches when all (and) or any (or) of its nodes match.
+type QueryGroup struct {
+	Type  QueryGroupType
+	Nodes []QueryNode
+}
+
+// QueryNot matches when its node does not match.
+type QueryNot struct {
+	Node QueryNode
+}
+
+// QueryOperator is how a predicate compares a field with its value.
+type QueryOperator string
+
+const (
+	QueryEquals             QueryOperator = "eq"
+	QueryGreaterThan        QueryOperator = "gt"
+	QueryGreaterThanOrEqual QueryOperator = "gte"
+	QueryLessThan           QueryOperator = "lt"
+	QueryLessThanOrEqual    QueryOperator = "lte"
+	// QueryWildcard matches Pattern, a regular expression anchored to the
+	// whole value built from the * and ? wildcards.
+	QueryWildcard QueryOperator = "wildcard"
+	QueryExists   QueryOperator = "exists"
+	// QueryContains matches the text anywhere in the field, ignoring case.
+	QueryContains QueryOperator = "contains"
+)
+
+// QueryPredicate compares a field of the log with a value.
+type QueryPredicate struct {
+	Field    string
+	Operator QueryOperator
+	// Value is the typed value: a float64, bool, time.Time or string.
+	Value any
+	// Text is the value as written, so "500" can match both the number and
+	// the string.
+	Text    string
+	Pattern string
+}
+
+func (QueryGroup) isQueryNode()     {}
+func (QueryNot) isQueryNode()       {}
+func (QueryPredicate) isQueryNode() {}
+
+// QuerySyntaxError reports where a query could not be parsed. Position is
+// the 0-based offset in bytes in the query.
+type QuerySyntaxError struct {
+	Position int
+	Message  string
+}
+
+func (e *QuerySyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s", e.Position, e.Message)
+}
+
+// queryFields are the log fields that can be searched besides the nested
+// ones under data and stackTrace.
+var queryFields = map[string]bool{
+	"level":       true,
+	"raw":         true,
+	"timestamp":   true,
+	"fingerprint": true,
+	"patternId":   true,
+}
+
+// IsQueryField tells whether the field can be searched: a log field or a
+// nested field under data or stackTrace.
+func IsQueryField(field string) bool {
+	if queryFields[field] {
+		return true
+	}
+
+	for _, prefix := range []string{"data.", "stackTrace."} {
+		rest, ok := strings.CutPrefix(field, prefix)
+		if ok && rest != "" && !strings.HasPrefix(rest, ".") && !strings.HasSuffix(rest, ".") && !strings.Contains(rest, "..") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "fmt"
+
+// QueryFilter turns the expression tree of a query into a filter group, so
+// that queries are matched by the repositories like any other criteria.
+func QueryFilter(node QueryNode) (Filter, error) {
+	switch n := node.(type) {
+	case QueryGroup:
+		filters := make([]Filter, len(n.Nodes))
+		for i, child := range n.Nodes {
+			filter, err := QueryFilter(child)
+			if err != nil {
+				return Filter{}, err
+			}
+			filters[i] = filter
+		}
+		if n.Type == QueryOr {
+			return NewOrFilter(filters...), nil
+		}
+		return NewAndFilter(filters...), nil
+	case QueryNot:
+		filter, err := QueryFilter(n.Node)
+		if err != nil {
+			return Filter{}, err
+		}
+		return NewNotFilter(filter), nil
+	case QueryPredicate:
+		return queryPredicateFilter(n)
+	}
+	return Filter{}, fmt.Errorf("%w: unknown query node %T", ErrCriteria, node)
+}
+
+func queryPredicateFilter(p QueryPredicate) (Filter, error) {
+	switch p.Operator {
+	case QueryEquals:
+		if _, ok := p.Value.(string); ok {
+			return NewFilter(p.Field, Equals, p.Text), nil
+		}
+		// Values parsed from text, like the fields of plain or csv logs, keep
+		// numbers and booleans as strings.
+		return NewFilter(p.Field, In, []any{p.Value, p.Text}), nil
+	case QueryGreaterThan:
+		return NewFilter(p.Field, GreaterThan, p.Value), nil
+	case QueryGreaterThanOrEqual:
+		return NewFilter(p.Field, GreaterThanOrEqual, p.Value), nil
+	case QueryLessThan:
+		return NewFilter(p.Field, LessThan, p.Value), nil
+	case QueryLessThanOrEqual:
+		return NewFilter(p.Field, LessThanOrEqual, p.Value), nil
+	case QueryWildcard:
+		return NewFilter(p.Field, Regex, "(?i)"+p.Pattern), nil
+	case QueryExists:
+		return NewAndFilter(NewFilter(p.Field, Exists, true), NewFilter(p.Field, NotEquals, nil)), nil
+	case QueryContains:
+		return NewFilter(p.Field, Like, p.Text), nil
+	}
+	return Filter{}, fmt.Errorf("%w: unknown query operator %q", ErrCriteria, p.Operator)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// GeneratedQuery is a search written from a question, in the query language
+// of ParseQuery and never as a database query, so it goes through the same
+// validation and scoping as the searches of users.
+type GeneratedQuery struct {
+	Query       string     `json:"query"`
+	From        *time.Time `json:"from,omitempty"`
+	To          *time.Time `json:"to,omitempty"`
+	SortOrder   SortOrder  `json:"sortOrder"`
+	Explanation string     `json:"explanation"`
+}
+
+// QueryGenerator turns a question in plain language into a search of the
+// logs described by the schema, asked at the given time.
+type QueryGenerator interface {
+	GenerateQuery(ctx context.Context, question string, schema LogSchema, now time.Time) (*GeneratedQuery, error)
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// QueryHistoryEntry is a log search a user ran. Running the same search
+// again updates its entry instead of adding one.
+type QueryHistoryEntry struct {
+	id         ID
+	userID     ID
+	query      string
+	searchTerm string
+	logLevel   string
+	appID      string
+	from       *time.Time
+	to         *time.Time
+	runs       int
+	lastRunAt  time.Time
+}
+
+func NewQueryHistoryEntry(
+	id ID,
+	userID ID,
+	query string,
+	searchTerm string,
+	logLevel string,
+	appID string,
+	from *time.Time,
+	to *time.Time,
+	runs int,
+	lastRunAt time.Time,
+) (*QueryHistoryEntry, error) {
+	return &QueryHistoryEntry{
+		id:         id,
+		userID:     userID,
+		query:      query,
+		searchTerm: searchTerm,
+		logLevel:   logLevel,
+		appID:      appID,
+		from:       from,
+		to:         to,
+		runs:       runs,
+		lastRunAt:  lastRunAt,
+	}, nil
+}
+
+func (e *QueryHistoryEntry) ID() ID {
+	return e.id
+}
+
+func (e *QueryHistoryEntry) UserID() ID {
+	return e.userID
+}
+
+func (e *QueryHistoryEntry) Query() string {
+	return e.query
+}
+
+func (e *QueryHistoryEntry) SearchTerm() string {
+	return e.searchTerm
+}
+
+func (e *QueryHistoryEntry) LogLevel() string {
+	return e.logLevel
+}
+
+func (e *QueryHistoryEntry) AppID() string {
+	return e.appID
+}
+
+func (e *QueryHistoryEntry) From() *time.Time {
+	return e.from
+}
+
+func (e *QueryHistoryEntry) To() *time.Time {
+	return e.to
+}
+
+func (e *QueryHistoryEntry) Runs() int {
+	return e.runs
+}
+
+func (e *QueryHistoryEntry) LastRunAt() time.Time {
+	return e.lastRunAt
+}
+
+// Key is the same for the entries of the same search of a user.
+func (e *QueryHistoryEntry) Key() string {
+	content, _ := json.Marshal([]any{e.query, e.searchTerm, e.logLevel, e.appID, e.from, e.to})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func (e QueryHistoryEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":         e.id,
+		"query":      e.query,
+		"searchTerm": e.searchTerm,
+		"logLevel":   e.logLevel,
+		"appId":      e.appID,
+		"from":       e.from,
+		"to":         e.to,
+		"runs":       e.runs,
+		"lastRunAt":  e.lastRunAt,
+	})
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"context"
+)
+
+type QueryHistoryRepo interface {
+	// RecordQueryHistoryEntry adds the entry, or counts a run of the entry of
+	// the user with the same key, and keeps the latest entries of the user.
+	RecordQueryHistoryEntry(ctx context.Context, entry QueryHistoryEntry, keep int) error
+	ListQueryHistory(ctx context.Context, criteria Criteria) ([]QueryHistoryEntry, error)
+	GetQueryHistoryEntryByID(ctx context.Context, id ID) (*QueryHistoryEntry, error)
+	DeleteQueryHistoryEntry(ctx context.Context, id ID) error
+	DeleteQueryHistoryByUserID(ctx context.Context, userID ID) error
+}
@@ -0,0 +1,267 @@
+package domain
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queryPatterns caches the compiled patterns of wildcard predicates.
+var queryPatterns sync.Map
+
+// MatchQuery tells whether the log matches the query the way the MongoDB
+// filter of the query would, for logs that are not read from the database,
+// like those of a live tail. A nil query matches every log.
+func MatchQuery(node QueryNode, log Log) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case QueryGroup:
+		for _, child := range n.Nodes {
+			matched := MatchQuery(child, log)
+			if n.Type == QueryOr && matched {
+				return true
+			}
+			if n.Type == QueryAnd && !matched {
+				return false
+			}
+		}
+		return n.Type == QueryAnd
+	case QueryNot:
+		return !MatchQuery(n.Node, log)
+	case QueryPredicate:
+		for _, value := range logFieldValues(log, n.Field) {
+			if matchQueryPredicate(n, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// logFieldValues returns the values of a field of the log. Like in MongoDB,
+// a path through an array yields the values of all its elements.
+func logFieldValues(log Log, field string) []any {
+	switch field {
+	case "level":
+		return []any{log.level}
+	case "raw":
+		return []any{log.raw}
+	case "timestamp":
+		return []any{log.timestamp}
+	case "fingerprint":
+		if log.fingerprint == "" {
+			return nil
+		}
+		return []any{log.fingerprint}
+	case "patternId":
+		if log.patternID == "" {
+			return nil
+		}
+		return []any{log.patternID}
+	}
+
+	if path, ok := strings.CutPrefix(field, "data."); ok {
+		return pathValues(log.data, strings.Split(path, "."))
+	}
+
+	if path, ok := strings.CutPrefix(field, "stackTrace."); ok && log.stackTrace != nil {
+		return pathValues(stackTraceValue(log.stackTrace), strings.Split(path, "."))
+	}
+	return nil
+}
+
+func stackTraceValue(stackTrace *StackTrace) map[string]any {
+	frames := make([]any, len(stackTrace.Frames))
+	for i, frame := range stackTrace.Frames {
+		frames[i] = map[string]any{
+			"file":     frame.File,
+			"function": frame.Function,
+			"line":     float64(frame.Line),
+			"column":   float64(frame.Column),
+			"inApp":    frame.InApp,
+		}
+	}
+
+	return map[string]any{
+		"language":      stackTrace.Language,
+		"exceptionType": stackTrace.ExceptionType,
+		"message":       stackTrace.Message,
+		"frames":        frames,
+	}
+}
+
+func pathValues(value any, path []string) []any {
+	if list, ok := asList(value); ok {
+		values := []any{}
+		for _, item := range list {
+			values = append(values, pathValues(item, path)...)
+		}
+		if len(path) == 0 {
+			values = append(values, value)
+		}
+		return values
+	}
+
+	if len(path) == 0 {
+		return []any{value}
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	child, ok := object[path[0]]
+	if !ok {
+		return nil
+	}
+	return pathValues(child, path[1:])
+}
+
+// asList returns the elements of slices, like the arrays decoded by the
+// MongoDB driver.
+func asList(value any) ([]any, bool) {
+	if list, ok := value.([]any); ok {
+		return list, true
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	list := make([]any, v.Len())
+	for i := range list {
+		list[i] = v.Index(i).Interface()
+	}
+	return list, true
+}
+
+func matchQueryPredicate(p QueryPredicate, value any) bool {
+	switch p.Operator {
+	case QueryExists:
+		return value != nil
+	case QueryEquals:
+		if text, ok := value.(string); ok {
+			return text == p.Text
+		}
+		cmp, ok := compareQueryValues(value, p.Value)
+		return ok && cmp == 0
+	case QueryGreaterThan, QueryGreaterThanOrEqual, QueryLessThan, QueryLessThanOrEqual:
+		cmp, ok := compareQueryValues(value, p.Value)
+		if !ok {
+			return false
+		}
+		switch p.Operator {
+		case QueryGreaterThan:
+			return cmp > 0
+		case QueryGreaterThanOrEqual:
+			return cmp >= 0
+		case QueryLessThan:
+			return cmp < 0
+		default:
+			return cmp <= 0
+		}
+	case QueryWildcard:
+		text, ok := value.(string)
+		if !ok {
+			return false
+		}
+		pattern, err := queryPattern(p.Pattern)
+		return err == nil && pattern.MatchString(text)
+	case QueryContains:
+		text, ok := value.(string)
+		return ok && strings.Contains(strings.ToLower(text), strings.ToLower(p.Text))
+	}
+	return false
+}
+
+func queryPattern(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := queryPatterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	compiled, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	queryPatterns.Store(pattern, compiled)
+	return compiled, nil
+}
+
+// compareQueryValues compares values of the same kind, numbers, strings,
+// booleans or times, and reports false for values that cannot be compared,
+// which MongoDB never matches with each other.
+func compareQueryValues(a, b any) (int, bool) {
+	if x, ok := queryTime(a); ok {
+		y, ok := queryTime(b)
+		if !ok {
+			return 0, false
+		}
+		return x.Compare(y), true
+	}
+
+	if x, ok := queryNumber(a); ok {
+		y, ok := queryNumber(b)
+		if !ok {
+			return 0, false
+		}
+		return compareOrdered(x, y), true
+	}
+
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return strings.Compare(x, y), ok
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		if x == y {
+			return 0, true
+		}
+		if x {
+			return 1, true
+		}
+		return -1, true
+	}
+
+	return 0, false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func queryNumber(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// queryTime returns the time of time values, including the dates decoded by
+// the MongoDB driver.
+func queryTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case interface{ Time() time.Time }:
+		return v.Time(), true
+	}
+	return time.Time{}, false
+}
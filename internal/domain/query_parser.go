@@ -0,0 +1,522 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxQueryLength = 4096
+	maxQueryDepth  = 32
+)
+
+type queryTokenType int
+
+const (
+	queryTokenEOF queryTokenType = iota
+	queryTokenWord
+	queryTokenQuoted
+	queryTokenColon
+	queryTokenCompare
+	queryTokenLParen
+	queryTokenRParen
+	queryTokenLBracket
+	queryTokenRBracket
+	queryTokenLBrace
+	queryTokenRBrace
+)
+
+type queryToken struct {
+	typ queryTokenType
+	pos int
+	// raw is the token as written, used to recognize keywords.
+	raw string
+	// text is the value with escapes resolved.
+	text string
+	// pattern is the regular expression of a word with wildcards.
+	pattern  string
+	wildcard bool
+}
+
+func (t queryToken) isKeyword(keyword string) bool {
+	return t.typ == queryTokenWord && t.raw == keyword
+}
+
+// ParseQuery parses a search query into its expression tree. An empty query
+// returns a nil node.
+//
+//	level:ERROR AND data.status>=500 AND NOT data.path:"/health"
+//
+// Terms are joined with AND, OR and NOT, or with AND when no operator is
+// written, and grouped with parentheses. A term is one of:
+//
+//	field:value          equals, or matches * and ? wildcards
+//	field:"a phrase"     equals the phrase
+//	field:*              the field exists, same as _exists_:field
+//	field>=value         also >, < and <=
+//	field:[1 TO 5]       inclusive range, {1 TO 5} excludes the bounds
+//	value or "a phrase"  contained in the raw log
+func ParseQuery(query string) (QueryNode, error) {
+	if len(query) > maxQueryLength {
+		return nil, &QuerySyntaxError{Position: maxQueryLength, Message: fmt.Sprintf("query cannot be longer than %d characters", maxQueryLength)}
+	}
+
+	tokens, err := lexQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	if p.peek().typ == queryTokenEOF {
+		return nil, nil
+	}
+
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.peek(); token.typ != queryTokenEOF {
+		return nil, p.unexpected(token)
+	}
+	return node, nil
+}
+
+func lexQuery(query string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0)
+	singles := map[byte]queryTokenType{
+		':': queryTokenColon,
+		'(': queryTokenLParen,
+		')': queryTokenRParen,
+		'[': queryTokenLBracket,
+		']': queryTokenRBracket,
+		'{': queryTokenLBrace,
+		'}': queryTokenRBrace,
+	}
+
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case singles[c] != 0:
+			tokens = append(tokens, queryToken{typ: singles[c], pos: i, raw: string(c)})
+			i++
+		case c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(query) && query[i+1] == '=' {
+				op += "="
+			}
+			tokens = append(tokens, queryToken{typ: queryTokenCompare, pos: i, raw: op})
+			i += len(op)
+		case c == '=':
+			return nil, &QuerySyntaxError{Position: i, Message: `unexpected "=", use ":" to compare a field with a value`}
+		case c == '"':
+			token, next, err := lexQuoted(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = next
+		default:
+			token, next, err := lexWord(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i = next
+		}
+	}
+
+	return append(tokens, queryToken{typ: queryTokenEOF, pos: len(query)}), nil
+}
+
+func lexQuoted(query string, start int) (queryToken, int, error) {
+	var text strings.Builder
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if i+1 < len(query) {
+				i++
+				text.WriteByte(query[i])
+			}
+		case '"':
+			return queryToken{
+				typ:  queryTokenQuoted,
+				pos:  start,
+				raw:  query[start : i+1],
+				text: text.String(),
+			}, i + 1, nil
+		default:
+			text.WriteByte(query[i])
+		}
+	}
+	return queryToken{}, 0, &QuerySyntaxError{Position: start, Message: "unterminated quoted phrase"}
+}
+
+func lexWord(query string, start int) (queryToken, int, error) {
+	var text, pattern strings.Builder
+	wildcard := false
+
+	i := start
+	for i < len(query) && !strings.ContainsRune(" \t\r\n:()[]{}\"<>=", rune(query[i])) {
+		c := query[i]
+		switch c {
+		case '\\':
+			if i+1 == len(query) {
+				return queryToken{}, 0, &QuerySyntaxError{Position: i, Message: "nothing to escape at the end of the query"}
+			}
+			i++
+			text.WriteByte(query[i])
+			pattern.WriteString(regexp.QuoteMeta(query[i : i+1]))
+		case '*':
+			wildcard = true
+			text.WriteByte(c)
+			pattern.WriteString(".*")
+		case '?':
+			wildcard = true
+			text.WriteByte(c)
+			pattern.WriteString(".")
+		default:
+			text.WriteByte(c)
+			pattern.WriteString(regexp.QuoteMeta(query[i : i+1]))
+		}
+		i++
+	}
+
+	return queryToken{
+		typ:      queryTokenWord,
+		pos:      start,
+		raw:      query[start:i],
+		text:     text.String(),
+		pattern:  pattern.String(),
+		wildcard: wildcard,
+	}, i, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	token := p.tokens[p.pos]
+	if token.typ != queryTokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *queryParser) unexpected(token queryToken) error {
+	if token.typ == queryTokenEOF {
+		return &QuerySyntaxError{Position: token.pos, Message: "unexpected end of query"}
+	}
+	return &QuerySyntaxError{Position: token.pos, Message: fmt.Sprintf("unexpected %q", token.raw)}
+}
+
+func (p *queryParser) parseOr(depth int) (QueryNode, error) {
+	node, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []QueryNode{node}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		node, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return QueryGroup{Type: QueryOr, Nodes: nodes}, nil
+}
+
+func (p *queryParser) parseAnd(depth int) (QueryNode, error) {
+	node, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []QueryNode{node}
+	for {
+		token := p.peek()
+		if token.isKeyword("AND") {
+			p.next()
+		} else if !p.startsTerm(token) {
+			break
+		}
+
+		node, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return QueryGroup{Type: QueryAnd, Nodes: nodes}, nil
+}
+
+// startsTerm reports whether the token begins a term joined without an
+// explicit operator.
+func (p *queryParser) startsTerm(token queryToken) bool {
+	switch token.typ {
+	case queryTokenQuoted, queryTokenLParen:
+		return true
+	case queryTokenWord:
+		return !token.isKeyword("AND") && !token.isKeyword("OR")
+	}
+	return false
+}
+
+func (p *queryParser) parseUnary(depth int) (QueryNode, error) {
+	if depth > maxQueryDepth {
+		return nil, &QuerySyntaxError{Position: p.peek().pos, Message: fmt.Sprintf("query cannot be nested more than %d levels", maxQueryDepth)}
+	}
+
+	token := p.peek()
+	if token.isKeyword("NOT") {
+		p.next()
+		node, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return QueryNot{Node: node}, nil
+	}
+
+	if token.typ == queryTokenLParen {
+		p.next()
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.typ != queryTokenRParen {
+			if closing.typ == queryTokenEOF {
+				return nil, &QuerySyntaxError{Position: token.pos, Message: "unclosed parenthesis"}
+			}
+			return nil, p.unexpected(closing)
+		}
+		return node, nil
+	}
+
+	return p.parseTerm()
+}
+
+func (p *queryParser) parseTerm() (QueryNode, error) {
+	token := p.next()
+	switch token.typ {
+	case queryTokenQuoted:
+		return QueryPredicate{Field: "raw", Operator: QueryContains, Value: token.text, Text: token.text}, nil
+	case queryTokenWord:
+	default:
+		return nil, p.unexpected(token)
+	}
+
+	switch p.peek().typ {
+	case queryTokenColon:
+		p.next()
+		return p.parseFieldValue(token)
+	case queryTokenCompare:
+		field, err := p.field(token)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseComparison(field)
+	}
+
+	if token.wildcard {
+		return QueryPredicate{Field: "raw", Operator: QueryWildcard, Text: token.text, Pattern: token.pattern}, nil
+	}
+	return QueryPredicate{Field: "raw", Operator: QueryContains, Value: token.text, Text: token.text}, nil
+}
+
+func (p *queryParser) field(token queryToken) (string, error) {
+	if token.wildcard || token.raw != token.text || !IsQueryField(token.text) {
+		return "", &QuerySyntaxError{
+			Position: token.pos,
+			Message:  fmt.Sprintf("unknown field %q, use level, raw, timestamp, fingerprint, patternId, data.* or stackTrace.*", token.raw),
+		}
+	}
+	return token.text, nil
+}
+
+func (p *queryParser) parseFieldValue(fieldToken queryToken) (QueryNode, error) {
+	if fieldToken.raw == "_exists_" {
+		token := p.next()
+		if token.typ != queryTokenWord {
+			return nil, p.unexpected(token)
+		}
+
+		field, err := p.field(token)
+		if err != nil {
+			return nil, err
+		}
+		return QueryPredicate{Field: field, Operator: QueryExists}, nil
+	}
+
+	field, err := p.field(fieldToken)
+	if err != nil {
+		return nil, err
+	}
+
+	token := p.next()
+	switch token.typ {
+	case queryTokenQuoted:
+		return QueryPredicate{Field: field, Operator: QueryEquals, Value: token.text, Text: token.text}, nil
+	case queryTokenWord:
+		if token.raw == "*" {
+			return QueryPredicate{Field: field, Operator: QueryExists}, nil
+		}
+		if token.wildcard {
+			return QueryPredicate{Field: field, Operator: QueryWildcard, Text: token.text, Pattern: "^" + token.pattern + "$"}, nil
+		}
+		return QueryPredicate{Field: field, Operator: QueryEquals, Value: parseQueryValue(token.text), Text: token.text}, nil
+	case queryTokenCompare:
+		p.pos--
+		return p.parseComparison(field)
+	case queryTokenLBracket, queryTokenLBrace:
+		return p.parseRange(field, token)
+	}
+	return nil, p.unexpected(token)
+}
+
+func (p *queryParser) parseComparison(field string) (QueryNode, error) {
+	operators := map[string]QueryOperator{
+		">":  QueryGreaterThan,
+		">=": QueryGreaterThanOrEqual,
+		"<":  QueryLessThan,
+		"<=": QueryLessThanOrEqual,
+	}
+
+	operator := operators[p.next().raw]
+	token := p.next()
+	if token.typ != queryTokenWord && token.typ != queryTokenQuoted {
+		return nil, p.unexpected(token)
+	}
+	if token.wildcard {
+		return nil, &QuerySyntaxError{Position: token.pos, Message: "wildcards cannot be compared"}
+	}
+
+	return QueryPredicate{Field: field, Operator: operator, Value: parseQueryValue(token.text), Text: token.text}, nil
+}
+
+// parseRange parses [from TO to], where a square bracket includes the bound,
+// a curly one excludes it and * leaves the side open.
+func (p *queryParser) parseRange(field string, opening queryToken) (QueryNode, error) {
+	from, err := p.rangeBound()
+	if err != nil {
+		return nil, err
+	}
+
+	if token := p.next(); !token.isKeyword("TO") {
+		if token.typ == queryTokenEOF {
+			return nil, &QuerySyntaxError{Position: opening.pos, Message: "unclosed range"}
+		}
+		return nil, &QuerySyntaxError{Position: token.pos, Message: "expected TO in range"}
+	}
+
+	to, err := p.rangeBound()
+	if err != nil {
+		return nil, err
+	}
+
+	closing := p.next()
+	if closing.typ != queryTokenRBracket && closing.typ != queryTokenRBrace {
+		if closing.typ == queryTokenEOF {
+			return nil, &QuerySyntaxError{Position: opening.pos, Message: "unclosed range"}
+		}
+		return nil, p.unexpected(closing)
+	}
+
+	nodes := make([]QueryNode, 0, 2)
+	if from != nil {
+		operator := QueryGreaterThanOrEqual
+		if opening.typ == queryTokenLBrace {
+			operator = QueryGreaterThan
+		}
+		nodes = append(nodes, QueryPredicate{Field: field, Operator: operator, Value: parseQueryValue(from.text), Text: from.text})
+	}
+	if to != nil {
+		operator := QueryLessThanOrEqual
+		if closing.typ == queryTokenRBrace {
+			operator = QueryLessThan
+		}
+		nodes = append(nodes, QueryPredicate{Field: field, Operator: operator, Value: parseQueryValue(to.text), Text: to.text})
+	}
+
+	switch len(nodes) {
+	case 0:
+		return QueryPredicate{Field: field, Operator: QueryExists}, nil
+	case 1:
+		return nodes[0], nil
+	}
+	return QueryGroup{Type: QueryAnd, Nodes: nodes}, nil
+}
+
+// rangeBound returns the bound of a range, or nil for an open one.
+func (p *queryParser) rangeBound() (*queryToken, error) {
+	token := p.next()
+	switch {
+	case token.typ == queryTokenWord && token.raw == "*":
+		return nil, nil
+	case token.typ == queryTokenQuoted, token.typ == queryTokenWord && !token.wildcard && !token.isKeyword("TO"):
+		return &token, nil
+	}
+	return nil, p.unexpected(token)
+}
+
+// parseQueryValue types a value written in a query as a number, a boolean,
+// a date or a string.
+func parseQueryValue(text string) any {
+	if isQueryNumber(text) {
+		if number, err := strconv.ParseFloat(text, 64); err == nil {
+			return number
+		}
+	}
+
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return text
+}
+
+// isQueryNumber leaves out what ParseFloat accepts but is not written as a
+// number, like "inf" or "0x1p3".
+func isQueryNumber(text string) bool {
+	if text == "" {
+		return false
+	}
+	for i, r := range text {
+		if !unicode.IsDigit(r) && r != '.' && !(i == 0 && (r == '-' || r == '+')) {
+			return false
+		}
+	}
+	return true
+}
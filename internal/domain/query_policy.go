@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrQueryPolicy = fmt.Errorf("error in query policy")
+)
+
+// QueryLimits bound the searches of the logs. Zero values are no limit.
+type QueryLimits struct {
+	// MaxRange is the longest time range a search can cover.
+	MaxRange time.Duration
+	// MaxScan is the most logs a search can read, estimated by the logs of
+	// its apps in its time range.
+	MaxScan int64
+	// MaxTime is how long each query of a search can run in the database.
+	MaxTime time.Duration
+}
+
+// NewQueryLimits validates limits written like in requests: a length like
+// 7d, a number of logs and milliseconds, empty or 0 for no limit.
+func NewQueryLimits(maxRange string, maxScan int64, maxTimeMS int64) (QueryLimits, error) {
+	limits := QueryLimits{MaxScan: maxScan, MaxTime: time.Duration(maxTimeMS) * time.Millisecond}
+	if maxRange != "" {
+		length, err := ParseLength(maxRange)
+		if err != nil {
+			return QueryLimits{}, fmt.Errorf("%w: max range: %w", ErrQueryPolicy, err)
+		}
+		limits.MaxRange = length
+	}
+
+	if maxScan < 0 {
+		return QueryLimits{}, fmt.Errorf("%w: max scan cannot be negative", ErrQueryPolicy)
+	}
+
+	if maxTimeMS < 0 {
+		return QueryLimits{}, fmt.Errorf("%w: max time cannot be negative", ErrQueryPolicy)
+	}
+
+	return limits, nil
+}
+
+// Stricter keeps the lowest of each limit, so a policy can only narrow the
+// limits it is applied to.
+func (l QueryLimits) Stricter(other QueryLimits) QueryLimits {
+	return QueryLimits{
+		MaxRange: lowestLimit(l.MaxRange, other.MaxRange),
+		MaxScan:  lowestLimit(l.MaxScan, other.MaxScan),
+		MaxTime:  lowestLimit(l.MaxTime, other.MaxTime),
+	}
+}
+
+func lowestLimit[T time.Duration | int64](a T, b T) T {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+func (l QueryLimits) MarshalJSON() ([]byte, error) {
+	var maxRange string
+	if l.MaxRange > 0 {
+		maxRange = FormatLength(l.MaxRange)
+	}
+
+	return json.Marshal(map[string]any{
+		"maxRange":  maxRange,
+		"maxScan":   l.MaxScan,
+		"maxTimeMs": l.MaxTime.Milliseconds(),
+	})
+}
+
+// QueryPolicy narrows the server-wide limits of the searches of the users of
+// an account.
+type QueryPolicy struct {
+	// accountID is the root user of the account.
+	accountID ID
+	limits    QueryLimits
+	updatedBy ID
+	updatedAt time.Time
+}
+
+func NewQueryPolicy(accountID ID, limits QueryLimits, updatedBy ID, updatedAt time.Time) (*QueryPolicy, error) {
+	return &QueryPolicy{
+		accountID: accountID,
+		limits:    limits,
+		updatedBy: updatedBy,
+		updatedAt: updatedAt,
+	}, nil
+}
+
+func (p *QueryPolicy) AccountID() ID {
+	return p.accountID
+}
+
+func (p *QueryPolicy) Limits() QueryLimits {
+	return p.limits
+}
+
+func (p *QueryPolicy) UpdatedBy() ID {
+	return p.updatedBy
+}
+
+func (p *QueryPolicy) UpdatedAt() time.Time {
+	return p.updatedAt
+}
+
+func (p QueryPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"accountId": p.accountID.Hex(),
+		"limits":    p.limits,
+		"updatedBy": p.updatedBy.Hex(),
+		"updatedAt": p.updatedAt,
+	})
+}
@@ -0,0 +1,11 @@
+package domain
+
+import (
+	"context"
+)
+
+type QueryPolicyRepo interface {
+	// SaveQueryPolicy creates or replaces the policy of the account.
+	SaveQueryPolicy(ctx context.Context, policy QueryPolicy) error
+	GetQueryPolicy(ctx context.Context, accountID ID) (*QueryPolicy, error)
+}
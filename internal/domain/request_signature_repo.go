@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRequestSignatureAlreadyUsed = errors.New("request signature already used")
+)
+
+// RequestSignatureRetention is how long the signatures are remembered,
+// longer than the timestamp of a signed request is accepted for.
+const RequestSignatureRetention = 10 * time.Minute
+
+// RequestSignatureRepo remembers the signatures of accepted requests so a
+// signed request cannot be replayed while its timestamp is still valid.
+type RequestSignatureRepo interface {
+	SaveRequestSignature(ctx context.Context, appID ID, signature string, receivedAt time.Time) error
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSavedSearch = fmt.Errorf("error in saved search")
+)
+
+// SavedSearch is a named log search. It is private to the user who saved it
+// or shared with all the users of the account, the root user and the users
+// it created.
+type SavedSearch struct {
+	id     ID
+	userID ID
+	// accountID is the root user of the account of the owner.
+	accountID ID
+	name      string
+	query     string
+	// appIDs limits the search to some apps, all apps when empty.
+	appIDs []ID
+	// timeRange is relative to when the search runs, like 1h for the last
+	// hour, or empty for all time.
+	timeRange string
+	// columns are the fields displayed, like level or data.status.
+	columns   []string
+	shared    bool
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+func NewSavedSearch(
+	id ID,
+	userID ID,
+	accountID ID,
+	name string,
+	query string,
+	appIDs []ID,
+	timeRange string,
+	columns []string,
+	shared bool,
+	createdAt time.Time,
+	updatedAt time.Time,
+) (*SavedSearch, error) {
+	search := &SavedSearch{
+		id:        id,
+		userID:    userID,
+		accountID: accountID,
+		shared:    shared,
+		createdAt: createdAt,
+		updatedAt: updatedAt,
+	}
+
+	if err := search.ChangeName(name); err != nil {
+		return nil, err
+	}
+
+	if err := search.ChangeSearch(query, appIDs, timeRange, columns); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+func (s *SavedSearch) ID() ID {
+	return s.id
+}
+
+func (s *SavedSearch) UserID() ID {
+	return s.userID
+}
+
+func (s *SavedSearch) AccountID() ID {
+	return s.accountID
+}
+
+func (s *SavedSearch) Name() string {
+	return s.name
+}
+
+func (s *SavedSearch) Query() string {
+	return s.query
+}
+
+func (s *SavedSearch) AppIDs() []ID {
+	return s.appIDs
+}
+
+func (s *SavedSearch) TimeRange() string {
+	return s.timeRange
+}
+
+func (s *SavedSearch) Columns() []string {
+	return s.columns
+}
+
+func (s *SavedSearch) IsShared() bool {
+	return s.shared
+}
+
+func (s *SavedSearch) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+func (s *SavedSearch) UpdatedAt() time.Time {
+	return s.updatedAt
+}
+
+// IsVisibleTo tells whether a user of an account can see the search.
+func (s *SavedSearch) IsVisibleTo(userID ID, accountID ID) bool {
+	return s.userID == userID || (s.shared && s.accountID == accountID)
+}
+
+// Range is the time range of the search when run at now, nil for all time.
+func (s *SavedSearch) Range(now time.Time) *Range {
+	if s.timeRange == "" {
+		return nil
+	}
+
+	interval, err := ParseInterval(s.timeRange)
+	if err != nil {
+		return nil
+	}
+	return &Range{From: interval.Add(now, -1), To: now}
+}
+
+func (s *SavedSearch) ChangeName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name cannot be empty", ErrSavedSearch)
+	}
+
+	s.name = name
+	return nil
+}
+
+// ChangeSearch sets what the search looks for. The query must parse, and the
+// time range is an interval like 15m, 24h or 7d.
+func (s *SavedSearch) ChangeSearch(query string, appIDs []ID, timeRange string, columns []string) error {
+	if _, err := ParseQuery(query); err != nil {
+		return fmt.Errorf("%w: %s", ErrSavedSearch, err)
+	}
+
+	timeRange = strings.TrimSpace(timeRange)
+	if timeRange != "" {
+		if _, err := ParseInterval(timeRange); err != nil {
+			return fmt.Errorf("%w: %s", ErrSavedSearch, err)
+		}
+	}
+
+	if appIDs == nil {
+		appIDs = []ID{}
+	}
+
+	if columns == nil {
+		columns = []string{}
+	}
+
+	s.query = query
+	s.appIDs = appIDs
+	s.timeRange = timeRange
+	s.columns = columns
+	return nil
+}
+
+func (s *SavedSearch) ChangeShared(shared bool) {
+	s.shared = shared
+}
+
+func (s *SavedSearch) MarkUpdated(at time.Time) {
+	s.updatedAt = at
+}
+
+func (s SavedSearch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":        s.id,
+		"userId":    s.userID,
+		"name":      s.name,
+		"query":     s.query,
+		"appIds":    s.appIDs,
+		"timeRange": s.timeRange,
+		"columns":   s.columns,
+		"shared":    s.shared,
+		"createdAt": s.createdAt,
+		"updatedAt": s.updatedAt,
+	})
+}
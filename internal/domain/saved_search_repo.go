@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+)
+
+type SavedSearchRepo interface {
+	SaveSavedSearch(ctx context.Context, search SavedSearch) error
+	UpdateSavedSearch(ctx context.Context, search SavedSearch) error
+	DeleteSavedSearch(ctx context.Context, id ID) error
+	GetSavedSearchByID(ctx context.Context, id ID) (*SavedSearch, error)
+	ListSavedSearches(ctx context.Context, criteria Criteria) ([]SavedSearch, error)
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSchemaDriftEvent = fmt.Errorf("error in schema drift event")
+)
+
+// SchemaDriftKind is how the fields of the logs of an app changed.
+type SchemaDriftKind string
+
+const (
+	// SchemaFieldAdded is a field the app never sent before.
+	SchemaFieldAdded SchemaDriftKind = "fieldAdded"
+	// SchemaFieldStopped is a field the app has not sent for a while, though
+	// it keeps sending logs.
+	SchemaFieldStopped SchemaDriftKind = "fieldStopped"
+	// SchemaTypeChanged is a field that got values of a type it never had,
+	// like a status sent as a string and then as a number.
+	SchemaTypeChanged SchemaDriftKind = "typeChanged"
+)
+
+// SchemaDriftEvent is a change of the fields of the logs of an app, found
+// comparing the logs ingested with the schema catalog of the app.
+type SchemaDriftEvent struct {
+	id     ID
+	appID  ID
+	userID ID
+	kind   SchemaDriftKind
+	// path is the path of the field under data.
+	path string
+	// previousTypes are the types the field had before the change.
+	previousTypes []string
+	// valueType is the new type of a typeChanged event.
+	valueType string
+	// occurredAt is the timestamp of the logs with the change, or when the
+	// field was last seen for a fieldStopped event.
+	occurredAt time.Time
+	detectedAt time.Time
+}
+
+func NewSchemaDriftEvent(
+	id ID,
+	appID ID,
+	userID ID,
+	kind SchemaDriftKind,
+	path string,
+	previousTypes []string,
+	valueType string,
+	occurredAt time.Time,
+	detectedAt time.Time,
+) (*SchemaDriftEvent, error) {
+	if kind != SchemaFieldAdded && kind != SchemaFieldStopped && kind != SchemaTypeChanged {
+		return nil, fmt.Errorf("%w: invalid kind %s", ErrSchemaDriftEvent, kind)
+	}
+
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("%w: path cannot be empty", ErrSchemaDriftEvent)
+	}
+
+	if kind == SchemaTypeChanged && valueType == "" {
+		return nil, fmt.Errorf("%w: type cannot be empty", ErrSchemaDriftEvent)
+	}
+
+	if previousTypes == nil {
+		previousTypes = []string{}
+	}
+
+	return &SchemaDriftEvent{
+		id:            id,
+		appID:         appID,
+		userID:        userID,
+		kind:          kind,
+		path:          path,
+		previousTypes: previousTypes,
+		valueType:     valueType,
+		occurredAt:    occurredAt,
+		detectedAt:    detectedAt,
+	}, nil
+}
+
+func (e *SchemaDriftEvent) ID() ID {
+	return e.id
+}
+
+func (e *SchemaDriftEvent) AppID() ID {
+	return e.appID
+}
+
+func (e *SchemaDriftEvent) UserID() ID {
+	return e.userID
+}
+
+func (e *SchemaDriftEvent) Kind() SchemaDriftKind {
+	return e.kind
+}
+
+func (e *SchemaDriftEvent) Path() string {
+	return e.path
+}
+
+func (e *SchemaDriftEvent) PreviousTypes() []string {
+	return e.previousTypes
+}
+
+func (e *SchemaDriftEvent) Type() string {
+	return e.valueType
+}
+
+func (e *SchemaDriftEvent) OccurredAt() time.Time {
+	return e.occurredAt
+}
+
+func (e *SchemaDriftEvent) DetectedAt() time.Time {
+	return e.detectedAt
+}
+
+// Key tells the change apart from the other changes of the app. A field is
+// added once and gets a type once, but it can stop several times.
+func (e *SchemaDriftEvent) Key() string {
+	switch e.kind {
+	case SchemaTypeChanged:
+		return string(e.kind) + ":" + e.path + ":" + e.valueType
+	case SchemaFieldStopped:
+		return string(e.kind) + ":" + e.path + ":" + strconv.FormatInt(e.occurredAt.UnixMilli(), 10)
+	}
+	return string(e.kind) + ":" + e.path
+}
+
+// Describe tells the change in a sentence, like "field status changed from
+// string to int".
+func (e *SchemaDriftEvent) Describe() string {
+	switch e.kind {
+	case SchemaFieldStopped:
+		return fmt.Sprintf("field %s stopped, last seen at %s", e.path, e.occurredAt.UTC().Format(time.RFC3339))
+	case SchemaTypeChanged:
+		return fmt.Sprintf("field %s changed from %s to %s", e.path, strings.Join(e.previousTypes, ", "), e.valueType)
+	}
+	return fmt.Sprintf("field %s added", e.path)
+}
+
+func (e SchemaDriftEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":            e.id,
+		"appId":         e.appID,
+		"kind":          e.kind,
+		"path":          e.path,
+		"previousTypes": e.previousTypes,
+		"type":          e.valueType,
+		"description":   e.Describe(),
+		"occurredAt":    e.occurredAt,
+		"detectedAt":    e.detectedAt,
+	})
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrSchemaDriftEventAlreadyDetected = errors.New("schema drift event already detected")
+)
+
+type SchemaDriftEventRepo interface {
+	// SaveSchemaDriftEvent returns ErrSchemaDriftEventAlreadyDetected when
+	// the app has an event with the same key.
+	SaveSchemaDriftEvent(ctx context.Context, event SchemaDriftEvent) error
+	ListSchemaDriftEvents(ctx context.Context, criteria Criteria) ([]SchemaDriftEvent, error)
+}
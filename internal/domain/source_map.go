@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSourceMap = fmt.Errorf("error in source map")
+)
+
+// MaxSourceMapSize keeps source maps under the document size limit.
+const MaxSourceMapSize = 10 * 1024 * 1024
+
+// SourceMap un-minifies the stack frames of a generated file of an app
+// release.
+type SourceMap struct {
+	id        ID
+	appID     ID
+	release   string
+	file      string
+	content   string
+	size      int
+	createdAt time.Time
+}
+
+func NewSourceMap(
+	id ID,
+	appID ID,
+	release string,
+	file string,
+	content string,
+	createdAt time.Time,
+) (*SourceMap, error) {
+	if content == "" {
+		return nil, fmt.Errorf("%w: content cannot be empty", ErrSourceMap)
+	}
+
+	if len(content) > MaxSourceMapSize {
+		return nil, fmt.Errorf("%w: content cannot be larger than %d bytes", ErrSourceMap, MaxSourceMapSize)
+	}
+
+	sourceMap, err := NewSourceMapInfo(id, appID, release, file, len(content), createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceMap.content = content
+	return sourceMap, nil
+}
+
+// NewSourceMapInfo builds a map without its content, as listed, so that
+// listing the maps of a release does not load them all.
+func NewSourceMapInfo(
+	id ID,
+	appID ID,
+	release string,
+	file string,
+	size int,
+	createdAt time.Time,
+) (*SourceMap, error) {
+	if strings.TrimSpace(release) == "" {
+		return nil, fmt.Errorf("%w: release cannot be empty", ErrSourceMap)
+	}
+
+	if strings.TrimSpace(file) == "" {
+		return nil, fmt.Errorf("%w: file cannot be empty", ErrSourceMap)
+	}
+
+	return &SourceMap{
+		id:        id,
+		appID:     appID,
+		release:   release,
+		file:      file,
+		size:      size,
+		createdAt: createdAt,
+	}, nil
+}
+
+func (s *SourceMap) ID() ID {
+	return s.id
+}
+
+func (s *SourceMap) AppID() ID {
+	return s.appID
+}
+
+func (s *SourceMap) Release() string {
+	return s.release
+}
+
+// File is the URL, path or name of the generated file the map belongs to.
+func (s *SourceMap) File() string {
+	return s.file
+}
+
+// Content is empty for maps built with NewSourceMapInfo.
+func (s *SourceMap) Content() string {
+	return s.content
+}
+
+func (s *SourceMap) Size() int {
+	return s.size
+}
+
+func (s *SourceMap) CreatedAt() time.Time {
+	return s.createdAt
+}
+
+// Matches reports whether the map belongs to the generated file at the given
+// URL, comparing the full URL, then its path and then its base name.
+func (s *SourceMap) Matches(fileURL string) bool {
+	if s.file == fileURL {
+		return true
+	}
+
+	path := sourceMapPath(fileURL)
+	file := s.file
+	if !strings.Contains(file, "/") {
+		return file == path[strings.LastIndex(path, "/")+1:]
+	}
+	return "/"+strings.TrimLeft(file, "/") == path
+}
+
+// SourceMapFiles returns the files a map can be stored under to match the
+// generated file at the given URL, so that only those maps are looked up.
+func SourceMapFiles(fileURL string) []string {
+	path := sourceMapPath(fileURL)
+	return []string{
+		fileURL,
+		path,
+		strings.TrimLeft(path, "/"),
+		path[strings.LastIndex(path, "/")+1:],
+	}
+}
+
+// sourceMapPath returns the path of the URL without its query and fragment.
+func sourceMapPath(fileURL string) string {
+	path := fileURL
+	if _, rest, ok := strings.Cut(fileURL, "://"); ok {
+		path = rest[strings.Index(rest+"/", "/"):]
+	}
+	if idx := strings.IndexAny(path, "?#"); idx >= 0 {
+		path = path[:idx]
+	}
+	return path
+}
+
+func (s SourceMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"id":        s.id,
+		"appId":     s.appID,
+		"release":   s.release,
+		"file":      s.file,
+		"size":      s.size,
+		"createdAt": s.createdAt,
+	})
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"context"
+)
+
+type SourceMapRepo interface {
+	// SaveSourceMap stores the map, replacing the one of the same app,
+	// release and file if any.
+	SaveSourceMap(ctx context.Context, sourceMap SourceMap) error
+	GetSourceMapByID(ctx context.Context, id ID) (*SourceMap, error)
+	// ListSourceMaps lists the maps without their content.
+	ListSourceMaps(ctx context.Context, criteria Criteria) ([]SourceMap, error)
+	DeleteSourceMap(ctx context.Context, id ID) error
+}
@@ -0,0 +1,18 @@
+package domain
+
+// StackTrace is the exception found in a log and the frames of its stack,
+// innermost call first.
+type StackTrace struct {
+	Language      string       `json:"language"`
+	ExceptionType string       `json:"exceptionType"`
+	Message       string       `json:"message"`
+	Frames        []StackFrame `json:"frames"`
+}
+
+type StackFrame struct {
+	File     string `json:"file"`
+	Function string `json:"function"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	InApp    bool   `json:"inApp"`
+}
@@ -0,0 +1,209 @@
+// Package drain groups log messages into templates with Drain, a streaming
+// algorithm that routes each message through a fixed depth prefix tree to a
+// few candidate clusters and joins it to the most similar one.
+//
+// He, Zhu, Zheng and Lyu, "Drain: An Online Log Parsing Approach with Fixed
+// Depth Tree", ICWS 2017.
+package drain
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"monitoring/internal/fingerprint"
+)
+
+// Wildcard replaces the tokens that vary between the messages of a cluster.
+const Wildcard = "<*>"
+
+const (
+	// DefaultDepth is the depth of the tree: the root, the token count, the
+	// first token and the clusters.
+	DefaultDepth = 4
+	// DefaultSimilarity is the share of tokens a message must have in common
+	// with a template to join its cluster.
+	DefaultSimilarity = 0.4
+	// DefaultMaxChildren bounds the children of a node, past it new tokens
+	// are routed to the wildcard child.
+	DefaultMaxChildren = 100
+)
+
+// maxTokens keeps long messages, like dumped payloads, from being compared
+// token by token past their start.
+const maxTokens = 80
+
+// Cluster is a template of messages. ID is set by the caller when the
+// cluster is created, so it can be stored and loaded back.
+type Cluster struct {
+	ID     string
+	Tokens []string
+}
+
+// Template is the text of the cluster, like "user <num> logged in from <*>".
+func (c *Cluster) Template() string {
+	return strings.Join(c.Tokens, " ")
+}
+
+// Change is how matching a message changed the clusters of the tree.
+type Change int
+
+const (
+	Unchanged Change = iota
+	// Created is reported when the message started a new cluster.
+	Created
+	// Updated is reported when tokens of the template became wildcards.
+	Updated
+)
+
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+func newNode() *node {
+	return &node{children: map[string]*node{}}
+}
+
+// Tree holds the clusters of one source of logs. It is not safe for
+// concurrent use.
+type Tree struct {
+	root        *node
+	depth       int
+	similarity  float64
+	maxChildren int
+	size        int
+}
+
+// NewTree returns an empty tree with the default parameters.
+func NewTree() *Tree {
+	return &Tree{
+		root:        newNode(),
+		depth:       DefaultDepth,
+		similarity:  DefaultSimilarity,
+		maxChildren: DefaultMaxChildren,
+	}
+}
+
+// Len is the number of clusters of the tree.
+func (t *Tree) Len() int {
+	return t.size
+}
+
+// Tokenize splits the first line of a message into tokens, masking the
+// values that are obviously variable, like numbers, ids and quoted text.
+func Tokenize(message string) []string {
+	tokens := strings.Fields(fingerprint.Template(message))
+	if len(tokens) > maxTokens {
+		tokens = tokens[:maxTokens]
+	}
+	return tokens
+}
+
+// Add loads a known cluster, like one learned earlier and stored. Its
+// wildcards lead down the same branches as the tokens they replaced.
+func (t *Tree) Add(cluster *Cluster) {
+	leaf := t.leaf(cluster.Tokens)
+	leaf.clusters = append(leaf.clusters, cluster)
+	t.size++
+}
+
+// Match returns the cluster of the tokens, joining them to the most similar
+// cluster or creating a new one. A new cluster has no ID. When create is
+// false and no cluster is similar enough, Match returns nil.
+func (t *Tree) Match(tokens []string, create bool) (*Cluster, Change) {
+	leaf := t.leaf(tokens)
+
+	var best *Cluster
+	bestSimilarity, bestParams := -1.0, -1
+	for _, cluster := range leaf.clusters {
+		similarity, params := similarity(cluster.Tokens, tokens)
+		if similarity > bestSimilarity || (similarity == bestSimilarity && params > bestParams) {
+			best, bestSimilarity, bestParams = cluster, similarity, params
+		}
+	}
+
+	if best == nil || bestSimilarity < t.similarity {
+		if !create {
+			return nil, Unchanged
+		}
+		cluster := &Cluster{Tokens: append([]string(nil), tokens...)}
+		leaf.clusters = append(leaf.clusters, cluster)
+		t.size++
+		return cluster, Created
+	}
+
+	change := Unchanged
+	for i, token := range tokens {
+		if best.Tokens[i] != token && best.Tokens[i] != Wildcard {
+			best.Tokens[i] = Wildcard
+			change = Updated
+		}
+	}
+	return best, change
+}
+
+// leaf walks down the tree by the number of tokens and then by the leading
+// tokens, creating the missing nodes. Tokens with digits and tokens past
+// the capacity of a node go down the wildcard child.
+func (t *Tree) leaf(tokens []string) *node {
+	current := t.root.child(strconv.Itoa(len(tokens)))
+	for i := 0; i < t.depth-3 && i < len(tokens); i++ {
+		key := tokens[i]
+		if isVariable(key) {
+			key = Wildcard
+		}
+
+		if next, ok := current.children[key]; ok {
+			current = next
+			continue
+		}
+		if len(current.children) >= t.maxChildren-1 {
+			key = Wildcard
+		}
+		current = current.child(key)
+	}
+	return current
+}
+
+func (n *node) child(key string) *node {
+	child, ok := n.children[key]
+	if !ok {
+		child = newNode()
+		n.children[key] = child
+	}
+	return child
+}
+
+// similarity is the share of tokens of the message that are equal in the
+// template, and the number of wildcards of the template to break ties.
+func similarity(template []string, tokens []string) (float64, int) {
+	if len(template) != len(tokens) {
+		return 0, 0
+	}
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+
+	equal, params := 0, 0
+	for i, token := range template {
+		if token == Wildcard {
+			params++
+			continue
+		}
+		if token == tokens[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(tokens)), params
+}
+
+// isVariable tells the tokens that are likely values, like ids, counts or
+// the placeholders of masked values, which would otherwise split the tree on
+// every value.
+func isVariable(token string) bool {
+	if strings.Contains(token, "<") && strings.Contains(token, ">") {
+		return true
+	}
+	return strings.IndexFunc(token, unicode.IsDigit) >= 0
+}
@@ -0,0 +1,240 @@
+// Package export writes logs as CSV, NDJSON or Parquet files.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"monitoring/internal/domain"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	CSV     Format = "csv"
+	NDJSON  Format = "ndjson"
+	Parquet Format = "parquet"
+)
+
+// DefaultColumns are exported when no columns are chosen.
+var DefaultColumns = []string{"id", "appId", "timestamp", "level", "raw"}
+
+// Writer writes logs one at a time. Close must be called to flush them.
+type Writer interface {
+	Write(log domain.Log) error
+	Close() error
+}
+
+// ParseFormat validates a format, NDJSON being the default.
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case "", NDJSON:
+		return NDJSON, nil
+	case CSV:
+		return CSV, nil
+	case Parquet:
+		return Parquet, nil
+	}
+	return "", fmt.Errorf("format must be csv, ndjson or parquet")
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "application/x-ndjson"
+}
+
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// NewWriter returns a writer of the format. NDJSON writes whole logs, CSV
+// and Parquet only write the given columns.
+func NewWriter(format Format, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, columns)
+	case Parquet:
+		return newParquetWriter(w, columns), nil
+	}
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+}
+
+// Value returns the value of a column of the log, like "level" or
+// "data.request.path", or nil when the log does not have it.
+func Value(log domain.Log, column string) any {
+	switch column {
+	case "id":
+		return log.ID().Hex()
+	case "appId":
+		return log.AppID().Hex()
+	case "timestamp":
+		return log.Timestamp()
+	case "level":
+		return log.Level()
+	case "raw":
+		return log.Raw()
+	case "fingerprint":
+		return log.Fingerprint()
+	case "patternId":
+		return log.PatternID()
+	case "stackTrace.exceptionType", "stackTrace.message":
+		if log.StackTrace() == nil {
+			return nil
+		}
+		if column == "stackTrace.message" {
+			return log.StackTrace().Message
+		}
+		return log.StackTrace().ExceptionType
+	}
+
+	path, ok := strings.CutPrefix(column, "data.")
+	if !ok {
+		return nil
+	}
+
+	var value any = log.Data()
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+var logColumns = map[string]bool{
+	"id":                       true,
+	"appId":                    true,
+	"timestamp":                true,
+	"level":                    true,
+	"raw":                      true,
+	"fingerprint":              true,
+	"patternId":                true,
+	"stackTrace.exceptionType": true,
+	"stackTrace.message":       true,
+}
+
+// ValidateColumns checks the columns are log fields or data fields.
+func ValidateColumns(columns []string) error {
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		path, isData := strings.CutPrefix(column, "data.")
+		if !logColumns[column] && (!isData || path == "" || strings.Contains(path, "..")) {
+			return fmt.Errorf("unknown column %q, use id, appId, timestamp, level, raw, fingerprint, patternId, stackTrace.exceptionType, stackTrace.message or data.*", column)
+		}
+		if seen[column] {
+			return fmt.Errorf("duplicated column %q", column)
+		}
+		seen[column] = true
+	}
+	return nil
+}
+
+// text formats a value for the columns of CSV and Parquet files.
+func text(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), true
+	case fmt.Stringer:
+		return v.String(), true
+	case map[string]any, []any:
+		content, _ := json.Marshal(v)
+		return string(content), true
+	}
+	return fmt.Sprint(value), true
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(log domain.Log) error {
+	return w.encoder.Encode(log)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return nil
+}
+
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, columns: columns}, nil
+}
+
+func (w *csvWriter) Write(log domain.Log) error {
+	record := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		record[i], _ = text(Value(log, column))
+	}
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// parquetWriter writes every column as an optional string, since the same
+// data field can hold different types from one log to the next.
+type parquetWriter struct {
+	writer  *parquet.Writer
+	schema  *parquet.Schema
+	columns []string
+}
+
+func newParquetWriter(w io.Writer, columns []string) *parquetWriter {
+	group := parquet.Group{}
+	for _, column := range columns {
+		group[column] = parquet.Optional(parquet.String())
+	}
+
+	schema := parquet.NewSchema("log", group)
+	return &parquetWriter{
+		writer:  parquet.NewWriter(w, schema),
+		schema:  schema,
+		columns: columns,
+	}
+}
+
+func (w *parquetWriter) Write(log domain.Log) error {
+	values := make(map[string]any, len(w.columns))
+	for _, column := range w.columns {
+		if value, ok := text(Value(log, column)); ok {
+			values[column] = value
+		} else {
+			values[column] = nil
+		}
+	}
+
+	_, err := w.writer.WriteRows([]parquet.Row{w.schema.Deconstruct(nil, values)})
+	return err
+}
+
+func (w *parquetWriter) Close() error {
+	return w.writer.Close()
+}
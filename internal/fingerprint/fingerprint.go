@@ -0,0 +1,98 @@
+// Package fingerprint groups error events that share the same cause.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// maxTemplateLength keeps long messages, like dumped payloads, from making
+// every event unique past their first sentence.
+const maxTemplateLength = 300
+
+// maxFrames is how many of the top frames take part in the fingerprint.
+const maxFrames = 3
+
+// placeholders replace the variable parts of a message, most specific first.
+var placeholders = []struct {
+	regex       *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), `"<str>"`},
+	{regexp.MustCompile(`\b[\w.+-]+@[\w-]+(?:\.[\w-]+)+\b`), "<email>"},
+	{regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://\S+`), "<url>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<ip>"},
+	{hexRegex, "<hex>"},
+	{regexp.MustCompile(`\d+(?:[.,:]\d+)*`), "<num>"},
+}
+
+var hexRegex = regexp.MustCompile(`(?i)\b(?:0x[0-9a-f]+|[0-9a-f]*[a-f][0-9a-f]*)\b`)
+
+var spacesRegex = regexp.MustCompile(`\s+`)
+
+// Frame is the part of a stack frame that identifies the code, leaving out
+// line numbers so the fingerprint survives unrelated edits of the file.
+type Frame struct {
+	File     string
+	Function string
+	InApp    bool
+}
+
+// Template normalizes a message by replacing ids, numbers, quoted values and
+// other variable parts with placeholders.
+//
+//	user 42 not found in "orders" → user <num> not found in "<str>"
+func Template(message string) string {
+	message, _, _ = strings.Cut(strings.TrimSpace(message), "\n")
+	for _, placeholder := range placeholders {
+		message = placeholder.regex.ReplaceAllStringFunc(message, func(match string) string {
+			if placeholder.regex == hexRegex && !isHash(match) {
+				return match
+			}
+			return placeholder.replacement
+		})
+	}
+
+	message = strings.TrimSpace(spacesRegex.ReplaceAllString(message, " "))
+	if len(message) > maxTemplateLength {
+		message = message[:maxTemplateLength]
+	}
+	return message
+}
+
+// Compute returns the fingerprint of an event from its message template and
+// its top frames, preferring the frames of the app over the ones of its
+// dependencies.
+func Compute(template string, frames []Frame) string {
+	top := make([]Frame, 0, maxFrames)
+	for _, frame := range frames {
+		if frame.InApp && len(top) < maxFrames {
+			top = append(top, frame)
+		}
+	}
+	if len(top) == 0 {
+		top = frames[:min(len(frames), maxFrames)]
+	}
+
+	var b strings.Builder
+	b.WriteString(template)
+	for _, frame := range top {
+		b.WriteString("\n")
+		b.WriteString(path.Base(strings.ReplaceAll(frame.File, "\\", "/")))
+		b.WriteString(":")
+		b.WriteString(frame.Function)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// isHash tells hashes and object ids apart from words that only use the
+// letters a to f, like "added" or "cafe".
+func isHash(word string) bool {
+	return len(word) >= 8 && strings.ContainsAny(word, "0123456789") || strings.HasPrefix(strings.ToLower(word), "0x")
+}
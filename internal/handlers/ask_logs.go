@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// AskLogs godoc
+// @Summary      AskLogs
+// @Description  AskLogs
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.AskLogsReq    true    "Request"
+// @Success      200    {object}    scripts.AskLogsResp
+// @Failure      400    {object}    QuerySyntaxErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/ask [post]
+func AskLogs(db *mongo.Database, queryGenerator domain.QueryGenerator, limits domain.QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.AskLogsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewAskLogsScript(
+			persistence.NewAppRepo(db),
+			persistence.NewLogRepo(db),
+			persistence.NewLogSchemaRepo(db),
+			queryGenerator,
+			newQueryGuard(db, limits),
+		)
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			respondSearchError(c, err, http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -2,14 +2,83 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
 )
 
 type ErrorResp struct {
 	Message string `json:"message"`
 }
 
+// QuerySyntaxErrorResp points at the offset of a search query where parsing
+// failed.
+type QuerySyntaxErrorResp struct {
+	Message  string `json:"message"`
+	Position int    `json:"position"`
+}
+
+// authErrorStatus maps app key and request signature errors to their HTTP
+// status, falling back to the given one.
+func authErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, scripts.ErrAuthenticateAppKeyScriptInvalidKey),
+		errors.Is(err, scripts.ErrReceiveLogsScriptSignatureRequired),
+		errors.Is(err, scripts.ErrReceiveLogsScriptInvalidSignature),
+		errors.Is(err, scripts.ErrReceiveLogsScriptStaleSignature),
+		errors.Is(err, domain.ErrRequestSignatureAlreadyUsed):
+		return http.StatusUnauthorized
+	case errors.Is(err, scripts.ErrAuthenticateAppKeyScriptScopeNotAllowed),
+		errors.Is(err, scripts.ErrReceiveBrowserErrorsScriptOriginNotAllowed):
+		return http.StatusForbidden
+	default:
+		return fallback
+	}
+}
+
+// newQueryGuard bounds the searches of the request by the server-wide limits
+// and the query policy of the account of the user.
+func newQueryGuard(db *mongo.Database, limits domain.QueryLimits) *scripts.QueryGuard {
+	return scripts.NewQueryGuard(limits, persistence.NewUserRepo(db), persistence.NewQueryPolicyRepo(db))
+}
+
+// respondSearchError answers a failed log search or export: syntax errors
+// with their position, invalid search terms, invalid exports, timeouts and
+// exceeded query limits as bad requests, and other errors with the fallback
+// status.
+func respondSearchError(c *gin.Context, err error, fallback int) {
+	var syntaxErr *domain.QuerySyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		c.JSON(http.StatusBadRequest, QuerySyntaxErrorResp{Message: syntaxErr.Error(), Position: syntaxErr.Position})
+	case errors.Is(err, scripts.ErrSearchLogsScriptInvalidSearchMode),
+		errors.Is(err, scripts.ErrSearchLogsScriptInvalidRegex),
+		errors.Is(err, scripts.ErrSearchLogsScriptTimeout),
+		errors.Is(err, scripts.ErrSearchLogsScriptLimitExceeded),
+		errors.Is(err, scripts.ErrSearchLogsScriptInvalidCursor),
+		errors.Is(err, scripts.ErrExportLogsScriptInvalidExport),
+		errors.Is(err, scripts.ErrExportLogsScriptTooManyLogs),
+		errors.Is(err, domain.ErrLogBrokerInvalidCursor),
+		errors.Is(err, scripts.ErrHistogramLogsScriptInvalidHistogram),
+		errors.Is(err, scripts.ErrAggregateLogsScriptInvalidAggregation),
+		errors.Is(err, scripts.ErrCompareLogsScriptInvalidComparison),
+		errors.Is(err, scripts.ErrAskLogsScriptInvalidQuestion),
+		errors.Is(err, scripts.ErrAskLogsScriptInvalidGenerated),
+		errors.Is(err, scripts.ErrListLogPatternsScriptInvalidList),
+		errors.Is(err, scripts.ErrGetLogFieldValuesScriptInvalidField):
+		c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+	default:
+		c.JSON(fallback, ErrorResp{Message: err.Error()})
+	}
+}
+
 func GithubInfoExtractor(token string) (string, string, error) {
 	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
 	if err != nil {
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// CompareLogs godoc
+// @Summary      CompareLogs
+// @Description  CompareLogs
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.CompareLogsReq    true    "Request"
+// @Success      200    {object}    scripts.CompareLogsResp
+// @Failure      400    {object}    QuerySyntaxErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/compare [post]
+func CompareLogs(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.CompareLogsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewCompareLogsScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db), persistence.NewLogPatternRepo(db), newQueryGuard(db, limits))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			respondSearchError(c, err, http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
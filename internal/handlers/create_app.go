@@ -29,7 +29,7 @@ func CreateApp(db *mongo.Database) gin.HandlerFunc {
 		}
 		req.UserID = c.GetString("user_id")
 
-		script := scripts.NewCreateAppScript(persistence.NewAppRepo(db))
+		script := scripts.NewCreateAppScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db))
 		resp, err := script.Exec(c, req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
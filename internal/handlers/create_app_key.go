@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// CreateAppKey godoc
+// @Summary      CreateAppKey
+// @Description  CreateAppKey
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.CreateAppKeyReq    true    "Request"
+// @Success      201    {object}    scripts.CreateAppKeyResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/keys [post]
+func CreateAppKey(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.CreateAppKeyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.AppID = c.Param("appID")
+
+		script := scripts.NewCreateAppKeyScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
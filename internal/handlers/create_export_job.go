@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/config"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// CreateExportJob godoc
+// @Summary      CreateExportJob
+// @Description  CreateExportJob
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.CreateExportJobReq    true    "Request"
+// @Success      202    {object}    scripts.CreateExportJobResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Failure      501    {object}    ErrorResp
+// @Router       /api/v1/backoffice/exports [post]
+func CreateExportJob(db *mongo.Database, cfg config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// A job is written after the request is answered, which a serverless
+		// function does not outlive.
+		if cfg.Serverless {
+			c.JSON(http.StatusNotImplemented, ErrorResp{Message: "export jobs need the long-running server, use GET /api/v1/backoffice/logs/export instead"})
+			return
+		}
+
+		var req scripts.CreateExportJobReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewCreateExportJobScript(
+			persistence.NewAppRepo(db),
+			persistence.NewLogRepo(db),
+			persistence.NewLogSchemaRepo(db),
+			persistence.NewExportJobRepo(db),
+			persistence.NewExportFileRepo(db),
+			newQueryGuard(db, cfg.QueryLimits),
+		)
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			respondSearchError(c, err, http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusAccepted, resp)
+	}
+}
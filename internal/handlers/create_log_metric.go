@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// CreateLogMetric godoc
+// @Summary      CreateLogMetric
+// @Description  Defines a metric computed from the logs received from then on, like the count of level:ERROR by app.
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.CreateLogMetricReq    true    "Request"
+// @Success      201    {object}    scripts.CreateLogMetricResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/log-metrics [post]
+func CreateLogMetric(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.CreateLogMetricReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewCreateLogMetricScript(persistence.NewAppRepo(db), persistence.NewLogMetricRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrLogMetric) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// CreateNotificationChannel godoc
+// @Summary      CreateNotificationChannel
+// @Description  Creates a webhook or email channel that is sent the chosen events, like schemaDrift, of all or some apps.
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.CreateNotificationChannelReq    true    "Request"
+// @Success      201    {object}    scripts.CreateNotificationChannelResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/notification-channels [post]
+func CreateNotificationChannel(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.CreateNotificationChannelReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewCreateNotificationChannelScript(persistence.NewAppRepo(db), persistence.NewNotificationChannelRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrNotificationChannel) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
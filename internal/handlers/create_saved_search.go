@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// CreateSavedSearch godoc
+// @Summary      CreateSavedSearch
+// @Description  Saves a named search, private or shared with all the users of the account.
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.CreateSavedSearchReq    true    "Request"
+// @Success      201    {object}    scripts.CreateSavedSearchResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/saved-searches [post]
+func CreateSavedSearch(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.CreateSavedSearchReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewCreateSavedSearchScript(
+			persistence.NewUserRepo(db),
+			persistence.NewAppRepo(db),
+			persistence.NewSavedSearchRepo(db),
+		)
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrSavedSearch) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
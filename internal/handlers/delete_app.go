@@ -23,7 +23,7 @@ func DeleteApp(db *mongo.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		appID := c.Param("appID")
 		req := scripts.DeleteAppReq{AppID: appID}
-		script := scripts.NewDeleteAppScript(persistence.NewAppRepo(db))
+		script := scripts.NewDeleteAppScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db))
 		err := script.Exec(c, req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
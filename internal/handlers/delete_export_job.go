@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DeleteExportJob godoc
+// @Summary      DeleteExportJob
+// @Description  DeleteExportJob
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      409    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/exports/{exportID} [delete]
+func DeleteExportJob(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDeleteExportJobScript(persistence.NewExportJobRepo(db), persistence.NewExportFileRepo(db))
+		err := script.Exec(c, scripts.DeleteExportJobReq{
+			UserID:      c.GetString("user_id"),
+			ExportJobID: c.Param("exportID"),
+		})
+		if errors.Is(err, scripts.ErrDeleteExportJobScriptRunning) {
+			c.JSON(http.StatusConflict, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
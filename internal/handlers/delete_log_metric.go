@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DeleteLogMetric godoc
+// @Summary      DeleteLogMetric
+// @Description  Deletes a log metric with its series.
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/log-metrics/{metricID} [delete]
+func DeleteLogMetric(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDeleteLogMetricScript(
+			persistence.NewLogMetricRepo(db),
+			persistence.NewLogMetricRollupRepo(db),
+			persistence.NewLogMetricAlertStateRepo(db),
+		)
+		err := script.Exec(c, scripts.DeleteLogMetricReq{
+			UserID:   c.GetString("user_id"),
+			MetricID: c.Param("metricID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
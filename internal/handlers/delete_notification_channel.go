@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DeleteNotificationChannel godoc
+// @Summary      DeleteNotificationChannel
+// @Description  DeleteNotificationChannel
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/notification-channels/{channelID} [delete]
+func DeleteNotificationChannel(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDeleteNotificationChannelScript(persistence.NewNotificationChannelRepo(db))
+		err := script.Exec(c, scripts.DeleteNotificationChannelReq{
+			UserID:    c.GetString("user_id"),
+			ChannelID: c.Param("channelID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
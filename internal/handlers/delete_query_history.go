@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DeleteQueryHistory godoc
+// @Summary      DeleteQueryHistory
+// @Description  Deletes an entry of the query history of the user, or the whole history without entry ID.
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/query-history/{entryID} [delete]
+func DeleteQueryHistory(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDeleteQueryHistoryScript(persistence.NewQueryHistoryRepo(db))
+		err := script.Exec(c, scripts.DeleteQueryHistoryReq{
+			UserID:  c.GetString("user_id"),
+			EntryID: c.Param("entryID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DeleteSavedSearch godoc
+// @Summary      DeleteSavedSearch
+// @Description  DeleteSavedSearch
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/saved-searches/{searchID} [delete]
+func DeleteSavedSearch(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDeleteSavedSearchScript(persistence.NewSavedSearchRepo(db))
+		err := script.Exec(c, scripts.DeleteSavedSearchReq{
+			UserID:   c.GetString("user_id"),
+			SearchID: c.Param("searchID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
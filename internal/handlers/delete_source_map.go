@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DeleteSourceMap godoc
+// @Summary      DeleteSourceMap
+// @Description  DeleteSourceMap
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/source-maps/{sourceMapID} [delete]
+func DeleteSourceMap(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDeleteSourceMapScript(persistence.NewAppRepo(db), persistence.NewSourceMapRepo(db))
+		err := script.Exec(c, scripts.DeleteSourceMapReq{
+			UserID:      c.GetString("user_id"),
+			AppID:       c.Param("appID"),
+			SourceMapID: c.Param("sourceMapID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusNoContent, nil)
+	}
+}
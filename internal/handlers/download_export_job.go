@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// DownloadExportJob godoc
+// @Summary      DownloadExportJob
+// @Description  DownloadExportJob
+// @Accept       json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.apache.parquet
+// @Success      200
+// @Failure      409    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/exports/{exportID}/download [get]
+func DownloadExportJob(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewDownloadExportJobScript(persistence.NewExportJobRepo(db), persistence.NewExportFileRepo(db))
+		resp, err := script.Exec(c, scripts.DownloadExportJobReq{
+			UserID:      c.GetString("user_id"),
+			ExportJobID: c.Param("exportID"),
+		})
+		if errors.Is(err, scripts.ErrDownloadExportJobScriptNotCompleted) {
+			c.JSON(http.StatusConflict, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		defer resp.File.Close()
+
+		c.DataFromReader(http.StatusOK, resp.FileSize, resp.ContentType, resp.File, map[string]string{
+			"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, resp.FileName),
+		})
+	}
+}
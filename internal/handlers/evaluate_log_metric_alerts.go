@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// EvaluateLogMetricAlerts godoc
+// @Summary      EvaluateLogMetricAlerts
+// @Description  Evaluates the alerts of the log metrics, called every minute by a cron job where no server runs them
+// @Produce      json
+// @Success      200    {object}    scripts.EvaluateLogMetricAlertsResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/cron/log-metric-alerts [get]
+func EvaluateLogMetricAlerts(db *mongo.Database, notifier domain.Notifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c, scripts.LogMetricAlertEvaluationInterval)
+		defer cancel()
+
+		script := scripts.NewEvaluateLogMetricAlertsScript(
+			persistence.NewLogMetricRepo(db),
+			persistence.NewLogMetricRollupRepo(db),
+			persistence.NewLogMetricAlertStateRepo(db),
+			persistence.NewNotificationChannelRepo(db),
+			notifier,
+		)
+		resp, err := script.Exec(ctx, scripts.EvaluateLogMetricAlertsReq{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
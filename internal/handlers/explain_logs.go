@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ExplainLogs godoc
+// @Summary      ExplainLogs
+// @Description  ExplainLogs
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.SearchLogsReq    true    "Request"
+// @Success      200    {object}    scripts.ExplainLogsResp
+// @Failure      400    {object}    QuerySyntaxErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/explain [get]
+func ExplainLogs(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.SearchLogsReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewExplainLogsScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db), newQueryGuard(db, limits))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			respondSearchError(c, err, http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
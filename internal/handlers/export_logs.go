@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ExportLogs godoc
+// @Summary      ExportLogs
+// @Description  ExportLogs
+// @Accept       json
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Produce      application/vnd.apache.parquet
+// @Param        body  body    scripts.ExportLogsReq    true    "Request"
+// @Success      200
+// @Failure      400    {object}    ErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/export [get]
+func ExportLogs(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.ExportLogsReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewExportLogsScript(
+			persistence.NewAppRepo(db),
+			persistence.NewLogRepo(db),
+			persistence.NewLogSchemaRepo(db),
+			newQueryGuard(db, limits),
+		)
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			respondSearchError(c, err, http.StatusInternalServerError)
+			return
+		}
+
+		c.Header("Content-Type", resp.Format.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, resp.FileName))
+		c.Status(http.StatusOK)
+		// The status is already sent, so a failure can only cut the file short.
+		if _, err := resp.WriteTo(c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
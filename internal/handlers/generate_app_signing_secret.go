@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GenerateAppSigningSecret godoc
+// @Summary      GenerateAppSigningSecret
+// @Description  GenerateAppSigningSecret
+// @Accept       json
+// @Produce      json
+// @Success      201    {object}    scripts.GenerateAppSigningSecretResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/signing-secret [post]
+func GenerateAppSigningSecret(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewGenerateAppSigningSecretScript(persistence.NewAppRepo(db))
+		resp, err := script.Exec(c, scripts.GenerateAppSigningSecretReq{
+			UserID: c.GetString("user_id"),
+			AppID:  c.Param("appID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"monitoring/internal/domain"
 	"monitoring/internal/persistence"
 	"monitoring/internal/scripts"
 )
@@ -17,9 +18,10 @@ import (
 // @Produce      json
 // @Param        body  body    scripts.GetDashboardOverviewReq    true    "Request"
 // @Success      200    {object}    scripts.GetDashboardOverviewResp
+// @Failure      400    {object}    ErrorResp
 // @Failure      500    {object}    ErrorResp
 // @Router       /api/v1/dashboard/overview [get]
-func GetDashboardOverview(db *mongo.Database) gin.HandlerFunc {
+func GetDashboardOverview(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req scripts.GetDashboardOverviewReq
 		if err := c.ShouldBindQuery(&req); err != nil {
@@ -28,10 +30,10 @@ func GetDashboardOverview(db *mongo.Database) gin.HandlerFunc {
 		}
 		req.UserID = c.GetString("user_id")
 
-		script := scripts.NewGetDashboardOverviewScript(persistence.NewDashboardRepo(db))
+		script := scripts.NewGetDashboardOverviewScript(persistence.NewDashboardRepo(db), newQueryGuard(db, limits))
 		resp, err := script.Exec(c, req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			respondSearchError(c, err, http.StatusInternalServerError)
 			return
 		}
 		c.JSON(http.StatusOK, resp)
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetExportJob godoc
+// @Summary      GetExportJob
+// @Description  GetExportJob
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.GetExportJobResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/exports/{exportID} [get]
+func GetExportJob(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewGetExportJobScript(persistence.NewExportJobRepo(db))
+		resp, err := script.Exec(c, scripts.GetExportJobReq{
+			UserID:      c.GetString("user_id"),
+			ExportJobID: c.Param("exportID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetIssue godoc
+// @Summary      GetIssue
+// @Description  GetIssue
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.GetIssueResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/issues/{issueID} [get]
+func GetIssue(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewGetIssueScript(
+			persistence.NewIssueRepo(db),
+			persistence.NewAppRepo(db),
+			persistence.NewLogRepo(db),
+		)
+		resp, err := script.Exec(c, scripts.GetIssueReq{
+			UserID:  c.GetString("user_id"),
+			IssueID: c.Param("issueID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
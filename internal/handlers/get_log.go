@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetLog godoc
+// @Summary      GetLog
+// @Description  GetLog
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.GetLogResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/{logID} [get]
+func GetLog(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewGetLogScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db))
+		resp, err := script.Exec(c, scripts.GetLogReq{
+			UserID: c.GetString("user_id"),
+			LogID:  c.Param("logID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
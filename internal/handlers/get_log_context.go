@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetLogContext godoc
+// @Summary      GetLogContext
+// @Description  GetLogContext
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.GetLogContextReq    true    "Request"
+// @Success      200    {object}    scripts.GetLogContextResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/{logID}/context [get]
+func GetLogContext(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.GetLogContextReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.LogID = c.Param("logID")
+
+		script := scripts.NewGetLogContextScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, scripts.ErrGetLogContextScriptInvalidField) || errors.Is(err, scripts.ErrSearchLogsScriptInvalidCursor) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
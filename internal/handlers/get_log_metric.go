@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetLogMetric godoc
+// @Summary      GetLogMetric
+// @Description  Returns a log metric with the state of its alert for each group.
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.GetLogMetricResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/log-metrics/{metricID} [get]
+func GetLogMetric(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewGetLogMetricScript(persistence.NewLogMetricRepo(db), persistence.NewLogMetricAlertStateRepo(db))
+		resp, err := script.Exec(c, scripts.GetLogMetricReq{
+			UserID:   c.GetString("user_id"),
+			MetricID: c.Param("metricID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
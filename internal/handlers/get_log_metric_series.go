@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetLogMetricSeries godoc
+// @Summary      GetLogMetricSeries
+// @Description  Returns the series of the groups of a log metric with the most logs over a time range.
+// @Accept       json
+// @Produce      json
+// @Param        from       query   string   false   "Start of the range, 24h before to by default"
+// @Param        to         query   string   false   "End of the range, now by default"
+// @Param        interval   query   string   false   "Length of the points, from 1m, or auto"
+// @Param        timezone   query   string   false   "IANA name of the zone the points start in"
+// @Param        stats      query   []string false   "Stats of a distribution: count, sum, avg, min, max, p50, p90, p95 or p99"
+// @Param        limit      query   int      false   "How many groups are returned"
+// @Success      200    {object}    scripts.GetLogMetricSeriesResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/log-metrics/{metricID}/series [get]
+func GetLogMetricSeries(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.GetLogMetricSeriesReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.MetricID = c.Param("metricID")
+
+		script := scripts.NewGetLogMetricSeriesScript(persistence.NewLogMetricRepo(db), persistence.NewLogMetricRollupRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, scripts.ErrGetLogMetricSeriesScriptInvalidSeries) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
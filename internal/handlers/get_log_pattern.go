@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetLogPattern godoc
+// @Summary      GetLogPattern
+// @Description  GetLogPattern
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.GetLogPatternReq    true    "Request"
+// @Success      200    {object}    scripts.GetLogPatternResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/patterns/{patternID} [get]
+func GetLogPattern(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.GetLogPatternReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.PatternID = c.Param("patternID")
+
+		script := scripts.NewGetLogPatternScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db), persistence.NewLogPatternRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
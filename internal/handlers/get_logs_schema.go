@@ -17,14 +17,19 @@ import (
 // @Produce      json
 // @Param        body  body    scripts.GetLogsSchemaReq    true    "Request"
 // @Success      200    {object}    scripts.GetLogsSchemaResp
+// @Failure      400    {object}    ErrorResp
 // @Failure      500    {object}    ErrorResp
 // @Router       /api/v1/logs/schema [get]
 func GetLogsSchema(db *mongo.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req scripts.GetLogsSchemaReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
 		req.UserID = c.GetString("user_id")
 
-		script := scripts.NewGetLogsSchemaScript(persistence.NewLogSchemaRepo(db))
+		script := scripts.NewGetLogsSchemaScript(persistence.NewAppRepo(db), persistence.NewLogSchemaRepo(db))
 		resp, err := script.Exec(c, req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetMetricSeries godoc
+// @Summary      GetMetricSeries
+// @Description  Returns the series of a metric with the most samples over a time range, selected by the values of their labels.
+// @Accept       json
+// @Produce      json
+// @Param        name       query   string   true    "Name of the metric"
+// @Param        appIds     query   []string false   "Apps of the series, all by default"
+// @Param        labels     query   []string false   "Values of labels of the series, like method=GET"
+// @Param        from       query   string   false   "Start of the range, 1h before to by default"
+// @Param        to         query   string   false   "End of the range, now by default"
+// @Param        interval   query   string   false   "Length of the points, from 10s, or auto"
+// @Param        timezone   query   string   false   "IANA name of the zone the points start in"
+// @Param        stats      query   []string false   "Stats of the points: count, sum, avg, min, max, last, rate, p50, p90, p95 or p99"
+// @Param        limit      query   int      false   "How many series are returned"
+// @Success      200    {object}    scripts.GetMetricSeriesResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/metrics/series [get]
+func GetMetricSeries(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.GetMetricSeriesReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewGetMetricSeriesScript(persistence.NewAppRepo(db), persistence.NewMetricRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, scripts.ErrGetMetricSeriesScriptInvalidSeries) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
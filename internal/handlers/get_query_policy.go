@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetQueryPolicy godoc
+// @Summary      GetQueryPolicy
+// @Description  GetQueryPolicy
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.GetQueryPolicyResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/query-policy [get]
+func GetQueryPolicy(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req := scripts.GetQueryPolicyReq{UserID: c.GetString("user_id")}
+
+		script := scripts.NewGetQueryPolicyScript(persistence.NewUserRepo(db), persistence.NewQueryPolicyRepo(db), newQueryGuard(db, limits))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// GetSavedSearch godoc
+// @Summary      GetSavedSearch
+// @Description  Returns a saved search, with the time range it would have if it ran now.
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.GetSavedSearchResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/saved-searches/{searchID} [get]
+func GetSavedSearch(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewGetSavedSearchScript(persistence.NewUserRepo(db), persistence.NewSavedSearchRepo(db))
+		resp, err := script.Exec(c, scripts.GetSavedSearchReq{
+			UserID:   c.GetString("user_id"),
+			SearchID: c.Param("searchID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListAppKeys godoc
+// @Summary      ListAppKeys
+// @Description  ListAppKeys
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.ListAppKeysResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/keys [get]
+func ListAppKeys(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewListAppKeysScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db))
+		resp, err := script.Exec(c, scripts.ListAppKeysReq{
+			UserID: c.GetString("user_id"),
+			AppID:  c.Param("appID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListIssues godoc
+// @Summary      ListIssues
+// @Description  ListIssues
+// @Accept       json
+// @Produce      json
+// @Param        status     query   string   false   "open, resolved, ignored or regressed"
+// @Param        appId      query   string   false   "App ID"
+// @Param        page       query   int      false   "Page"
+// @Param        limit      query   int      false   "Limit"
+// @Success      200    {object}    scripts.ListIssuesResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/issues [get]
+func ListIssues(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.ListIssuesReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewListIssuesScript(persistence.NewIssueRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListMetrics godoc
+// @Summary      ListMetrics
+// @Description  Lists the metrics the apps of the user reported, with their kinds and the names of their labels.
+// @Accept       json
+// @Produce      json
+// @Param        appIds       query   []string false   "Apps of the metrics, all by default"
+// @Param        searchTerm   query   string   false   "Part of the name"
+// @Success      200    {object}    scripts.ListMetricsResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/metrics [get]
+func ListMetrics(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.ListMetricsReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewListMetricsScript(persistence.NewAppRepo(db), persistence.NewMetricSeriesRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
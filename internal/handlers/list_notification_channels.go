@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListNotificationChannels godoc
+// @Summary      ListNotificationChannels
+// @Description  ListNotificationChannels
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.ListNotificationChannelsResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/notification-channels [get]
+func ListNotificationChannels(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewListNotificationChannelsScript(persistence.NewNotificationChannelRepo(db))
+		resp, err := script.Exec(c, scripts.ListNotificationChannelsReq{
+			UserID: c.GetString("user_id"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListQueryHistory godoc
+// @Summary      ListQueryHistory
+// @Description  Lists the latest log searches of the user, the last run first.
+// @Accept       json
+// @Produce      json
+// @Param        searchTerm   query   string   false   "Part of the query or search term"
+// @Param        page         query   int      false   "Page"
+// @Param        limit        query   int      false   "Limit"
+// @Success      200    {object}    scripts.ListQueryHistoryResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/query-history [get]
+func ListQueryHistory(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.ListQueryHistoryReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewListQueryHistoryScript(persistence.NewQueryHistoryRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
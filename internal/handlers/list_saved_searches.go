@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListSavedSearches godoc
+// @Summary      ListSavedSearches
+// @Description  Lists the searches saved by the user and those shared with its account.
+// @Accept       json
+// @Produce      json
+// @Param        searchTerm   query   string   false   "Part of the name"
+// @Param        shared       query   bool     false   "Only shared or only private searches"
+// @Success      200    {object}    scripts.ListSavedSearchesResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/saved-searches [get]
+func ListSavedSearches(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.ListSavedSearchesReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewListSavedSearchesScript(persistence.NewUserRepo(db), persistence.NewSavedSearchRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
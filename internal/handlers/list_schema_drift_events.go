@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListSchemaDriftEvents godoc
+// @Summary      ListSchemaDriftEvents
+// @Description  Lists the changes of the fields of the logs: fields added, fields stopped and fields that changed type.
+// @Accept       json
+// @Produce      json
+// @Param        appId      query   string   false   "App ID"
+// @Param        kind       query   string   false   "fieldAdded, fieldStopped or typeChanged"
+// @Param        path       query   string   false   "Prefix of the path of the field"
+// @Param        from       query   string   false   "Detected from, RFC 3339"
+// @Param        to         query   string   false   "Detected to, RFC 3339"
+// @Param        page       query   int      false   "Page"
+// @Param        limit      query   int      false   "Limit"
+// @Success      200    {object}    scripts.ListSchemaDriftEventsResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/schema/drift [get]
+func ListSchemaDriftEvents(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.ListSchemaDriftEventsReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewListSchemaDriftEventsScript(persistence.NewSchemaDriftEventRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
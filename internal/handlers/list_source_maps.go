@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ListSourceMaps godoc
+// @Summary      ListSourceMaps
+// @Description  ListSourceMaps
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.ListSourceMapsResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/releases/{release}/source-maps [get]
+func ListSourceMaps(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewListSourceMapsScript(persistence.NewAppRepo(db), persistence.NewSourceMapRepo(db))
+		resp, err := script.Exec(c, scripts.ListSourceMapsReq{
+			UserID:  c.GetString("user_id"),
+			AppID:   c.Param("appID"),
+			Release: c.Param("release"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
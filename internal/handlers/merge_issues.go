@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// MergeIssues godoc
+// @Summary      MergeIssues
+// @Description  MergeIssues
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.MergeIssuesReq    true    "Request"
+// @Success      200    {object}    scripts.MergeIssuesResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/issues/{issueID}/merge [post]
+func MergeIssues(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.MergeIssuesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+
+		req.UserID = c.GetString("user_id")
+		req.IssueID = c.Param("issueID")
+
+		script := scripts.NewMergeIssuesScript(persistence.NewIssueRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ReceiveBrowserErrors godoc
+// @Summary      ReceiveBrowserErrors
+// @Description  ReceiveBrowserErrors
+// @Accept       json
+// @Produce      json
+// @Param        key   query   string                              true    "Public app key"
+// @Param        body  body    scripts.ReceiveBrowserErrorsReq    true    "Request"
+// @Success      201    {object}    scripts.ReceiveBrowserErrorsResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      403    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/browser/errors [post]
+func ReceiveBrowserErrors(db *mongo.Database, logBroker domain.LogBroker, notifier domain.Notifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Public keys are restricted by origin, so the wildcard set by the
+		// CORS middleware is replaced by the origin once it is allowed.
+		origin := c.GetHeader("Origin")
+		c.Writer.Header().Del("Access-Control-Allow-Origin")
+		c.Writer.Header().Del("Access-Control-Allow-Credentials")
+		c.Writer.Header().Add("Vary", "Origin")
+
+		var req scripts.ReceiveBrowserErrorsReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.AppKey = c.Query("key")
+		req.Origin = origin
+
+		script := scripts.NewReceiveBrowserErrorsScript(
+			persistence.NewLogRepo(db),
+			persistence.NewAppRepo(db),
+			persistence.NewAppKeyRepo(db),
+			persistence.NewSourceMapRepo(db),
+			persistence.NewIssueRepo(db),
+			persistence.NewLogPatternRepo(db),
+			persistence.NewLogSchemaRepo(db),
+			persistence.NewSchemaDriftEventRepo(db),
+			persistence.NewLogMetricRepo(db),
+			persistence.NewLogMetricRollupRepo(db),
+			persistence.NewNotificationChannelRepo(db),
+			logBroker,
+			notifier,
+		)
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(authErrorStatus(err, http.StatusInternalServerError), ErrorResp{Message: err.Error()})
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.JSON(http.StatusCreated, resp)
+	}
+}
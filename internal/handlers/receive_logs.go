@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"monitoring/internal/domain"
 	"monitoring/internal/persistence"
 	"monitoring/internal/scripts"
 )
@@ -19,21 +21,45 @@ import (
 // @Success      201    {object}    scripts.ReceiveLogsResp
 // @Failure      400    {object}    ErrorResp
 // @Failure      401    {object}    ErrorResp
+// @Failure      403    {object}    ErrorResp
 // @Failure      500    {object}    ErrorResp
 // @Router       /api/v1/apps/logs [post]
-func ReceiveLogs(db *mongo.Database) gin.HandlerFunc {
+func ReceiveLogs(db *mongo.Database, logBroker domain.LogBroker, notifier domain.Notifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+
 		var req scripts.ReceiveLogsReq
-		if err := c.ShouldBindJSON(&req); err != nil {
+		if err := json.Unmarshal(body, &req); err != nil {
 			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
 			return
 		}
 		req.AppKey = c.GetHeader("x-app-key")
+		req.Signature = c.GetHeader("x-app-signature")
+		req.SignatureTimestamp = c.GetHeader("x-app-timestamp")
+		req.Body = body
 
-		script := scripts.NewReceiveLogsScript(persistence.NewLogRepo(db), persistence.NewAppRepo(db))
+		script := scripts.NewReceiveLogsScript(
+			persistence.NewLogRepo(db),
+			persistence.NewAppRepo(db),
+			persistence.NewAppKeyRepo(db),
+			persistence.NewRequestSignatureRepo(db),
+			persistence.NewIssueRepo(db),
+			persistence.NewLogPatternRepo(db),
+			persistence.NewLogSchemaRepo(db),
+			persistence.NewSchemaDriftEventRepo(db),
+			persistence.NewLogMetricRepo(db),
+			persistence.NewLogMetricRollupRepo(db),
+			persistence.NewNotificationChannelRepo(db),
+			logBroker,
+			notifier,
+		)
 		resp, err := script.Exec(c, req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			c.JSON(authErrorStatus(err, http.StatusInternalServerError), ErrorResp{Message: err.Error()})
 			return
 		}
 		c.JSON(http.StatusCreated, resp)
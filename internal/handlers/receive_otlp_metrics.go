@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/otlp"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ReceiveOTLPMetrics godoc
+// @Summary      ReceiveOTLPMetrics
+// @Description  Receives metrics exported with the JSON encoding of OTLP/HTTP: gauges, sums and histograms with explicit buckets.
+// @Accept       json
+// @Produce      json
+// @Param        body  body    otlp.ExportMetricsServiceRequest    true    "Request"
+// @Success      200    {object}    otlp.ExportMetricsServiceResponse
+// @Failure      400    {object}    ErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      403    {object}    ErrorResp
+// @Failure      415    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/apps/metrics/otlp [post]
+func ReceiveOTLPMetrics(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.Contains(c.ContentType(), "protobuf") {
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResp{Message: "only the JSON encoding of OTLP is supported"})
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+
+		script := scripts.NewReceiveMetricsScript(
+			persistence.NewAppRepo(db),
+			persistence.NewAppKeyRepo(db),
+			persistence.NewMetricRepo(db),
+			persistence.NewMetricSeriesRepo(db),
+		)
+		resp, err := script.Exec(c, scripts.ReceiveMetricsReq{
+			AppKey: c.GetHeader("x-app-key"),
+			Format: scripts.MetricFormatOTLP,
+			Body:   body,
+		})
+		if errors.Is(err, scripts.ErrReceiveMetricsScriptInvalidMetrics) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(authErrorStatus(err, http.StatusInternalServerError), ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, otlp.NewResponse(resp.Rejected, resp.Errors))
+	}
+}
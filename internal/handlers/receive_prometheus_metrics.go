@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// ReceivePrometheusMetrics godoc
+// @Summary      ReceivePrometheusMetrics
+// @Description  Receives the samples of Prometheus remote write. The samples that are valid are recorded even when others are rejected.
+// @Accept       application/x-protobuf
+// @Produce      json
+// @Success      204
+// @Failure      400    {object}    ErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      403    {object}    ErrorResp
+// @Failure      415    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/apps/metrics/prometheus [post]
+func ReceivePrometheusMetrics(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if encoding := c.GetHeader("Content-Encoding"); encoding != "" && encoding != "snappy" {
+			c.JSON(http.StatusUnsupportedMediaType, ErrorResp{Message: "the body must be compressed with snappy"})
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+
+		script := scripts.NewReceiveMetricsScript(
+			persistence.NewAppRepo(db),
+			persistence.NewAppKeyRepo(db),
+			persistence.NewMetricRepo(db),
+			persistence.NewMetricSeriesRepo(db),
+		)
+		resp, err := script.Exec(c, scripts.ReceiveMetricsReq{
+			AppKey: c.GetHeader("x-app-key"),
+			Format: scripts.MetricFormatPrometheus,
+			Body:   body,
+		})
+		if errors.Is(err, scripts.ErrReceiveMetricsScriptInvalidMetrics) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(authErrorStatus(err, http.StatusInternalServerError), ErrorResp{Message: err.Error()})
+			return
+		}
+		// Prometheus does not retry the requests rejected with a client
+		// error, so the samples rejected are not sent again.
+		if resp.Rejected > 0 {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: fmt.Sprintf("%d samples rejected: %s", resp.Rejected, strings.Join(resp.Errors, "; "))})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
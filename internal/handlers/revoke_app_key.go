@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// RevokeAppKey godoc
+// @Summary      RevokeAppKey
+// @Description  RevokeAppKey
+// @Accept       json
+// @Produce      json
+// @Success      200    {object}    scripts.RevokeAppKeyResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/keys/{keyID} [delete]
+func RevokeAppKey(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		script := scripts.NewRevokeAppKeyScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db))
+		resp, err := script.Exec(c, scripts.RevokeAppKeyReq{
+			UserID: c.GetString("user_id"),
+			AppID:  c.Param("appID"),
+			KeyID:  c.Param("keyID"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
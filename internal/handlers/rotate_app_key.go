@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// RotateAppKey godoc
+// @Summary      RotateAppKey
+// @Description  RotateAppKey
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.RotateAppKeyReq    true    "Request"
+// @Success      201    {object}    scripts.RotateAppKeyResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/keys/{keyID}/rotate [post]
+func RotateAppKey(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.RotateAppKeyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.AppID = c.Param("appID")
+		req.KeyID = c.Param("keyID")
+
+		script := scripts.NewRotateAppKeyScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// SearchAppLogs godoc
+// @Summary      SearchAppLogs
+// @Description  SearchAppLogs
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.SearchAppLogsReq    true    "Request"
+// @Success      200    {object}    scripts.SearchLogsResp
+// @Failure      400    {object}    QuerySyntaxErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      403    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/apps/logs [get]
+func SearchAppLogs(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.SearchAppLogsReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.AppKey = c.GetHeader("x-app-key")
+
+		script := scripts.NewSearchAppLogsScript(persistence.NewAppRepo(db), persistence.NewAppKeyRepo(db), persistence.NewLogRepo(db), newQueryGuard(db, limits))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			respondSearchError(c, err, authErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
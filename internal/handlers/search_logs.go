@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"monitoring/internal/domain"
 	"monitoring/internal/persistence"
 	"monitoring/internal/scripts"
 )
@@ -17,10 +19,11 @@ import (
 // @Produce      json
 // @Param        body  body    scripts.SearchLogsReq    true    "Request"
 // @Success      200    {object}    scripts.SearchLogsResp
+// @Failure      400    {object}    QuerySyntaxErrorResp
 // @Failure      401    {object}    ErrorResp
 // @Failure      500    {object}    ErrorResp
 // @Router       /api/v1/backoffice/logs [get]
-func SearchLogs(db *mongo.Database) gin.HandlerFunc {
+func SearchLogs(db *mongo.Database, limits domain.QueryLimits) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req scripts.SearchLogsReq
 		if err := c.ShouldBindQuery(&req); err != nil {
@@ -29,12 +32,19 @@ func SearchLogs(db *mongo.Database) gin.HandlerFunc {
 		}
 		req.UserID = c.GetString("user_id")
 
-		script := scripts.NewSearchLogsScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db))
+		script := scripts.NewSearchLogsScript(persistence.NewAppRepo(db), persistence.NewLogRepo(db), newQueryGuard(db, limits))
 		resp, err := script.Exec(c, req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			respondSearchError(c, err, http.StatusInternalServerError)
 			return
 		}
+
+		// The search succeeded, so its results are returned even when it
+		// cannot be added to the history.
+		history := scripts.NewRecordQueryHistoryScript(persistence.NewQueryHistoryRepo(db))
+		if _, err := history.Exec(c, scripts.RecordQueryHistoryReq{Search: req}); err != nil {
+			log.Printf("recording the query history of user %s: %v", req.UserID, err)
+		}
 		c.JSON(http.StatusOK, resp)
 	}
 }
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// tailKeepAlive is how often a comment is sent to keep an idle tail open
+// through proxies.
+const tailKeepAlive = 15 * time.Second
+
+// TailLogs godoc
+// @Summary      TailLogs
+// @Description  Streams the logs ingested from now on as server-sent events: "log" events with the log, and "gap" events when logs were missed. The id of each event is the cursor to resume from, sent back as the Last-Event-ID header or the cursor parameter.
+// @Accept       json
+// @Produce      text/event-stream
+// @Param        body  body    scripts.TailLogsReq    true    "Request"
+// @Success      200
+// @Failure      400    {object}    QuerySyntaxErrorResp
+// @Failure      401    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/logs/tail [get]
+func TailLogs(db *mongo.Database, logBroker domain.LogBroker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.TailLogsReq
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		if req.Cursor == "" {
+			req.Cursor = c.GetHeader("Last-Event-ID")
+		}
+
+		// The context of the request is done once the client disconnects,
+		// unlike the gin context.
+		ctx := c.Request.Context()
+
+		script := scripts.NewTailLogsScript(persistence.NewAppRepo(db), logBroker)
+		resp, err := script.Exec(ctx, req)
+		if err != nil {
+			respondSearchError(c, err, http.StatusInternalServerError)
+			return
+		}
+		defer resp.Close(context.WithoutCancel(ctx))
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		controller := http.NewResponseController(c.Writer)
+		lastWrite := time.Now()
+		for {
+			event, err := resp.Next(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					writeServerSentEvent(c, "error", "", ErrorResp{Message: err.Error()})
+				}
+				return
+			}
+
+			switch {
+			case event == nil && time.Since(lastWrite) < tailKeepAlive:
+				continue
+			case event == nil:
+				_, err = fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			case event.Gap:
+				err = writeServerSentEvent(c, "gap", event.Cursor, ErrorResp{Message: "some logs were missed"})
+			default:
+				err = writeServerSentEvent(c, "log", event.Cursor, event.Log)
+			}
+			// A client that went away is only noticed when writing to it.
+			if err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+	}
+}
+
+func writeServerSentEvent(c *gin.Context, name string, id string, data any) error {
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(c.Writer, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", name, content)
+	return err
+}
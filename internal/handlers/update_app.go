@@ -29,6 +29,7 @@ func UpdateApp(db *mongo.Database) gin.HandlerFunc {
 		}
 
 		req.ID = c.Param("appID")
+		req.UserID = c.GetString("user_id")
 
 		script := scripts.NewUpdateAppScript(persistence.NewAppRepo(db))
 		resp, err := script.Exec(c, req)
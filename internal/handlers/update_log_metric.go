@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// UpdateLogMetric godoc
+// @Summary      UpdateLogMetric
+// @Description  Changes a log metric. Changing what it counts deletes its series.
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.UpdateLogMetricReq    true    "Request"
+// @Success      200    {object}    scripts.UpdateLogMetricResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/log-metrics/{metricID} [patch]
+func UpdateLogMetric(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.UpdateLogMetricReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.MetricID = c.Param("metricID")
+
+		script := scripts.NewUpdateLogMetricScript(
+			persistence.NewAppRepo(db),
+			persistence.NewLogMetricRepo(db),
+			persistence.NewLogMetricRollupRepo(db),
+			persistence.NewLogMetricAlertStateRepo(db),
+		)
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrLogMetric) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// UpdateNotificationChannel godoc
+// @Summary      UpdateNotificationChannel
+// @Description  UpdateNotificationChannel
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.UpdateNotificationChannelReq    true    "Request"
+// @Success      200    {object}    scripts.UpdateNotificationChannelResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/notification-channels/{channelID} [patch]
+func UpdateNotificationChannel(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.UpdateNotificationChannelReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.ChannelID = c.Param("channelID")
+
+		script := scripts.NewUpdateNotificationChannelScript(persistence.NewAppRepo(db), persistence.NewNotificationChannelRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrNotificationChannel) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
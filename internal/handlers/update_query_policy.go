@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// UpdateQueryPolicy godoc
+// @Summary      UpdateQueryPolicy
+// @Description  UpdateQueryPolicy
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.UpdateQueryPolicyReq    true    "Request"
+// @Success      200    {object}    domain.QueryPolicy
+// @Failure      400    {object}    ErrorResp
+// @Failure      403    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/query-policy [put]
+func UpdateQueryPolicy(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.UpdateQueryPolicyReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+
+		script := scripts.NewUpdateQueryPolicyScript(persistence.NewUserRepo(db), persistence.NewQueryPolicyRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrQueryPolicy) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if errors.Is(err, scripts.ErrUpdateQueryPolicyScriptNotRoot) {
+			c.JSON(http.StatusForbidden, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
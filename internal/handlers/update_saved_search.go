@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// UpdateSavedSearch godoc
+// @Summary      UpdateSavedSearch
+// @Description  UpdateSavedSearch
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.UpdateSavedSearchReq    true    "Request"
+// @Success      200    {object}    scripts.UpdateSavedSearchResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/saved-searches/{searchID} [patch]
+func UpdateSavedSearch(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.UpdateSavedSearchReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.SearchID = c.Param("searchID")
+
+		script := scripts.NewUpdateSavedSearchScript(persistence.NewAppRepo(db), persistence.NewSavedSearchRepo(db))
+		resp, err := script.Exec(c, req)
+		if errors.Is(err, domain.ErrSavedSearch) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// UploadSourceMap godoc
+// @Summary      UploadSourceMap
+// @Description  UploadSourceMap
+// @Accept       json
+// @Produce      json
+// @Param        body  body    scripts.UploadSourceMapReq    true    "Request"
+// @Success      201    {object}    scripts.UploadSourceMapResp
+// @Failure      400    {object}    ErrorResp
+// @Failure      500    {object}    ErrorResp
+// @Router       /api/v1/backoffice/apps/{appID}/releases/{release}/source-maps [post]
+func UploadSourceMap(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scripts.UploadSourceMapReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Message: err.Error()})
+			return
+		}
+		req.UserID = c.GetString("user_id")
+		req.AppID = c.Param("appID")
+		req.Release = c.Param("release")
+
+		script := scripts.NewUploadSourceMapScript(persistence.NewAppRepo(db), persistence.NewSourceMapRepo(db))
+		resp, err := script.Exec(c, req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResp{Message: err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, resp)
+	}
+}
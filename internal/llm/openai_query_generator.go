@@ -0,0 +1,161 @@
+// Package llm writes log searches from questions with a language model.
+package llm
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.QueryGenerator = &OpenAIQueryGenerator{}
+
+var (
+	ErrOpenAIQueryGeneratorNoAnswer = errors.New("the model did not write a query")
+)
+
+// maxPromptFields is how many of the most common fields of the schema are
+// given to the model.
+const maxPromptFields = 200
+
+const systemPrompt = `You translate questions about application logs into searches.
+Answer with a query in this query language, never with a database query:
+
+- Terms are joined with AND, OR and NOT (AND when omitted) and grouped with parentheses.
+- field:value matches a value; * and ? are wildcards; field:"a phrase" matches the phrase.
+- field>=value (also >, <, <=) and field:[1 TO 5] for ranges ({} excludes the bounds, * leaves them open).
+- field:* checks the field exists.
+- A value or phrase without a field is searched in the raw log.
+
+Fields are level (like ERROR, WARNING, INFO or DEBUG), raw, timestamp,
+fingerprint, stackTrace.exceptionType, stackTrace.message, stackTrace.frames.file
+and the data fields listed below, always written with their data. prefix.
+Use from and to, in RFC 3339, for time ranges instead of the query, or null
+when the question has none. Use an empty query to match every log.`
+
+// OpenAIQueryGenerator writes queries with the chat completions of OpenAI,
+// using structured outputs so the answer always has the same shape.
+type OpenAIQueryGenerator struct {
+	client *openai.Client
+	model  openai.ChatModel
+}
+
+// NewOpenAIQueryGenerator reads the API key from OPENAI_API_KEY.
+func NewOpenAIQueryGenerator(model string) *OpenAIQueryGenerator {
+	client := openai.NewClient()
+	return &OpenAIQueryGenerator{client: &client, model: model}
+}
+
+type generatedQueryAnswer struct {
+	Query       string  `json:"query"`
+	From        *string `json:"from"`
+	To          *string `json:"to"`
+	SortOrder   string  `json:"sortOrder"`
+	Explanation string  `json:"explanation"`
+}
+
+var answerSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"query":       map[string]any{"type": "string"},
+		"from":        map[string]any{"type": []string{"string", "null"}},
+		"to":          map[string]any{"type": []string{"string", "null"}},
+		"sortOrder":   map[string]any{"type": "string", "enum": []string{"asc", "desc"}},
+		"explanation": map[string]any{"type": "string"},
+	},
+	"required":             []string{"query", "from", "to", "sortOrder", "explanation"},
+	"additionalProperties": false,
+}
+
+func (g *OpenAIQueryGenerator) GenerateQuery(ctx context.Context, question string, schema domain.LogSchema, now time.Time) (*domain.GeneratedQuery, error) {
+	completion, err := g.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Model: g.model,
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage(systemPrompt),
+			openai.SystemMessage(schemaPrompt(schema, now)),
+			openai.UserMessage(question),
+		},
+		Temperature: openai.Float(0),
+		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
+				JSONSchema: openai.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:   "log_search",
+					Schema: answerSchema,
+					Strict: openai.Bool(true),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return nil, ErrOpenAIQueryGeneratorNoAnswer
+	}
+
+	var answer generatedQueryAnswer
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &answer); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrOpenAIQueryGeneratorNoAnswer, err)
+	}
+
+	generated := &domain.GeneratedQuery{
+		Query:       answer.Query,
+		SortOrder:   domain.SortOrder(answer.SortOrder),
+		Explanation: answer.Explanation,
+	}
+	if generated.From, err = parseAnswerTime(answer.From); err != nil {
+		return nil, err
+	}
+	if generated.To, err = parseAnswerTime(answer.To); err != nil {
+		return nil, err
+	}
+	return generated, nil
+}
+
+// schemaPrompt lists the most common data fields with their types and the
+// current time, so relative times like "last hour" can be resolved.
+func schemaPrompt(schema domain.LogSchema, now time.Time) string {
+	fields := make([]domain.LogSchemaField, 0, len(schema.Schema))
+	for _, field := range schema.Fields {
+		if _, ok := schema.Schema[field.Path]; ok {
+			fields = append(fields, field)
+		}
+	}
+	slices.SortFunc(fields, func(a, b domain.LogSchemaField) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Path, b.Path))
+	})
+	if len(fields) > maxPromptFields {
+		fields = fields[:maxPromptFields]
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "The current time is %s.\n", now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&prompt, "Among %d logs, their data fields, with how many logs have them and their types, are:\n", schema.Total)
+	for _, field := range fields {
+		types := slices.Sorted(maps.Keys(field.Types))
+		fmt.Fprintf(&prompt, "- data.%s (%d, %s)\n", field.Path, field.Count, strings.Join(types, " or "))
+	}
+	return prompt.String()
+}
+
+func parseAnswerTime(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid time %q", ErrOpenAIQueryGeneratorNoAnswer, *value)
+	}
+	return &t, nil
+}
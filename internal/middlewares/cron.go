@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HasCronSecret lets through the requests with the secret as bearer token,
+// like the cron jobs of Vercel send it. Without a secret, every request is
+// refused.
+func HasCronSecret(cronSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if cronSecret == "" || subtle.ConstantTimeCompare([]byte(token), []byte("Bearer "+cronSecret)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Token inválido"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,110 @@
+// Package notify sends notifications to webhooks and email addresses.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/mail"
+)
+
+var _ domain.Notifier = &Notifier{}
+
+var (
+	ErrWebhookAddressNotAllowed = errors.New("webhook address not allowed")
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Notifier sends a notification through the webhook or the email address of
+// a channel.
+type Notifier struct {
+	client     *http.Client
+	mailSender *mail.MailSender
+}
+
+func NewNotifier(mailSender *mail.MailSender) *Notifier {
+	return &Notifier{
+		client:     newWebhookClient(),
+		mailSender: mailSender,
+	}
+}
+
+// newWebhookClient returns a client that only connects to public addresses,
+// since the users choose the URLs of the webhooks, which would otherwise
+// reach the services of the network of the server. The addresses are
+// checked when dialing, once the host is resolved, and redirects are not
+// followed.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, address)
+			}
+			if !domain.IsPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s is not public", ErrWebhookAddressNotAllowed, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the webhook.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (n *Notifier) Notify(ctx context.Context, channel domain.NotificationChannel, notification domain.Notification) error {
+	switch channel.Type() {
+	case domain.NotificationWebhook:
+		return n.post(ctx, channel.Target(), notification)
+	case domain.NotificationEmail:
+		return n.mailSender.Send(channel.Target(), notification.Subject, notification.Text)
+	}
+	return fmt.Errorf("unknown notification channel type %s", channel.Type())
+}
+
+// post sends the notification as JSON. Its text field is what chat webhooks
+// show.
+func (n *Notifier) post(ctx context.Context, url string, notification domain.Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
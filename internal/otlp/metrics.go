@@ -0,0 +1,318 @@
+// Package otlp parses the metrics of OpenTelemetry exported with the JSON
+// encoding of OTLP/HTTP: gauges, sums and histograms with explicit buckets.
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+// The temporalities of sums and histograms.
+const (
+	temporalityDelta      = 1
+	temporalityCumulative = 2
+)
+
+type ExportMetricsServiceRequest struct {
+	ResourceMetrics []ResourceMetrics `json:"resourceMetrics"`
+}
+
+type ResourceMetrics struct {
+	Resource     Resource       `json:"resource"`
+	ScopeMetrics []ScopeMetrics `json:"scopeMetrics"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type ScopeMetrics struct {
+	Metrics []Metric `json:"metrics"`
+}
+
+// Metric holds one of Gauge, Sum or Histogram. Exponential histograms and
+// summaries are not supported.
+type Metric struct {
+	Name      string     `json:"name"`
+	Gauge     *Gauge     `json:"gauge"`
+	Sum       *Sum       `json:"sum"`
+	Histogram *Histogram `json:"histogram"`
+}
+
+type Gauge struct {
+	DataPoints []NumberDataPoint `json:"dataPoints"`
+}
+
+type Sum struct {
+	DataPoints             []NumberDataPoint `json:"dataPoints"`
+	AggregationTemporality Temporality       `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type Histogram struct {
+	DataPoints             []HistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality Temporality          `json:"aggregationTemporality"`
+}
+
+type NumberDataPoint struct {
+	Attributes        []KeyValue `json:"attributes"`
+	StartTimeUnixNano Int64      `json:"startTimeUnixNano"`
+	TimeUnixNano      Int64      `json:"timeUnixNano"`
+	AsDouble          *float64   `json:"asDouble"`
+	AsInt             *Int64     `json:"asInt"`
+}
+
+// HistogramDataPoint counts the values by bucket: BucketCounts has one more
+// bucket than ExplicitBounds, the last one being above the last bound.
+type HistogramDataPoint struct {
+	Attributes        []KeyValue `json:"attributes"`
+	StartTimeUnixNano Int64      `json:"startTimeUnixNano"`
+	TimeUnixNano      Int64      `json:"timeUnixNano"`
+	Count             Int64      `json:"count"`
+	Sum               *float64   `json:"sum"`
+	BucketCounts      []Int64    `json:"bucketCounts"`
+	ExplicitBounds    []float64  `json:"explicitBounds"`
+	Min               *float64   `json:"min"`
+	Max               *float64   `json:"max"`
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue holds one of its values, arrays and maps being left out of the
+// labels.
+type AnyValue struct {
+	StringValue *string  `json:"stringValue"`
+	BoolValue   *bool    `json:"boolValue"`
+	IntValue    *Int64   `json:"intValue"`
+	DoubleValue *float64 `json:"doubleValue"`
+}
+
+// Int64 is a 64 bits integer, which the JSON encoding of OTLP writes as a
+// string, or as a number.
+type Int64 int64
+
+func (i *Int64) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*i = Int64(value)
+	return nil
+}
+
+// Temporality is the aggregation temporality of a sum or histogram, written
+// as a number or as the name of the enum.
+type Temporality int
+
+func (t *Temporality) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "1", "AGGREGATION_TEMPORALITY_DELTA":
+		*t = temporalityDelta
+	case "2", "AGGREGATION_TEMPORALITY_CUMULATIVE":
+		*t = temporalityCumulative
+	default:
+		*t = 0
+	}
+	return nil
+}
+
+// ExportMetricsServiceResponse tells the exporter about the data points that
+// were rejected, PartialSuccess being empty when all were accepted.
+type ExportMetricsServiceResponse struct {
+	PartialSuccess *ExportMetricsPartialSuccess `json:"partialSuccess,omitempty"`
+}
+
+type ExportMetricsPartialSuccess struct {
+	RejectedDataPoints int64  `json:"rejectedDataPoints"`
+	ErrorMessage       string `json:"errorMessage"`
+}
+
+// NewResponse is the response of an export of which some data points were
+// rejected for the reasons.
+func NewResponse(rejected int, reasons []string) ExportMetricsServiceResponse {
+	if rejected == 0 {
+		return ExportMetricsServiceResponse{}
+	}
+	return ExportMetricsServiceResponse{PartialSuccess: &ExportMetricsPartialSuccess{
+		RejectedDataPoints: int64(rejected),
+		ErrorMessage:       strings.Join(reasons, "; "),
+	}}
+}
+
+// Parse turns the data points of an export into samples, the attributes of
+// their resource and their own becoming their labels. Monotonic sums are
+// counters, and sums that are not are gauges unless they are deltas. It
+// returns why the data points it leaves out were rejected, and an error when
+// the body is not an export.
+func Parse(body []byte) ([]domain.MetricSample, []error, error) {
+	var req ExportMetricsServiceRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, nil, err
+	}
+
+	samples := []domain.MetricSample{}
+	rejected := []error{}
+	for _, resourceMetrics := range req.ResourceMetrics {
+		resource := labels(nil, resourceMetrics.Resource.Attributes)
+		for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+			for _, metric := range scopeMetrics.Metrics {
+				switch {
+				case metric.Gauge != nil:
+					for _, point := range metric.Gauge.DataPoints {
+						sample, err := numberSample(metric.Name, domain.MetricGauge, false, resource, point)
+						samples, rejected = appendSample(samples, rejected, sample, err)
+					}
+				case metric.Sum != nil:
+					kind := domain.MetricCounter
+					cumulative := metric.Sum.AggregationTemporality == temporalityCumulative
+					if !metric.Sum.IsMonotonic && cumulative {
+						kind, cumulative = domain.MetricGauge, false
+					}
+					for _, point := range metric.Sum.DataPoints {
+						sample, err := numberSample(metric.Name, kind, cumulative, resource, point)
+						samples, rejected = appendSample(samples, rejected, sample, err)
+					}
+				case metric.Histogram != nil:
+					cumulative := metric.Histogram.AggregationTemporality == temporalityCumulative
+					for _, point := range metric.Histogram.DataPoints {
+						sample, err := histogramSample(metric.Name, cumulative, resource, point)
+						samples, rejected = appendSample(samples, rejected, sample, err)
+					}
+				default:
+					rejected = append(rejected, fmt.Errorf("%w: %s is not a gauge, sum or histogram with explicit buckets", domain.ErrMetric, metric.Name))
+				}
+			}
+		}
+	}
+
+	return samples, rejected, nil
+}
+
+func appendSample(samples []domain.MetricSample, rejected []error, sample *domain.MetricSample, err error) ([]domain.MetricSample, []error) {
+	if err != nil {
+		return samples, append(rejected, err)
+	}
+	return append(samples, *sample), rejected
+}
+
+func numberSample(name string, kind domain.MetricKind, cumulative bool, resource map[string]string, point NumberDataPoint) (*domain.MetricSample, error) {
+	sample, err := domain.NewMetricSample(name, kind, labels(resource, point.Attributes), unixNanoTime(point.TimeUnixNano))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case point.AsDouble != nil:
+		sample.Value = *point.AsDouble
+	case point.AsInt != nil:
+		sample.Value = float64(*point.AsInt)
+	default:
+		return nil, fmt.Errorf("%w: a data point of %s has no value", domain.ErrMetric, name)
+	}
+
+	if cumulative {
+		sample.Cumulative = true
+		sample.StartTime = unixNanoTime(point.StartTimeUnixNano)
+	}
+	return sample, nil
+}
+
+// histogramSample adds the values of each bucket at its middle, those of the
+// first bucket at its upper bound and those of the last one at its lower
+// bound, the sum, minimum and maximum being kept when the point has them.
+func histogramSample(name string, cumulative bool, resource map[string]string, point HistogramDataPoint) (*domain.MetricSample, error) {
+	sample, err := domain.NewMetricSample(name, domain.MetricHistogram, labels(resource, point.Attributes), unixNanoTime(point.TimeUnixNano))
+	if err != nil {
+		return nil, err
+	}
+
+	// A histogram without buckets only has a count and a sum.
+	bounds, counts := point.ExplicitBounds, point.BucketCounts
+	if len(counts) == 0 {
+		counts = []Int64{point.Count}
+	}
+	if len(counts) != len(bounds)+1 {
+		return nil, fmt.Errorf("%w: a data point of %s has %d buckets for %d bounds", domain.ErrMetric, name, len(counts), len(bounds))
+	}
+
+	distribution := &domain.Distribution{}
+	for i, count := range counts {
+		var value float64
+		switch {
+		case len(bounds) == 0:
+			if point.Sum != nil && count > 0 {
+				value = *point.Sum / float64(count)
+			}
+		case i == 0:
+			value = bounds[0]
+		case i == len(bounds):
+			value = bounds[i-1]
+		default:
+			value = (bounds[i-1] + bounds[i]) / 2
+		}
+		distribution.AddN(value, int64(count))
+	}
+	if distribution.Count != int64(point.Count) {
+		return nil, fmt.Errorf("%w: a data point of %s counts %d values in its buckets for a count of %d", domain.ErrMetric, name, distribution.Count, point.Count)
+	}
+
+	if point.Sum != nil {
+		distribution.Sum = *point.Sum
+	}
+	if distribution.Count > 0 && point.Min != nil && point.Max != nil {
+		distribution.Min, distribution.Max = *point.Min, *point.Max
+	}
+	if math.IsNaN(distribution.Sum) || math.IsInf(distribution.Sum, 0) {
+		return nil, fmt.Errorf("%w: a data point of %s has an invalid sum", domain.ErrMetric, name)
+	}
+
+	sample.Distribution = distribution
+	if cumulative {
+		sample.Cumulative = true
+		sample.StartTime = unixNanoTime(point.StartTimeUnixNano)
+	}
+	return sample, nil
+}
+
+// unixNanoTime is the time of a timestamp, zero when it is not set.
+func unixNanoTime(unixNano Int64) time.Time {
+	if unixNano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(unixNano)).UTC()
+}
+
+// labels adds the attributes to a copy of the labels, their keys made label
+// names.
+func labels(base map[string]string, attributes []KeyValue) map[string]string {
+	labels := make(map[string]string, len(base)+len(attributes))
+	maps.Copy(labels, base)
+	for _, attribute := range attributes {
+		var value string
+		switch v := attribute.Value; {
+		case v.StringValue != nil:
+			value = *v.StringValue
+		case v.BoolValue != nil:
+			value = strconv.FormatBool(*v.BoolValue)
+		case v.IntValue != nil:
+			value = strconv.FormatInt(int64(*v.IntValue), 10)
+		case v.DoubleValue != nil:
+			value = strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+		default:
+			continue
+		}
+		labels[domain.SanitizeMetricLabel(attribute.Key)] = value
+	}
+	return labels
+}
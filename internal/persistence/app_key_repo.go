@@ -0,0 +1,187 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.AppKeyRepo = &appKeyRepo{}
+
+type appKeyRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type AppKeyDoc struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	AppID          primitive.ObjectID `bson:"appId"`
+	Name           string             `bson:"name"`
+	Prefix         string             `bson:"prefix"`
+	Hash           string             `bson:"hash"`
+	Scopes         []string           `bson:"scopes"`
+	AllowedOrigins []string           `bson:"allowedOrigins"`
+	ExpiresAt      *time.Time         `bson:"expiresAt"`
+	LastUsedAt     *time.Time         `bson:"lastUsedAt"`
+	RevokedAt      *time.Time         `bson:"revokedAt"`
+	CreatedAt      time.Time          `bson:"createdAt"`
+}
+
+func appKeyFromDomain(appKey domain.AppKey) AppKeyDoc {
+	scopes := make([]string, len(appKey.Scopes()))
+	for i, scope := range appKey.Scopes() {
+		scopes[i] = string(scope)
+	}
+
+	return AppKeyDoc{
+		ID:             appKey.ID(),
+		AppID:          appKey.AppID(),
+		Name:           appKey.Name(),
+		Prefix:         appKey.Prefix(),
+		Hash:           appKey.Hash(),
+		Scopes:         scopes,
+		AllowedOrigins: appKey.AllowedOrigins(),
+		ExpiresAt:      appKey.ExpiresAt(),
+		LastUsedAt:     appKey.LastUsedAt(),
+		RevokedAt:      appKey.RevokedAt(),
+		CreatedAt:      appKey.CreatedAt(),
+	}
+}
+
+func appKeyToDomain(appKey *AppKeyDoc) (*domain.AppKey, error) {
+	scopes := make([]domain.AppKeyScope, len(appKey.Scopes))
+	for i, scope := range appKey.Scopes {
+		scopes[i] = domain.AppKeyScope(scope)
+	}
+
+	return domain.NewAppKey(
+		appKey.ID,
+		appKey.AppID,
+		appKey.Name,
+		appKey.Prefix,
+		appKey.Hash,
+		scopes,
+		appKey.AllowedOrigins,
+		appKey.ExpiresAt,
+		appKey.LastUsedAt,
+		appKey.RevokedAt,
+		appKey.CreatedAt,
+	)
+}
+
+func NewAppKeyRepo(db *mongo.Database) *appKeyRepo {
+	return &appKeyRepo{db: db, collection: "appKeys"}
+}
+
+func (r *appKeyRepo) SaveAppKey(ctx context.Context, appKey domain.AppKey) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, appKeyFromDomain(appKey))
+	return err
+}
+
+func (r *appKeyRepo) UpdateAppKey(ctx context.Context, appKey domain.AppKey) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": appKey.ID()}, bson.M{
+		"$set": appKeyFromDomain(appKey),
+	})
+	return err
+}
+
+// ExpireAppKey only sets the expiry of a key that is not revoked, so a
+// rotation never writes back a key revoked since it was read.
+func (r *appKeyRepo) ExpireAppKey(ctx context.Context, id domain.ID, at time.Time) (*domain.AppKey, error) {
+	collection := r.db.Collection(r.collection)
+
+	var appKey AppKeyDoc
+	err := collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":       id,
+			"revokedAt": nil,
+			"$or":       bson.A{bson.M{"expiresAt": nil}, bson.M{"expiresAt": bson.M{"$gt": at}}},
+		},
+		bson.M{"$set": bson.M{"expiresAt": at}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&appKey)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// The key is revoked or expires sooner.
+		err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&appKey)
+		if err == nil && appKey.RevokedAt != nil {
+			return nil, domain.ErrAppKeyRevoked
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return appKeyToDomain(&appKey)
+}
+
+// TouchAppKey only moves the last use of the key forward, so it never writes
+// back a revocation or scopes changed since the key was read.
+func (r *appKeyRepo) TouchAppKey(ctx context.Context, id domain.ID, at time.Time) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$max": bson.M{"lastUsedAt": at},
+	})
+	return err
+}
+
+func (r *appKeyRepo) GetAppKeyByID(ctx context.Context, id domain.ID) (*domain.AppKey, error) {
+	var appKey AppKeyDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&appKey)
+	if err != nil {
+		return nil, err
+	}
+	return appKeyToDomain(&appKey)
+}
+
+func (r *appKeyRepo) GetAppKeyByHash(ctx context.Context, hash string) (*domain.AppKey, error) {
+	var appKey AppKeyDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"hash": hash}).Decode(&appKey)
+	if err != nil {
+		return nil, err
+	}
+	return appKeyToDomain(&appKey)
+}
+
+func (r *appKeyRepo) ListAppKeys(ctx context.Context, criteria domain.Criteria) ([]domain.AppKey, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	appKeys := make([]domain.AppKey, 0)
+	for cursor.Next(ctx) {
+		var appKey AppKeyDoc
+		if err := cursor.Decode(&appKey); err != nil {
+			return nil, err
+		}
+
+		domainAppKey, err := appKeyToDomain(&appKey)
+		if err != nil {
+			return nil, err
+		}
+
+		appKeys = append(appKeys, *domainAppKey)
+	}
+
+	return appKeys, nil
+}
+
+func (r *appKeyRepo) DeleteAppKeysByAppID(ctx context.Context, appID domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteMany(ctx, bson.M{"appId": appID})
+	return err
+}
@@ -19,20 +19,22 @@ type appRepo struct {
 }
 
 type AppDoc struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	Name      string             `bson:"name"`
-	AppKey    string             `bson:"appKey"`
-	UserID    primitive.ObjectID `bson:"userId"`
-	CreatedAt time.Time          `bson:"createdAt"`
+	ID               primitive.ObjectID `bson:"_id"`
+	Name             string             `bson:"name"`
+	UserID           primitive.ObjectID `bson:"userId"`
+	CreatedAt        time.Time          `bson:"createdAt"`
+	SigningSecret    string             `bson:"signingSecret"`
+	RequireSignature bool               `bson:"requireSignature"`
 }
 
 func appFromDomain(app domain.App) AppDoc {
 	return AppDoc{
-		ID:        app.ID(),
-		Name:      app.Name(),
-		AppKey:    app.AppKey(),
-		UserID:    app.UserID(),
-		CreatedAt: app.CreatedAt(),
+		ID:               app.ID(),
+		Name:             app.Name(),
+		UserID:           app.UserID(),
+		CreatedAt:        app.CreatedAt(),
+		SigningSecret:    app.SigningSecret(),
+		RequireSignature: app.RequireSignature(),
 	}
 }
 
@@ -40,9 +42,10 @@ func appToDomain(app *AppDoc) (*domain.App, error) {
 	return domain.NewApp(
 		app.ID,
 		app.Name,
-		app.AppKey,
 		app.UserID,
 		app.CreatedAt,
+		app.SigningSecret,
+		app.RequireSignature,
 	)
 }
 
@@ -73,7 +76,7 @@ func (r *appRepo) GetAppByID(ctx context.Context, appID domain.ID) (*domain.App,
 	return appToDomain(&app)
 }
 
-func (r *appRepo) GetAppByKey(ctx context.Context, appKey string) (*domain.App, error) {
+func (r *appRepo) GetAppByLegacyKey(ctx context.Context, appKey string) (*domain.App, error) {
 	var app AppDoc
 	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"appKey": appKey}).Decode(&app)
 	if err != nil {
@@ -82,6 +85,14 @@ func (r *appRepo) GetAppByKey(ctx context.Context, appKey string) (*domain.App,
 	return appToDomain(&app)
 }
 
+func (r *appRepo) RemoveLegacyAppKey(ctx context.Context, appID domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": appID}, bson.M{
+		"$unset": bson.M{"appKey": ""},
+	})
+	return err
+}
+
 func (r *appRepo) DeleteApp(ctx context.Context, appID domain.ID) error {
 	collection := r.db.Collection(r.collection)
 	_, err := collection.DeleteOne(ctx, map[string]any{"_id": appID})
@@ -90,7 +101,11 @@ func (r *appRepo) DeleteApp(ctx context.Context, appID domain.ID) error {
 
 func (r *appRepo) ListApps(ctx context.Context, criteria domain.Criteria) ([]domain.App, error) {
 	collection := r.db.Collection(r.collection)
-	cursor, err := collection.Aggregate(ctx, criteriaToPipeline(criteria))
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
 	if err != nil {
 		return nil, err
 	}
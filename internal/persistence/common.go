@@ -3,8 +3,14 @@ package persistence
 import (
 	"fmt"
 	"monitoring/internal/domain"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func toAnySlice[T any](values []T) []any {
@@ -16,37 +22,44 @@ func toAnySlice[T any](values []T) []any {
 }
 
 // criteriaToPipeline maps the criteria to a MongoDB aggregation pipeline.
-func criteriaToPipeline(c domain.Criteria) []bson.M {
+// Criteria it cannot map are an error rather than a wider match.
+func criteriaToPipeline(c domain.Criteria) ([]bson.M, error) {
 	var pipeline []bson.M
 
 	if len(c.Filters) > 0 {
-		filterStage := bson.M{}
-		for _, f := range c.Filters {
-			switch f.Type {
-			case domain.Equals:
-				filterStage[f.Field] = bson.M{"$eq": f.Value}
-			case domain.NotEquals:
-				filterStage[f.Field] = bson.M{"$ne": f.Value}
-			case domain.Like:
-				pattern := fmt.Sprintf(".*%v.*", f.Value)
-				filterStage[f.Field] = bson.M{"$regex": pattern, "$options": "i"}
-			case domain.In:
-				filterStage[f.Field] = bson.M{"$in": f.Value}
-			case domain.GreaterThanOrEqual:
-				filterStage[f.Field] = bson.M{"$gte": f.Value}
-			case domain.LessThanOrEqual:
-				filterStage[f.Field] = bson.M{"$lte": f.Value}
-			}
+		match, err := filtersToMatch(domain.And, c.Filters)
+		if err != nil {
+			return nil, err
 		}
-		pipeline = append(pipeline, bson.M{"$match": filterStage})
+		pipeline = append(pipeline, bson.M{"$match": match})
 	}
 
-	if c.Sort.Field != "" {
-		order := 1
-		if c.Sort.Order == domain.Desc {
-			order = -1
+	if c.Query != nil {
+		filter, err := domain.QueryFilter(c.Query)
+		if err != nil {
+			return nil, err
+		}
+		match, err := filterToMatch(filter)
+		if err != nil {
+			return nil, err
 		}
-		pipeline = append(pipeline, bson.M{"$sort": bson.M{c.Sort.Field: order}})
+		pipeline = append(pipeline, bson.M{"$match": match})
+	}
+
+	if fields := c.Sort.Fields(); len(fields) > 0 {
+		sort := bson.D{}
+		for _, field := range fields {
+			order := 1
+			if field.Order == domain.Desc {
+				order = -1
+			}
+			if field.Field == domain.SortByRelevance {
+				sort = append(sort, bson.E{Key: "relevance", Value: bson.M{"$meta": "textScore"}})
+				continue
+			}
+			sort = append(sort, bson.E{Key: field.Field, Value: order})
+		}
+		pipeline = append(pipeline, bson.M{"$sort": sort})
 	}
 
 	if c.Pagination.Offset > 0 {
@@ -56,5 +69,149 @@ func criteriaToPipeline(c domain.Criteria) []bson.M {
 		pipeline = append(pipeline, bson.M{"$limit": c.Pagination.Limit})
 	}
 
-	return pipeline
+	return pipeline, nil
+}
+
+// aggregateOptions applies the time limit of the criteria.
+func aggregateOptions(c domain.Criteria) *options.AggregateOptions {
+	opts := options.Aggregate()
+	if c.MaxTime > 0 {
+		opts.SetMaxTime(c.MaxTime)
+	}
+	return opts
+}
+
+// filtersToMatch combines the filters with the operator of a group. Each
+// filter is its own condition, so filters on the same field do not overwrite
+// each other.
+func filtersToMatch(groupType domain.FilterType, filters []domain.Filter) (bson.M, error) {
+	conditions := make([]bson.M, len(filters))
+	for i, f := range filters {
+		condition, err := filterToMatch(f)
+		if err != nil {
+			return nil, err
+		}
+		conditions[i] = condition
+	}
+
+	switch groupType {
+	case domain.And:
+		switch len(conditions) {
+		case 0:
+			return bson.M{}, nil
+		case 1:
+			return conditions[0], nil
+		}
+		return bson.M{"$and": conditions}, nil
+	case domain.Or:
+		if len(conditions) == 0 {
+			// Like an empty $in, an empty OR matches nothing.
+			return bson.M{"_id": bson.M{"$in": bson.A{}}}, nil
+		}
+		return bson.M{"$or": conditions}, nil
+	case domain.Not:
+		if len(conditions) == 0 {
+			return bson.M{}, nil
+		}
+		return bson.M{"$nor": conditions}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown filter group %q", domain.ErrCriteria, groupType)
+}
+
+func filterToMatch(f domain.Filter) (bson.M, error) {
+	if f.IsGroup() {
+		return filtersToMatch(f.Type, f.Filters())
+	}
+
+	switch f.Type {
+	case domain.Equals:
+		return bson.M{f.Field: bson.M{"$eq": f.Value}}, nil
+	case domain.NotEquals:
+		return bson.M{f.Field: bson.M{"$ne": f.Value}}, nil
+	case domain.Like:
+		return bson.M{f.Field: bson.M{"$regex": regexp.QuoteMeta(fmt.Sprint(f.Value)), "$options": "i"}}, nil
+	case domain.In:
+		return bson.M{f.Field: bson.M{"$in": f.Value}}, nil
+	case domain.NotIn:
+		return bson.M{f.Field: bson.M{"$nin": f.Value}}, nil
+	case domain.GreaterThan:
+		return bson.M{f.Field: bson.M{"$gt": f.Value}}, nil
+	case domain.GreaterThanOrEqual:
+		return bson.M{f.Field: bson.M{"$gte": f.Value}}, nil
+	case domain.LessThan:
+		return bson.M{f.Field: bson.M{"$lt": f.Value}}, nil
+	case domain.LessThanOrEqual:
+		return bson.M{f.Field: bson.M{"$lte": f.Value}}, nil
+	case domain.Exists:
+		return bson.M{f.Field: bson.M{"$exists": f.Value}}, nil
+	case domain.Regex:
+		return bson.M{f.Field: bson.M{"$regex": f.Value}}, nil
+	case domain.StartsWith:
+		return bson.M{f.Field: bson.M{"$regex": "^" + regexp.QuoteMeta(fmt.Sprint(f.Value))}}, nil
+	case domain.Text:
+		return bson.M{"$text": bson.M{"$search": f.Value}}, nil
+	case domain.Between:
+		bounds, ok := f.Value.([2]any)
+		if !ok {
+			return nil, fmt.Errorf("%w: between filter on %s without bounds", domain.ErrCriteria, f.Field)
+		}
+		return bson.M{f.Field: bson.M{"$gte": bounds[0], "$lte": bounds[1]}}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown filter type %q", domain.ErrCriteria, f.Type)
+}
+
+// formatGroupValue formats a value grouped by an aggregation as text, which
+// is empty for missing values.
+func formatGroupValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case primitive.D, primitive.M, primitive.A:
+		content, err := bson.MarshalExtJSON(bson.M{"v": v}, false, false)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return strings.TrimSuffix(strings.TrimPrefix(string(content), `{"v":`), "}")
+	}
+	return fmt.Sprint(value)
+}
+
+func toInt64(value any) int64 {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// groupValue converts the values decoded from an aggregation to plain ones:
+// ids as hex, dates as times in the location, and documents and arrays as
+// their JSON.
+func groupValue(value any, location *time.Location) any {
+	switch v := value.(type) {
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		if location == nil {
+			location = time.UTC
+		}
+		return v.Time().In(location)
+	case int32:
+		return int64(v)
+	case primitive.D, primitive.M, primitive.A:
+		return formatGroupValue(v)
+	}
+	return value
 }
@@ -0,0 +1,245 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"monitoring/internal/domain"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// flatPipeline is the pipeline the criteria mapped to when all the filters
+// went into a single $match keyed by field, so a later filter on a field
+// replaced an earlier one. Like is mapped as a literal substring as it is now.
+func flatPipeline(c domain.Criteria) []bson.M {
+	var pipeline []bson.M
+
+	if len(c.Filters) > 0 {
+		filterStage := bson.M{}
+		for _, f := range c.Filters {
+			switch f.Type {
+			case domain.Equals:
+				filterStage[f.Field] = bson.M{"$eq": f.Value}
+			case domain.NotEquals:
+				filterStage[f.Field] = bson.M{"$ne": f.Value}
+			case domain.Like:
+				filterStage[f.Field] = bson.M{"$regex": regexp.QuoteMeta(fmt.Sprint(f.Value)), "$options": "i"}
+			case domain.In:
+				filterStage[f.Field] = bson.M{"$in": f.Value}
+			case domain.GreaterThanOrEqual:
+				filterStage[f.Field] = bson.M{"$gte": f.Value}
+			case domain.LessThanOrEqual:
+				filterStage[f.Field] = bson.M{"$lte": f.Value}
+			}
+		}
+		pipeline = append(pipeline, bson.M{"$match": filterStage})
+	}
+
+	if c.Sort.Field != "" {
+		order := 1
+		if c.Sort.Order == domain.Desc {
+			order = -1
+		}
+		pipeline = append(pipeline, bson.M{"$sort": bson.M{c.Sort.Field: order}})
+	}
+
+	if c.Pagination.Offset > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": c.Pagination.Offset})
+	}
+	if c.Pagination.Limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": c.Pagination.Limit})
+	}
+
+	return pipeline
+}
+
+// flatten merges the conditions of the $and of each $match and the fields of
+// each $sort into a single document, the shape of flatPipeline.
+func flatten(pipeline []bson.M) []bson.M {
+	flat := make([]bson.M, len(pipeline))
+	for i, stage := range pipeline {
+		flat[i] = stage
+		if match, ok := stage["$match"].(bson.M); ok {
+			if conditions, ok := match["$and"].([]bson.M); ok {
+				merged := bson.M{}
+				for _, condition := range conditions {
+					for field, value := range condition {
+						merged[field] = value
+					}
+				}
+				flat[i] = bson.M{"$match": merged}
+			}
+		}
+		if sort, ok := stage["$sort"].(bson.D); ok {
+			flat[i] = bson.M{"$sort": sort.Map()}
+		}
+	}
+	return flat
+}
+
+func TestCriteriaToPipeline(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		criteria domain.Criteria
+		want     []bson.M
+		// overwrites is whether flatPipeline lost a filter on a field that
+		// is filtered more than once.
+		overwrites bool
+	}{
+		{
+			name: "ListApps",
+			criteria: domain.NewCriteria(
+				[]domain.Filter{
+					domain.NewFilter("userId", domain.Equals, "u1"),
+					domain.NewFilter("name", domain.Like, "shop.api"),
+				},
+				domain.NewPagination(10, 20),
+				domain.NewSort("createdAt", domain.Desc),
+			),
+			want: []bson.M{
+				{"$match": bson.M{"$and": []bson.M{
+					{"userId": bson.M{"$eq": "u1"}},
+					{"name": bson.M{"$regex": `shop\.api`, "$options": "i"}},
+				}}},
+				{"$sort": bson.D{{Key: "createdAt", Value: -1}}},
+				{"$skip": 20},
+				{"$limit": 10},
+			},
+		},
+		{
+			name: "ListUsers",
+			criteria: domain.NewCriteria(
+				[]domain.Filter{
+					domain.NewFilter("rootUserId", domain.Equals, "u1"),
+					domain.NewFilter("email", domain.Like, "ada"),
+				},
+				domain.NewPagination(10, 0),
+				domain.NewSort("createdAt", domain.Asc),
+			),
+			want: []bson.M{
+				{"$match": bson.M{"$and": []bson.M{
+					{"rootUserId": bson.M{"$eq": "u1"}},
+					{"email": bson.M{"$regex": "ada", "$options": "i"}},
+				}}},
+				{"$sort": bson.D{{Key: "createdAt", Value: 1}}},
+				{"$limit": 10},
+			},
+		},
+		{
+			name: "ListUsers without search term",
+			criteria: domain.NewCriteria(
+				[]domain.Filter{domain.NewFilter("rootUserId", domain.Equals, "u1")},
+				domain.EmptyPagination,
+				domain.EmptySort,
+			),
+			want: []bson.M{
+				{"$match": bson.M{"rootUserId": bson.M{"$eq": "u1"}}},
+			},
+		},
+		{
+			name: "ListLogs",
+			criteria: domain.NewCriteria(
+				[]domain.Filter{
+					domain.NewFilter("level", domain.Equals, "error"),
+					domain.NewFilter("appId", domain.In, []string{"a1", "a2"}),
+					domain.NewFilter("timestamp", domain.GreaterThanOrEqual, from),
+				},
+				domain.NewPagination(51, 0),
+				domain.NewSort("timestamp", domain.Desc),
+			),
+			want: []bson.M{
+				{"$match": bson.M{"$and": []bson.M{
+					{"level": bson.M{"$eq": "error"}},
+					{"appId": bson.M{"$in": []string{"a1", "a2"}}},
+					{"timestamp": bson.M{"$gte": from}},
+				}}},
+				{"$sort": bson.D{{Key: "timestamp", Value: -1}}},
+				{"$limit": 51},
+			},
+		},
+		{
+			name: "ListLogs between two times",
+			criteria: domain.NewCriteria(
+				[]domain.Filter{
+					domain.NewFilter("timestamp", domain.GreaterThanOrEqual, from),
+					domain.NewFilter("timestamp", domain.LessThanOrEqual, to),
+					domain.NewFilter("appId", domain.In, []string{"a1"}),
+				},
+				domain.NewPagination(51, 0),
+				domain.NewSort("timestamp", domain.Desc),
+			),
+			want: []bson.M{
+				{"$match": bson.M{"$and": []bson.M{
+					{"timestamp": bson.M{"$gte": from}},
+					{"timestamp": bson.M{"$lte": to}},
+					{"appId": bson.M{"$in": []string{"a1"}}},
+				}}},
+				{"$sort": bson.D{{Key: "timestamp", Value: -1}}},
+				{"$limit": 51},
+			},
+			overwrites: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := criteriaToPipeline(tt.criteria)
+			if err != nil {
+				t.Fatalf("criteriaToPipeline() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("criteriaToPipeline() = %v, want %v", got, tt.want)
+			}
+
+			flat := flatPipeline(tt.criteria)
+			if !reflect.DeepEqual(flatten(got), flat) {
+				t.Errorf("flattened criteriaToPipeline() = %v, flat pipeline %v", flatten(got), flat)
+			}
+
+			flatMatch := flat[0]["$match"].(bson.M)
+			lost := len(flatMatch) < len(tt.criteria.Filters)
+			if lost != tt.overwrites {
+				t.Errorf("flat pipeline lost a filter = %v, want %v", lost, tt.overwrites)
+			}
+		})
+	}
+}
+
+func TestCriteriaToPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []domain.Filter
+	}{
+		{
+			name:    "unknown filter type",
+			filters: []domain.Filter{domain.NewFilter("level", "near", "error")},
+		},
+		{
+			name:    "between without bounds",
+			filters: []domain.Filter{domain.NewFilter("timestamp", domain.Between, time.Now())},
+		},
+		{
+			name: "unknown filter type in a group",
+			filters: []domain.Filter{domain.NewOrFilter(
+				domain.NewFilter("level", domain.Equals, "error"),
+				domain.NewFilter("level", "near", "warn"),
+			)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria := domain.NewCriteria(tt.filters, domain.EmptyPagination, domain.EmptySort)
+			if _, err := criteriaToPipeline(criteria); !errors.Is(err, domain.ErrCriteria) {
+				t.Errorf("criteriaToPipeline() error = %v, want %v", err, domain.ErrCriteria)
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package persistence
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"monitoring/internal/domain"
 )
@@ -26,6 +28,7 @@ func (r *dashboardRepo) OverviewKPIs(
 	ctx context.Context,
 	userID domain.ID,
 	optionalRange *domain.Range,
+	maxTime time.Duration,
 ) (domain.DashboardOverviewKPIs, error) {
 	logsColl := r.db.Collection("logs")
 
@@ -81,7 +84,11 @@ func (r *dashboardRepo) OverviewKPIs(
 		}},
 	}}})
 
-	cur, err := logsColl.Aggregate(ctx, pipeline)
+	opts := options.Aggregate()
+	if maxTime > 0 {
+		opts.SetMaxTime(maxTime)
+	}
+	cur, err := logsColl.Aggregate(ctx, pipeline, opts)
 	if err != nil {
 		return domain.DashboardOverviewKPIs{}, err
 	}
@@ -0,0 +1,69 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.ExportFileRepo = &exportFileRepo{}
+
+// exportFileRepo keeps export files in GridFS, since they can be larger
+// than a document.
+type exportFileRepo struct {
+	db     *mongo.Database
+	bucket string
+}
+
+func NewExportFileRepo(db *mongo.Database) *exportFileRepo {
+	return &exportFileRepo{db: db, bucket: "exports"}
+}
+
+func (r *exportFileRepo) gridFSBucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(r.db, options.GridFSBucket().SetName(r.bucket))
+}
+
+func (r *exportFileRepo) CreateExportFile(ctx context.Context, id domain.ID, name string) (io.WriteCloser, error) {
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenUploadStreamWithID(id, name)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (r *exportFileRepo) OpenExportFile(ctx context.Context, id domain.ID) (io.ReadCloser, int64, error) {
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return stream, stream.GetFile().Length, nil
+}
+
+func (r *exportFileRepo) DeleteExportFile(ctx context.Context, id domain.ID) error {
+	bucket, err := r.gridFSBucket()
+	if err != nil {
+		return err
+	}
+
+	err = bucket.DeleteContext(ctx, id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil
+	}
+	return err
+}
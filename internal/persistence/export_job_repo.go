@@ -0,0 +1,182 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.ExportJobRepo = &exportJobRepo{}
+
+type exportJobRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type ExportJobDoc struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	UserID     primitive.ObjectID `bson:"userId"`
+	Format     string             `bson:"format"`
+	Columns    []string           `bson:"columns"`
+	Status     string             `bson:"status"`
+	Processed  int64              `bson:"processed"`
+	Total      int64              `bson:"total"`
+	Error      string             `bson:"error"`
+	FileSize   int64              `bson:"fileSize"`
+	CreatedAt  time.Time          `bson:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt"`
+	FinishedAt *time.Time         `bson:"finishedAt"`
+}
+
+func exportJobFromDomain(job domain.ExportJob) ExportJobDoc {
+	return ExportJobDoc{
+		ID:         job.ID(),
+		UserID:     job.UserID(),
+		Format:     job.Format(),
+		Columns:    job.Columns(),
+		Status:     string(job.Status()),
+		Processed:  job.Processed(),
+		Total:      job.Total(),
+		Error:      job.ErrorMessage(),
+		FileSize:   job.FileSize(),
+		CreatedAt:  job.CreatedAt(),
+		UpdatedAt:  job.UpdatedAt(),
+		FinishedAt: job.FinishedAt(),
+	}
+}
+
+func exportJobToDomain(job *ExportJobDoc) (*domain.ExportJob, error) {
+	// Jobs saved before leases were renewed count from their creation.
+	updatedAt := job.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = job.CreatedAt
+	}
+
+	return domain.NewExportJob(
+		job.ID,
+		job.UserID,
+		job.Format,
+		job.Columns,
+		domain.ExportJobStatus(job.Status),
+		job.Processed,
+		job.Total,
+		job.Error,
+		job.FileSize,
+		job.CreatedAt,
+		updatedAt,
+		job.FinishedAt,
+	)
+}
+
+func NewExportJobRepo(db *mongo.Database) *exportJobRepo {
+	return &exportJobRepo{db: db, collection: "exportJobs"}
+}
+
+func (r *exportJobRepo) SaveExportJob(ctx context.Context, job domain.ExportJob) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, exportJobFromDomain(job))
+	return err
+}
+
+// unfinishedExportJob matches the job while it is pending or running.
+func unfinishedExportJob(id domain.ID) bson.M {
+	return bson.M{
+		"_id":    id,
+		"status": bson.M{"$in": bson.A{domain.ExportJobStatusPending, domain.ExportJobStatusRunning}},
+	}
+}
+
+func (r *exportJobRepo) UpdateExportJob(ctx context.Context, job domain.ExportJob) error {
+	collection := r.db.Collection(r.collection)
+	result, err := collection.UpdateOne(ctx, unfinishedExportJob(job.ID()), bson.M{
+		"$set": exportJobFromDomain(job),
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrExportJobFinished
+	}
+	return nil
+}
+
+func (r *exportJobRepo) RenewExportJob(ctx context.Context, id domain.ID, at time.Time) error {
+	collection := r.db.Collection(r.collection)
+	result, err := collection.UpdateOne(ctx, unfinishedExportJob(id), bson.M{
+		"$max": bson.M{"updatedAt": at},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrExportJobFinished
+	}
+	return nil
+}
+
+func (r *exportJobRepo) FailAbandonedExportJob(ctx context.Context, job domain.ExportJob, before time.Time) (bool, error) {
+	filter := unfinishedExportJob(job.ID())
+	filter["$or"] = bson.A{
+		bson.M{"updatedAt": bson.M{"$lt": before}},
+		bson.M{"updatedAt": bson.M{"$exists": false}, "createdAt": bson.M{"$lt": before}},
+	}
+
+	collection := r.db.Collection(r.collection)
+	result, err := collection.UpdateOne(ctx, filter, bson.M{
+		"$set": exportJobFromDomain(job),
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *exportJobRepo) GetExportJobByID(ctx context.Context, id domain.ID) (*domain.ExportJob, error) {
+	var job ExportJobDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+	return exportJobToDomain(&job)
+}
+
+func (r *exportJobRepo) ListExportJobs(ctx context.Context, criteria domain.Criteria) ([]domain.ExportJob, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := make([]domain.ExportJob, 0)
+	for cursor.Next(ctx) {
+		var job ExportJobDoc
+		if err := cursor.Decode(&job); err != nil {
+			return nil, err
+		}
+
+		domainJob, err := exportJobToDomain(&job)
+		if err != nil {
+			return nil, err
+		}
+
+		jobs = append(jobs, *domainJob)
+	}
+
+	return jobs, nil
+}
+
+func (r *exportJobRepo) DeleteExportJob(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,341 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+// namespaceExistsCode is the error of creating a collection that exists.
+const namespaceExistsCode = 48
+
+// EnsureIndexes creates the indexes the repositories rely on. Creating an
+// index that already exists is a no-op, so it is safe to run on every start.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("logs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Backs searches of the apps of a user sorted by timestamp and ID,
+			// which is the order cursors page in.
+			Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("logs_app_timestamp"),
+		},
+		{
+			// Backs the text search mode of the logs, a collection can only
+			// have one text index.
+			Keys: bson.D{
+				{Key: "raw", Value: "text"},
+				{Key: "data.message", Value: "text"},
+				{Key: "data.msg", Value: "text"},
+				{Key: "data.error", Value: "text"},
+				{Key: "stackTrace.exceptionType", Value: "text"},
+				{Key: "stackTrace.message", Value: "text"},
+			},
+			Options: options.Index().
+				SetName("logs_text").
+				// Log messages are not prose, so words are neither stemmed nor
+				// dropped as stop words.
+				SetDefaultLanguage("none").
+				SetWeights(bson.D{
+					{Key: "raw", Value: 1},
+					{Key: "data.message", Value: 5},
+					{Key: "data.msg", Value: 5},
+					{Key: "data.error", Value: 5},
+					{Key: "stackTrace.exceptionType", Value: 10},
+					{Key: "stackTrace.message", Value: 5},
+				}),
+		},
+		{
+			// Backs the example logs of a pattern. Only logs with a pattern
+			// are indexed.
+			Keys: bson.D{{Key: "patternId", Value: 1}, {Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().
+				SetName("logs_pattern_timestamp").
+				SetPartialFilterExpression(bson.M{"patternId": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("appKeys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Every request authenticated by an app key finds it by its hash.
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetName("appKeys_hash").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("sourceMaps").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Every browser error looks up the maps of its frames, and uploads
+		// replace the map of the same file.
+		Keys: bson.D{
+			{Key: "appId", Value: 1},
+			{Key: "release", Value: 1},
+			{Key: "file", Value: 1},
+		},
+		Options: options.Index().SetName("sourceMaps_app_release_file"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("exportJobs").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Backs the listing of the export jobs of a user, newest first.
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+		Options: options.Index().SetName("exportJobs_user_createdAt"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("issues").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Backs finding the issue of a fingerprint at ingestion.
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "fingerprints", Value: 1}},
+			Options: options.Index().SetName("issues_user_fingerprints"),
+		},
+		{
+			// An issue is opened once per fingerprint, even when batches
+			// with its first events are ingested at once. Merging issues
+			// keeps the fingerprint that opened each, so it is not unique on
+			// all of them, and issues opened before it have none.
+			Keys: bson.D{{Key: "userId", Value: 1}, {Key: "fingerprint", Value: 1}},
+			Options: options.Index().
+				SetName("issues_user_fingerprint").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"fingerprint": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("requestSignatures").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "receivedAt", Value: 1}},
+		Options: options.Index().
+			SetName("requestSignatures_ttl").
+			SetExpireAfterSeconds(int32(domain.RequestSignatureRetention.Seconds())),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logPatterns").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Backs loading the patterns of an app at ingestion.
+			Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "lastSeen", Value: -1}},
+			Options: options.Index().SetName("logPatterns_app_lastSeen"),
+		},
+		{
+			// A template learned by batches ingested at once is one pattern.
+			// The patterns learned twice before it was unique are merged
+			// with MergeDuplicateLogPatterns.
+			Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "template", Value: 1}},
+			Options: options.Index().SetName("logPatterns_app_template").SetUnique(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logSchemaFields").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Backs the updates of the catalog at ingestion. It is not unique:
+		// two batches can add the same new field at once, and the catalog is
+		// read adding up the documents of a path.
+		Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "path", Value: 1}},
+		Options: options.Index().SetName("logSchemaFields_app_path"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logSchemaFields").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Finds the fields an app stopped sending.
+		Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "lastSeen", Value: 1}},
+		Options: options.Index().SetName("logSchemaFields_app_lastSeen"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("schemaDriftEvents").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Each change is recorded once, even when batches ingested at once
+		// detect it.
+		Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetName("schemaDriftEvents_app_key").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("schemaDriftEvents").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "detectedAt", Value: -1}},
+		Options: options.Index().SetName("schemaDriftEvents_user_detectedAt"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("notificationChannels").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}},
+		Options: options.Index().SetName("notificationChannels_user"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("savedSearches").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("savedSearches_user_name"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("savedSearches").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Lists the searches shared with the users of an account.
+		Keys:    bson.D{{Key: "accountId", Value: 1}, {Key: "shared", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("savedSearches_account_shared_name"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("queryHistory").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Runs of the same search update one entry.
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetName("queryHistory_user_key").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("queryHistory").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "lastRunAt", Value: -1}},
+		Options: options.Index().SetName("queryHistory_user_lastRunAt"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logMetrics").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("logMetrics_user_name"),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logMetricRollups").Indexes().CreateOne(ctx, mongo.IndexModel{
+		// Batches ingested at once add up to one rollup per group and minute,
+		// and the series read the rollups of a metric by start.
+		Keys:    bson.D{{Key: "metricId", Value: 1}, {Key: "start", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetName("logMetricRollups_metric_start_key").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logMetricRollups").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "start", Value: 1}},
+		Options: options.Index().
+			SetName("logMetricRollups_ttl").
+			SetExpireAfterSeconds(int32(domain.LogMetricRollupRetention.Seconds())),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection(logMetricGroupsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "metricId", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetName("logMetricGroups_metric_key").SetUnique(true),
+		},
+		{
+			// Groups are claimed again once expired, so those without logs
+			// since free their place.
+			Keys: bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().
+				SetName("logMetricGroups_ttl").
+				SetExpireAfterSeconds(int32(domain.LogMetricRollupRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Collection("logMetricAlertStates").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "metricId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetName("logMetricAlertStates_metric_key").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	return ensureMetricCollections(ctx, db)
+}
+
+// ensureMetricCollections creates the time series collection of the metric
+// samples, which expire after their retention, and the indexes of the
+// rollups, which expire after theirs.
+func ensureMetricCollections(ctx context.Context, db *mongo.Database) error {
+	err := db.CreateCollection(ctx, metricSamplesCollection, options.CreateCollection().
+		SetTimeSeriesOptions(options.TimeSeries().
+			SetTimeField("timestamp").
+			SetMetaField("meta").
+			SetGranularity("seconds")).
+		SetExpireAfterSeconds(int64(domain.MetricSampleRetention.Seconds())))
+	var commandErr mongo.CommandError
+	if err != nil && !(errors.As(err, &commandErr) && commandErr.HasErrorCode(namespaceExistsCode)) {
+		return err
+	}
+
+	_, err = db.Collection(metricSamplesCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "meta.appId", Value: 1}, {Key: "meta.name", Value: 1}, {Key: "timestamp", Value: 1}},
+		Options: options.Index().SetName("metricSamples_app_name_timestamp"),
+	})
+	if err != nil {
+		return err
+	}
+
+	for resolution, retention := range domain.MetricRollupRetentions {
+		name := metricRollupsCollection(resolution)
+		_, err = db.Collection(name).Indexes().CreateMany(ctx, []mongo.IndexModel{
+			{
+				// Samples ingested at once add up to one rollup per series
+				// and bucket.
+				Keys:    bson.D{{Key: "meta.appId", Value: 1}, {Key: "meta.series", Value: 1}, {Key: "start", Value: 1}},
+				Options: options.Index().SetName(name + "_app_series_start").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "meta.appId", Value: 1}, {Key: "meta.name", Value: 1}, {Key: "start", Value: 1}},
+				Options: options.Index().SetName(name + "_app_name_start"),
+			},
+			{
+				Keys:    bson.D{{Key: "start", Value: 1}},
+				Options: options.Index().SetName(name + "_ttl").SetExpireAfterSeconds(int32(retention.Seconds())),
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Collection("metricSeries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetName("metricSeries_app_key").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "appId", Value: 1}, {Key: "name", Value: 1}},
+			Options: options.Index().SetName("metricSeries_app_name"),
+		},
+	})
+	return err
+}
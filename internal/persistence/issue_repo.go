@@ -0,0 +1,205 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.IssueRepo = &issueRepo{}
+
+type issueRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type IssueDoc struct {
+	ID           primitive.ObjectID   `bson:"_id"`
+	UserID       primitive.ObjectID   `bson:"userId"`
+	Fingerprints []string             `bson:"fingerprints"`
+	Title        string               `bson:"title"`
+	Level        string               `bson:"level"`
+	Status       string               `bson:"status"`
+	AppIDs       []primitive.ObjectID `bson:"appIds"`
+	Count        int64                `bson:"count"`
+	FirstSeen    time.Time            `bson:"firstSeen"`
+	LastSeen     time.Time            `bson:"lastSeen"`
+	ResolvedAt   *time.Time           `bson:"resolvedAt"`
+}
+
+func issueToDomain(issue *IssueDoc) (*domain.Issue, error) {
+	return domain.NewIssue(
+		issue.ID,
+		issue.UserID,
+		issue.Fingerprints,
+		issue.Title,
+		issue.Level,
+		domain.IssueStatus(issue.Status),
+		issue.AppIDs,
+		issue.Count,
+		issue.FirstSeen,
+		issue.LastSeen,
+		issue.ResolvedAt,
+	)
+}
+
+func NewIssueRepo(db *mongo.Database) *issueRepo {
+	return &issueRepo{db: db, collection: "issues"}
+}
+
+func (r *issueRepo) GetIssueByID(ctx context.Context, id domain.ID) (*domain.Issue, error) {
+	var issue IssueDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&issue)
+	if err != nil {
+		return nil, err
+	}
+	return issueToDomain(&issue)
+}
+
+func (r *issueRepo) RecordIssueEvents(ctx context.Context, userID domain.ID, events domain.IssueEvents) (*domain.Issue, error) {
+	// The update is a pipeline so the level and the regression depend on
+	// the issue as it is when the events are added, in one write. An issue
+	// it opens is marked with the fingerprint, unique per user, so two
+	// batches cannot open the same issue twice, the second one adding its
+	// events to the first after a duplicate key error.
+	isNew := bson.M{"$eq": bson.A{bson.M{"$type": "$count"}, "missing"}}
+	appIDs := bson.M{"$ifNull": bson.A{"$appIds", bson.A{}}}
+	regressed := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$status", string(domain.IssueStatusResolved)}},
+		bson.M{"$eq": bson.A{bson.M{"$type": "$resolvedAt"}, "date"}},
+		bson.M{"$gt": bson.A{events.LastSeen, "$resolvedAt"}},
+	}}
+	update := bson.A{bson.M{"$set": bson.M{
+		"fingerprint":  bson.M{"$cond": bson.A{isNew, events.Fingerprint, "$fingerprint"}},
+		"fingerprints": bson.M{"$cond": bson.A{isNew, bson.A{events.Fingerprint}, "$fingerprints"}},
+		"title":        bson.M{"$cond": bson.A{isNew, events.Title, "$title"}},
+		"level":        bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{events.LastSeen, "$lastSeen"}}, events.Level, "$level"}},
+		"status": bson.M{"$switch": bson.M{
+			"branches": bson.A{
+				bson.M{"case": isNew, "then": string(domain.IssueStatusOpen)},
+				bson.M{"case": regressed, "then": string(domain.IssueStatusRegressed)},
+			},
+			"default": "$status",
+		}},
+		"resolvedAt": bson.M{"$cond": bson.A{bson.M{"$or": bson.A{isNew, regressed}}, nil, "$resolvedAt"}},
+		"appIds": bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{events.AppID, appIDs}},
+			appIDs,
+			bson.M{"$concatArrays": bson.A{appIDs, bson.A{events.AppID}}},
+		}},
+		"count":     bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$count", 0}}, events.Count}},
+		"firstSeen": bson.M{"$min": bson.A{"$firstSeen", events.FirstSeen}},
+		"lastSeen":  bson.M{"$max": bson.A{"$lastSeen", events.LastSeen}},
+	}}}
+
+	collection := r.db.Collection(r.collection)
+	filter := bson.M{"userId": userID, "fingerprints": events.Fingerprint}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var issue IssueDoc
+	err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&issue)
+	if mongo.IsDuplicateKeyError(err) {
+		err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&issue)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return issueToDomain(&issue)
+}
+
+func (r *issueRepo) ChangeIssueStatus(ctx context.Context, issue domain.Issue) (*domain.Issue, error) {
+	collection := r.db.Collection(r.collection)
+	update := bson.M{"$set": bson.M{
+		"status":     string(issue.Status()),
+		"resolvedAt": issue.ResolvedAt(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var doc IssueDoc
+	if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": issue.ID()}, update, opts).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return issueToDomain(&doc)
+}
+
+func (r *issueRepo) MergeIssue(ctx context.Context, targetID domain.ID, other domain.Issue) (*domain.Issue, error) {
+	collection := r.db.Collection(r.collection)
+
+	// The fingerprints are moved first, so the events recorded until the
+	// other issue is deleted go to either issue and are all counted.
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{
+		"$addToSet": bson.M{"fingerprints": bson.M{"$each": other.Fingerprints()}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted IssueDoc
+	err = collection.FindOneAndDelete(ctx, bson.M{"_id": other.ID(), "userId": other.UserID()}).Decode(&deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	// The level follows the latest event, compared with the issue as it is
+	// before the update.
+	appIDs := bson.A{}
+	for _, appID := range deleted.AppIDs {
+		appIDs = append(appIDs, appID)
+	}
+	update := bson.A{bson.M{"$set": bson.M{
+		"level": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{deleted.LastSeen, "$lastSeen"}}, deleted.Level, "$level"}},
+		"appIds": bson.M{"$concatArrays": bson.A{
+			"$appIds",
+			bson.M{"$setDifference": bson.A{bson.M{"$literal": appIDs}, "$appIds"}},
+		}},
+		"count":     bson.M{"$add": bson.A{"$count", deleted.Count}},
+		"firstSeen": bson.M{"$min": bson.A{"$firstSeen", deleted.FirstSeen}},
+		"lastSeen":  bson.M{"$max": bson.A{"$lastSeen", deleted.LastSeen}},
+	}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var issue IssueDoc
+	if err := collection.FindOneAndUpdate(ctx, bson.M{"_id": targetID}, update, opts).Decode(&issue); err != nil {
+		return nil, err
+	}
+	return issueToDomain(&issue)
+}
+
+func (r *issueRepo) ListIssues(ctx context.Context, criteria domain.Criteria) ([]domain.Issue, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	issues := make([]domain.Issue, 0)
+	for cursor.Next(ctx) {
+		var issue IssueDoc
+		if err := cursor.Decode(&issue); err != nil {
+			return nil, err
+		}
+
+		domainIssue, err := issueToDomain(&issue)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, *domainIssue)
+	}
+
+	return issues, nil
+}
+
+func (r *issueRepo) DeleteIssue(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
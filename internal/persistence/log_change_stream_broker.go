@@ -0,0 +1,106 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.LogBroker = &logChangeStreamBroker{}
+
+// changeStreamHistoryLost is the error code of a resume token that is no
+// longer in the oplog.
+const changeStreamHistoryLost = 286
+
+// logChangeStreamBroker follows the inserts in the logs collection, so the
+// logs saved by any instance reach the live tails of every instance. It needs
+// MongoDB to run as a replica set.
+type logChangeStreamBroker struct {
+	db         *mongo.Database
+	collection string
+}
+
+func NewLogChangeStreamBroker(db *mongo.Database) *logChangeStreamBroker {
+	return &logChangeStreamBroker{db: db, collection: "logs"}
+}
+
+// Publish does nothing, the inserts themselves are the events.
+func (b *logChangeStreamBroker) Publish(logs []domain.Log) {}
+
+func (b *logChangeStreamBroker) Subscribe(ctx context.Context, appIDs []domain.ID, cursor string) (domain.LogSubscription, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType":      "insert",
+			"fullDocument.appId": bson.M{"$in": appIDs},
+		}}},
+	}
+
+	collection := b.db.Collection(b.collection)
+	opts := options.ChangeStream().SetMaxAwaitTime(time.Second)
+	if cursor == "" {
+		stream, err := collection.Watch(ctx, pipeline, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &logChangeStreamSubscription{stream: stream}, nil
+	}
+
+	stream, err := collection.Watch(ctx, pipeline, opts.SetResumeAfter(bson.M{"_data": cursor}))
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == changeStreamHistoryLost || cmdErr.HasErrorLabel("NonResumableChangeStreamError")) {
+		stream, err = collection.Watch(ctx, pipeline, options.ChangeStream().SetMaxAwaitTime(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		return &logChangeStreamSubscription{stream: stream, gap: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &logChangeStreamSubscription{stream: stream}, nil
+}
+
+type logChangeStreamSubscription struct {
+	stream *mongo.ChangeStream
+	gap    bool
+}
+
+func (s *logChangeStreamSubscription) cursor() string {
+	data, _ := s.stream.ResumeToken().Lookup("_data").StringValueOK()
+	return data
+}
+
+func (s *logChangeStreamSubscription) Next(ctx context.Context) (*domain.LogEvent, error) {
+	if s.gap {
+		s.gap = false
+		return &domain.LogEvent{Cursor: s.cursor(), Gap: true}, nil
+	}
+
+	if !s.stream.TryNext(ctx) {
+		return nil, s.stream.Err()
+	}
+
+	var event struct {
+		FullDocument LogDoc `bson:"fullDocument"`
+	}
+	if err := s.stream.Decode(&event); err != nil {
+		return nil, err
+	}
+
+	log, err := logToDomain(&event.FullDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LogEvent{Log: log, Cursor: s.cursor()}, nil
+}
+
+func (s *logChangeStreamSubscription) Close(ctx context.Context) error {
+	return s.stream.Close(ctx)
+}
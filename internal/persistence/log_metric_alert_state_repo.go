@@ -0,0 +1,92 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.LogMetricAlertStateRepo = &logMetricAlertStateRepo{}
+
+type logMetricAlertStateRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+// LogMetricAlertStateDoc is unique per metric and group, Key being the group
+// as one value.
+type LogMetricAlertStateDoc struct {
+	MetricID  primitive.ObjectID `bson:"metricId"`
+	Key       string             `bson:"key"`
+	Keys      []string           `bson:"keys"`
+	Firing    bool               `bson:"firing"`
+	Value     float64            `bson:"value"`
+	ChangedAt time.Time          `bson:"changedAt"`
+}
+
+func logMetricAlertStateFromDomain(state domain.LogMetricAlertState) LogMetricAlertStateDoc {
+	return LogMetricAlertStateDoc{
+		MetricID:  state.MetricID,
+		Key:       domain.LogMetricGroupKey(state.Keys),
+		Keys:      state.Keys,
+		Firing:    state.Firing,
+		Value:     state.Value,
+		ChangedAt: state.ChangedAt,
+	}
+}
+
+func logMetricAlertStateToDomain(state *LogMetricAlertStateDoc) domain.LogMetricAlertState {
+	return domain.LogMetricAlertState{
+		MetricID:  state.MetricID,
+		Keys:      state.Keys,
+		Firing:    state.Firing,
+		Value:     state.Value,
+		ChangedAt: state.ChangedAt,
+	}
+}
+
+func NewLogMetricAlertStateRepo(db *mongo.Database) *logMetricAlertStateRepo {
+	return &logMetricAlertStateRepo{db: db, collection: "logMetricAlertStates"}
+}
+
+func (r *logMetricAlertStateRepo) SaveAlertState(ctx context.Context, state domain.LogMetricAlertState) error {
+	collection := r.db.Collection(r.collection)
+	doc := logMetricAlertStateFromDomain(state)
+	_, err := collection.ReplaceOne(ctx,
+		bson.M{"metricId": doc.MetricID, "key": doc.Key},
+		doc,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *logMetricAlertStateRepo) ListAlertStates(ctx context.Context, metricID domain.ID) ([]domain.LogMetricAlertState, error) {
+	collection := r.db.Collection(r.collection)
+	cursor, err := collection.Find(ctx, bson.M{"metricId": metricID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	states := make([]domain.LogMetricAlertState, 0)
+	for cursor.Next(ctx) {
+		var state LogMetricAlertStateDoc
+		if err := cursor.Decode(&state); err != nil {
+			return nil, err
+		}
+		states = append(states, logMetricAlertStateToDomain(&state))
+	}
+
+	return states, cursor.Err()
+}
+
+func (r *logMetricAlertStateRepo) DeleteAlertStates(ctx context.Context, metricID domain.ID) error {
+	_, err := r.db.Collection(r.collection).DeleteMany(ctx, bson.M{"metricId": metricID})
+	return err
+}
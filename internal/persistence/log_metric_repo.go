@@ -0,0 +1,167 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.LogMetricRepo = &logMetricRepo{}
+
+type logMetricRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+// LogMetricDoc leaves out when the alert was last evaluated, which only
+// ClaimAlertEvaluation sets.
+type LogMetricDoc struct {
+	ID        primitive.ObjectID   `bson:"_id"`
+	UserID    primitive.ObjectID   `bson:"userId"`
+	Name      string               `bson:"name"`
+	Type      string               `bson:"type"`
+	Query     string               `bson:"query"`
+	AppIDs    []primitive.ObjectID `bson:"appIds"`
+	Field     string               `bson:"field"`
+	GroupBy   []string             `bson:"groupBy"`
+	Alert     *LogMetricAlertDoc   `bson:"alert"`
+	CreatedAt time.Time            `bson:"createdAt"`
+	UpdatedAt time.Time            `bson:"updatedAt"`
+}
+
+type LogMetricAlertDoc struct {
+	Stat      string        `bson:"stat"`
+	Op        string        `bson:"op"`
+	Threshold float64       `bson:"threshold"`
+	Window    time.Duration `bson:"window"`
+}
+
+func logMetricFromDomain(metric domain.LogMetric) LogMetricDoc {
+	doc := LogMetricDoc{
+		ID:        metric.ID(),
+		UserID:    metric.UserID(),
+		Name:      metric.Name(),
+		Type:      string(metric.Type()),
+		Query:     metric.Query(),
+		AppIDs:    metric.AppIDs(),
+		Field:     metric.Field(),
+		GroupBy:   metric.GroupBy(),
+		CreatedAt: metric.CreatedAt(),
+		UpdatedAt: metric.UpdatedAt(),
+	}
+	if alert := metric.Alert(); alert != nil {
+		doc.Alert = &LogMetricAlertDoc{
+			Stat:      alert.Stat,
+			Op:        string(alert.Op),
+			Threshold: alert.Threshold,
+			Window:    alert.Window,
+		}
+	}
+	return doc
+}
+
+func logMetricToDomain(metric *LogMetricDoc) (*domain.LogMetric, error) {
+	var alert *domain.LogMetricAlert
+	if metric.Alert != nil {
+		alert = &domain.LogMetricAlert{
+			Stat:      metric.Alert.Stat,
+			Op:        domain.LogMetricAlertOp(metric.Alert.Op),
+			Threshold: metric.Alert.Threshold,
+			Window:    metric.Alert.Window,
+		}
+	}
+
+	return domain.NewLogMetric(
+		metric.ID,
+		metric.UserID,
+		metric.Name,
+		domain.LogMetricType(metric.Type),
+		metric.Query,
+		metric.AppIDs,
+		metric.Field,
+		metric.GroupBy,
+		alert,
+		metric.CreatedAt,
+		metric.UpdatedAt,
+	)
+}
+
+func NewLogMetricRepo(db *mongo.Database) *logMetricRepo {
+	return &logMetricRepo{db: db, collection: "logMetrics"}
+}
+
+func (r *logMetricRepo) SaveLogMetric(ctx context.Context, metric domain.LogMetric) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, logMetricFromDomain(metric))
+	return err
+}
+
+func (r *logMetricRepo) UpdateLogMetric(ctx context.Context, metric domain.LogMetric) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": metric.ID()}, bson.M{
+		"$set": logMetricFromDomain(metric),
+	})
+	return err
+}
+
+func (r *logMetricRepo) DeleteLogMetric(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *logMetricRepo) GetLogMetricByID(ctx context.Context, id domain.ID) (*domain.LogMetric, error) {
+	var metric LogMetricDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&metric)
+	if err != nil {
+		return nil, err
+	}
+	return logMetricToDomain(&metric)
+}
+
+func (r *logMetricRepo) ListLogMetrics(ctx context.Context, criteria domain.Criteria) ([]domain.LogMetric, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	metrics := make([]domain.LogMetric, 0)
+	for cursor.Next(ctx) {
+		var metric LogMetricDoc
+		if err := cursor.Decode(&metric); err != nil {
+			return nil, err
+		}
+
+		domainMetric, err := logMetricToDomain(&metric)
+		if err != nil {
+			return nil, err
+		}
+
+		metrics = append(metrics, *domainMetric)
+	}
+
+	return metrics, nil
+}
+
+func (r *logMetricRepo) ClaimAlertEvaluation(ctx context.Context, id domain.ID, at time.Time, since time.Time) (bool, error) {
+	collection := r.db.Collection(r.collection)
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": id, "alertEvaluatedAt": bson.M{"$not": bson.M{"$gt": since}}},
+		bson.M{"$set": bson.M{"alertEvaluatedAt": at}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
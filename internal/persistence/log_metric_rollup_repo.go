@@ -0,0 +1,257 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.LogMetricRollupRepo = &logMetricRollupRepo{}
+
+// logMetricGroupsCollection holds the groups of each metric, to limit them.
+const logMetricGroupsCollection = "logMetricGroups"
+
+type logMetricRollupRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+func NewLogMetricRollupRepo(db *mongo.Database) *logMetricRollupRepo {
+	return &logMetricRollupRepo{db: db, collection: "logMetricRollups"}
+}
+
+// RecordRollups upserts a document per metric, group and minute, whose key
+// is the group as one value, since a unique index on the keys array would
+// compare its elements one by one. The histogram is keyed by the buckets as
+// text.
+func (r *logMetricRollupRepo) RecordRollups(ctx context.Context, rollups []domain.LogMetricRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(rollups))
+	for i, rollup := range rollups {
+		update := bson.M{
+			"$inc":         bson.M{"count": rollup.Count},
+			"$setOnInsert": bson.M{"keys": rollup.Keys},
+		}
+		// Only distributions have values.
+		if len(rollup.Histogram) > 0 {
+			inc := bson.M{"count": rollup.Count, "sum": rollup.Sum}
+			for bucket, count := range rollup.Histogram {
+				inc["histogram."+strconv.Itoa(bucket)] = count
+			}
+			update["$inc"] = inc
+			update["$min"] = bson.M{"min": rollup.Min}
+			update["$max"] = bson.M{"max": rollup.Max}
+		}
+
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"metricId": rollup.MetricID,
+				"key":      domain.LogMetricGroupKey(rollup.Keys),
+				"start":    rollup.Start,
+			}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	_, err := r.db.Collection(r.collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *logMetricRollupRepo) ListRollups(
+	ctx context.Context,
+	metricID domain.ID,
+	dateRange domain.Range,
+	interval domain.Interval,
+	location *time.Location,
+) ([]domain.LogMetricRollup, error) {
+	collection := r.db.Collection(r.collection)
+	match := bson.M{"metricId": metricID, "start": bson.M{"$gte": dateRange.From, "$lt": dateRange.To}}
+	start := dateTruncExpression("$start", interval, location)
+
+	cursor, err := collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"start": start, "key": "$key"},
+			"keys":  bson.M{"$first": "$keys"},
+			"count": bson.M{"$sum": "$count"},
+			"sum":   bson.M{"$sum": "$sum"},
+			"min":   bson.M{"$min": "$min"},
+			"max":   bson.M{"$max": "$max"},
+		}},
+		bson.M{"$sort": bson.D{{Key: "_id.start", Value: 1}, {Key: "_id.key", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	type groupKey struct {
+		Start time.Time `bson:"start"`
+		Key   string    `bson:"key"`
+	}
+
+	// The rollups are indexed by their start and group.
+	type rollupKey struct {
+		start int64
+		key   string
+	}
+	rollups := []domain.LogMetricRollup{}
+	index := map[rollupKey]int{}
+	for cursor.Next(ctx) {
+		var group struct {
+			ID    groupKey `bson:"_id"`
+			Keys  []string `bson:"keys"`
+			Count int64    `bson:"count"`
+			Sum   float64  `bson:"sum"`
+			Min   float64  `bson:"min"`
+			Max   float64  `bson:"max"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, err
+		}
+
+		index[rollupKey{group.ID.Start.UnixMilli(), group.ID.Key}] = len(rollups)
+		rollups = append(rollups, domain.LogMetricRollup{
+			MetricID: metricID,
+			Start:    group.ID.Start,
+			Keys:     group.Keys,
+			Distribution: domain.Distribution{
+				Count: group.Count,
+				Sum:   group.Sum,
+				Min:   group.Min,
+				Max:   group.Max,
+			},
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// The histograms are merged bucket by bucket, count metrics having none.
+	match["histogram"] = bson.M{"$exists": true}
+	cursor, err = collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$project": bson.M{
+			"start":     start,
+			"key":       1,
+			"histogram": bson.M{"$objectToArray": "$histogram"},
+		}},
+		bson.M{"$unwind": "$histogram"},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"start": "$start", "key": "$key", "bucket": "$histogram.k"},
+			"count": bson.M{"$sum": "$histogram.v"},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var bucket struct {
+			ID struct {
+				Start  time.Time `bson:"start"`
+				Key    string    `bson:"key"`
+				Bucket string    `bson:"bucket"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&bucket); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[rollupKey{bucket.ID.Start.UnixMilli(), bucket.ID.Key}]
+		value, err := strconv.Atoi(bucket.ID.Bucket)
+		if !ok || err != nil {
+			continue
+		}
+		if rollups[i].Histogram == nil {
+			rollups[i].Histogram = map[int]int64{}
+		}
+		rollups[i].Histogram[value] += bucket.Count
+	}
+
+	return rollups, cursor.Err()
+}
+
+// ClaimGroups inserts a document per new group, which expire with the
+// rollups. Batches ingested at once can claim a few groups past the limit.
+func (r *logMetricRollupRepo) ClaimGroups(ctx context.Context, metricID domain.ID, keys []string, limit int) (map[string]bool, error) {
+	claimed := map[string]bool{}
+	if len(keys) == 0 {
+		return claimed, nil
+	}
+
+	collection := r.db.Collection(logMetricGroupsCollection)
+	cursor, err := collection.Find(ctx,
+		bson.M{"metricId": metricID, "key": bson.M{"$in": keys}},
+		options.Find().SetProjection(bson.M{"key": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var known []struct {
+		Key string `bson:"key"`
+	}
+	if err := cursor.All(ctx, &known); err != nil {
+		return nil, err
+	}
+	for _, group := range known {
+		claimed[group.Key] = true
+	}
+	if len(claimed) == len(keys) {
+		return claimed, nil
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"metricId": metricID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	groups := []any{}
+	for _, key := range keys {
+		if claimed[key] || int(count)+len(groups) >= limit {
+			continue
+		}
+		groups = append(groups, bson.M{"metricId": metricID, "key": key, "createdAt": now})
+		claimed[key] = true
+	}
+	if len(groups) == 0 {
+		return claimed, nil
+	}
+
+	// A group claimed by a concurrent batch is a duplicate, and claimed all
+	// the same.
+	_, err = collection.InsertMany(ctx, groups, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) && bulkErr.WriteConcernError == nil && !slices.ContainsFunc(bulkErr.WriteErrors, func(writeErr mongo.BulkWriteError) bool {
+		return !mongo.IsDuplicateKeyError(writeErr)
+	}) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (r *logMetricRollupRepo) DeleteRollups(ctx context.Context, metricID domain.ID) error {
+	_, err := r.db.Collection(r.collection).DeleteMany(ctx, bson.M{"metricId": metricID})
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection(logMetricGroupsCollection).DeleteMany(ctx, bson.M{"metricId": metricID})
+	return err
+}
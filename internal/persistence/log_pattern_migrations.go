@@ -0,0 +1,72 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+// MergeDuplicateLogPatterns merges the patterns of an app with the same
+// template, which concurrent batches could learn before templates were
+// unique, into the first one, moving their logs to it. It runs once, before
+// EnsureIndexes can make templates unique.
+func MergeDuplicateLogPatterns(ctx context.Context, db *mongo.Database) error {
+	patterns := db.Collection("logPatterns")
+	cursor, err := patterns.Aggregate(ctx, bson.A{
+		bson.M{"$sort": bson.D{{Key: "firstSeen", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$group": bson.M{
+			"_id":       bson.M{"appId": "$appId", "template": "$template"},
+			"ids":       bson.M{"$push": "$_id"},
+			"count":     bson.M{"$sum": "$count"},
+			"firstSeen": bson.M{"$min": "$firstSeen"},
+			"lastSeen":  bson.M{"$max": "$lastSeen"},
+		}},
+		bson.M{"$match": bson.M{"ids.1": bson.M{"$exists": true}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	var duplicates []struct {
+		IDs       []domain.ID `bson:"ids"`
+		Count     int64       `bson:"count"`
+		FirstSeen time.Time   `bson:"firstSeen"`
+		LastSeen  time.Time   `bson:"lastSeen"`
+	}
+	if err := cursor.All(ctx, &duplicates); err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		kept, merged := duplicate.IDs[0], duplicate.IDs[1:]
+		// Logs keep the ID of their pattern as text.
+		mergedIDs := make([]string, len(merged))
+		for i, id := range merged {
+			mergedIDs[i] = id.Hex()
+		}
+		_, err := db.Collection("logs").UpdateMany(ctx,
+			bson.M{"patternId": bson.M{"$in": mergedIDs}},
+			bson.M{"$set": bson.M{"patternId": kept.Hex()}},
+		)
+		if err != nil {
+			return err
+		}
+		_, err = patterns.UpdateOne(ctx, bson.M{"_id": kept}, bson.M{"$set": bson.M{
+			"count":     duplicate.Count,
+			"firstSeen": duplicate.FirstSeen,
+			"lastSeen":  duplicate.LastSeen,
+		}})
+		if err != nil {
+			return err
+		}
+		_, err = patterns.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": merged}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.LogPatternRepo = &logPatternRepo{}
+
+type logPatternRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type LogPatternDoc struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AppID     primitive.ObjectID `bson:"appId"`
+	Template  string             `bson:"template"`
+	Count     int64              `bson:"count"`
+	FirstSeen time.Time          `bson:"firstSeen"`
+	LastSeen  time.Time          `bson:"lastSeen"`
+}
+
+func logPatternToDomain(pattern *LogPatternDoc) (*domain.LogPattern, error) {
+	return domain.NewLogPattern(
+		pattern.ID,
+		pattern.AppID,
+		pattern.Template,
+		pattern.Count,
+		pattern.FirstSeen,
+		pattern.LastSeen,
+	)
+}
+
+func NewLogPatternRepo(db *mongo.Database) *logPatternRepo {
+	return &logPatternRepo{db: db, collection: "logPatterns"}
+}
+
+// RecordLogPattern finds a known pattern by its ID, which takes the template
+// it generalized to unless another pattern of the app has it. A new pattern
+// is upserted by its template, unique per app, so a template learned by two
+// batches at once is one pattern, whose ID is returned.
+func (r *logPatternRepo) RecordLogPattern(ctx context.Context, logs domain.LogPatternLogs) (*domain.LogPattern, error) {
+	collection := r.db.Collection(r.collection)
+
+	var pattern LogPatternDoc
+	var err error
+	if !logs.New {
+		filter := bson.M{"_id": logs.PatternID, "appId": logs.AppID}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		update := bson.M{"$set": bson.M{"template": logs.Template}}
+		err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&pattern)
+		if mongo.IsDuplicateKeyError(err) {
+			err = collection.FindOne(ctx, filter).Decode(&pattern)
+		}
+		if err == nil {
+			return logPatternToDomain(&pattern)
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+	}
+
+	filter := bson.M{"appId": logs.AppID, "template": logs.Template}
+	update := bson.M{"$setOnInsert": bson.M{"_id": logs.PatternID, "count": 0}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&pattern)
+	if mongo.IsDuplicateKeyError(err) {
+		err = collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return logPatternToDomain(&pattern)
+}
+
+// AddLogPatternLogs adds up the logs with $inc, $min and $max, so batches
+// ingested at once are all counted.
+func (r *logPatternRepo) AddLogPatternLogs(ctx context.Context, logs []domain.LogPatternLogs) error {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(logs))
+	for i, patternLogs := range logs {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": patternLogs.PatternID, "appId": patternLogs.AppID}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"count": patternLogs.Count},
+				"$min": bson.M{"firstSeen": patternLogs.FirstSeen},
+				"$max": bson.M{"lastSeen": patternLogs.LastSeen},
+			})
+	}
+
+	_, err := r.db.Collection(r.collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *logPatternRepo) GetLogPatternByID(ctx context.Context, id domain.ID) (*domain.LogPattern, error) {
+	var pattern LogPatternDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&pattern)
+	if err != nil {
+		return nil, err
+	}
+	return logPatternToDomain(&pattern)
+}
+
+func (r *logPatternRepo) ListLogPatterns(ctx context.Context, criteria domain.Criteria) ([]domain.LogPattern, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	patterns := make([]domain.LogPattern, 0)
+	for cursor.Next(ctx) {
+		var pattern LogPatternDoc
+		if err := cursor.Decode(&pattern); err != nil {
+			return nil, err
+		}
+
+		domainPattern, err := logPatternToDomain(&pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, *domainPattern)
+	}
+
+	return patterns, nil
+}
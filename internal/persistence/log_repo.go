@@ -2,28 +2,92 @@ package persistence
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"monitoring/internal/domain"
 )
 
 var _ domain.LogRepo = &logRepo{}
 
+// streamLogsBatchSize is how many logs are read from the server at a time
+// when streaming them.
+const streamLogsBatchSize = 1000
+
 type logRepo struct {
 	db         *mongo.Database
 	collection string
 }
 
 type LogDoc struct {
-	ID        primitive.ObjectID `bson:"_id"`
-	AppID     primitive.ObjectID `bson:"appId"`
-	Timestamp time.Time          `bson:"timestamp"`
-	Data      map[string]any     `bson:"data"`
-	Raw       string             `bson:"raw"`
-	Level     string             `bson:"level"`
+	ID          primitive.ObjectID `bson:"_id"`
+	AppID       primitive.ObjectID `bson:"appId"`
+	Timestamp   time.Time          `bson:"timestamp"`
+	Data        map[string]any     `bson:"data"`
+	Raw         string             `bson:"raw"`
+	Level       string             `bson:"level"`
+	StackTrace  *StackTraceDoc     `bson:"stackTrace,omitempty"`
+	Fingerprint string             `bson:"fingerprint,omitempty"`
+	PatternID   string             `bson:"patternId,omitempty"`
+}
+
+type StackTraceDoc struct {
+	Language      string          `bson:"language"`
+	ExceptionType string          `bson:"exceptionType"`
+	Message       string          `bson:"message"`
+	Frames        []StackFrameDoc `bson:"frames"`
+}
+
+type StackFrameDoc struct {
+	File     string `bson:"file"`
+	Function string `bson:"function"`
+	Line     int    `bson:"line"`
+	Column   int    `bson:"column,omitempty"`
+	InApp    bool   `bson:"inApp"`
+}
+
+func stackTraceFromDomain(stackTrace *domain.StackTrace) *StackTraceDoc {
+	if stackTrace == nil {
+		return nil
+	}
+
+	frames := make([]StackFrameDoc, len(stackTrace.Frames))
+	for i, frame := range stackTrace.Frames {
+		frames[i] = StackFrameDoc(frame)
+	}
+
+	return &StackTraceDoc{
+		Language:      stackTrace.Language,
+		ExceptionType: stackTrace.ExceptionType,
+		Message:       stackTrace.Message,
+		Frames:        frames,
+	}
+}
+
+func stackTraceToDomain(stackTrace *StackTraceDoc) *domain.StackTrace {
+	if stackTrace == nil {
+		return nil
+	}
+
+	frames := make([]domain.StackFrame, len(stackTrace.Frames))
+	for i, frame := range stackTrace.Frames {
+		frames[i] = domain.StackFrame(frame)
+	}
+
+	return &domain.StackTrace{
+		Language:      stackTrace.Language,
+		ExceptionType: stackTrace.ExceptionType,
+		Message:       stackTrace.Message,
+		Frames:        frames,
+	}
 }
 
 func logToDomain(log *LogDoc) (*domain.Log, error) {
@@ -34,17 +98,23 @@ func logToDomain(log *LogDoc) (*domain.Log, error) {
 		log.Data,
 		log.Raw,
 		log.Level,
+		stackTraceToDomain(log.StackTrace),
+		log.Fingerprint,
+		log.PatternID,
 	)
 }
 
 func logFromDomain(log domain.Log) LogDoc {
 	return LogDoc{
-		ID:        log.ID(),
-		AppID:     log.AppID(),
-		Timestamp: log.Timestamp(),
-		Data:      log.Data(),
-		Raw:       log.Raw(),
-		Level:     log.Level(),
+		ID:          log.ID(),
+		AppID:       log.AppID(),
+		Timestamp:   log.Timestamp(),
+		Data:        log.Data(),
+		Raw:         log.Raw(),
+		Level:       log.Level(),
+		StackTrace:  stackTraceFromDomain(log.StackTrace()),
+		Fingerprint: log.Fingerprint(),
+		PatternID:   log.PatternID(),
 	}
 }
 
@@ -66,9 +136,66 @@ func (r *logRepo) SaveLogs(ctx context.Context, logs []domain.Log) error {
 	return err
 }
 
-func (r *logRepo) ListLogs(ctx context.Context, criteria domain.Criteria) ([]domain.Log, error) {
+func (r *logRepo) GetLogByID(ctx context.Context, id domain.ID) (*domain.Log, error) {
+	var aLog LogDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&aLog)
+	if err != nil {
+		return nil, err
+	}
+	return logToDomain(&aLog)
+}
+
+// logsHint returns the index the logs of the criteria are read with:
+// logs_app_timestamp when they are scoped to apps, so that the planner does
+// not pick a plan that reads every log for a condition it misjudges, like a
+// regex. Text searches need the text index and the logs of a pattern have
+// their own index, so they get no hint.
+func logsHint(criteria domain.Criteria) string {
+	hint := ""
+	for _, f := range criteria.Filters {
+		switch {
+		case f.Type == domain.Text, f.Field == "patternId":
+			return ""
+		case f.Field == "appId":
+			hint = "logs_app_timestamp"
+		}
+	}
+	return hint
+}
+
+// missingHintIndex is the error MongoDB answers a hint with when the index
+// does not exist.
+const missingHintIndex = "hint provided does not correspond to an existing index"
+
+// aggregate runs the pipeline of the criteria followed by the stages over the
+// logs with the hint of logsHint, and without it when the index is missing,
+// like on a deployment where EnsureIndexes has not run.
+func (r *logRepo) aggregate(ctx context.Context, criteria domain.Criteria, stages []bson.M, opts *options.AggregateOptions) (*mongo.Cursor, error) {
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	pipeline = append(pipeline, stages...)
+
 	collection := r.db.Collection(r.collection)
-	cursor, err := collection.Aggregate(ctx, criteriaToPipeline(criteria))
+	if hint := logsHint(criteria); hint != "" {
+		hinted := *opts
+		hinted.SetHint(hint)
+		cursor, err := collection.Aggregate(ctx, pipeline, &hinted)
+		if !isMissingHintIndex(err) {
+			return cursor, err
+		}
+	}
+	return collection.Aggregate(ctx, pipeline, opts)
+}
+
+func isMissingHintIndex(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorMessage(missingHintIndex)
+}
+
+func (r *logRepo) ListLogs(ctx context.Context, criteria domain.Criteria) ([]domain.Log, error) {
+	cursor, err := r.aggregate(ctx, criteria, nil, aggregateOptions(criteria))
 	if err != nil {
 		return nil, err
 	}
@@ -91,3 +218,446 @@ func (r *logRepo) ListLogs(ctx context.Context, criteria domain.Criteria) ([]dom
 
 	return logs, nil
 }
+
+func (r *logRepo) CountLogs(ctx context.Context, criteria domain.Criteria, max int64) (int64, error) {
+	criteria.Pagination = domain.EmptyPagination
+	criteria.Sort = domain.EmptySort
+
+	stages := []bson.M{{"$limit": max}, {"$count": "count"}}
+	cursor, err := r.aggregate(ctx, criteria, stages, aggregateOptions(criteria))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Count int64 `bson:"count"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Count, cursor.Err()
+}
+
+func (r *logRepo) StreamLogs(ctx context.Context, criteria domain.Criteria, fn func(domain.Log) error) error {
+	opts := aggregateOptions(criteria).SetBatchSize(streamLogsBatchSize)
+	cursor, err := r.aggregate(ctx, criteria, nil, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var aLog LogDoc
+		if err := cursor.Decode(&aLog); err != nil {
+			return err
+		}
+
+		l, err := logToDomain(&aLog)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(*l); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func (r *logRepo) ExplainLogs(ctx context.Context, criteria domain.Criteria) (domain.LogQueryPlan, error) {
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return domain.LogQueryPlan{}, err
+	}
+
+	explain := func(hint string) (bson.M, error) {
+		aggregate := bson.D{
+			{Key: "aggregate", Value: r.collection},
+			{Key: "pipeline", Value: pipeline},
+			{Key: "cursor", Value: bson.D{}},
+		}
+		if hint != "" {
+			aggregate = append(aggregate, bson.E{Key: "hint", Value: hint})
+		}
+		command := bson.D{
+			{Key: "explain", Value: aggregate},
+			{Key: "verbosity", Value: "queryPlanner"},
+		}
+		if criteria.MaxTime > 0 {
+			command = append(command, bson.E{Key: "maxTimeMS", Value: criteria.MaxTime.Milliseconds()})
+		}
+
+		var result bson.M
+		err := r.db.RunCommand(ctx, command).Decode(&result)
+		return result, err
+	}
+
+	// The plan is explained like aggregate runs the query.
+	result, err := explain(logsHint(criteria))
+	if isMissingHintIndex(err) {
+		result, err = explain("")
+	}
+	if err != nil {
+		return domain.LogQueryPlan{}, err
+	}
+
+	// The plan is at the top, or in the $cursor stage when only part of the
+	// pipeline runs in the query layer.
+	plan := domain.LogQueryPlan{Indexes: []string{}}
+	walkExplain(result, func(key string, value any) {
+		switch key {
+		case "winningPlan":
+			if plan.Plan == nil {
+				plan.Plan = value
+			}
+		case "indexName":
+			if name, ok := value.(string); ok && !slices.Contains(plan.Indexes, name) {
+				plan.Indexes = append(plan.Indexes, name)
+			}
+		case "stage":
+			if value == "COLLSCAN" {
+				plan.CollectionScan = true
+			}
+		}
+	})
+	return plan, nil
+}
+
+// walkExplain calls fn with every key of the documents of an explain result,
+// except those of the rejected plans.
+func walkExplain(value any, fn func(key string, value any)) {
+	switch v := value.(type) {
+	case bson.M:
+		for key, child := range v {
+			if key == "rejectedPlans" {
+				continue
+			}
+			fn(key, child)
+			walkExplain(child, fn)
+		}
+	case bson.D:
+		for _, element := range v {
+			if element.Key == "rejectedPlans" {
+				continue
+			}
+			fn(element.Key, element.Value)
+			walkExplain(element.Value, fn)
+		}
+	case bson.A:
+		for _, child := range v {
+			walkExplain(child, fn)
+		}
+	}
+}
+
+func (r *logRepo) HistogramLogs(
+	ctx context.Context,
+	criteria domain.Criteria,
+	interval domain.Interval,
+	location *time.Location,
+	splitBy string,
+) ([]domain.LogHistogramBucket, error) {
+	criteria.Pagination = domain.EmptyPagination
+	criteria.Sort = domain.EmptySort
+
+	group := bson.M{"start": dateTruncExpression("$timestamp", interval, location)}
+	if splitBy != "" {
+		group["series"] = "$" + splitBy
+	}
+
+	stages := []bson.M{
+		{"$group": bson.M{"_id": group, "count": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Key: "_id.start", Value: 1}}},
+	}
+	cursor, err := r.aggregate(ctx, criteria, stages, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	buckets := make([]domain.LogHistogramBucket, 0)
+	for cursor.Next(ctx) {
+		var result struct {
+			ID struct {
+				Start  time.Time `bson:"start"`
+				Series any       `bson:"series"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+
+		buckets = append(buckets, domain.LogHistogramBucket{
+			Start:  result.ID.Start,
+			Series: formatGroupValue(result.ID.Series),
+			Count:  result.Count,
+		})
+	}
+
+	return buckets, cursor.Err()
+}
+
+func (r *logRepo) AggregateLogs(ctx context.Context, criteria domain.Criteria, aggregation domain.LogAggregation) ([]domain.LogAggregationRow, bool, error) {
+	criteria.Pagination = domain.EmptyPagination
+	criteria.Sort = domain.EmptySort
+
+	keys := bson.M{}
+	for i, group := range aggregation.Groups {
+		if group.Interval != nil {
+			keys[fmt.Sprintf("k%d", i)] = dateTruncExpression("$timestamp", *group.Interval, aggregation.Location)
+			continue
+		}
+		keys[fmt.Sprintf("k%d", i)] = "$" + group.Field
+	}
+
+	// Distinct values are counted apart, since collecting them in the group
+	// would hold every value of a group in memory.
+	accumulators := bson.M{"_id": keys, "count": bson.M{"$sum": 1}}
+	for i, metric := range aggregation.Metrics {
+		if metric.Op == domain.AggregationDistinct {
+			continue
+		}
+		accumulators[fmt.Sprintf("m%d", i)] = metricAccumulator(metric)
+	}
+
+	stages := []bson.M{
+		{"$group": accumulators},
+		{"$sort": bson.D{{Key: "count", Value: -1}}},
+		{"$limit": aggregation.MaxRows + 1},
+	}
+	for i, metric := range aggregation.Metrics {
+		if metric.Op == domain.AggregationPercentile {
+			field := fmt.Sprintf("m%d", i)
+			stages = append(stages, bson.M{"$set": bson.M{field: bson.M{"$first": "$" + field}}})
+		}
+	}
+
+	opts := aggregateOptions(criteria).SetAllowDiskUse(true)
+	cursor, err := r.aggregate(ctx, criteria, stages, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	defer cursor.Close(ctx)
+
+	rows := make([]domain.LogAggregationRow, 0)
+	for cursor.Next(ctx) {
+		var result bson.M
+		if err := cursor.Decode(&result); err != nil {
+			return nil, false, err
+		}
+
+		id, _ := result["_id"].(bson.M)
+		row := domain.LogAggregationRow{
+			Keys:   make([]any, len(aggregation.Groups)),
+			Count:  toInt64(result["count"]),
+			Values: make([]any, len(aggregation.Metrics)),
+		}
+		for i := range aggregation.Groups {
+			row.Keys[i] = groupValue(id[fmt.Sprintf("k%d", i)], aggregation.Location)
+		}
+		for i, metric := range aggregation.Metrics {
+			if metric.Op == domain.AggregationDistinct {
+				row.Values[i] = int64(0)
+				continue
+			}
+			row.Values[i] = groupValue(result[fmt.Sprintf("m%d", i)], aggregation.Location)
+		}
+		rows = append(rows, row)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, false, err
+	}
+
+	truncated := len(rows) > aggregation.MaxRows
+	if truncated {
+		rows = rows[:aggregation.MaxRows]
+	}
+
+	// The rows are found by their keys, typed so that 1 and "1" differ.
+	byKey := make(map[string]*domain.LogAggregationRow)
+	for i := range rows {
+		byKey[fmt.Sprintf("%#v", rows[i].Keys)] = &rows[i]
+	}
+	for i, metric := range aggregation.Metrics {
+		if metric.Op != domain.AggregationDistinct {
+			continue
+		}
+		if err := r.countDistinct(ctx, criteria, aggregation, keys, metric.Field, i, byKey); err != nil {
+			return nil, false, err
+		}
+	}
+	return rows, truncated, nil
+}
+
+// countDistinct sets the number of distinct values of the field in the rows,
+// grouping the logs by group and value and then counting the values of each
+// group. Logs without the field are left out.
+func (r *logRepo) countDistinct(
+	ctx context.Context,
+	criteria domain.Criteria,
+	aggregation domain.LogAggregation,
+	keys bson.M,
+	field string,
+	metric int,
+	byKey map[string]*domain.LogAggregationRow,
+) error {
+	stages := []bson.M{
+		{"$group": bson.M{"_id": bson.M{"k": keys, "v": "$" + field}}},
+		{"$match": bson.M{"_id.v": bson.M{"$exists": true}}},
+		{"$group": bson.M{"_id": "$_id.k", "n": bson.M{"$sum": 1}}},
+	}
+
+	opts := aggregateOptions(criteria).SetAllowDiskUse(true)
+	cursor, err := r.aggregate(ctx, criteria, stages, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var result bson.M
+		if err := cursor.Decode(&result); err != nil {
+			return err
+		}
+
+		id, _ := result["_id"].(bson.M)
+		rowKeys := make([]any, len(aggregation.Groups))
+		for i := range aggregation.Groups {
+			rowKeys[i] = groupValue(id[fmt.Sprintf("k%d", i)], aggregation.Location)
+		}
+		// Groups left out of the rows are skipped.
+		if row, ok := byKey[fmt.Sprintf("%#v", rowKeys)]; ok {
+			row.Values[metric] = toInt64(result["n"])
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *logRepo) FieldValues(ctx context.Context, criteria domain.Criteria, field string, prefix string, size int) (domain.LogFieldValues, error) {
+	// Arrays are counted by element, and values that are not text are
+	// matched with the prefix by their text, like 50 for 503.
+	values := []bson.M{{"$unwind": "$v"}}
+	if prefix != "" {
+		values = append(values, bson.M{"$match": bson.M{"$expr": bson.M{"$regexMatch": bson.M{
+			"input":   bson.M{"$convert": bson.M{"input": "$v", "to": "string", "onError": "", "onNull": ""}},
+			"regex":   "^" + regexp.QuoteMeta(prefix),
+			"options": "i",
+		}}}})
+	}
+
+	stages := []bson.M{
+		{"$project": bson.M{"_id": 0, "v": "$" + field}},
+		{"$facet": bson.M{
+			"scanned": []bson.M{{"$count": "n"}},
+			"values": append(slices.Clone(values),
+				bson.M{"$group": bson.M{"_id": "$v", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": size},
+			),
+			"distinct": append(slices.Clone(values),
+				bson.M{"$group": bson.M{"_id": "$v"}},
+				bson.M{"$count": "n"},
+			),
+			"range": append(slices.Clone(values),
+				bson.M{"$match": bson.M{"v": bson.M{"$type": "number"}}},
+				bson.M{"$group": bson.M{
+					"_id": nil,
+					"min": bson.M{"$min": bson.M{"$toDouble": "$v"}},
+					"max": bson.M{"$max": bson.M{"$toDouble": "$v"}},
+				}},
+			),
+		}},
+	}
+
+	cursor, err := r.aggregate(ctx, criteria, stages, aggregateOptions(criteria))
+	if err != nil {
+		return domain.LogFieldValues{}, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Scanned []struct {
+			N int64 `bson:"n"`
+		} `bson:"scanned"`
+		Values []struct {
+			ID    any   `bson:"_id"`
+			Count int64 `bson:"count"`
+		} `bson:"values"`
+		Distinct []struct {
+			N int64 `bson:"n"`
+		} `bson:"distinct"`
+		Range []struct {
+			Min *float64 `bson:"min"`
+			Max *float64 `bson:"max"`
+		} `bson:"range"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return domain.LogFieldValues{}, err
+	}
+
+	fieldValues := domain.LogFieldValues{Values: []domain.LogFieldValue{}}
+	if len(results) == 0 {
+		return fieldValues, nil
+	}
+
+	result := results[0]
+	for _, value := range result.Values {
+		fieldValues.Values = append(fieldValues.Values, domain.LogFieldValue{
+			Value: groupValue(value.ID, time.UTC),
+			Count: value.Count,
+		})
+	}
+	if len(result.Scanned) > 0 {
+		fieldValues.Scanned = result.Scanned[0].N
+	}
+	if len(result.Distinct) > 0 {
+		fieldValues.Distinct = result.Distinct[0].N
+	}
+	if len(result.Range) > 0 {
+		fieldValues.Min = result.Range[0].Min
+		fieldValues.Max = result.Range[0].Max
+	}
+	return fieldValues, nil
+}
+
+// dateTruncExpression truncates a date, like the timestamp of a log, to the
+// start of its interval in the location, weeks starting on Monday.
+func dateTruncExpression(date string, interval domain.Interval, location *time.Location) bson.M {
+	return bson.M{"$dateTrunc": bson.M{
+		"date":        date,
+		"unit":        string(interval.Unit),
+		"binSize":     interval.Size,
+		"timezone":    location.String(),
+		"startOfWeek": "monday",
+	}}
+}
+
+// metricAccumulator is the $group accumulator of a metric. Percentiles come
+// in an array, and distinct values are counted apart by countDistinct.
+func metricAccumulator(metric domain.AggregationMetric) bson.M {
+	field := "$" + metric.Field
+	numeric := bson.M{"$cond": bson.A{bson.M{"$isNumber": field}, field, nil}}
+
+	switch metric.Op {
+	case domain.AggregationSum:
+		return bson.M{"$sum": numeric}
+	case domain.AggregationAvg:
+		return bson.M{"$avg": numeric}
+	case domain.AggregationMin:
+		return bson.M{"$min": numeric}
+	case domain.AggregationMax:
+		return bson.M{"$max": numeric}
+	case domain.AggregationPercentile:
+		return bson.M{"$percentile": bson.M{
+			"input":  numeric,
+			"p":      bson.A{metric.Percentile / 100},
+			"method": "approximate",
+		}}
+	}
+	return bson.M{"$sum": 1}
+}
@@ -2,135 +2,274 @@ package persistence
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"monitoring/internal/domain"
 )
 
 var _ domain.LogSchemaRepo = &logSchemaRepo{}
 
+const (
+	// maxLogSchemaFields is how many fields the catalog of an app keeps,
+	// past it only the known fields are updated.
+	maxLogSchemaFields = 5000
+	// maxLogSchemaSamples bounds the samples read per field. Samples are
+	// added while a field has fewer, so it can store a few more.
+	maxLogSchemaSamples = 5
+)
+
 type logSchemaRepo struct {
-	db *mongo.Database
+	db               *mongo.Database
+	collection       string
+	fieldsCollection string
+}
+
+// LogSchemaDoc counts the logs of an app added to the catalog.
+type LogSchemaDoc struct {
+	AppID    primitive.ObjectID `bson:"_id"`
+	Total    int                `bson:"total"`
+	LastSeen time.Time          `bson:"lastSeen"`
+	// RebuildingSince is when the rebuild of the catalog started, while it
+	// runs.
+	RebuildingSince *time.Time `bson:"rebuildingSince,omitempty"`
+}
+
+type LogSchemaFieldDoc struct {
+	AppID     primitive.ObjectID `bson:"appId"`
+	Path      string             `bson:"path"`
+	Count     int                `bson:"count"`
+	Types     map[string]int     `bson:"types"`
+	FirstSeen time.Time          `bson:"firstSeen"`
+	LastSeen  time.Time          `bson:"lastSeen"`
+	Samples   []any              `bson:"samples"`
+}
+
+func logSchemaFieldToDomain(field *LogSchemaFieldDoc) domain.LogSchemaField {
+	samples := make([]any, 0, len(field.Samples))
+	for _, sample := range field.Samples[:min(len(field.Samples), maxLogSchemaSamples)] {
+		samples = append(samples, groupValue(sample, time.UTC))
+	}
+
+	return domain.LogSchemaField{
+		Path:      field.Path,
+		Count:     field.Count,
+		Types:     field.Types,
+		FirstSeen: field.FirstSeen,
+		LastSeen:  field.LastSeen,
+		Samples:   samples,
+	}
 }
 
 func NewLogSchemaRepo(db *mongo.Database) *logSchemaRepo {
 	return &logSchemaRepo{
-		db: db,
+		db:               db,
+		collection:       "logSchemas",
+		fieldsCollection: "logSchemaFields",
 	}
 }
 
 func (r *logSchemaRepo) Get(ctx context.Context, userID domain.ID, appIDs []domain.ID, optionalRange *domain.Range) (domain.LogSchema, error) {
-	logsColl := r.db.Collection("logs")
-
-	pipeline := mongo.Pipeline{
-		{
-			{Key: "$lookup", Value: bson.M{
-				"from":         "apps",
-				"localField":   "appId",
-				"foreignField": "_id",
-				"as":           "app",
-			}},
-		},
-		{{Key: "$unwind", Value: "$app"}},
+	appsFilter := bson.M{"userId": userID}
+	if len(appIDs) > 0 {
+		appsFilter["_id"] = bson.M{"$in": appIDs}
 	}
 
-	matchFilter := bson.M{}
-	if len(appIDs) > 0 {
-		matchFilter["app._id"] = bson.M{"$in": appIDs}
-	} else {
-		matchFilter["app.userId"] = userID
+	userAppIDs, err := r.db.Collection("apps").Distinct(ctx, "_id", appsFilter)
+	if err != nil {
+		return domain.LogSchema{}, err
+	}
+
+	schemas, err := r.db.Collection(r.collection).Find(ctx, bson.M{"_id": bson.M{"$in": userAppIDs}})
+	if err != nil {
+		return domain.LogSchema{}, err
+	}
+
+	var schemaDocs []LogSchemaDoc
+	if err := schemas.All(ctx, &schemaDocs); err != nil {
+		return domain.LogSchema{}, err
 	}
+
+	total, lastSeen, rebuilding := 0, time.Time{}, false
+	for _, schema := range schemaDocs {
+		total += schema.Total
+		if schema.LastSeen.After(lastSeen) {
+			lastSeen = schema.LastSeen
+		}
+		rebuilding = rebuilding || schema.RebuildingSince != nil
+	}
+
+	filter := bson.M{"appId": bson.M{"$in": userAppIDs}}
 	if optionalRange != nil {
-		matchFilter["timestamp"] = bson.M{
-			"$gte": optionalRange.From,
-			"$lte": optionalRange.To,
+		if !optionalRange.From.IsZero() {
+			filter["lastSeen"] = bson.M{"$gte": optionalRange.From}
+		}
+		if !optionalRange.To.IsZero() {
+			filter["firstSeen"] = bson.M{"$lte": optionalRange.To}
 		}
 	}
-	pipeline = append(pipeline, bson.D{{Key: "$match", Value: matchFilter}})
-
-	pipeline = append(pipeline, bson.D{{
-		Key: "$facet", Value: bson.M{
-			"total": []bson.M{
-				{"$count": "total"},
-			},
-			"schema": []bson.M{
-				{
-					"$project": bson.M{
-						"flattenedFields": bson.M{
-							"$reduce": bson.M{
-								"input": bson.M{
-									"$map": bson.M{
-										"input": bson.M{"$objectToArray": "$data"},
-										"as":    "elem",
-										"in": bson.M{
-											"$cond": []interface{}{
-												bson.M{"$eq": []interface{}{bson.M{"$type": "$$elem.v"}, "object"}},
-												// If it is an object, an array is created with the subfields (level 2)
-												bson.M{
-													"$map": bson.M{
-														"input": bson.M{"$objectToArray": "$$elem.v"},
-														"as":    "sub",
-														"in": bson.M{
-															"$concat": []interface{}{"$$elem.k", ".", "$$sub.k"},
-														},
-													},
-												},
-												// Otherwise, an array with the key is returned.
-												[]interface{}{"$$elem.k"},
-											},
-										},
-									},
-								},
-								"initialValue": []interface{}{},
-								"in":           bson.M{"$concatArrays": []interface{}{"$$value", "$$this"}},
-							},
-						},
-					},
-				},
-				// Ensure that each document has a string in flattenedFields after unpacking.
-				{"$unwind": "$flattenedFields"},
-				{"$group": bson.M{
-					"_id":   "$flattenedFields",
-					"count": bson.M{"$sum": 1},
-				}},
-			},
-		},
-	}})
 
-	cursor, err := logsColl.Aggregate(ctx, pipeline)
+	fields, err := r.listFields(ctx, filter)
 	if err != nil {
 		return domain.LogSchema{}, err
 	}
-	defer cursor.Close(ctx)
+	catalog := domain.NewLogSchema(total, lastSeen, fields)
+	catalog.Rebuilding = rebuilding
+	return catalog, nil
+}
 
-	var aggregateResult []struct {
-		Total []struct {
-			Total int `bson:"total"`
-		} `bson:"total"`
-		Schema []struct {
-			ID    string `bson:"_id"`
-			Count int    `bson:"count"`
-		} `bson:"schema"`
+func (r *logSchemaRepo) GetFields(ctx context.Context, appID domain.ID, paths []string) (domain.LogSchema, error) {
+	var schema LogSchemaDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": appID}).Decode(&schema)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return domain.NewLogSchema(0, time.Time{}, []domain.LogSchemaField{}), nil
+	}
+	if err != nil {
+		return domain.LogSchema{}, err
 	}
 
-	if err := cursor.All(ctx, &aggregateResult); err != nil {
+	fields, err := r.listFields(ctx, bson.M{"appId": appID, "path": bson.M{"$in": paths}})
+	if err != nil {
 		return domain.LogSchema{}, err
 	}
+	catalog := domain.NewLogSchema(schema.Total, schema.LastSeen, fields)
+	catalog.Rebuilding = schema.RebuildingSince != nil
+	return catalog, nil
+}
+
+func (r *logSchemaRepo) ListFieldsLastSeen(ctx context.Context, appID domain.ID, dateRange domain.Range) ([]domain.LogSchemaField, error) {
+	paths, err := r.db.Collection(r.fieldsCollection).Distinct(ctx, "path", bson.M{
+		"appId":    appID,
+		"lastSeen": bson.M{"$gte": dateRange.From, "$lt": dateRange.To},
+	})
+	if err != nil || len(paths) == 0 {
+		return []domain.LogSchemaField{}, err
+	}
+
+	// Another document of a path can have been seen later.
+	fields, err := r.listFields(ctx, bson.M{"appId": appID, "path": bson.M{"$in": paths}})
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeen := make([]domain.LogSchemaField, 0, len(fields))
+	for _, field := range fields {
+		if !field.LastSeen.Before(dateRange.From) && field.LastSeen.Before(dateRange.To) {
+			lastSeen = append(lastSeen, field)
+		}
+	}
+	return lastSeen, nil
+}
+
+func (r *logSchemaRepo) listFields(ctx context.Context, filter bson.M) ([]domain.LogSchemaField, error) {
+	cursor, err := r.db.Collection(r.fieldsCollection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// Fields are merged by path, since an app can have a few documents of
+	// the same path and a user many apps.
+	byPath := map[string]*domain.LogSchemaField{}
+	for cursor.Next(ctx) {
+		var field LogSchemaFieldDoc
+		if err := cursor.Decode(&field); err != nil {
+			return nil, err
+		}
+
+		merged, ok := byPath[field.Path]
+		if !ok {
+			merged = &domain.LogSchemaField{Path: field.Path, Samples: []any{}}
+			byPath[field.Path] = merged
+		}
+		merged.Merge(logSchemaFieldToDomain(&field))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
 
-	result := domain.LogSchema{
-		Schema: make(map[string]int),
-		Total:  0,
+	fields := make([]domain.LogSchemaField, 0, len(byPath))
+	for _, field := range byPath {
+		fields = append(fields, *field)
+	}
+	return fields, nil
+}
+
+func (r *logSchemaRepo) Record(ctx context.Context, appID domain.ID, schema domain.LogSchema) error {
+	_, err := r.db.Collection(r.collection).UpdateOne(ctx,
+		bson.M{"_id": appID},
+		bson.M{
+			"$inc": bson.M{"total": schema.Total},
+			"$max": bson.M{"lastSeen": schema.LastSeen},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil || len(schema.Fields) == 0 {
+		return err
 	}
-	if len(aggregateResult) > 0 {
-		if len(aggregateResult[0].Total) > 0 {
-			result.Total = aggregateResult[0].Total[0].Total
+
+	known, err := r.db.Collection(r.fieldsCollection).CountDocuments(ctx, bson.M{"appId": appID})
+	if err != nil {
+		return err
+	}
+
+	fullSamples := fmt.Sprintf("samples.%d", maxLogSchemaSamples-1)
+	models := make([]mongo.WriteModel, 0, 2*len(schema.Fields))
+	for _, field := range schema.Fields {
+		inc := bson.M{"count": field.Count}
+		for valueType, count := range field.Types {
+			inc["types."+valueType] = count
 		}
-		for _, s := range aggregateResult[0].Schema {
-			result.Schema[s.ID] = s.Count
+
+		filter := bson.M{"appId": appID, "path": field.Path}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(filter).
+			SetUpdate(bson.M{
+				"$inc": inc,
+				"$min": bson.M{"firstSeen": field.FirstSeen},
+				"$max": bson.M{"lastSeen": field.LastSeen},
+			}).
+			SetUpsert(known < maxLogSchemaFields),
+		)
+
+		if len(field.Samples) > 0 {
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"appId": appID, "path": field.Path, fullSamples: bson.M{"$exists": false}}).
+				SetUpdate(bson.M{"$addToSet": bson.M{"samples": bson.M{"$each": field.Samples}}}),
+			)
 		}
 	}
 
-	return result, nil
+	_, err = r.db.Collection(r.fieldsCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+	return err
+}
+
+// StartRebuild marks the catalog before emptying it, so it is never read
+// empty without the mark.
+func (r *logSchemaRepo) StartRebuild(ctx context.Context, appID domain.ID) error {
+	_, err := r.db.Collection(r.collection).UpdateOne(ctx,
+		bson.M{"_id": appID},
+		bson.M{"$set": bson.M{"total": 0, "lastSeen": time.Time{}, "rebuildingSince": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Collection(r.fieldsCollection).DeleteMany(ctx, bson.M{"appId": appID})
+	return err
+}
+
+func (r *logSchemaRepo) FinishRebuild(ctx context.Context, appID domain.ID) error {
+	_, err := r.db.Collection(r.collection).UpdateOne(ctx,
+		bson.M{"_id": appID},
+		bson.M{"$unset": bson.M{"rebuildingSince": ""}},
+	)
+	return err
 }
@@ -0,0 +1,334 @@
+package persistence
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.MetricRepo = &metricRepo{}
+
+// metricSamplesCollection is a time series collection, which EnsureIndexes
+// creates.
+const metricSamplesCollection = "metricSamples"
+
+type metricRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+// MetricMetaDoc identifies the series of the samples and rollups, Series
+// being unique within the app.
+type MetricMetaDoc struct {
+	AppID  primitive.ObjectID `bson:"appId"`
+	Series string             `bson:"series"`
+	Name   string             `bson:"name"`
+	Kind   string             `bson:"kind"`
+	Labels map[string]string  `bson:"labels"`
+}
+
+// MetricLastDoc is the latest value of a gauge or counter. Documents compare
+// field by field, so the greatest one is the latest.
+type MetricLastDoc struct {
+	At    time.Time `bson:"at"`
+	Value float64   `bson:"value"`
+}
+
+// MetricSampleDoc holds a sample like a rollup of it alone. Min and Max are
+// left out of the histograms that observed no values.
+type MetricSampleDoc struct {
+	Timestamp time.Time        `bson:"timestamp"`
+	Meta      MetricMetaDoc    `bson:"meta"`
+	Count     int64            `bson:"count"`
+	Sum       float64          `bson:"sum"`
+	Min       *float64         `bson:"min,omitempty"`
+	Max       *float64         `bson:"max,omitempty"`
+	Last      *MetricLastDoc   `bson:"last,omitempty"`
+	Histogram map[string]int64 `bson:"histogram,omitempty"`
+}
+
+func metricSampleFromDomain(sample domain.MetricSample) MetricSampleDoc {
+	rollup := sample.Rollup(0)
+	doc := MetricSampleDoc{
+		Timestamp: sample.Timestamp,
+		Meta:      metricMetaFromDomain(rollup),
+		Count:     rollup.Count,
+		Sum:       rollup.Sum,
+		Histogram: histogramFromDomain(rollup.Histogram),
+	}
+	if rollup.Count > 0 {
+		doc.Min, doc.Max = &rollup.Min, &rollup.Max
+	}
+	if !rollup.LastAt.IsZero() {
+		doc.Last = &MetricLastDoc{At: rollup.LastAt, Value: rollup.Last}
+	}
+	return doc
+}
+
+func metricMetaFromDomain(rollup domain.MetricRollup) MetricMetaDoc {
+	return MetricMetaDoc{
+		AppID:  rollup.AppID,
+		Series: domain.MetricSeriesKey(rollup.Name, rollup.Labels),
+		Name:   rollup.Name,
+		Kind:   string(rollup.Kind),
+		Labels: rollup.Labels,
+	}
+}
+
+// histogramFromDomain keys the buckets of a histogram as text, nil when it
+// has none.
+func histogramFromDomain(histogram map[int]int64) map[string]int64 {
+	if len(histogram) == 0 {
+		return nil
+	}
+	doc := make(map[string]int64, len(histogram))
+	for bucket, count := range histogram {
+		doc[strconv.Itoa(bucket)] = count
+	}
+	return doc
+}
+
+func histogramToDomain(histogram map[string]int64) map[int]int64 {
+	if len(histogram) == 0 {
+		return nil
+	}
+	buckets := make(map[int]int64, len(histogram))
+	for key, count := range histogram {
+		if bucket, err := strconv.Atoi(key); err == nil {
+			buckets[bucket] = count
+		}
+	}
+	return buckets
+}
+
+func NewMetricRepo(db *mongo.Database) *metricRepo {
+	return &metricRepo{db: db, collection: metricSamplesCollection}
+}
+
+// metricRollupsCollection is the collection of the rollups of a resolution,
+// like metricRollups5m.
+func metricRollupsCollection(resolution time.Duration) string {
+	return "metricRollups" + domain.FormatLength(resolution)
+}
+
+func (r *metricRepo) SaveMetricSamples(ctx context.Context, samples []domain.MetricSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	docs := make([]any, len(samples))
+	for i, sample := range samples {
+		docs[i] = metricSampleFromDomain(sample)
+	}
+	_, err := r.db.Collection(r.collection).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	return err
+}
+
+// RecordMetricRollups upserts a document per series and bucket of the
+// resolution.
+func (r *metricRepo) RecordMetricRollups(ctx context.Context, resolution time.Duration, rollups []domain.MetricRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(rollups))
+	for i, rollup := range rollups {
+		meta := metricMetaFromDomain(rollup)
+		inc := bson.M{"count": rollup.Count, "sum": rollup.Sum}
+		for bucket, count := range histogramFromDomain(rollup.Histogram) {
+			inc["histogram."+bucket] = count
+		}
+		update := bson.M{
+			"$inc": inc,
+			"$setOnInsert": bson.M{
+				"meta.name":   meta.Name,
+				"meta.kind":   meta.Kind,
+				"meta.labels": meta.Labels,
+			},
+		}
+		if rollup.Count > 0 {
+			maxima := bson.M{"max": rollup.Max}
+			if !rollup.LastAt.IsZero() {
+				maxima["last"] = MetricLastDoc{At: rollup.LastAt, Value: rollup.Last}
+			}
+			update["$min"] = bson.M{"min": rollup.Min}
+			update["$max"] = maxima
+		}
+
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"meta.appId":  meta.AppID,
+				"meta.series": meta.Series,
+				"start":       rollup.Start,
+			}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	_, err := r.db.Collection(metricRollupsCollection(resolution)).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// source is the collection the points of the query are read from, and its
+// time field: the rollups of the longest resolution that divides the
+// interval and the offset of the time zone, else the samples.
+func (r *metricRepo) source(query domain.MetricQuery) (string, string) {
+	_, offset := query.Range.From.In(query.Location).Zone()
+	resolutions := slices.Sorted(maps.Keys(domain.MetricRollupRetentions))
+	for _, resolution := range slices.Backward(resolutions) {
+		if query.Interval.Duration()%resolution == 0 && time.Duration(offset)*time.Second%resolution == 0 {
+			return metricRollupsCollection(resolution), "start"
+		}
+	}
+	return r.collection, "timestamp"
+}
+
+func (r *metricRepo) ListMetricRollups(ctx context.Context, query domain.MetricQuery) ([]domain.MetricRollup, error) {
+	name, timeField := r.source(query)
+	collection := r.db.Collection(name)
+
+	match := bson.M{
+		"meta.appId": bson.M{"$in": query.AppIDs},
+		"meta.name":  query.Name,
+		timeField:    bson.M{"$gte": query.Range.From, "$lt": query.Range.To},
+	}
+	for label, value := range query.Labels {
+		match["meta.labels."+label] = value
+	}
+	start := dateTruncExpression("$"+timeField, query.Interval, query.Location)
+
+	cursor, err := collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":    bson.M{"start": start, "appId": "$meta.appId", "series": "$meta.series"},
+			"kind":   bson.M{"$first": "$meta.kind"},
+			"labels": bson.M{"$first": "$meta.labels"},
+			"count":  bson.M{"$sum": "$count"},
+			"sum":    bson.M{"$sum": "$sum"},
+			"min":    bson.M{"$min": "$min"},
+			"max":    bson.M{"$max": "$max"},
+			"last":   bson.M{"$max": "$last"},
+		}},
+		bson.M{"$sort": bson.D{
+			{Key: "_id.start", Value: 1},
+			{Key: "_id.appId", Value: 1},
+			{Key: "_id.series", Value: 1},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	// The rollups are indexed by their start and series.
+	type rollupKey struct {
+		start  int64
+		appID  primitive.ObjectID
+		series string
+	}
+	rollups := []domain.MetricRollup{}
+	index := map[rollupKey]int{}
+	for cursor.Next(ctx) {
+		var group struct {
+			ID struct {
+				Start  time.Time          `bson:"start"`
+				AppID  primitive.ObjectID `bson:"appId"`
+				Series string             `bson:"series"`
+			} `bson:"_id"`
+			Kind   string            `bson:"kind"`
+			Labels map[string]string `bson:"labels"`
+			Count  int64             `bson:"count"`
+			Sum    float64           `bson:"sum"`
+			Min    float64           `bson:"min"`
+			Max    float64           `bson:"max"`
+			Last   *MetricLastDoc    `bson:"last"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, err
+		}
+
+		rollup := domain.MetricRollup{
+			AppID:  group.ID.AppID,
+			Name:   query.Name,
+			Kind:   domain.MetricKind(group.Kind),
+			Labels: group.Labels,
+			Start:  group.ID.Start,
+			Distribution: domain.Distribution{
+				Count: group.Count,
+				Sum:   group.Sum,
+				Min:   group.Min,
+				Max:   group.Max,
+			},
+		}
+		if group.Last != nil {
+			rollup.Last, rollup.LastAt = group.Last.Value, group.Last.At
+		}
+		index[rollupKey{group.ID.Start.UnixMilli(), group.ID.AppID, group.ID.Series}] = len(rollups)
+		rollups = append(rollups, rollup)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	// The histograms are merged bucket by bucket, only histograms having
+	// them.
+	match["histogram"] = bson.M{"$exists": true}
+	cursor, err = collection.Aggregate(ctx, bson.A{
+		bson.M{"$match": match},
+		bson.M{"$project": bson.M{
+			"start":     start,
+			"meta":      1,
+			"histogram": bson.M{"$objectToArray": "$histogram"},
+		}},
+		bson.M{"$unwind": "$histogram"},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{
+				"start":  "$start",
+				"appId":  "$meta.appId",
+				"series": "$meta.series",
+				"bucket": "$histogram.k",
+			},
+			"count": bson.M{"$sum": "$histogram.v"},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var bucket struct {
+			ID struct {
+				Start  time.Time          `bson:"start"`
+				AppID  primitive.ObjectID `bson:"appId"`
+				Series string             `bson:"series"`
+				Bucket string             `bson:"bucket"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&bucket); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[rollupKey{bucket.ID.Start.UnixMilli(), bucket.ID.AppID, bucket.ID.Series}]
+		value, err := strconv.Atoi(bucket.ID.Bucket)
+		if !ok || err != nil {
+			continue
+		}
+		if rollups[i].Histogram == nil {
+			rollups[i].Histogram = map[int]int64{}
+		}
+		rollups[i].Histogram[value] += bucket.Count
+	}
+
+	return rollups, cursor.Err()
+}
@@ -0,0 +1,222 @@
+package persistence
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.MetricSeriesRepo = &metricSeriesRepo{}
+
+type metricSeriesRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+// MetricSeriesDoc is unique per app and key.
+type MetricSeriesDoc struct {
+	AppID       primitive.ObjectID `bson:"appId"`
+	Key         string             `bson:"key"`
+	Name        string             `bson:"name"`
+	Kind        string             `bson:"kind"`
+	Labels      map[string]string  `bson:"labels"`
+	FirstSeenAt time.Time          `bson:"firstSeenAt"`
+	LastSeenAt  time.Time          `bson:"lastSeenAt"`
+	Total       *MetricTotalDoc    `bson:"total,omitempty"`
+}
+
+type MetricTotalDoc struct {
+	StartTime    time.Time        `bson:"startTime"`
+	Timestamp    time.Time        `bson:"timestamp"`
+	Value        float64          `bson:"value"`
+	Distribution *DistributionDoc `bson:"distribution,omitempty"`
+}
+
+type DistributionDoc struct {
+	Count     int64            `bson:"count"`
+	Sum       float64          `bson:"sum"`
+	Min       float64          `bson:"min"`
+	Max       float64          `bson:"max"`
+	Histogram map[string]int64 `bson:"histogram,omitempty"`
+}
+
+func metricSeriesToDomain(doc *MetricSeriesDoc) domain.MetricSeries {
+	series := domain.MetricSeries{
+		AppID:       doc.AppID,
+		Key:         doc.Key,
+		Name:        doc.Name,
+		Kind:        domain.MetricKind(doc.Kind),
+		Labels:      doc.Labels,
+		FirstSeenAt: doc.FirstSeenAt,
+		LastSeenAt:  doc.LastSeenAt,
+	}
+	if doc.Total != nil {
+		series.Total = &domain.MetricTotal{
+			StartTime: doc.Total.StartTime,
+			Timestamp: doc.Total.Timestamp,
+			Value:     doc.Total.Value,
+		}
+		if d := doc.Total.Distribution; d != nil {
+			series.Total.Distribution = &domain.Distribution{
+				Count:     d.Count,
+				Sum:       d.Sum,
+				Min:       d.Min,
+				Max:       d.Max,
+				Histogram: histogramToDomain(d.Histogram),
+			}
+		}
+	}
+	return series
+}
+
+func metricTotalFromDomain(total *domain.MetricTotal) *MetricTotalDoc {
+	if total == nil {
+		return nil
+	}
+	doc := &MetricTotalDoc{
+		StartTime: total.StartTime,
+		Timestamp: total.Timestamp,
+		Value:     total.Value,
+	}
+	if d := total.Distribution; d != nil {
+		doc.Distribution = &DistributionDoc{
+			Count:     d.Count,
+			Sum:       d.Sum,
+			Min:       d.Min,
+			Max:       d.Max,
+			Histogram: histogramFromDomain(d.Histogram),
+		}
+	}
+	return doc
+}
+
+func NewMetricSeriesRepo(db *mongo.Database) *metricSeriesRepo {
+	return &metricSeriesRepo{db: db, collection: "metricSeries"}
+}
+
+func (r *metricSeriesRepo) ListMetricSeriesByKeys(ctx context.Context, appID domain.ID, keys []string) ([]domain.MetricSeries, error) {
+	collection := r.db.Collection(r.collection)
+	cursor, err := collection.Find(ctx, bson.M{"appId": appID, "key": bson.M{"$in": keys}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	series := make([]domain.MetricSeries, 0)
+	for cursor.Next(ctx) {
+		var doc MetricSeriesDoc
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		series = append(series, metricSeriesToDomain(&doc))
+	}
+
+	return series, cursor.Err()
+}
+
+func (r *metricSeriesRepo) CountMetricSeries(ctx context.Context, appID domain.ID) (int64, error) {
+	return r.db.Collection(r.collection).CountDocuments(ctx, bson.M{"appId": appID})
+}
+
+func (r *metricSeriesRepo) SaveMetricSeries(ctx context.Context, series []domain.MetricSeries) error {
+	if len(series) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(series))
+	for i, s := range series {
+		update := bson.M{
+			"$max": bson.M{"lastSeenAt": s.LastSeenAt},
+			"$setOnInsert": bson.M{
+				"name":        s.Name,
+				"kind":        string(s.Kind),
+				"labels":      s.Labels,
+				"firstSeenAt": s.FirstSeenAt,
+			},
+		}
+		if total := metricTotalFromDomain(s.Total); total != nil {
+			update["$set"] = bson.M{"total": total}
+		}
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"appId": s.AppID, "key": s.Key}).
+			SetUpdate(update).
+			SetUpsert(true)
+	}
+
+	_, err := r.db.Collection(r.collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *metricSeriesRepo) ListMetricNames(ctx context.Context, appIDs []domain.ID, searchTerm string, limit int) ([]domain.MetricName, error) {
+	match := bson.M{"appId": bson.M{"$in": appIDs}}
+	if searchTerm != "" {
+		match["name"] = bson.M{"$regex": regexp.QuoteMeta(searchTerm), "$options": "i"}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":        "$name",
+			"kinds":      bson.M{"$addToSet": "$kind"},
+			"labels":     bson.M{"$addToSet": bson.M{"$map": bson.M{"input": bson.M{"$objectToArray": "$labels"}, "in": "$$this.k"}}},
+			"series":     bson.M{"$sum": 1},
+			"lastSeenAt": bson.M{"$max": "$lastSeenAt"},
+		}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+
+	cursor, err := r.db.Collection(r.collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	names := make([]domain.MetricName, 0)
+	for cursor.Next(ctx) {
+		var group struct {
+			Name       string     `bson:"_id"`
+			Kinds      []string   `bson:"kinds"`
+			Labels     [][]string `bson:"labels"`
+			Series     int        `bson:"series"`
+			LastSeenAt time.Time  `bson:"lastSeenAt"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return nil, err
+		}
+
+		// The labels are collected by set of labels of the series.
+		labels := map[string]bool{}
+		for _, set := range group.Labels {
+			for _, label := range set {
+				labels[label] = true
+			}
+		}
+		slices.Sort(group.Kinds)
+		kinds := make([]domain.MetricKind, len(group.Kinds))
+		for i, kind := range group.Kinds {
+			kinds[i] = domain.MetricKind(kind)
+		}
+
+		names = append(names, domain.MetricName{
+			Name:       group.Name,
+			Kinds:      kinds,
+			Labels:     slices.Sorted(maps.Keys(labels)),
+			Series:     group.Series,
+			LastSeenAt: group.LastSeenAt,
+		})
+	}
+
+	return names, cursor.Err()
+}
@@ -0,0 +1,129 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.NotificationChannelRepo = &notificationChannelRepo{}
+
+type notificationChannelRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type NotificationChannelDoc struct {
+	ID        primitive.ObjectID   `bson:"_id"`
+	UserID    primitive.ObjectID   `bson:"userId"`
+	Name      string               `bson:"name"`
+	Type      string               `bson:"type"`
+	Target    string               `bson:"target"`
+	Events    []string             `bson:"events"`
+	AppIDs    []primitive.ObjectID `bson:"appIds"`
+	CreatedAt time.Time            `bson:"createdAt"`
+}
+
+func notificationChannelFromDomain(channel domain.NotificationChannel) NotificationChannelDoc {
+	events := make([]string, len(channel.Events()))
+	for i, event := range channel.Events() {
+		events[i] = string(event)
+	}
+
+	return NotificationChannelDoc{
+		ID:        channel.ID(),
+		UserID:    channel.UserID(),
+		Name:      channel.Name(),
+		Type:      string(channel.Type()),
+		Target:    channel.Target(),
+		Events:    events,
+		AppIDs:    channel.AppIDs(),
+		CreatedAt: channel.CreatedAt(),
+	}
+}
+
+func notificationChannelToDomain(channel *NotificationChannelDoc) (*domain.NotificationChannel, error) {
+	events := make([]domain.NotificationEvent, len(channel.Events))
+	for i, event := range channel.Events {
+		events[i] = domain.NotificationEvent(event)
+	}
+
+	return domain.NewNotificationChannel(
+		channel.ID,
+		channel.UserID,
+		channel.Name,
+		domain.NotificationChannelType(channel.Type),
+		channel.Target,
+		events,
+		channel.AppIDs,
+		channel.CreatedAt,
+	)
+}
+
+func NewNotificationChannelRepo(db *mongo.Database) *notificationChannelRepo {
+	return &notificationChannelRepo{db: db, collection: "notificationChannels"}
+}
+
+func (r *notificationChannelRepo) SaveNotificationChannel(ctx context.Context, channel domain.NotificationChannel) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, notificationChannelFromDomain(channel))
+	return err
+}
+
+func (r *notificationChannelRepo) UpdateNotificationChannel(ctx context.Context, channel domain.NotificationChannel) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": channel.ID()}, bson.M{
+		"$set": notificationChannelFromDomain(channel),
+	})
+	return err
+}
+
+func (r *notificationChannelRepo) DeleteNotificationChannel(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *notificationChannelRepo) GetNotificationChannelByID(ctx context.Context, id domain.ID) (*domain.NotificationChannel, error) {
+	var channel NotificationChannelDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&channel)
+	if err != nil {
+		return nil, err
+	}
+	return notificationChannelToDomain(&channel)
+}
+
+func (r *notificationChannelRepo) ListNotificationChannels(ctx context.Context, criteria domain.Criteria) ([]domain.NotificationChannel, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	channels := make([]domain.NotificationChannel, 0)
+	for cursor.Next(ctx) {
+		var channel NotificationChannelDoc
+		if err := cursor.Decode(&channel); err != nil {
+			return nil, err
+		}
+
+		domainChannel, err := notificationChannelToDomain(&channel)
+		if err != nil {
+			return nil, err
+		}
+
+		channels = append(channels, *domainChannel)
+	}
+
+	return channels, nil
+}
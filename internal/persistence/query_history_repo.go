@@ -0,0 +1,157 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.QueryHistoryRepo = &queryHistoryRepo{}
+
+type queryHistoryRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type QueryHistoryEntryDoc struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	UserID     primitive.ObjectID `bson:"userId"`
+	Key        string             `bson:"key"`
+	Query      string             `bson:"query"`
+	SearchTerm string             `bson:"searchTerm"`
+	LogLevel   string             `bson:"logLevel"`
+	AppID      string             `bson:"appId"`
+	From       *time.Time         `bson:"from"`
+	To         *time.Time         `bson:"to"`
+	Runs       int                `bson:"runs"`
+	LastRunAt  time.Time          `bson:"lastRunAt"`
+}
+
+func queryHistoryEntryToDomain(entry *QueryHistoryEntryDoc) (*domain.QueryHistoryEntry, error) {
+	return domain.NewQueryHistoryEntry(
+		entry.ID,
+		entry.UserID,
+		entry.Query,
+		entry.SearchTerm,
+		entry.LogLevel,
+		entry.AppID,
+		entry.From,
+		entry.To,
+		entry.Runs,
+		entry.LastRunAt,
+	)
+}
+
+func NewQueryHistoryRepo(db *mongo.Database) *queryHistoryRepo {
+	return &queryHistoryRepo{db: db, collection: "queryHistory"}
+}
+
+func (r *queryHistoryRepo) RecordQueryHistoryEntry(ctx context.Context, entry domain.QueryHistoryEntry, keep int) error {
+	collection := r.db.Collection(r.collection)
+	filter := bson.M{"userId": entry.UserID(), "key": entry.Key()}
+	update := bson.M{
+		"$set": bson.M{
+			"query":      entry.Query(),
+			"searchTerm": entry.SearchTerm(),
+			"logLevel":   entry.LogLevel(),
+			"appId":      entry.AppID(),
+			"from":       entry.From(),
+			"to":         entry.To(),
+			"lastRunAt":  entry.LastRunAt(),
+		},
+		"$inc":         bson.M{"runs": 1},
+		"$setOnInsert": bson.M{"_id": entry.ID()},
+	}
+	// The key is unique per user, so of two runs upserting a new entry at
+	// once, the second fails and updates the entry of the first.
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	}
+	if err != nil {
+		return err
+	}
+
+	// The entries past the latest ones are dropped.
+	cursor, err := collection.Find(ctx,
+		bson.M{"userId": entry.UserID()},
+		options.Find().
+			SetSort(bson.D{{Key: "lastRunAt", Value: -1}}).
+			SetSkip(int64(keep)).
+			SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+
+	var old []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &old); err != nil || len(old) == 0 {
+		return err
+	}
+
+	ids := make([]primitive.ObjectID, len(old))
+	for i, entry := range old {
+		ids[i] = entry.ID
+	}
+	_, err = collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+func (r *queryHistoryRepo) ListQueryHistory(ctx context.Context, criteria domain.Criteria) ([]domain.QueryHistoryEntry, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := make([]domain.QueryHistoryEntry, 0)
+	for cursor.Next(ctx) {
+		var entry QueryHistoryEntryDoc
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+
+		domainEntry, err := queryHistoryEntryToDomain(&entry)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, *domainEntry)
+	}
+
+	return entries, nil
+}
+
+func (r *queryHistoryRepo) GetQueryHistoryEntryByID(ctx context.Context, id domain.ID) (*domain.QueryHistoryEntry, error) {
+	var entry QueryHistoryEntryDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if err != nil {
+		return nil, err
+	}
+	return queryHistoryEntryToDomain(&entry)
+}
+
+func (r *queryHistoryRepo) DeleteQueryHistoryEntry(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *queryHistoryRepo) DeleteQueryHistoryByUserID(ctx context.Context, userID domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
@@ -0,0 +1,70 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.QueryPolicyRepo = &queryPolicyRepo{}
+
+type queryPolicyRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+// QueryPolicyDoc is keyed by the account, which has at most one policy.
+type QueryPolicyDoc struct {
+	AccountID primitive.ObjectID `bson:"_id"`
+	MaxRange  time.Duration      `bson:"maxRange"`
+	MaxScan   int64              `bson:"maxScan"`
+	MaxTime   time.Duration      `bson:"maxTime"`
+	UpdatedBy primitive.ObjectID `bson:"updatedBy"`
+	UpdatedAt time.Time          `bson:"updatedAt"`
+}
+
+func queryPolicyFromDomain(policy domain.QueryPolicy) QueryPolicyDoc {
+	return QueryPolicyDoc{
+		AccountID: policy.AccountID(),
+		MaxRange:  policy.Limits().MaxRange,
+		MaxScan:   policy.Limits().MaxScan,
+		MaxTime:   policy.Limits().MaxTime,
+		UpdatedBy: policy.UpdatedBy(),
+		UpdatedAt: policy.UpdatedAt(),
+	}
+}
+
+func queryPolicyToDomain(policy *QueryPolicyDoc) (*domain.QueryPolicy, error) {
+	return domain.NewQueryPolicy(
+		policy.AccountID,
+		domain.QueryLimits{MaxRange: policy.MaxRange, MaxScan: policy.MaxScan, MaxTime: policy.MaxTime},
+		policy.UpdatedBy,
+		policy.UpdatedAt,
+	)
+}
+
+func NewQueryPolicyRepo(db *mongo.Database) *queryPolicyRepo {
+	return &queryPolicyRepo{db: db, collection: "queryPolicies"}
+}
+
+func (r *queryPolicyRepo) SaveQueryPolicy(ctx context.Context, policy domain.QueryPolicy) error {
+	collection := r.db.Collection(r.collection)
+	doc := queryPolicyFromDomain(policy)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": doc.AccountID}, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *queryPolicyRepo) GetQueryPolicy(ctx context.Context, accountID domain.ID) (*domain.QueryPolicy, error) {
+	var policy QueryPolicyDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": accountID}).Decode(&policy)
+	if err != nil {
+		return nil, err
+	}
+	return queryPolicyToDomain(&policy)
+}
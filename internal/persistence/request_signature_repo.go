@@ -0,0 +1,49 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.RequestSignatureRepo = &requestSignatureRepo{}
+
+type requestSignatureRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type RequestSignatureDoc struct {
+	// ID combines the app and the signature, so the unique _id index rejects
+	// replays atomically. The signatures expire with a TTL index on
+	// ReceivedAt.
+	ID         string             `bson:"_id"`
+	AppID      primitive.ObjectID `bson:"appId"`
+	ReceivedAt time.Time          `bson:"receivedAt"`
+}
+
+func NewRequestSignatureRepo(db *mongo.Database) *requestSignatureRepo {
+	return &requestSignatureRepo{db: db, collection: "requestSignatures"}
+}
+
+func (r *requestSignatureRepo) SaveRequestSignature(
+	ctx context.Context,
+	appID domain.ID,
+	signature string,
+	receivedAt time.Time,
+) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, RequestSignatureDoc{
+		ID:         appID.Hex() + ":" + signature,
+		AppID:      appID,
+		ReceivedAt: receivedAt,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrRequestSignatureAlreadyUsed
+	}
+	return err
+}
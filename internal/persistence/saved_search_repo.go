@@ -0,0 +1,128 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.SavedSearchRepo = &savedSearchRepo{}
+
+type savedSearchRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type SavedSearchDoc struct {
+	ID        primitive.ObjectID   `bson:"_id"`
+	UserID    primitive.ObjectID   `bson:"userId"`
+	AccountID primitive.ObjectID   `bson:"accountId"`
+	Name      string               `bson:"name"`
+	Query     string               `bson:"query"`
+	AppIDs    []primitive.ObjectID `bson:"appIds"`
+	TimeRange string               `bson:"timeRange"`
+	Columns   []string             `bson:"columns"`
+	Shared    bool                 `bson:"shared"`
+	CreatedAt time.Time            `bson:"createdAt"`
+	UpdatedAt time.Time            `bson:"updatedAt"`
+}
+
+func savedSearchFromDomain(search domain.SavedSearch) SavedSearchDoc {
+	return SavedSearchDoc{
+		ID:        search.ID(),
+		UserID:    search.UserID(),
+		AccountID: search.AccountID(),
+		Name:      search.Name(),
+		Query:     search.Query(),
+		AppIDs:    search.AppIDs(),
+		TimeRange: search.TimeRange(),
+		Columns:   search.Columns(),
+		Shared:    search.IsShared(),
+		CreatedAt: search.CreatedAt(),
+		UpdatedAt: search.UpdatedAt(),
+	}
+}
+
+func savedSearchToDomain(search *SavedSearchDoc) (*domain.SavedSearch, error) {
+	return domain.NewSavedSearch(
+		search.ID,
+		search.UserID,
+		search.AccountID,
+		search.Name,
+		search.Query,
+		search.AppIDs,
+		search.TimeRange,
+		search.Columns,
+		search.Shared,
+		search.CreatedAt,
+		search.UpdatedAt,
+	)
+}
+
+func NewSavedSearchRepo(db *mongo.Database) *savedSearchRepo {
+	return &savedSearchRepo{db: db, collection: "savedSearches"}
+}
+
+func (r *savedSearchRepo) SaveSavedSearch(ctx context.Context, search domain.SavedSearch) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, savedSearchFromDomain(search))
+	return err
+}
+
+func (r *savedSearchRepo) UpdateSavedSearch(ctx context.Context, search domain.SavedSearch) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": search.ID()}, bson.M{
+		"$set": savedSearchFromDomain(search),
+	})
+	return err
+}
+
+func (r *savedSearchRepo) DeleteSavedSearch(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *savedSearchRepo) GetSavedSearchByID(ctx context.Context, id domain.ID) (*domain.SavedSearch, error) {
+	var search SavedSearchDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&search)
+	if err != nil {
+		return nil, err
+	}
+	return savedSearchToDomain(&search)
+}
+
+func (r *savedSearchRepo) ListSavedSearches(ctx context.Context, criteria domain.Criteria) ([]domain.SavedSearch, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	searches := make([]domain.SavedSearch, 0)
+	for cursor.Next(ctx) {
+		var search SavedSearchDoc
+		if err := cursor.Decode(&search); err != nil {
+			return nil, err
+		}
+
+		domainSearch, err := savedSearchToDomain(&search)
+		if err != nil {
+			return nil, err
+		}
+
+		searches = append(searches, *domainSearch)
+	}
+
+	return searches, nil
+}
@@ -0,0 +1,104 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.SchemaDriftEventRepo = &schemaDriftEventRepo{}
+
+type schemaDriftEventRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type SchemaDriftEventDoc struct {
+	ID     primitive.ObjectID `bson:"_id"`
+	AppID  primitive.ObjectID `bson:"appId"`
+	UserID primitive.ObjectID `bson:"userId"`
+	// Key is unique per app, so a change is only recorded once.
+	Key           string    `bson:"key"`
+	Kind          string    `bson:"kind"`
+	Path          string    `bson:"path"`
+	PreviousTypes []string  `bson:"previousTypes"`
+	Type          string    `bson:"type"`
+	OccurredAt    time.Time `bson:"occurredAt"`
+	DetectedAt    time.Time `bson:"detectedAt"`
+}
+
+func schemaDriftEventFromDomain(event domain.SchemaDriftEvent) SchemaDriftEventDoc {
+	return SchemaDriftEventDoc{
+		ID:            event.ID(),
+		AppID:         event.AppID(),
+		UserID:        event.UserID(),
+		Key:           event.Key(),
+		Kind:          string(event.Kind()),
+		Path:          event.Path(),
+		PreviousTypes: event.PreviousTypes(),
+		Type:          event.Type(),
+		OccurredAt:    event.OccurredAt(),
+		DetectedAt:    event.DetectedAt(),
+	}
+}
+
+func schemaDriftEventToDomain(event *SchemaDriftEventDoc) (*domain.SchemaDriftEvent, error) {
+	return domain.NewSchemaDriftEvent(
+		event.ID,
+		event.AppID,
+		event.UserID,
+		domain.SchemaDriftKind(event.Kind),
+		event.Path,
+		event.PreviousTypes,
+		event.Type,
+		event.OccurredAt,
+		event.DetectedAt,
+	)
+}
+
+func NewSchemaDriftEventRepo(db *mongo.Database) *schemaDriftEventRepo {
+	return &schemaDriftEventRepo{db: db, collection: "schemaDriftEvents"}
+}
+
+func (r *schemaDriftEventRepo) SaveSchemaDriftEvent(ctx context.Context, event domain.SchemaDriftEvent) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.InsertOne(ctx, schemaDriftEventFromDomain(event))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrSchemaDriftEventAlreadyDetected
+	}
+	return err
+}
+
+func (r *schemaDriftEventRepo) ListSchemaDriftEvents(ctx context.Context, criteria domain.Criteria) ([]domain.SchemaDriftEvent, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	events := make([]domain.SchemaDriftEvent, 0)
+	for cursor.Next(ctx) {
+		var event SchemaDriftEventDoc
+		if err := cursor.Decode(&event); err != nil {
+			return nil, err
+		}
+
+		domainEvent, err := schemaDriftEventToDomain(&event)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, *domainEvent)
+	}
+
+	return events, nil
+}
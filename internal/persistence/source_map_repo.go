@@ -0,0 +1,145 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"monitoring/internal/domain"
+)
+
+var _ domain.SourceMapRepo = &sourceMapRepo{}
+
+type sourceMapRepo struct {
+	db         *mongo.Database
+	collection string
+}
+
+type SourceMapDoc struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AppID     primitive.ObjectID `bson:"appId"`
+	Release   string             `bson:"release"`
+	File      string             `bson:"file"`
+	Content   string             `bson:"content"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+// SourceMapInfoDoc is a source map listed without its content.
+type SourceMapInfoDoc struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	AppID     primitive.ObjectID `bson:"appId"`
+	Release   string             `bson:"release"`
+	File      string             `bson:"file"`
+	Size      int                `bson:"size"`
+	CreatedAt time.Time          `bson:"createdAt"`
+}
+
+func sourceMapFromDomain(sourceMap domain.SourceMap) SourceMapDoc {
+	return SourceMapDoc{
+		ID:        sourceMap.ID(),
+		AppID:     sourceMap.AppID(),
+		Release:   sourceMap.Release(),
+		File:      sourceMap.File(),
+		Content:   sourceMap.Content(),
+		CreatedAt: sourceMap.CreatedAt(),
+	}
+}
+
+func sourceMapToDomain(sourceMap *SourceMapDoc) (*domain.SourceMap, error) {
+	return domain.NewSourceMap(
+		sourceMap.ID,
+		sourceMap.AppID,
+		sourceMap.Release,
+		sourceMap.File,
+		sourceMap.Content,
+		sourceMap.CreatedAt,
+	)
+}
+
+func sourceMapInfoToDomain(sourceMap *SourceMapInfoDoc) (*domain.SourceMap, error) {
+	return domain.NewSourceMapInfo(
+		sourceMap.ID,
+		sourceMap.AppID,
+		sourceMap.Release,
+		sourceMap.File,
+		sourceMap.Size,
+		sourceMap.CreatedAt,
+	)
+}
+
+func NewSourceMapRepo(db *mongo.Database) *sourceMapRepo {
+	return &sourceMapRepo{db: db, collection: "sourceMaps"}
+}
+
+func (r *sourceMapRepo) SaveSourceMap(ctx context.Context, sourceMap domain.SourceMap) error {
+	collection := r.db.Collection(r.collection)
+	doc := sourceMapFromDomain(sourceMap)
+	_, err := collection.UpdateOne(
+		ctx,
+		bson.M{"appId": doc.AppID, "release": doc.Release, "file": doc.File},
+		bson.M{
+			"$set": bson.M{
+				"content":   doc.Content,
+				"createdAt": doc.CreatedAt,
+			},
+			"$setOnInsert": bson.M{"_id": doc.ID},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *sourceMapRepo) GetSourceMapByID(ctx context.Context, id domain.ID) (*domain.SourceMap, error) {
+	var sourceMap SourceMapDoc
+	err := r.db.Collection(r.collection).FindOne(ctx, bson.M{"_id": id}).Decode(&sourceMap)
+	if err != nil {
+		return nil, err
+	}
+	return sourceMapToDomain(&sourceMap)
+}
+
+func (r *sourceMapRepo) ListSourceMaps(ctx context.Context, criteria domain.Criteria) ([]domain.SourceMap, error) {
+	collection := r.db.Collection(r.collection)
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	// The content of a map can be up to domain.MaxSourceMapSize, so only its
+	// size leaves the server.
+	pipeline = append(pipeline,
+		bson.M{"$addFields": bson.M{"size": bson.M{"$strLenBytes": "$content"}}},
+		bson.M{"$project": bson.M{"content": 0}},
+	)
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sourceMaps := make([]domain.SourceMap, 0)
+	for cursor.Next(ctx) {
+		var sourceMap SourceMapInfoDoc
+		if err := cursor.Decode(&sourceMap); err != nil {
+			return nil, err
+		}
+
+		domainSourceMap, err := sourceMapInfoToDomain(&sourceMap)
+		if err != nil {
+			return nil, err
+		}
+
+		sourceMaps = append(sourceMaps, *domainSourceMap)
+	}
+
+	return sourceMaps, nil
+}
+
+func (r *sourceMapRepo) DeleteSourceMap(ctx context.Context, id domain.ID) error {
+	collection := r.db.Collection(r.collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
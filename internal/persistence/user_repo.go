@@ -117,7 +117,11 @@ func (r *userRepo) UpdateUser(ctx context.Context, user domain.User) error {
 
 func (r *userRepo) ListUsers(ctx context.Context, criteria domain.Criteria) ([]domain.User, error) {
 	collection := r.db.Collection(r.collection)
-	cursor, err := collection.Aggregate(ctx, criteriaToPipeline(criteria))
+	pipeline, err := criteriaToPipeline(criteria)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline, aggregateOptions(criteria))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,190 @@
+// Package promwrite decodes the write requests of Prometheus remote write,
+// protobuf messages compressed with snappy.
+package promwrite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid remote write request")
+)
+
+// maxDecodedLength bounds the size of a request once decompressed.
+const maxDecodedLength = 32 << 20
+
+// The numbers of the fields of the messages of a write request.
+const (
+	writeRequestTimeseries = 1
+	timeSeriesLabels       = 1
+	timeSeriesSamples      = 2
+	labelName              = 1
+	labelValue             = 2
+	sampleValue            = 1
+	sampleTimestamp        = 2
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	value     float64
+	timestamp int64
+}
+
+// Decode turns the samples of the series of a write request into samples.
+// Remote write has no types, so series whose name ends with _total are
+// cumulative counters, as Prometheus names them, and the others gauges.
+// Staleness markers are left out. It returns why the series it leaves out
+// were rejected, and an error when the body is not a write request.
+func Decode(body []byte) ([]domain.MetricSample, []error, error) {
+	length, err := snappy.DecodedLen(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+	if length > maxDecodedLength {
+		return nil, nil, fmt.Errorf("%w: larger than %d bytes", ErrInvalidRequest, maxDecodedLength)
+	}
+	message, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidRequest, err)
+	}
+
+	samples := []domain.MetricSample{}
+	rejected := []error{}
+	err = eachField(message, func(number protowire.Number, value []byte) error {
+		if number != writeRequestTimeseries {
+			return nil
+		}
+		series, err := decodeTimeSeries(value)
+		if err != nil {
+			return err
+		}
+		samples, rejected = appendSeries(samples, rejected, series.labels, series.samples)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return samples, rejected, nil
+}
+
+type timeSeries struct {
+	labels  []label
+	samples []sample
+}
+
+func decodeTimeSeries(message []byte) (timeSeries, error) {
+	series := timeSeries{}
+	err := eachField(message, func(number protowire.Number, value []byte) error {
+		switch number {
+		case timeSeriesLabels:
+			l := label{}
+			err := eachField(value, func(number protowire.Number, value []byte) error {
+				switch number {
+				case labelName:
+					l.name = string(value)
+				case labelValue:
+					l.value = string(value)
+				}
+				return nil
+			})
+			series.labels = append(series.labels, l)
+			return err
+		case timeSeriesSamples:
+			s := sample{}
+			err := eachField(value, func(number protowire.Number, value []byte) error {
+				switch number {
+				case sampleValue:
+					bits, n := protowire.ConsumeFixed64(value)
+					if n < 0 {
+						return fmt.Errorf("%w: %s", ErrInvalidRequest, protowire.ParseError(n))
+					}
+					s.value = math.Float64frombits(bits)
+				case sampleTimestamp:
+					timestamp, n := protowire.ConsumeVarint(value)
+					if n < 0 {
+						return fmt.Errorf("%w: %s", ErrInvalidRequest, protowire.ParseError(n))
+					}
+					s.timestamp = int64(timestamp)
+				}
+				return nil
+			})
+			series.samples = append(series.samples, s)
+			return err
+		}
+		return nil
+	})
+	return series, err
+}
+
+func appendSeries(samples []domain.MetricSample, rejected []error, labels []label, values []sample) ([]domain.MetricSample, []error) {
+	name := ""
+	named := make(map[string]string, len(labels))
+	for _, l := range labels {
+		if l.name == "__name__" {
+			name = l.value
+			continue
+		}
+		named[l.name] = l.value
+	}
+
+	kind, cumulative := domain.MetricGauge, false
+	if strings.HasSuffix(name, "_total") {
+		kind, cumulative = domain.MetricCounter, true
+	}
+
+	for _, value := range values {
+		if math.IsNaN(value.value) {
+			continue
+		}
+		sample, err := domain.NewMetricSample(name, kind, named, time.UnixMilli(value.timestamp))
+		if err != nil {
+			return samples, append(rejected, err)
+		}
+		sample.Value = value.value
+		sample.Cumulative = cumulative
+		samples = append(samples, *sample)
+	}
+	return samples, rejected
+}
+
+// eachField calls field with the number and the value of each field of a
+// message, the content of the length delimited ones and the encoded value of
+// the others.
+func eachField(message []byte, field func(number protowire.Number, value []byte) error) error {
+	for len(message) > 0 {
+		number, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidRequest, protowire.ParseError(n))
+		}
+		message = message[n:]
+
+		n = protowire.ConsumeFieldValue(number, typ, message)
+		if n < 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidRequest, protowire.ParseError(n))
+		}
+		value := message[:n]
+		if typ == protowire.BytesType {
+			value, _ = protowire.ConsumeBytes(value)
+		}
+		message = message[n:]
+
+		if err := field(number, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
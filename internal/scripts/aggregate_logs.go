@@ -0,0 +1,270 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrAggregateLogsScriptInvalidAggregation = errors.New("invalid aggregation")
+)
+
+const (
+	maxAggregationGroups  = 3
+	maxAggregationMetrics = 10
+	defaultAggregationTop = 10
+	maxAggregationTop     = 1000
+	// maxAggregationRows is the most groups read from the database.
+	maxAggregationRows = 100000
+)
+
+var percentileOpRegex = regexp.MustCompile(`^p([0-9]{1,2}(\.[0-9]+)?)$`)
+
+type AggregateLogsGroup struct {
+	// Field is a field to group by, like level, appId or data.path.
+	Field string `json:"field"`
+	// Size is how many values with the most logs are kept, 10 by default.
+	Size int `json:"size"`
+	// Interval groups by time bucket instead, like 5m or 1d.
+	Interval string `json:"interval"`
+}
+
+type AggregateLogsMetric struct {
+	// Op is count, distinct, sum, avg, min, max, percentile, or a
+	// percentile like p95.
+	Op         string  `json:"op"`
+	Field      string  `json:"field"`
+	Percentile float64 `json:"percentile"`
+}
+
+type AggregateLogsReq struct {
+	UserID  string                `json:"-"`
+	Search  SearchLogsReq         `json:"search"`
+	GroupBy []AggregateLogsGroup  `json:"groupBy"`
+	Metrics []AggregateLogsMetric `json:"metrics"`
+	// Timezone is the IANA name of the zone time buckets start in.
+	Timezone string `json:"timezone"`
+}
+
+type AggregateLogsColumn struct {
+	Name string `json:"name"`
+	// Type is group or metric.
+	Type string `json:"type"`
+}
+
+// AggregateLogsResp is a table with a column per group and metric, and a row
+// per innermost group. Groups by field are sorted by their number of logs and
+// time buckets chronologically.
+type AggregateLogsResp struct {
+	Columns   []AggregateLogsColumn `json:"columns"`
+	Rows      [][]any               `json:"rows"`
+	Truncated bool                  `json:"truncated"`
+}
+
+type AggregateLogsScript struct {
+	search  *SearchLogsScript
+	logRepo domain.LogRepo
+}
+
+func NewAggregateLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, guard *QueryGuard) *AggregateLogsScript {
+	return &AggregateLogsScript{search: NewSearchLogsScript(appRepo, logRepo, guard), logRepo: logRepo}
+}
+
+func (s *AggregateLogsScript) Exec(ctx context.Context, req AggregateLogsReq) (*AggregateLogsResp, error) {
+	aggregation, err := newLogAggregation(req)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Search.UserID = req.UserID
+	search, err := s.search.prepare(ctx, req.Search)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.search.checkScan(ctx, search); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkBuckets(ctx, search, req.Search, *aggregation); err != nil {
+		return nil, err
+	}
+
+	rows, truncated, err := s.logRepo.AggregateLogs(ctx, search.criteria(domain.EmptyPagination, domain.EmptySort), *aggregation)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &AggregateLogsResp{Columns: []AggregateLogsColumn{}, Rows: [][]any{}, Truncated: truncated}
+	for _, group := range aggregation.Groups {
+		resp.Columns = append(resp.Columns, AggregateLogsColumn{Name: group.Name(), Type: "group"})
+	}
+	resp.Columns = append(resp.Columns, AggregateLogsColumn{Name: string(domain.AggregationCount), Type: "metric"})
+	for _, metric := range aggregation.Metrics {
+		resp.Columns = append(resp.Columns, AggregateLogsColumn{Name: metric.Name(), Type: "metric"})
+	}
+
+	for _, row := range topAggregationRows(rows, aggregation.Groups, 0) {
+		values := append(slices.Clone(row.Keys), row.Count)
+		resp.Rows = append(resp.Rows, append(values, row.Values...))
+	}
+	return resp, nil
+}
+
+// checkBuckets keeps groups by interval to maxHistogramBuckets buckets over
+// the time range of the search, like histograms.
+func (s *AggregateLogsScript) checkBuckets(ctx context.Context, search *logSearch, req SearchLogsReq, aggregation domain.LogAggregation) error {
+	if !slices.ContainsFunc(aggregation.Groups, func(group domain.AggregationGroup) bool { return group.Interval != nil }) {
+		return nil
+	}
+
+	from, to, err := searchTimeRange(ctx, s.logRepo, search, req.From, req.To)
+	if err != nil || from.IsZero() {
+		return err
+	}
+
+	location := aggregation.Location
+	if location == nil {
+		location = time.UTC
+	}
+	for _, group := range aggregation.Groups {
+		if group.Interval != nil && group.Interval.Buckets(from.In(location), to.In(location)) > maxHistogramBuckets {
+			return fmt.Errorf("%w: more than %d buckets, use a longer interval or a shorter time range", ErrAggregateLogsScriptInvalidAggregation, maxHistogramBuckets)
+		}
+	}
+	return nil
+}
+
+// newLogAggregation validates the groups and metrics of the request. The
+// count is always computed, so it is not one of the metrics.
+func newLogAggregation(req AggregateLogsReq) (*domain.LogAggregation, error) {
+	if len(req.GroupBy) > maxAggregationGroups {
+		return nil, fmt.Errorf("%w: at most %d groups", ErrAggregateLogsScriptInvalidAggregation, maxAggregationGroups)
+	}
+
+	if len(req.Metrics) > maxAggregationMetrics {
+		return nil, fmt.Errorf("%w: at most %d metrics", ErrAggregateLogsScriptInvalidAggregation, maxAggregationMetrics)
+	}
+
+	aggregation := &domain.LogAggregation{Location: time.UTC, MaxRows: maxAggregationRows}
+	if strings.TrimSpace(req.Timezone) != "" {
+		location, err := time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %s", ErrAggregateLogsScriptInvalidAggregation, req.Timezone)
+		}
+		aggregation.Location = location
+	}
+
+	for _, group := range req.GroupBy {
+		if group.Interval != "" {
+			interval, err := domain.ParseInterval(group.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrAggregateLogsScriptInvalidAggregation, err)
+			}
+			aggregation.Groups = append(aggregation.Groups, domain.AggregationGroup{Interval: &interval})
+			continue
+		}
+
+		if group.Field != "appId" && !domain.IsQueryField(group.Field) {
+			return nil, fmt.Errorf("%w: cannot group by %q, use level, appId or a data field", ErrAggregateLogsScriptInvalidAggregation, group.Field)
+		}
+
+		size := group.Size
+		if size <= 0 {
+			size = defaultAggregationTop
+		}
+		aggregation.Groups = append(aggregation.Groups, domain.AggregationGroup{Field: group.Field, Size: min(size, maxAggregationTop)})
+	}
+
+	for _, metric := range req.Metrics {
+		op := domain.AggregationOp(metric.Op)
+		percentile := metric.Percentile
+		if matches := percentileOpRegex.FindStringSubmatch(metric.Op); matches != nil {
+			op = domain.AggregationPercentile
+			percentile, _ = strconv.ParseFloat(matches[1], 64)
+		}
+
+		switch op {
+		case domain.AggregationCount:
+			continue
+		case domain.AggregationDistinct, domain.AggregationSum, domain.AggregationAvg, domain.AggregationMin, domain.AggregationMax:
+		case domain.AggregationPercentile:
+			if percentile <= 0 || percentile >= 100 {
+				return nil, fmt.Errorf("%w: percentile must be between 0 and 100", ErrAggregateLogsScriptInvalidAggregation)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown metric %q, use count, distinct, sum, avg, min, max, percentile or p95", ErrAggregateLogsScriptInvalidAggregation, metric.Op)
+		}
+
+		if !domain.IsQueryField(metric.Field) {
+			return nil, fmt.Errorf("%w: %s needs a field, like data.duration", ErrAggregateLogsScriptInvalidAggregation, op)
+		}
+
+		aggregation.Metrics = append(aggregation.Metrics, domain.AggregationMetric{Op: op, Field: metric.Field, Percentile: percentile})
+	}
+
+	return aggregation, nil
+}
+
+// topAggregationRows orders the rows level by level: at each group by field,
+// only the values with the most logs are kept, and time buckets are sorted
+// chronologically.
+func topAggregationRows(rows []domain.LogAggregationRow, groups []domain.AggregationGroup, level int) []domain.LogAggregationRow {
+	if level >= len(groups) {
+		return rows
+	}
+
+	type subgroup struct {
+		key   any
+		count int64
+		rows  []domain.LogAggregationRow
+	}
+
+	subgroups := []*subgroup{}
+	byKey := map[string]*subgroup{}
+	for _, row := range rows {
+		key := fmt.Sprint(row.Keys[level])
+		if _, ok := byKey[key]; !ok {
+			byKey[key] = &subgroup{key: row.Keys[level]}
+			subgroups = append(subgroups, byKey[key])
+		}
+		byKey[key].count += row.Count
+		byKey[key].rows = append(byKey[key].rows, row)
+	}
+
+	group := groups[level]
+	if group.Interval != nil {
+		slices.SortFunc(subgroups, func(a, b *subgroup) int {
+			x, _ := a.key.(time.Time)
+			y, _ := b.key.(time.Time)
+			return x.Compare(y)
+		})
+	} else {
+		slices.SortFunc(subgroups, func(a, b *subgroup) int {
+			return cmp.Or(cmp.Compare(b.count, a.count), cmp.Compare(fmt.Sprint(a.key), fmt.Sprint(b.key)))
+		})
+		if len(subgroups) > group.Size {
+			subgroups = subgroups[:group.Size]
+		}
+	}
+
+	result := []domain.LogAggregationRow{}
+	for _, sub := range subgroups {
+		result = append(result, topAggregationRows(sub.rows, groups, level+1)...)
+	}
+	return result
+}
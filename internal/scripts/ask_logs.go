@@ -0,0 +1,118 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrAskLogsScriptInvalidQuestion  = errors.New("question must not be empty nor longer than 1000 characters")
+	ErrAskLogsScriptInvalidGenerated = errors.New("the generated query is not valid")
+)
+
+const maxQuestionLength = 1000
+
+type AskLogsReq struct {
+	UserID   string `json:"-"`
+	Question string `json:"question"`
+	AppID    string `json:"appId"`
+	Limit    int    `json:"limit"`
+}
+
+// AskLogsResp has the logs found and the query they were searched with, which
+// can be run again with GET /logs, like with its cursors.
+type AskLogsResp struct {
+	GeneratedQuery domain.GeneratedQuery `json:"generatedQuery"`
+	SearchLogsResp
+}
+
+type AskLogsScript struct {
+	search         *SearchLogsScript
+	logSchemaRepo  domain.LogSchemaRepo
+	queryGenerator domain.QueryGenerator
+}
+
+func NewAskLogsScript(
+	appRepo domain.AppRepo,
+	logRepo domain.LogRepo,
+	logSchemaRepo domain.LogSchemaRepo,
+	queryGenerator domain.QueryGenerator,
+	guard *QueryGuard,
+) *AskLogsScript {
+	return &AskLogsScript{
+		search:         NewSearchLogsScript(appRepo, logRepo, guard),
+		logSchemaRepo:  logSchemaRepo,
+		queryGenerator: queryGenerator,
+	}
+}
+
+// Exec has a query written from the question, with the schema of the logs of
+// the user, and searches with it like any other search of the user.
+func (s *AskLogsScript) Exec(ctx context.Context, req AskLogsReq) (*AskLogsResp, error) {
+	question := strings.TrimSpace(req.Question)
+	if question == "" || len(question) > maxQuestionLength {
+		return nil, ErrAskLogsScriptInvalidQuestion
+	}
+
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var appIDs []domain.ID
+	if strings.TrimSpace(req.AppID) != "" {
+		appID, err := domain.NewID(req.AppID)
+		if err != nil {
+			return nil, err
+		}
+		appIDs = []domain.ID{appID}
+	}
+
+	schema, err := s.logSchemaRepo.Get(ctx, userID, appIDs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := s.queryGenerator.GenerateQuery(ctx, question, schema, Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := domain.ParseQuery(generated.Query); err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrAskLogsScriptInvalidGenerated, generated.Query, err)
+	}
+
+	if generated.SortOrder != domain.Asc {
+		generated.SortOrder = domain.Desc
+	}
+
+	searchReq := SearchLogsReq{
+		UserID:       req.UserID,
+		AppID:        req.AppID,
+		Limit:        req.Limit,
+		SortOrder:    string(generated.SortOrder),
+		Query:        generated.Query,
+		IncludeTotal: true,
+	}
+	if generated.From != nil {
+		searchReq.From = *generated.From
+	}
+	if generated.To != nil {
+		searchReq.To = *generated.To
+	}
+	if !searchReq.From.IsZero() && !searchReq.To.IsZero() && searchReq.To.Before(searchReq.From) {
+		return nil, fmt.Errorf("%w: from %s is after to %s", ErrAskLogsScriptInvalidGenerated, searchReq.From.Format(time.RFC3339), searchReq.To.Format(time.RFC3339))
+	}
+
+	resp, err := s.search.Exec(ctx, searchReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AskLogsResp{GeneratedQuery: *generated, SearchLogsResp: *resp}, nil
+}
@@ -0,0 +1,150 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"monitoring/internal/domain"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeQueryGenerator answers every question with the same query.
+type fakeQueryGenerator struct {
+	generated domain.GeneratedQuery
+	questions []string
+}
+
+func (g *fakeQueryGenerator) GenerateQuery(ctx context.Context, question string, schema domain.LogSchema, now time.Time) (*domain.GeneratedQuery, error) {
+	g.questions = append(g.questions, question)
+	generated := g.generated
+	return &generated, nil
+}
+
+type fakeLogSchemaRepo struct {
+	domain.LogSchemaRepo
+}
+
+func (r *fakeLogSchemaRepo) Get(ctx context.Context, userID domain.ID, appIDs []domain.ID, optionalRange *domain.Range) (domain.LogSchema, error) {
+	return domain.NewLogSchema(0, time.Time{}, []domain.LogSchemaField{}), nil
+}
+
+type fakeAppRepo struct {
+	domain.AppRepo
+	apps []domain.App
+}
+
+func (r *fakeAppRepo) ListApps(ctx context.Context, criteria domain.Criteria) ([]domain.App, error) {
+	return r.apps, nil
+}
+
+// fakeLogRepo finds no logs and keeps the criteria it was listed with.
+type fakeLogRepo struct {
+	domain.LogRepo
+	listed []domain.Criteria
+}
+
+func (r *fakeLogRepo) ListLogs(ctx context.Context, criteria domain.Criteria) ([]domain.Log, error) {
+	r.listed = append(r.listed, criteria)
+	return []domain.Log{}, nil
+}
+
+func (r *fakeLogRepo) CountLogs(ctx context.Context, criteria domain.Criteria, max int64) (int64, error) {
+	return 0, nil
+}
+
+func TestAskLogsScript(t *testing.T) {
+	userID := domain.NewAutoID()
+	app, err := domain.NewApp(domain.NewAutoID(), "shop", userID, time.Now(), "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		question  string
+		generated domain.GeneratedQuery
+		wantErr   error
+		// wantSort is the order the logs are listed in when the search runs.
+		wantSort domain.SortOrder
+	}{
+		{
+			name:      "valid query",
+			question:  "errors of the checkout yesterday",
+			generated: domain.GeneratedQuery{Query: `level:ERROR AND data.path:"/checkout"`, From: &from, To: &to, SortOrder: domain.Asc},
+			wantSort:  domain.Asc,
+		},
+		{
+			name:      "valid query without sort order",
+			question:  "server errors",
+			generated: domain.GeneratedQuery{Query: "data.status>=500"},
+			wantSort:  domain.Desc,
+		},
+		{
+			name:      "invalid query",
+			question:  "errors of the checkout",
+			generated: domain.GeneratedQuery{Query: `level:ERROR AND (data.path:"/checkout"`},
+			wantErr:   ErrAskLogsScriptInvalidGenerated,
+		},
+		{
+			name:      "query on a field that cannot be searched",
+			question:  "logs of the secret",
+			generated: domain.GeneratedQuery{Query: "password:hunter2"},
+			wantErr:   ErrAskLogsScriptInvalidGenerated,
+		},
+		{
+			name:      "from after to",
+			question:  "errors of the checkout",
+			generated: domain.GeneratedQuery{Query: "level:ERROR", From: &to, To: &from},
+			wantErr:   ErrAskLogsScriptInvalidGenerated,
+		},
+		{
+			name:     "empty question",
+			question: "  ",
+			wantErr:  ErrAskLogsScriptInvalidQuestion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := &fakeQueryGenerator{generated: tt.generated}
+			logRepo := &fakeLogRepo{}
+			script := NewAskLogsScript(&fakeAppRepo{apps: []domain.App{*app}}, logRepo, &fakeLogSchemaRepo{}, generator, nil)
+
+			resp, err := script.Exec(context.Background(), AskLogsReq{UserID: userID.Hex(), Question: tt.question})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Exec() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(logRepo.listed) > 0 {
+					t.Errorf("Exec() searched the logs with an invalid query")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exec() error = %v", err)
+			}
+
+			if resp.GeneratedQuery.Query != tt.generated.Query || resp.GeneratedQuery.SortOrder != tt.wantSort {
+				t.Errorf("Exec() generated query = %+v, want %q sorted %s", resp.GeneratedQuery, tt.generated.Query, tt.wantSort)
+			}
+
+			if len(logRepo.listed) != 1 {
+				t.Fatalf("Exec() listed the logs %d times, want 1", len(logRepo.listed))
+			}
+			listed := logRepo.listed[0]
+			want, err := domain.ParseQuery(tt.generated.Query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(listed.Query, want) {
+				t.Errorf("Exec() searched with query %#v, want %#v", listed.Query, want)
+			}
+			if listed.Sort.Order != tt.wantSort {
+				t.Errorf("Exec() listed the logs sorted %s, want %s", listed.Sort.Order, tt.wantSort)
+			}
+		})
+	}
+}
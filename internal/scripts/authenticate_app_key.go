@@ -0,0 +1,130 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrAuthenticateAppKeyScriptInvalidKey      = errors.New("invalid app key")
+	ErrAuthenticateAppKeyScriptScopeNotAllowed = errors.New("app key scope not allowed")
+)
+
+// appKeyLastUsedResolution avoids writing the key on every request.
+const appKeyLastUsedResolution = time.Minute
+
+type AuthenticateAppKeyReq struct {
+	AppKey string
+	Scope  domain.AppKeyScope
+}
+
+type AuthenticateAppKeyResp struct {
+	App    domain.App
+	AppKey domain.AppKey
+}
+
+type AuthenticateAppKeyScript struct {
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
+}
+
+func NewAuthenticateAppKeyScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *AuthenticateAppKeyScript {
+	return &AuthenticateAppKeyScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
+}
+
+func (s *AuthenticateAppKeyScript) Exec(ctx context.Context, req AuthenticateAppKeyReq) (*AuthenticateAppKeyResp, error) {
+	if strings.TrimSpace(req.AppKey) == "" {
+		return nil, ErrAuthenticateAppKeyScriptInvalidKey
+	}
+
+	key, err := s.appKeyRepo.GetAppKeyByHash(ctx, hashAppKeySecret(req.AppKey))
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		key, err = s.migrateLegacyKey(ctx, req.AppKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := Now().UTC()
+	if !key.IsActive(now) {
+		return nil, ErrAuthenticateAppKeyScriptInvalidKey
+	}
+
+	if !key.HasScope(req.Scope) {
+		return nil, ErrAuthenticateAppKeyScriptScopeNotAllowed
+	}
+
+	app, err := s.appRepo.GetAppByID(ctx, key.AppID())
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAuthenticateAppKeyScriptInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key.LastUsedAt() == nil || now.Sub(*key.LastUsedAt()) >= appKeyLastUsedResolution {
+		key.MarkUsed(now)
+		err = s.appKeyRepo.TouchAppKey(ctx, key.ID(), now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &AuthenticateAppKeyResp{App: *app, AppKey: *key}, nil
+}
+
+// migrateLegacyKey turns the plain key of an app created before AppKey
+// existed into a hashed key, so existing shippers keep working.
+func (s *AuthenticateAppKeyScript) migrateLegacyKey(ctx context.Context, secret string) (*domain.AppKey, error) {
+	app, err := s.appRepo.GetAppByLegacyKey(ctx, secret)
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAuthenticateAppKeyScriptInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := secret
+	if len(prefix) > 4 {
+		prefix = prefix[:4]
+	}
+
+	key, err := domain.NewAppKey(
+		domain.NewAutoID(),
+		app.ID(),
+		"legacy",
+		prefix,
+		hashAppKeySecret(secret),
+		[]domain.AppKeyScope{domain.AppKeyScopeIngest, domain.AppKeyScopeRead},
+		nil,
+		nil,
+		nil,
+		nil,
+		Now().UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appKeyRepo.SaveAppKey(ctx, *key)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request migrated the key first.
+		return s.appKeyRepo.GetAppKeyByHash(ctx, key.Hash())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appRepo.RemoveLegacyAppKey(ctx, app.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,44 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type ChangeIssueStatusReq struct {
+	UserID  string `json:"-"`
+	IssueID string `json:"-"`
+	Status  string `json:"status"`
+}
+
+type ChangeIssueStatusResp struct {
+	Issue domain.Issue `json:"issue"`
+}
+
+type ChangeIssueStatusScript struct {
+	issueRepo domain.IssueRepo
+}
+
+func NewChangeIssueStatusScript(issueRepo domain.IssueRepo) *ChangeIssueStatusScript {
+	return &ChangeIssueStatusScript{issueRepo: issueRepo}
+}
+
+func (s *ChangeIssueStatusScript) Exec(ctx context.Context, req ChangeIssueStatusReq) (*ChangeIssueStatusResp, error) {
+	issue, err := getUserIssue(ctx, s.issueRepo, req.UserID, req.IssueID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = issue.ChangeStatus(domain.IssueStatus(req.Status), Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err = s.issueRepo.ChangeIssueStatus(ctx, *issue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChangeIssueStatusResp{Issue: *issue}, nil
+}
@@ -1,13 +1,22 @@
 package scripts
 
 import (
+	"context"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"log"
 	"math/rand"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
+
+	"monitoring/internal/domain"
 )
 
 var Now = time.Now
@@ -58,3 +67,402 @@ func encryptPassword(plainPassword string) (string, error) {
 
 	return string(hashed), err
 }
+
+const (
+	appKeySecretPrefix       = "mk_"
+	publicAppKeySecretPrefix = "pk_"
+)
+
+// generateAppKeySecret returns a new random app key secret and the short
+// prefix used to recognize it without revealing the secret.
+func generateAppKeySecret(public bool) (string, string, error) {
+	bytes := make([]byte, 24)
+	if _, err := crand.Read(bytes); err != nil {
+		return "", "", err
+	}
+
+	prefix := appKeySecretPrefix
+	if public {
+		prefix = publicAppKeySecretPrefix
+	}
+
+	secret := prefix + base64.RawURLEncoding.EncodeToString(bytes)
+	return secret, secret[:len(prefix)+8], nil
+}
+
+func hashAppKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// getUserApp returns the app only when it belongs to the given user.
+func getUserApp(ctx context.Context, appRepo domain.AppRepo, userID string, appID string) (*domain.App, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(appID)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := appRepo.GetAppByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.UserID() != uid {
+		return nil, fmt.Errorf("app with ID %s does not exist for the user", appID)
+	}
+
+	return app, nil
+}
+
+// getAppKey returns the key only when it belongs to the given app.
+func getAppKey(ctx context.Context, appKeyRepo domain.AppKeyRepo, appID domain.ID, keyID string) (*domain.AppKey, error) {
+	id, err := domain.NewID(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := appKeyRepo.GetAppKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.AppID() != appID {
+		return nil, fmt.Errorf("key with ID %s does not exist for the app", keyID)
+	}
+
+	return key, nil
+}
+
+// getUserIssue returns the issue only when it belongs to the given user.
+func getUserIssue(ctx context.Context, issueRepo domain.IssueRepo, userID string, issueID string) (*domain.Issue, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(issueID)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := issueRepo.GetIssueByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if issue.UserID() != uid {
+		return nil, fmt.Errorf("issue with ID %s does not exist for the user", issueID)
+	}
+
+	return issue, nil
+}
+
+func generateSigningSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := crand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// signRequest computes the signature of an ingestion request, which is the
+// hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the app signing
+// secret. The Go SDK signs requests the same way.
+func signRequest(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getUserExportJob returns the export job only when it belongs to the given
+// user.
+func getUserExportJob(ctx context.Context, exportJobRepo domain.ExportJobRepo, userID string, exportJobID string) (*domain.ExportJob, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(exportJobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := exportJobRepo.GetExportJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.UserID() != uid {
+		return nil, fmt.Errorf("export job with ID %s does not exist for the user", exportJobID)
+	}
+
+	return job, nil
+}
+
+// getUserLog returns the log only when it belongs to an app of the given
+// user.
+func getUserLog(ctx context.Context, appRepo domain.AppRepo, logRepo domain.LogRepo, userID string, logID string) (*domain.Log, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(logID)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := logRepo.GetLogByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := appRepo.GetAppByID(ctx, log.AppID())
+	if err != nil || app.UserID() != uid {
+		return nil, fmt.Errorf("log with ID %s does not exist for the user", logID)
+	}
+
+	return log, nil
+}
+
+// getUserLogPattern returns the pattern only when it belongs to an app of the
+// given user.
+func getUserLogPattern(ctx context.Context, appRepo domain.AppRepo, logPatternRepo domain.LogPatternRepo, userID string, patternID string) (*domain.LogPattern, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(patternID)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := logPatternRepo.GetLogPatternByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := appRepo.GetAppByID(ctx, pattern.AppID())
+	if err != nil || app.UserID() != uid {
+		return nil, fmt.Errorf("pattern with ID %s does not exist for the user", patternID)
+	}
+
+	return pattern, nil
+}
+
+// notifyChannelsTimeout bounds the time notifications add to the request
+// that sends them.
+const notifyChannelsTimeout = 10 * time.Second
+
+// notifyChannels sends the notification through the channels of the user
+// subscribed to its event and app. It sends them before returning, since
+// nothing outlives the request on serverless deployments, even when the
+// request is canceled. Failures are only logged, they do not fail the
+// request.
+func notifyChannels(
+	ctx context.Context,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	notifier domain.Notifier,
+	userID domain.ID,
+	notification domain.Notification,
+) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyChannelsTimeout)
+	defer cancel()
+
+	channels, err := notificationChannelRepo.ListNotificationChannels(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("userId", domain.Equals, userID),
+			domain.NewFilter("events", domain.Equals, string(notification.Event)),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		log.Printf("listing notification channels of user %s: %v", userID.Hex(), err)
+		return
+	}
+
+	for _, channel := range channels {
+		if !channel.IsSubscribed(notification.Event, notification.AppID) {
+			continue
+		}
+		if err := notifier.Notify(ctx, channel, notification); err != nil {
+			log.Printf("notifying channel %s: %v", channel.ID().Hex(), err)
+		}
+	}
+}
+
+func getUserNotificationChannel(
+	ctx context.Context,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	userID string,
+	channelID string,
+) (*domain.NotificationChannel, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := notificationChannelRepo.GetNotificationChannelByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel.UserID() != uid {
+		return nil, fmt.Errorf("notification channel with ID %s does not exist for the user", channelID)
+	}
+
+	return channel, nil
+}
+
+// getUserAppIDs checks the apps belong to the user.
+func getUserAppIDs(ctx context.Context, appRepo domain.AppRepo, userID string, appIDs []string) ([]domain.ID, error) {
+	ids := make([]domain.ID, len(appIDs))
+	for i, appID := range appIDs {
+		app, err := getUserApp(ctx, appRepo, userID, appID)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = app.ID()
+	}
+	return ids, nil
+}
+
+// getUserAppIDsOrAll returns the IDs of the apps of the user asked for, all
+// of them when none are.
+func getUserAppIDsOrAll(ctx context.Context, appRepo domain.AppRepo, userID string, appIDs []string) ([]domain.ID, error) {
+	if len(appIDs) > 0 {
+		return getUserAppIDs(ctx, appRepo, userID, appIDs)
+	}
+
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := appRepo.ListApps(ctx, domain.NewCriteria(
+		[]domain.Filter{domain.NewFilter("userId", domain.Equals, uid)},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]domain.ID, len(apps))
+	for i, app := range apps {
+		ids[i] = app.ID()
+	}
+	return ids, nil
+}
+
+// getUserAccount returns the user and the ID of its account, the root user
+// that created it or itself when it is a root user.
+func getUserAccount(ctx context.Context, userRepo domain.UserRepo, userID string) (*domain.User, domain.ID, error) {
+	id, err := domain.NewID(userID)
+	if err != nil {
+		return nil, domain.ID{}, err
+	}
+
+	user, err := userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, domain.ID{}, err
+	}
+
+	if user.IsRoot() {
+		return user, user.ID(), nil
+	}
+	return user, *user.RootUserID(), nil
+}
+
+// getVisibleSavedSearch returns the saved search when the user saved it or it
+// is shared with the account of the user.
+func getVisibleSavedSearch(
+	ctx context.Context,
+	userRepo domain.UserRepo,
+	savedSearchRepo domain.SavedSearchRepo,
+	userID string,
+	searchID string,
+) (*domain.SavedSearch, error) {
+	user, accountID, err := getUserAccount(ctx, userRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := savedSearchRepo.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !search.IsVisibleTo(user.ID(), accountID) {
+		return nil, fmt.Errorf("saved search with ID %s does not exist for the user", searchID)
+	}
+
+	return search, nil
+}
+
+// getUserSavedSearch returns the saved search only when the user saved it,
+// since only its owner can change it.
+func getUserSavedSearch(ctx context.Context, savedSearchRepo domain.SavedSearchRepo, userID string, searchID string) (*domain.SavedSearch, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(searchID)
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := savedSearchRepo.GetSavedSearchByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if search.UserID() != uid {
+		return nil, fmt.Errorf("saved search with ID %s does not exist for the user", searchID)
+	}
+
+	return search, nil
+}
+
+func getUserLogMetric(ctx context.Context, logMetricRepo domain.LogMetricRepo, userID string, metricID string) (*domain.LogMetric, error) {
+	uid, err := domain.NewID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := domain.NewID(metricID)
+	if err != nil {
+		return nil, err
+	}
+
+	metric, err := logMetricRepo.GetLogMetricByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if metric.UserID() != uid {
+		return nil, fmt.Errorf("log metric with ID %s does not exist for the user", metricID)
+	}
+
+	return metric, nil
+}
@@ -0,0 +1,588 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrCompareLogsScriptInvalidComparison = errors.New("invalid comparison")
+)
+
+const (
+	// defaultCompareRange is the current window when from is not given.
+	defaultCompareRange = time.Hour
+	maxCompareFields    = 5
+	// compareHighlights is how many patterns, fields and values of each
+	// field are highlighted.
+	compareHighlights = 10
+	// compareFieldSample is how many of the latest logs of each window are
+	// read to tell the share of the logs that have each data field.
+	compareFieldSample = 5000
+)
+
+// CompareLogsWindow is a time range, both ends included.
+type CompareLogsWindow struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type CompareLogsReq struct {
+	UserID string `json:"-"`
+	// Search selects the logs, its from and to are left out for the windows.
+	Search   SearchLogsReq         `json:"search"`
+	GroupBy  []AggregateLogsGroup  `json:"groupBy"`
+	Metrics  []AggregateLogsMetric `json:"metrics"`
+	Timezone string                `json:"timezone"`
+	// Current is the last hour by default.
+	Current CompareLogsWindow `json:"current"`
+	// Baseline is the window of the same length right before Current by
+	// default.
+	Baseline CompareLogsWindow `json:"baseline"`
+	// Offset places the baseline that long before Current instead, like 1d
+	// or 7d.
+	Offset string `json:"offset"`
+	// Fields are the fields whose values are compared, level by default.
+	Fields []string `json:"fields"`
+}
+
+// CompareLogsValue is a metric in both windows. Delta is the absolute change
+// and Change the relative one, nil when the baseline is 0 or the metric is
+// not a number in both windows.
+type CompareLogsValue struct {
+	Current  any      `json:"current"`
+	Baseline any      `json:"baseline"`
+	Delta    *float64 `json:"delta"`
+	Change   *float64 `json:"change"`
+}
+
+// CompareLogsRow is a group of the aggregation with a value per metric, the
+// count first. Time buckets of the baseline are moved to the current window
+// to line up with its buckets.
+type CompareLogsRow struct {
+	Keys   []any              `json:"keys"`
+	Values []CompareLogsValue `json:"values"`
+}
+
+// CompareLogsShare is the share of the logs of each window with a pattern, a
+// field or a value. Delta is the change of the share, in points between 0
+// and 1.
+type CompareLogsShare struct {
+	// Field is the field of a value, like level.
+	Field string `json:"field,omitempty"`
+	// Key is the pattern id, the path of the field, like data.route, or the
+	// value.
+	Key any `json:"key"`
+	// Template is the template of a pattern.
+	Template      string  `json:"template,omitempty"`
+	CurrentCount  int64   `json:"currentCount"`
+	BaselineCount int64   `json:"baselineCount"`
+	CurrentShare  float64 `json:"currentShare"`
+	BaselineShare float64 `json:"baselineShare"`
+	Delta         float64 `json:"delta"`
+}
+
+// CompareLogsHighlights are what changed the most between the windows, by
+// their share of the logs.
+type CompareLogsHighlights struct {
+	Patterns []CompareLogsShare `json:"patterns"`
+	// Fields are sampled from the latest logs of each window.
+	Fields []CompareLogsShare `json:"fields"`
+	Values []CompareLogsShare `json:"values"`
+}
+
+type CompareLogsResp struct {
+	Current    CompareLogsWindow     `json:"current"`
+	Baseline   CompareLogsWindow     `json:"baseline"`
+	Groups     []string              `json:"groups"`
+	Metrics    []string              `json:"metrics"`
+	Total      CompareLogsValue      `json:"total"`
+	Rows       []CompareLogsRow      `json:"rows"`
+	Truncated  bool                  `json:"truncated"`
+	Highlights CompareLogsHighlights `json:"highlights"`
+}
+
+type CompareLogsScript struct {
+	search         *SearchLogsScript
+	logRepo        domain.LogRepo
+	logPatternRepo domain.LogPatternRepo
+}
+
+func NewCompareLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, logPatternRepo domain.LogPatternRepo, guard *QueryGuard) *CompareLogsScript {
+	return &CompareLogsScript{
+		search:         NewSearchLogsScript(appRepo, logRepo, guard),
+		logRepo:        logRepo,
+		logPatternRepo: logPatternRepo,
+	}
+}
+
+// Exec runs the aggregation of a search in two windows and compares them.
+func (s *CompareLogsScript) Exec(ctx context.Context, req CompareLogsReq) (*CompareLogsResp, error) {
+	aggregation, err := newLogAggregation(AggregateLogsReq{GroupBy: req.GroupBy, Metrics: req.Metrics, Timezone: req.Timezone})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCompareLogsScriptInvalidComparison, err)
+	}
+
+	current, baseline, err := compareWindows(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := req.Fields
+	if len(fields) == 0 {
+		fields = []string{"level"}
+	}
+	if len(fields) > maxCompareFields {
+		return nil, fmt.Errorf("%w: at most %d fields", ErrCompareLogsScriptInvalidComparison, maxCompareFields)
+	}
+	for _, field := range fields {
+		if field != "appId" && !domain.IsQueryField(field) {
+			return nil, fmt.Errorf("%w: cannot compare the values of %q, use level, appId or a data field", ErrCompareLogsScriptInvalidComparison, field)
+		}
+	}
+
+	// The windows are applied apart, like the ranges of the patterns.
+	searchReq := req.Search
+	searchReq.UserID = req.UserID
+	search, err := s.search.prepareWindows(ctx, searchReq, domain.Range(current), domain.Range(baseline))
+	if err != nil {
+		return nil, err
+	}
+
+	currentFilters, baselineFilters := compareFilters(current, baseline)
+	for _, filters := range [][]domain.Filter{currentFilters, baselineFilters} {
+		if err := s.search.checkScan(ctx, search, filters...); err != nil {
+			return nil, err
+		}
+	}
+
+	resp := &CompareLogsResp{
+		Current:  current,
+		Baseline: baseline,
+		Groups:   []string{},
+		Metrics:  []string{string(domain.AggregationCount)},
+		Rows:     []CompareLogsRow{},
+	}
+	for _, group := range aggregation.Groups {
+		resp.Groups = append(resp.Groups, group.Name())
+	}
+	for _, metric := range aggregation.Metrics {
+		resp.Metrics = append(resp.Metrics, metric.Name())
+	}
+
+	currentRows, currentTruncated, err := s.aggregate(ctx, search, *aggregation, currentFilters...)
+	if err != nil {
+		return nil, err
+	}
+	baselineRows, baselineTruncated, err := s.aggregate(ctx, search, *aggregation, baselineFilters...)
+	if err != nil {
+		return nil, err
+	}
+	resp.Truncated = currentTruncated || baselineTruncated
+	resp.Rows = compareRows(currentRows, baselineRows, *aggregation, current.From.Sub(baseline.From))
+
+	totals := domain.LogAggregation{Location: time.UTC, MaxRows: 1}
+	currentTotal, _, err := s.aggregate(ctx, search, totals, currentFilters...)
+	if err != nil {
+		return nil, err
+	}
+	baselineTotal, _, err := s.aggregate(ctx, search, totals, baselineFilters...)
+	if err != nil {
+		return nil, err
+	}
+	currentCount, baselineCount := totalCount(currentTotal), totalCount(baselineTotal)
+	resp.Total = compareValues(currentCount, baselineCount)
+
+	resp.Highlights.Patterns, err = s.comparePatterns(ctx, search, currentFilters, baselineFilters, currentCount, baselineCount)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Highlights.Fields, err = s.compareFields(ctx, search, currentFilters, baselineFilters)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Highlights.Values = []CompareLogsShare{}
+	for _, field := range fields {
+		values, err := s.compareFieldValues(ctx, search, field, currentFilters, baselineFilters, currentCount, baselineCount)
+		if err != nil {
+			return nil, err
+		}
+		resp.Highlights.Values = append(resp.Highlights.Values, values...)
+	}
+	sortCompareShares(resp.Highlights.Values)
+
+	return resp, nil
+}
+
+// compareWindows fills the windows that were not given.
+func compareWindows(req CompareLogsReq) (CompareLogsWindow, CompareLogsWindow, error) {
+	current := CompareLogsWindow{From: req.Current.From.UTC(), To: req.Current.To.UTC()}
+	if req.Current.To.IsZero() {
+		current.To = Now().UTC()
+	}
+	if req.Current.From.IsZero() {
+		current.From = current.To.Add(-defaultCompareRange)
+	}
+	if !current.From.Before(current.To) {
+		return CompareLogsWindow{}, CompareLogsWindow{}, fmt.Errorf("%w: the current window must start before it ends", ErrCompareLogsScriptInvalidComparison)
+	}
+
+	baseline := CompareLogsWindow{From: req.Baseline.From.UTC(), To: req.Baseline.To.UTC()}
+	switch {
+	case req.Offset != "" && (!req.Baseline.From.IsZero() || !req.Baseline.To.IsZero()):
+		return CompareLogsWindow{}, CompareLogsWindow{}, fmt.Errorf("%w: give either the baseline or the offset", ErrCompareLogsScriptInvalidComparison)
+	case req.Offset != "":
+		offset, err := domain.ParseInterval(req.Offset)
+		if err != nil {
+			return CompareLogsWindow{}, CompareLogsWindow{}, fmt.Errorf("%w: %s", ErrCompareLogsScriptInvalidComparison, err)
+		}
+		baseline = CompareLogsWindow{From: offset.Add(current.From, -1), To: offset.Add(current.To, -1)}
+	case req.Baseline.From.IsZero() && req.Baseline.To.IsZero():
+		baseline = CompareLogsWindow{From: current.From.Add(-current.To.Sub(current.From)), To: current.From}
+	case req.Baseline.From.IsZero() || req.Baseline.To.IsZero():
+		return CompareLogsWindow{}, CompareLogsWindow{}, fmt.Errorf("%w: the baseline needs both from and to", ErrCompareLogsScriptInvalidComparison)
+	}
+	if !baseline.From.Before(baseline.To) {
+		return CompareLogsWindow{}, CompareLogsWindow{}, fmt.Errorf("%w: the baseline window must start before it ends", ErrCompareLogsScriptInvalidComparison)
+	}
+	return current, baseline, nil
+}
+
+// compareFilters select the logs of each window. A baseline that ends where
+// the current window starts leaves out its end, to not count the same logs
+// twice.
+func compareFilters(current CompareLogsWindow, baseline CompareLogsWindow) ([]domain.Filter, []domain.Filter) {
+	currentFilters := []domain.Filter{
+		domain.NewFilter("timestamp", domain.GreaterThanOrEqual, current.From),
+		domain.NewFilter("timestamp", domain.LessThanOrEqual, current.To),
+	}
+
+	baselineEnd := domain.NewFilter("timestamp", domain.LessThanOrEqual, baseline.To)
+	if baseline.To.Equal(current.From) {
+		baselineEnd = domain.NewFilter("timestamp", domain.LessThan, baseline.To)
+	}
+	baselineFilters := []domain.Filter{
+		domain.NewFilter("timestamp", domain.GreaterThanOrEqual, baseline.From),
+		baselineEnd,
+	}
+	return currentFilters, baselineFilters
+}
+
+func (s *CompareLogsScript) aggregate(ctx context.Context, search *logSearch, aggregation domain.LogAggregation, filters ...domain.Filter) ([]domain.LogAggregationRow, bool, error) {
+	rows, truncated, err := s.logRepo.AggregateLogs(ctx, search.criteria(domain.EmptyPagination, domain.EmptySort, filters...), aggregation)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, false, ErrSearchLogsScriptTimeout
+	}
+	return rows, truncated, err
+}
+
+func totalCount(rows []domain.LogAggregationRow) int64 {
+	if len(rows) == 0 {
+		return 0
+	}
+	return rows[0].Count
+}
+
+// compareRows joins the groups of both windows. The groups are ordered like
+// an aggregation by their logs in both windows, and time buckets of the
+// baseline are moved by the offset between the windows.
+func compareRows(currentRows []domain.LogAggregationRow, baselineRows []domain.LogAggregationRow, aggregation domain.LogAggregation, offset time.Duration) []CompareLogsRow {
+	type joinedRow struct {
+		current  *domain.LogAggregationRow
+		baseline *domain.LogAggregationRow
+		merged   int
+	}
+
+	joined := map[string]*joinedRow{}
+	merged := []domain.LogAggregationRow{}
+	join := func(row domain.LogAggregationRow, isBaseline bool) {
+		keys := slices.Clone(row.Keys)
+		for i, group := range aggregation.Groups {
+			bucket, ok := keys[i].(time.Time)
+			if isBaseline && ok && group.Interval != nil {
+				keys[i] = group.Interval.Truncate(bucket.Add(offset).In(aggregation.Location))
+			}
+		}
+		key := compareRowKey(keys)
+		if _, ok := joined[key]; !ok {
+			joined[key] = &joinedRow{merged: len(merged)}
+			merged = append(merged, domain.LogAggregationRow{Keys: keys})
+		}
+		merged[joined[key].merged].Count += row.Count
+		row.Keys = keys
+		if isBaseline {
+			joined[key].baseline = &row
+		} else {
+			joined[key].current = &row
+		}
+	}
+	for _, row := range currentRows {
+		join(row, false)
+	}
+	for _, row := range baselineRows {
+		join(row, true)
+	}
+
+	result := []CompareLogsRow{}
+	for _, row := range topAggregationRows(merged, aggregation.Groups, 0) {
+		pair := joined[compareRowKey(row.Keys)]
+		values := []CompareLogsValue{compareValues(rowCount(pair.current), rowCount(pair.baseline))}
+		for i := range aggregation.Metrics {
+			values = append(values, compareValues(rowValue(pair.current, i), rowValue(pair.baseline, i)))
+		}
+		result = append(result, CompareLogsRow{Keys: row.Keys, Values: values})
+	}
+	return result
+}
+
+func compareRowKey(keys []any) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if bucket, ok := key.(time.Time); ok {
+			parts[i] = bucket.UTC().Format(time.RFC3339Nano)
+			continue
+		}
+		parts[i] = fmt.Sprintf("%T:%v", key, key)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func rowCount(row *domain.LogAggregationRow) int64 {
+	if row == nil {
+		return 0
+	}
+	return row.Count
+}
+
+func rowValue(row *domain.LogAggregationRow, i int) any {
+	if row == nil || i >= len(row.Values) {
+		return nil
+	}
+	return row.Values[i]
+}
+
+func compareValues(current any, baseline any) CompareLogsValue {
+	value := CompareLogsValue{Current: current, Baseline: baseline}
+	x, ok := compareNumber(current)
+	if !ok {
+		return value
+	}
+	y, ok := compareNumber(baseline)
+	if !ok {
+		return value
+	}
+
+	delta := x - y
+	value.Delta = &delta
+	if y != 0 {
+		change := delta / math.Abs(y)
+		value.Change = &change
+	}
+	return value
+}
+
+func compareNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, !math.IsNaN(v) && !math.IsInf(v, 0)
+	}
+	return 0, false
+}
+
+// compareShares turns the counts of both windows into shares of their
+// totals, keeping those whose share changed the most.
+func compareShares(currentCounts map[any]int64, baselineCounts map[any]int64, currentTotal int64, baselineTotal int64) []CompareLogsShare {
+	shares := []CompareLogsShare{}
+	add := func(key any) {
+		share := CompareLogsShare{Key: key, CurrentCount: currentCounts[key], BaselineCount: baselineCounts[key]}
+		if currentTotal > 0 {
+			share.CurrentShare = float64(share.CurrentCount) / float64(currentTotal)
+		}
+		if baselineTotal > 0 {
+			share.BaselineShare = float64(share.BaselineCount) / float64(baselineTotal)
+		}
+		share.Delta = share.CurrentShare - share.BaselineShare
+		if share.Delta != 0 {
+			shares = append(shares, share)
+		}
+	}
+	for key := range currentCounts {
+		add(key)
+	}
+	for key := range baselineCounts {
+		if _, ok := currentCounts[key]; !ok {
+			add(key)
+		}
+	}
+
+	sortCompareShares(shares)
+	if len(shares) > compareHighlights {
+		shares = shares[:compareHighlights]
+	}
+	return shares
+}
+
+// sortCompareShares puts the largest changes of share first, gains and
+// losses alike.
+func sortCompareShares(shares []CompareLogsShare) {
+	slices.SortFunc(shares, func(a, b CompareLogsShare) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(b.Delta), math.Abs(a.Delta)),
+			cmp.Compare(a.Field, b.Field),
+			cmp.Compare(fmt.Sprint(a.Key), fmt.Sprint(b.Key)),
+		)
+	})
+}
+
+// countByField counts the logs of each value of the field.
+func (s *CompareLogsScript) countByField(ctx context.Context, search *logSearch, field string, filters ...domain.Filter) (map[any]int64, error) {
+	rows, _, err := s.aggregate(ctx, search, domain.LogAggregation{
+		Groups:  []domain.AggregationGroup{{Field: field}},
+		MaxRows: maxAggregationRows,
+	}, filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[any]int64, len(rows))
+	for _, row := range rows {
+		if row.Keys[0] == nil || row.Keys[0] == "" || !isComparableKey(row.Keys[0]) {
+			continue
+		}
+		counts[row.Keys[0]] = row.Count
+	}
+	return counts, nil
+}
+
+// isComparableKey leaves out the values that cannot be map keys, like arrays
+// grouped as a whole.
+func isComparableKey(key any) bool {
+	switch key.(type) {
+	case string, bool, int, int32, int64, float64, time.Time, domain.ID:
+		return true
+	}
+	return false
+}
+
+func (s *CompareLogsScript) comparePatterns(ctx context.Context, search *logSearch, currentFilters []domain.Filter, baselineFilters []domain.Filter, currentTotal int64, baselineTotal int64) ([]CompareLogsShare, error) {
+	currentCounts, err := s.countByField(ctx, search, "patternId", currentFilters...)
+	if err != nil {
+		return nil, err
+	}
+	baselineCounts, err := s.countByField(ctx, search, "patternId", baselineFilters...)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := compareShares(currentCounts, baselineCounts, currentTotal, baselineTotal)
+	ids := make([]any, 0, len(shares))
+	for _, share := range shares {
+		if hexID, ok := share.Key.(string); ok {
+			if id, err := domain.NewID(hexID); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return shares, nil
+	}
+
+	patterns, err := s.logPatternRepo.ListLogPatterns(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("_id", domain.In, ids),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]string, len(patterns))
+	for _, pattern := range patterns {
+		templates[pattern.ID().Hex()] = pattern.Template()
+	}
+	for i := range shares {
+		if hexID, ok := shares[i].Key.(string); ok {
+			shares[i].Template = templates[hexID]
+		}
+	}
+	return shares, nil
+}
+
+// compareFields compares how many of the latest logs of each window have
+// each data field.
+func (s *CompareLogsScript) compareFields(ctx context.Context, search *logSearch, currentFilters []domain.Filter, baselineFilters []domain.Filter) ([]CompareLogsShare, error) {
+	currentSchema, err := s.sampleSchema(ctx, search, currentFilters...)
+	if err != nil {
+		return nil, err
+	}
+	baselineSchema, err := s.sampleSchema(ctx, search, baselineFilters...)
+	if err != nil {
+		return nil, err
+	}
+
+	currentCounts := make(map[any]int64, len(currentSchema.Schema))
+	for path, count := range currentSchema.Schema {
+		currentCounts["data."+path] = int64(count)
+	}
+	baselineCounts := make(map[any]int64, len(baselineSchema.Schema))
+	for path, count := range baselineSchema.Schema {
+		baselineCounts["data."+path] = int64(count)
+	}
+	return compareShares(currentCounts, baselineCounts, int64(currentSchema.Total), int64(baselineSchema.Total)), nil
+}
+
+func (s *CompareLogsScript) sampleSchema(ctx context.Context, search *logSearch, filters ...domain.Filter) (domain.LogSchema, error) {
+	builder := domain.NewLogSchemaBuilder()
+	criteria := search.criteria(domain.NewPagination(compareFieldSample, 0), domain.NewSort("timestamp", domain.Desc), filters...)
+	err := s.logRepo.StreamLogs(ctx, criteria, func(log domain.Log) error {
+		builder.Add(log.Timestamp(), log.Data())
+		return nil
+	})
+	if err != nil && mongo.IsTimeout(err) {
+		return domain.LogSchema{}, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return domain.LogSchema{}, err
+	}
+	return builder.Build(), nil
+}
+
+func (s *CompareLogsScript) compareFieldValues(ctx context.Context, search *logSearch, field string, currentFilters []domain.Filter, baselineFilters []domain.Filter, currentTotal int64, baselineTotal int64) ([]CompareLogsShare, error) {
+	currentCounts, err := s.countByField(ctx, search, field, currentFilters...)
+	if err != nil {
+		return nil, err
+	}
+	baselineCounts, err := s.countByField(ctx, search, field, baselineFilters...)
+	if err != nil {
+		return nil, err
+	}
+
+	shares := compareShares(currentCounts, baselineCounts, currentTotal, baselineTotal)
+	for i := range shares {
+		shares[i].Field = field
+		if id, ok := shares[i].Key.(domain.ID); ok {
+			shares[i].Key = id.Hex()
+		}
+	}
+	return shares, nil
+}
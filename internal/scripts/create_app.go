@@ -2,41 +2,31 @@ package scripts
 
 import (
 	"context"
-	"errors"
-
-	"go.mongodb.org/mongo-driver/mongo"
 
 	"monitoring/internal/domain"
 )
 
 type CreateAppReq struct {
 	Name   string `json:"name"`
-	AppKey string `json:"appKey"`
 	UserID string `json:"userId"`
 }
 
 type CreateAppResp struct {
-	domain.App
+	App    domain.App    `json:"app"`
+	Key    domain.AppKey `json:"key"`
+	Secret string        `json:"secret"`
 }
 
 type CreateAppScript struct {
-	appRepo domain.AppRepo
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
 }
 
-func NewCreateAppScript(appRepo domain.AppRepo) *CreateAppScript {
-	return &CreateAppScript{appRepo: appRepo}
+func NewCreateAppScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *CreateAppScript {
+	return &CreateAppScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
 }
 
 func (s *CreateAppScript) Exec(ctx context.Context, req CreateAppReq) (*CreateAppResp, error) {
-	existing, err := s.appRepo.GetAppByKey(ctx, req.AppKey)
-	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, err
-	}
-
-	if existing != nil {
-		return nil, errors.New("app with the provided app key already exists")
-	}
-
 	userID, err := domain.NewID(req.UserID)
 	if err != nil {
 		return nil, err
@@ -45,9 +35,21 @@ func (s *CreateAppScript) Exec(ctx context.Context, req CreateAppReq) (*CreateAp
 	app, err := domain.NewApp(
 		domain.NewAutoID(),
 		req.Name,
-		req.AppKey,
 		userID,
 		Now().UTC(),
+		"",
+		false,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key, secret, err := newAppKey(
+		app.ID(),
+		"default",
+		[]domain.AppKeyScope{domain.AppKeyScopeIngest, domain.AppKeyScopeRead},
+		nil,
+		nil,
 	)
 	if err != nil {
 		return nil, err
@@ -58,7 +60,14 @@ func (s *CreateAppScript) Exec(ctx context.Context, req CreateAppReq) (*CreateAp
 		return nil, err
 	}
 
+	err = s.appKeyRepo.SaveAppKey(ctx, *key)
+	if err != nil {
+		return nil, err
+	}
+
 	return &CreateAppResp{
-		App: *app,
+		App:    *app,
+		Key:    *key,
+		Secret: secret,
 	}, nil
 }
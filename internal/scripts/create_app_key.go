@@ -0,0 +1,96 @@
+package scripts
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+type CreateAppKeyReq struct {
+	UserID         string     `json:"-"`
+	AppID          string     `json:"-"`
+	Name           string     `json:"name"`
+	Scopes         []string   `json:"scopes"`
+	AllowedOrigins []string   `json:"allowedOrigins"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+}
+
+type CreateAppKeyResp struct {
+	Key    domain.AppKey `json:"key"`
+	Secret string        `json:"secret"`
+}
+
+type CreateAppKeyScript struct {
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
+}
+
+func NewCreateAppKeyScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *CreateAppKeyScript {
+	return &CreateAppKeyScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
+}
+
+func (s *CreateAppKeyScript) Exec(ctx context.Context, req CreateAppKeyReq) (*CreateAppKeyResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := make([]domain.AppKeyScope, len(req.Scopes))
+	for i, scope := range req.Scopes {
+		scopes[i] = domain.AppKeyScope(scope)
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		utc := req.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	key, secret, err := newAppKey(app.ID(), req.Name, scopes, req.AllowedOrigins, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appKeyRepo.SaveAppKey(ctx, *key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateAppKeyResp{Key: *key, Secret: secret}, nil
+}
+
+// newAppKey builds a key with a freshly generated secret. The secret is
+// returned apart because the key only keeps its hash.
+func newAppKey(
+	appID domain.ID,
+	name string,
+	scopes []domain.AppKeyScope,
+	allowedOrigins []string,
+	expiresAt *time.Time,
+) (*domain.AppKey, string, error) {
+	secret, prefix, err := generateAppKeySecret(slices.Contains(scopes, domain.AppKeyScopeBrowser))
+	if err != nil {
+		return nil, "", err
+	}
+
+	key, err := domain.NewAppKey(
+		domain.NewAutoID(),
+		appID,
+		name,
+		prefix,
+		hashAppKeySecret(secret),
+		scopes,
+		allowedOrigins,
+		expiresAt,
+		nil,
+		nil,
+		Now().UTC(),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
@@ -0,0 +1,193 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+const (
+	// maxExportJobLogs is the most logs an export job writes.
+	maxExportJobLogs = 10000000
+	// exportProgressEvery is how often, in logs, the progress of an export
+	// job is saved.
+	exportProgressEvery = 1000
+	exportJobTimeout    = time.Hour
+)
+
+type CreateExportJobReq struct {
+	UserID  string        `json:"-"`
+	Format  string        `json:"format"`
+	Columns []string      `json:"columns"`
+	Search  SearchLogsReq `json:"search"`
+}
+
+type CreateExportJobResp struct {
+	domain.ExportJob
+}
+
+type CreateExportJobScript struct {
+	search         *SearchLogsScript
+	logRepo        domain.LogRepo
+	logSchemaRepo  domain.LogSchemaRepo
+	exportJobRepo  domain.ExportJobRepo
+	exportFileRepo domain.ExportFileRepo
+}
+
+func NewCreateExportJobScript(
+	appRepo domain.AppRepo,
+	logRepo domain.LogRepo,
+	logSchemaRepo domain.LogSchemaRepo,
+	exportJobRepo domain.ExportJobRepo,
+	exportFileRepo domain.ExportFileRepo,
+	guard *QueryGuard,
+) *CreateExportJobScript {
+	return &CreateExportJobScript{
+		search:         NewSearchLogsScript(appRepo, logRepo, guard),
+		logRepo:        logRepo,
+		logSchemaRepo:  logSchemaRepo,
+		exportJobRepo:  exportJobRepo,
+		exportFileRepo: exportFileRepo,
+	}
+}
+
+// Exec saves a pending export job and starts writing its file in the
+// background.
+func (s *CreateExportJobScript) Exec(ctx context.Context, req CreateExportJobReq) (*CreateExportJobResp, error) {
+	req.Search.UserID = req.UserID
+	logExport, err := prepareLogExport(ctx, s.search, s.logSchemaRepo, req.Search, req.Format, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.logRepo.CountLogs(ctx, logExport.criteria, maxExportJobLogs+1)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	if total > maxExportJobLogs {
+		return nil, fmt.Errorf("%w: more than %d logs match the search, narrow the time range", ErrExportLogsScriptTooManyLogs, maxExportJobLogs)
+	}
+
+	job, err := domain.NewExportJob(
+		domain.NewAutoID(),
+		userID,
+		string(logExport.format),
+		logExport.columns,
+		domain.ExportJobStatusPending,
+		0,
+		total,
+		"",
+		0,
+		Now(),
+		Now(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.exportJobRepo.SaveExportJob(ctx, *job); err != nil {
+		return nil, err
+	}
+
+	go s.run(*job, logExport)
+
+	return &CreateExportJobResp{ExportJob: *job}, nil
+}
+
+// run writes the file of the job, saving its progress and renewing its lease,
+// and marks the job as failed when it cannot be written. It stops when
+// another server failed the job as abandoned.
+func (s *CreateExportJobScript) run(job domain.ExportJob, logExport *logExport) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportJobTimeout)
+	defer cancel()
+
+	go s.renew(ctx, cancel, job.ID())
+
+	fileSize, err := s.writeFile(ctx, &job, logExport)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("export took longer than %s", exportJobTimeout)
+		}
+		if deleteErr := s.exportFileRepo.DeleteExportFile(context.Background(), job.ID()); deleteErr != nil {
+			log.Printf("deleting file of export job %s: %v", job.ID().Hex(), deleteErr)
+		}
+		job.Fail(err, Now())
+	} else {
+		job.Complete(fileSize, Now())
+	}
+
+	err = s.exportJobRepo.UpdateExportJob(context.Background(), job)
+	if errors.Is(err, domain.ErrExportJobFinished) {
+		// The job was failed as abandoned, so its file is not downloaded.
+		if job.Status() == domain.ExportJobStatusCompleted {
+			if err := s.exportFileRepo.DeleteExportFile(context.Background(), job.ID()); err != nil {
+				log.Printf("deleting file of export job %s: %v", job.ID().Hex(), err)
+			}
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("finishing export job %s: %v", job.ID().Hex(), err)
+	}
+}
+
+// renew renews the lease of the job until ctx is done, cancelling the job
+// when it was failed as abandoned meanwhile.
+func (s *CreateExportJobScript) renew(ctx context.Context, cancel context.CancelFunc, id domain.ID) {
+	ticker := time.NewTicker(domain.ExportJobLease / 5)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := s.exportJobRepo.RenewExportJob(ctx, id, Now())
+		if errors.Is(err, domain.ErrExportJobFinished) {
+			cancel()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("renewing export job %s: %v", id.Hex(), err)
+		}
+	}
+}
+
+func (s *CreateExportJobScript) writeFile(ctx context.Context, job *domain.ExportJob, logExport *logExport) (int64, error) {
+	job.Start(Now())
+	if err := s.exportJobRepo.UpdateExportJob(ctx, *job); err != nil {
+		return 0, err
+	}
+
+	file, err := s.exportFileRepo.CreateExportFile(ctx, job.ID(), job.FileName())
+	if err != nil {
+		return 0, err
+	}
+
+	fileSize, err := logExport.write(ctx, s.logRepo, file, func(processed int64) error {
+		job.ChangeProcessed(processed, Now())
+		return s.exportJobRepo.UpdateExportJob(ctx, *job)
+	})
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	return fileSize, file.Close()
+}
@@ -0,0 +1,96 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type CreateLogMetricReq struct {
+	UserID string `json:"-"`
+	Name   string `json:"name"`
+	// Type is count or distribution.
+	Type  string `json:"type"`
+	Query string `json:"query"`
+	// AppIDs limits the metric to some apps, all apps when empty.
+	AppIDs []string `json:"appIds"`
+	// Field is the numeric field of a distribution, like data.duration_ms.
+	Field string `json:"field"`
+	// GroupBy splits the series by up to 3 fields: appId, level or data
+	// fields.
+	GroupBy []string           `json:"groupBy"`
+	Alert   *LogMetricAlertReq `json:"alert"`
+}
+
+type LogMetricAlertReq struct {
+	// Stat is count, sum, avg, min, max, p50, p90, p95 or p99, only count
+	// for a count.
+	Stat string `json:"stat"`
+	// Op is above or below.
+	Op        string  `json:"op"`
+	Threshold float64 `json:"threshold"`
+	// Window is how far back the stat is computed, like 5m or 1h.
+	Window string `json:"window"`
+}
+
+func (r *LogMetricAlertReq) alert() (*domain.LogMetricAlert, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return domain.NewLogMetricAlert(r.Stat, domain.LogMetricAlertOp(r.Op), r.Threshold, r.Window)
+}
+
+type CreateLogMetricResp struct {
+	domain.LogMetric
+}
+
+type CreateLogMetricScript struct {
+	appRepo       domain.AppRepo
+	logMetricRepo domain.LogMetricRepo
+}
+
+func NewCreateLogMetricScript(appRepo domain.AppRepo, logMetricRepo domain.LogMetricRepo) *CreateLogMetricScript {
+	return &CreateLogMetricScript{appRepo: appRepo, logMetricRepo: logMetricRepo}
+}
+
+// Exec defines a metric, which counts the logs received from then on.
+func (s *CreateLogMetricScript) Exec(ctx context.Context, req CreateLogMetricReq) (*CreateLogMetricResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs, err := getUserAppIDs(ctx, s.appRepo, req.UserID, req.AppIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := req.Alert.alert()
+	if err != nil {
+		return nil, err
+	}
+
+	now := Now().UTC()
+	metric, err := domain.NewLogMetric(
+		domain.NewAutoID(),
+		userID,
+		req.Name,
+		domain.LogMetricType(req.Type),
+		req.Query,
+		appIDs,
+		req.Field,
+		req.GroupBy,
+		alert,
+		now,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.logMetricRepo.SaveLogMetric(ctx, *metric); err != nil {
+		return nil, err
+	}
+
+	return &CreateLogMetricResp{LogMetric: *metric}, nil
+}
@@ -0,0 +1,71 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type CreateNotificationChannelReq struct {
+	UserID string `json:"-"`
+	Name   string `json:"name"`
+	// Type is webhook or email.
+	Type   string   `json:"type"`
+	Target string   `json:"target"`
+	Events []string `json:"events"`
+	// AppIDs limits the channel to some apps, all apps when empty.
+	AppIDs []string `json:"appIds"`
+}
+
+type CreateNotificationChannelResp struct {
+	domain.NotificationChannel
+}
+
+type CreateNotificationChannelScript struct {
+	appRepo                 domain.AppRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+}
+
+func NewCreateNotificationChannelScript(appRepo domain.AppRepo, notificationChannelRepo domain.NotificationChannelRepo) *CreateNotificationChannelScript {
+	return &CreateNotificationChannelScript{appRepo: appRepo, notificationChannelRepo: notificationChannelRepo}
+}
+
+func (s *CreateNotificationChannelScript) Exec(ctx context.Context, req CreateNotificationChannelReq) (*CreateNotificationChannelResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs, err := getUserAppIDs(ctx, s.appRepo, req.UserID, req.AppIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := domain.NewNotificationChannel(
+		domain.NewAutoID(),
+		userID,
+		req.Name,
+		domain.NotificationChannelType(req.Type),
+		req.Target,
+		notificationEvents(req.Events),
+		appIDs,
+		Now().UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.notificationChannelRepo.SaveNotificationChannel(ctx, *channel); err != nil {
+		return nil, err
+	}
+
+	return &CreateNotificationChannelResp{NotificationChannel: *channel}, nil
+}
+
+func notificationEvents(events []string) []domain.NotificationEvent {
+	notificationEvents := make([]domain.NotificationEvent, len(events))
+	for i, event := range events {
+		notificationEvents[i] = domain.NotificationEvent(event)
+	}
+	return notificationEvents
+}
@@ -0,0 +1,81 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/export"
+)
+
+type CreateSavedSearchReq struct {
+	UserID string `json:"-"`
+	Name   string `json:"name"`
+	Query  string `json:"query"`
+	// AppIDs limits the search to some apps, all apps when empty.
+	AppIDs []string `json:"appIds"`
+	// TimeRange is relative to when the search runs, like 15m, 24h or 7d,
+	// all time when empty.
+	TimeRange string   `json:"timeRange"`
+	Columns   []string `json:"columns"`
+	// Shared shares the search with all the users of the account.
+	Shared bool `json:"shared"`
+}
+
+type CreateSavedSearchResp struct {
+	domain.SavedSearch
+}
+
+type CreateSavedSearchScript struct {
+	userRepo        domain.UserRepo
+	appRepo         domain.AppRepo
+	savedSearchRepo domain.SavedSearchRepo
+}
+
+func NewCreateSavedSearchScript(
+	userRepo domain.UserRepo,
+	appRepo domain.AppRepo,
+	savedSearchRepo domain.SavedSearchRepo,
+) *CreateSavedSearchScript {
+	return &CreateSavedSearchScript{userRepo: userRepo, appRepo: appRepo, savedSearchRepo: savedSearchRepo}
+}
+
+func (s *CreateSavedSearchScript) Exec(ctx context.Context, req CreateSavedSearchReq) (*CreateSavedSearchResp, error) {
+	user, accountID, err := getUserAccount(ctx, s.userRepo, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs, err := getUserAppIDs(ctx, s.appRepo, req.UserID, req.AppIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := export.ValidateColumns(req.Columns); err != nil {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSavedSearch, err)
+	}
+
+	now := Now().UTC()
+	search, err := domain.NewSavedSearch(
+		domain.NewAutoID(),
+		user.ID(),
+		accountID,
+		req.Name,
+		req.Query,
+		appIDs,
+		req.TimeRange,
+		req.Columns,
+		req.Shared,
+		now,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.savedSearchRepo.SaveSavedSearch(ctx, *search); err != nil {
+		return nil, err
+	}
+
+	return &CreateSavedSearchResp{SavedSearch: *search}, nil
+}
@@ -11,11 +11,12 @@ type DeleteAppReq struct {
 }
 
 type DeleteAppScript struct {
-	appRepo domain.AppRepo
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
 }
 
-func NewDeleteAppScript(appRepo domain.AppRepo) *DeleteAppScript {
-	return &DeleteAppScript{appRepo: appRepo}
+func NewDeleteAppScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *DeleteAppScript {
+	return &DeleteAppScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
 }
 
 func (s *DeleteAppScript) Exec(ctx context.Context, req DeleteAppReq) error {
@@ -24,6 +25,11 @@ func (s *DeleteAppScript) Exec(ctx context.Context, req DeleteAppReq) error {
 		return err
 	}
 
+	err = s.appKeyRepo.DeleteAppKeysByAppID(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	err = s.appRepo.DeleteApp(ctx, id)
 	if err != nil {
 		return err
@@ -0,0 +1,46 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrDeleteExportJobScriptRunning = errors.New("export job is still running")
+)
+
+type DeleteExportJobReq struct {
+	UserID      string `json:"-"`
+	ExportJobID string `json:"-"`
+}
+
+type DeleteExportJobScript struct {
+	exportJobRepo  domain.ExportJobRepo
+	exportFileRepo domain.ExportFileRepo
+}
+
+func NewDeleteExportJobScript(exportJobRepo domain.ExportJobRepo, exportFileRepo domain.ExportFileRepo) *DeleteExportJobScript {
+	return &DeleteExportJobScript{exportJobRepo: exportJobRepo, exportFileRepo: exportFileRepo}
+}
+
+// Exec deletes a finished export job and its file.
+func (s *DeleteExportJobScript) Exec(ctx context.Context, req DeleteExportJobReq) error {
+	job, err := getUserExportJob(ctx, s.exportJobRepo, req.UserID, req.ExportJobID)
+	if err != nil {
+		return err
+	}
+
+	// An abandoned job is no longer written, even if it was not marked as
+	// failed yet.
+	if !job.IsFinished() && !job.IsAbandoned(Now()) {
+		return ErrDeleteExportJobScriptRunning
+	}
+
+	if err := s.exportFileRepo.DeleteExportFile(ctx, job.ID()); err != nil {
+		return err
+	}
+
+	return s.exportJobRepo.DeleteExportJob(ctx, job.ID())
+}
@@ -0,0 +1,48 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type DeleteLogMetricReq struct {
+	UserID   string `json:"-"`
+	MetricID string `json:"-"`
+}
+
+type DeleteLogMetricScript struct {
+	logMetricRepo           domain.LogMetricRepo
+	logMetricRollupRepo     domain.LogMetricRollupRepo
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo
+}
+
+func NewDeleteLogMetricScript(
+	logMetricRepo domain.LogMetricRepo,
+	logMetricRollupRepo domain.LogMetricRollupRepo,
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo,
+) *DeleteLogMetricScript {
+	return &DeleteLogMetricScript{
+		logMetricRepo:           logMetricRepo,
+		logMetricRollupRepo:     logMetricRollupRepo,
+		logMetricAlertStateRepo: logMetricAlertStateRepo,
+	}
+}
+
+// Exec deletes a metric of the user with its series.
+func (s *DeleteLogMetricScript) Exec(ctx context.Context, req DeleteLogMetricReq) error {
+	metric, err := getUserLogMetric(ctx, s.logMetricRepo, req.UserID, req.MetricID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.logMetricRepo.DeleteLogMetric(ctx, metric.ID()); err != nil {
+		return err
+	}
+
+	if err := s.logMetricRollupRepo.DeleteRollups(ctx, metric.ID()); err != nil {
+		return err
+	}
+
+	return s.logMetricAlertStateRepo.DeleteAlertStates(ctx, metric.ID())
+}
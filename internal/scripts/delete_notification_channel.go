@@ -0,0 +1,29 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type DeleteNotificationChannelReq struct {
+	UserID    string `json:"-"`
+	ChannelID string `json:"-"`
+}
+
+type DeleteNotificationChannelScript struct {
+	notificationChannelRepo domain.NotificationChannelRepo
+}
+
+func NewDeleteNotificationChannelScript(notificationChannelRepo domain.NotificationChannelRepo) *DeleteNotificationChannelScript {
+	return &DeleteNotificationChannelScript{notificationChannelRepo: notificationChannelRepo}
+}
+
+func (s *DeleteNotificationChannelScript) Exec(ctx context.Context, req DeleteNotificationChannelReq) error {
+	channel, err := getUserNotificationChannel(ctx, s.notificationChannelRepo, req.UserID, req.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	return s.notificationChannelRepo.DeleteNotificationChannel(ctx, channel.ID())
+}
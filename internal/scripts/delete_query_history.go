@@ -0,0 +1,51 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+type DeleteQueryHistoryReq struct {
+	UserID string `json:"-"`
+	// EntryID is the entry to delete, the whole history of the user when
+	// empty.
+	EntryID string `json:"-"`
+}
+
+type DeleteQueryHistoryScript struct {
+	queryHistoryRepo domain.QueryHistoryRepo
+}
+
+func NewDeleteQueryHistoryScript(queryHistoryRepo domain.QueryHistoryRepo) *DeleteQueryHistoryScript {
+	return &DeleteQueryHistoryScript{queryHistoryRepo: queryHistoryRepo}
+}
+
+func (s *DeleteQueryHistoryScript) Exec(ctx context.Context, req DeleteQueryHistoryReq) error {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(req.EntryID) == "" {
+		return s.queryHistoryRepo.DeleteQueryHistoryByUserID(ctx, userID)
+	}
+
+	id, err := domain.NewID(req.EntryID)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.queryHistoryRepo.GetQueryHistoryEntryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if entry.UserID() != userID {
+		return fmt.Errorf("query history entry with ID %s does not exist for the user", req.EntryID)
+	}
+
+	return s.queryHistoryRepo.DeleteQueryHistoryEntry(ctx, entry.ID())
+}
@@ -0,0 +1,29 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type DeleteSavedSearchReq struct {
+	UserID   string `json:"-"`
+	SearchID string `json:"-"`
+}
+
+type DeleteSavedSearchScript struct {
+	savedSearchRepo domain.SavedSearchRepo
+}
+
+func NewDeleteSavedSearchScript(savedSearchRepo domain.SavedSearchRepo) *DeleteSavedSearchScript {
+	return &DeleteSavedSearchScript{savedSearchRepo: savedSearchRepo}
+}
+
+func (s *DeleteSavedSearchScript) Exec(ctx context.Context, req DeleteSavedSearchReq) error {
+	search, err := getUserSavedSearch(ctx, s.savedSearchRepo, req.UserID, req.SearchID)
+	if err != nil {
+		return err
+	}
+
+	return s.savedSearchRepo.DeleteSavedSearch(ctx, search.ID())
+}
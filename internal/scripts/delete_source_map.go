@@ -0,0 +1,46 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+
+	"monitoring/internal/domain"
+)
+
+type DeleteSourceMapReq struct {
+	UserID      string `json:"-"`
+	AppID       string `json:"-"`
+	SourceMapID string `json:"-"`
+}
+
+type DeleteSourceMapScript struct {
+	appRepo       domain.AppRepo
+	sourceMapRepo domain.SourceMapRepo
+}
+
+func NewDeleteSourceMapScript(appRepo domain.AppRepo, sourceMapRepo domain.SourceMapRepo) *DeleteSourceMapScript {
+	return &DeleteSourceMapScript{appRepo: appRepo, sourceMapRepo: sourceMapRepo}
+}
+
+func (s *DeleteSourceMapScript) Exec(ctx context.Context, req DeleteSourceMapReq) error {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return err
+	}
+
+	id, err := domain.NewID(req.SourceMapID)
+	if err != nil {
+		return err
+	}
+
+	sourceMap, err := s.sourceMapRepo.GetSourceMapByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if sourceMap.AppID() != app.ID() {
+		return fmt.Errorf("source map with ID %s does not exist for the app", req.SourceMapID)
+	}
+
+	return s.sourceMapRepo.DeleteSourceMap(ctx, id)
+}
@@ -0,0 +1,203 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+// schemaFieldStoppedAfter is how long an app has to send logs without a
+// field for the field to be stopped.
+const schemaFieldStoppedAfter = 24 * time.Hour
+
+type DetectSchemaDriftReq struct {
+	App domain.App
+	// Schema is the schema of the logs ingested, not yet in the catalog.
+	Schema domain.LogSchema
+}
+
+type DetectSchemaDriftResp struct {
+	// Events are the changes detected for the first time.
+	Events []domain.SchemaDriftEvent
+}
+
+type DetectSchemaDriftScript struct {
+	logSchemaRepo           domain.LogSchemaRepo
+	schemaDriftEventRepo    domain.SchemaDriftEventRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+	notifier                domain.Notifier
+}
+
+func NewDetectSchemaDriftScript(
+	logSchemaRepo domain.LogSchemaRepo,
+	schemaDriftEventRepo domain.SchemaDriftEventRepo,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	notifier domain.Notifier,
+) *DetectSchemaDriftScript {
+	return &DetectSchemaDriftScript{
+		logSchemaRepo:           logSchemaRepo,
+		schemaDriftEventRepo:    schemaDriftEventRepo,
+		notificationChannelRepo: notificationChannelRepo,
+		notifier:                notifier,
+	}
+}
+
+// Exec compares the schema of the logs ingested with the catalog of the app,
+// before they are added to it, and records the fields added, the new types
+// of the fields and the fields not sent since schemaFieldStoppedAfter. The
+// channels of the user subscribed to the drift of the app are notified
+// before it returns.
+func (s *DetectSchemaDriftScript) Exec(ctx context.Context, req DetectSchemaDriftReq) (*DetectSchemaDriftResp, error) {
+	resp := &DetectSchemaDriftResp{Events: []domain.SchemaDriftEvent{}}
+	if req.Schema.Total == 0 {
+		return resp, nil
+	}
+
+	paths := make([]string, len(req.Schema.Fields))
+	for i, field := range req.Schema.Fields {
+		paths[i] = field.Path
+	}
+
+	catalog, err := s.logSchemaRepo.GetFields(ctx, req.App.ID(), paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first logs of an app have nothing to be compared with, nor do the
+	// logs ingested while the catalog is rebuilt, which misses fields.
+	if catalog.Total == 0 || catalog.Rebuilding {
+		return resp, nil
+	}
+
+	known := make(map[string]domain.LogSchemaField, len(catalog.Fields))
+	for _, field := range catalog.Fields {
+		known[field.Path] = field
+	}
+
+	detectedAt := Now().UTC()
+	events := []*domain.SchemaDriftEvent{}
+	newEvent := func(kind domain.SchemaDriftKind, path string, previousTypes []string, valueType string, occurredAt time.Time) error {
+		event, err := domain.NewSchemaDriftEvent(
+			domain.NewAutoID(),
+			req.App.ID(),
+			req.App.UserID(),
+			kind,
+			path,
+			previousTypes,
+			valueType,
+			occurredAt,
+			detectedAt,
+		)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+		return nil
+	}
+
+	for _, field := range req.Schema.Fields {
+		previous, ok := known[field.Path]
+		if !ok {
+			// Objects are left out, their fields are added with them.
+			if field.IsOnlyObject() {
+				continue
+			}
+			if err := newEvent(domain.SchemaFieldAdded, field.Path, nil, "", field.FirstSeen); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		previousTypes := schemaFieldTypes(previous)
+		for _, valueType := range slices.Sorted(maps.Keys(field.Types)) {
+			// A field sent without a value does not change its type.
+			if valueType == "null" || previous.Types[valueType] > 0 {
+				continue
+			}
+			if err := newEvent(domain.SchemaTypeChanged, field.Path, previousTypes, valueType, field.FirstSeen); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// A field is stopped when the logs of the app move past its deadline, so
+	// each batch only looks at the fields whose deadline fell between the
+	// latest log of the catalog and the latest log ingested.
+	if req.Schema.LastSeen.After(catalog.LastSeen) {
+		stopped, err := s.logSchemaRepo.ListFieldsLastSeen(ctx, req.App.ID(), domain.Range{
+			From: catalog.LastSeen.Add(-schemaFieldStoppedAfter),
+			To:   req.Schema.LastSeen.Add(-schemaFieldStoppedAfter),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		slices.SortFunc(stopped, func(a, b domain.LogSchemaField) int {
+			return strings.Compare(a.Path, b.Path)
+		})
+
+		ingested := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			ingested[path] = true
+		}
+
+		for _, field := range stopped {
+			if ingested[field.Path] || field.IsOnlyObject() {
+				continue
+			}
+			if err := newEvent(domain.SchemaFieldStopped, field.Path, schemaFieldTypes(field), "", field.LastSeen); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, event := range events {
+		err := s.schemaDriftEventRepo.SaveSchemaDriftEvent(ctx, *event)
+		if errors.Is(err, domain.ErrSchemaDriftEventAlreadyDetected) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		resp.Events = append(resp.Events, *event)
+	}
+
+	if len(resp.Events) > 0 {
+		notifyChannels(ctx, s.notificationChannelRepo, s.notifier, req.App.UserID(), schemaDriftNotification(req.App, resp.Events))
+	}
+
+	return resp, nil
+}
+
+// schemaFieldTypes are the types the field had, sorted.
+func schemaFieldTypes(field domain.LogSchemaField) []string {
+	types := []string{}
+	for valueType, count := range field.Types {
+		if count > 0 {
+			types = append(types, valueType)
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+func schemaDriftNotification(app domain.App, events []domain.SchemaDriftEvent) domain.Notification {
+	lines := make([]string, len(events))
+	for i, event := range events {
+		lines[i] = "- " + event.Describe()
+	}
+
+	return domain.Notification{
+		Event:   domain.NotificationSchemaDrift,
+		AppID:   app.ID(),
+		Subject: fmt.Sprintf("Schema drift in %s", app.Name()),
+		Text:    fmt.Sprintf("The fields of the logs of %s changed:\n%s", app.Name(), strings.Join(lines, "\n")),
+		Data:    events,
+	}
+}
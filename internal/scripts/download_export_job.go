@@ -0,0 +1,59 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/export"
+)
+
+var (
+	ErrDownloadExportJobScriptNotCompleted = errors.New("export job is not completed")
+)
+
+type DownloadExportJobReq struct {
+	UserID      string `json:"-"`
+	ExportJobID string `json:"-"`
+}
+
+// DownloadExportJobResp holds the file of the job, which must be closed.
+type DownloadExportJobResp struct {
+	File        io.ReadCloser
+	FileName    string
+	FileSize    int64
+	ContentType string
+}
+
+type DownloadExportJobScript struct {
+	exportJobRepo  domain.ExportJobRepo
+	exportFileRepo domain.ExportFileRepo
+}
+
+func NewDownloadExportJobScript(exportJobRepo domain.ExportJobRepo, exportFileRepo domain.ExportFileRepo) *DownloadExportJobScript {
+	return &DownloadExportJobScript{exportJobRepo: exportJobRepo, exportFileRepo: exportFileRepo}
+}
+
+func (s *DownloadExportJobScript) Exec(ctx context.Context, req DownloadExportJobReq) (*DownloadExportJobResp, error) {
+	job, err := getUserExportJob(ctx, s.exportJobRepo, req.UserID, req.ExportJobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status() != domain.ExportJobStatusCompleted {
+		return nil, ErrDownloadExportJobScriptNotCompleted
+	}
+
+	file, size, err := s.exportFileRepo.OpenExportFile(ctx, job.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return &DownloadExportJobResp{
+		File:        file,
+		FileName:    job.FileName(),
+		FileSize:    size,
+		ContentType: export.Format(job.Format()).ContentType(),
+	}, nil
+}
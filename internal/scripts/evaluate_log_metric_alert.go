@@ -0,0 +1,227 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+// LogMetricAlertEvaluationInterval is how often the alerts of the metrics
+// are evaluated.
+const LogMetricAlertEvaluationInterval = time.Minute
+
+type EvaluateLogMetricAlertReq struct {
+	Metric domain.LogMetric
+}
+
+type EvaluateLogMetricAlertResp struct {
+	// Evaluated is false when the alert was evaluated less than half
+	// LogMetricAlertEvaluationInterval ago, by another server.
+	Evaluated bool
+	// Changes are the groups whose alert fired or resolved.
+	Changes []domain.LogMetricAlertState
+}
+
+type EvaluateLogMetricAlertScript struct {
+	logMetricRepo           domain.LogMetricRepo
+	logMetricRollupRepo     domain.LogMetricRollupRepo
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+	notifier                domain.Notifier
+}
+
+func NewEvaluateLogMetricAlertScript(
+	logMetricRepo domain.LogMetricRepo,
+	logMetricRollupRepo domain.LogMetricRollupRepo,
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	notifier domain.Notifier,
+) *EvaluateLogMetricAlertScript {
+	return &EvaluateLogMetricAlertScript{
+		logMetricRepo:           logMetricRepo,
+		logMetricRollupRepo:     logMetricRollupRepo,
+		logMetricAlertStateRepo: logMetricAlertStateRepo,
+		notificationChannelRepo: notificationChannelRepo,
+		notifier:                notifier,
+	}
+}
+
+// Exec computes the stat of the alert of the metric over its window for each
+// group, and records the groups whose alert fires or resolves. The groups
+// with logs in the previous window are evaluated too, as are the firing
+// ones, so an alert on fewer logs fires when they stop and one on more logs
+// resolves. The channels of the user subscribed to the alerts of the metrics
+// are notified before it returns.
+func (s *EvaluateLogMetricAlertScript) Exec(ctx context.Context, req EvaluateLogMetricAlertReq) (*EvaluateLogMetricAlertResp, error) {
+	metric := req.Metric
+	alert := metric.Alert()
+	resp := &EvaluateLogMetricAlertResp{Changes: []domain.LogMetricAlertState{}}
+	if alert == nil {
+		return resp, nil
+	}
+
+	// Each server evaluates the alerts every interval, the claim keeping
+	// them from evaluating one twice at once. It lasts half the interval so
+	// a tick slightly early does not skip it.
+	now := Now().UTC()
+	claimed, err := s.logMetricRepo.ClaimAlertEvaluation(ctx, metric.ID(), now, now.Add(-LogMetricAlertEvaluationInterval/2))
+	if err != nil || !claimed {
+		return resp, err
+	}
+	resp.Evaluated = true
+
+	// The rollups are merged by group over the window, which the longest
+	// interval splits in two at most.
+	start := now.Add(-alert.Window).Truncate(domain.LogMetricResolution)
+	rollups, err := s.logMetricRollupRepo.ListRollups(ctx, metric.ID(), domain.Range{
+		From: start,
+		To:   now.Add(domain.LogMetricResolution),
+	}, domain.MaxInterval, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string]*domain.LogMetricRollup{}
+	for _, rollup := range rollups {
+		key := domain.LogMetricGroupKey(rollup.Keys)
+		if group, ok := groups[key]; ok {
+			group.Merge(rollup.Distribution)
+			continue
+		}
+		groups[key] = &rollup
+	}
+
+	// The groups that had logs in the previous window and none in this one
+	// are evaluated with none, as is a metric without groups.
+	previous, err := s.logMetricRollupRepo.ListRollups(ctx, metric.ID(), domain.Range{
+		From: start.Add(-alert.Window),
+		To:   start,
+	}, domain.MaxInterval, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	for _, rollup := range previous {
+		key := domain.LogMetricGroupKey(rollup.Keys)
+		if _, ok := groups[key]; !ok {
+			groups[key] = &domain.LogMetricRollup{Keys: rollup.Keys}
+		}
+	}
+	if len(metric.GroupBy()) == 0 && len(groups) == 0 {
+		keys := []string{}
+		groups[domain.LogMetricGroupKey(keys)] = &domain.LogMetricRollup{Keys: keys}
+	}
+
+	states, err := s.logMetricAlertStateRepo.ListAlertStates(ctx, metric.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	// The groups that fire are evaluated even without logs in the window, to
+	// resolve them.
+	firing := map[string]domain.LogMetricAlertState{}
+	for _, state := range states {
+		if !state.Firing {
+			continue
+		}
+		key := domain.LogMetricGroupKey(state.Keys)
+		firing[key] = state
+		if _, ok := groups[key]; !ok {
+			groups[key] = &domain.LogMetricRollup{Keys: state.Keys}
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(groups)) {
+		group := groups[key]
+		value, ok := group.Stat(alert.Stat)
+		exceeded := ok && alert.Exceeded(value)
+		if _, wasFiring := firing[key]; exceeded == wasFiring {
+			continue
+		}
+
+		state := domain.LogMetricAlertState{
+			MetricID:  metric.ID(),
+			Keys:      group.Keys,
+			Firing:    exceeded,
+			Value:     value,
+			ChangedAt: now,
+		}
+		if err := s.logMetricAlertStateRepo.SaveAlertState(ctx, state); err != nil {
+			return nil, err
+		}
+		resp.Changes = append(resp.Changes, state)
+	}
+
+	for appID, changes := range logMetricAlertChangesByApp(metric, resp.Changes) {
+		notifyChannels(ctx, s.notificationChannelRepo, s.notifier, metric.UserID(), logMetricAlertNotification(metric, appID, changes))
+	}
+
+	return resp, nil
+}
+
+// logMetricAlertChangesByApp splits the changes by the app they are of, so
+// channels limited to some apps get those of their apps: the app of the
+// group when the metric is grouped by app, else the only app of the metric.
+// The changes of metrics of several apps are of no app.
+func logMetricAlertChangesByApp(metric domain.LogMetric, changes []domain.LogMetricAlertState) map[domain.ID][]domain.LogMetricAlertState {
+	appID := domain.ID{}
+	if len(metric.AppIDs()) == 1 {
+		appID = metric.AppIDs()[0]
+	}
+	appGroup := slices.Index(metric.GroupBy(), "appId")
+
+	byApp := map[domain.ID][]domain.LogMetricAlertState{}
+	for _, change := range changes {
+		changeAppID := appID
+		if appGroup >= 0 && appGroup < len(change.Keys) {
+			if id, err := domain.NewID(change.Keys[appGroup]); err == nil {
+				changeAppID = id
+			}
+		}
+		byApp[changeAppID] = append(byApp[changeAppID], change)
+	}
+	return byApp
+}
+
+func logMetricAlertNotification(metric domain.LogMetric, appID domain.ID, changes []domain.LogMetricAlertState) domain.Notification {
+	alert := metric.Alert()
+	lines := make([]string, len(changes))
+	fired := 0
+	for i, change := range changes {
+		subject := alert.Stat
+		if len(change.Keys) > 0 && len(change.Keys) == len(metric.GroupBy()) {
+			groups := make([]string, len(change.Keys))
+			for j, key := range change.Keys {
+				groups[j] = metric.GroupBy()[j] + "=" + key
+			}
+			subject += " of " + strings.Join(groups, ", ")
+		}
+
+		value := strconv.FormatFloat(change.Value, 'g', -1, 64)
+		threshold := strconv.FormatFloat(alert.Threshold, 'g', -1, 64)
+		if change.Firing {
+			fired++
+			lines[i] = fmt.Sprintf("- firing: %s is %s, %s %s over the last %s", subject, value, alert.Op, threshold, domain.FormatLength(alert.Window))
+		} else {
+			lines[i] = fmt.Sprintf("- resolved: %s is no longer %s %s", subject, alert.Op, threshold)
+		}
+	}
+
+	title := fmt.Sprintf("Log metric %s resolved", metric.Name())
+	if fired > 0 {
+		title = fmt.Sprintf("Log metric %s alert", metric.Name())
+	}
+
+	return domain.Notification{
+		Event:   domain.NotificationLogMetricAlert,
+		AppID:   appID,
+		Subject: title,
+		Text:    fmt.Sprintf("The alert of the log metric %s changed:\n%s", metric.Name(), strings.Join(lines, "\n")),
+		Data:    map[string]any{"metric": metric, "changes": changes},
+	}
+}
@@ -0,0 +1,82 @@
+package scripts
+
+import (
+	"context"
+	"log"
+
+	"monitoring/internal/domain"
+)
+
+type EvaluateLogMetricAlertsReq struct{}
+
+type EvaluateLogMetricAlertsResp struct {
+	// Evaluated is how many alerts were evaluated, those claimed by other
+	// servers left out.
+	Evaluated int
+	// Changes are the groups whose alert fired or resolved.
+	Changes []domain.LogMetricAlertState
+}
+
+type EvaluateLogMetricAlertsScript struct {
+	logMetricRepo           domain.LogMetricRepo
+	logMetricRollupRepo     domain.LogMetricRollupRepo
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+	notifier                domain.Notifier
+}
+
+func NewEvaluateLogMetricAlertsScript(
+	logMetricRepo domain.LogMetricRepo,
+	logMetricRollupRepo domain.LogMetricRollupRepo,
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	notifier domain.Notifier,
+) *EvaluateLogMetricAlertsScript {
+	return &EvaluateLogMetricAlertsScript{
+		logMetricRepo:           logMetricRepo,
+		logMetricRollupRepo:     logMetricRollupRepo,
+		logMetricAlertStateRepo: logMetricAlertStateRepo,
+		notificationChannelRepo: notificationChannelRepo,
+		notifier:                notifier,
+	}
+}
+
+// Exec evaluates the alerts of all the metrics, whether they counted logs
+// lately or not. It runs every LogMetricAlertEvaluationInterval, and an alert
+// that fails to evaluate does not keep the others from being evaluated.
+func (s *EvaluateLogMetricAlertsScript) Exec(ctx context.Context, req EvaluateLogMetricAlertsReq) (*EvaluateLogMetricAlertsResp, error) {
+	metrics, err := s.logMetricRepo.ListLogMetrics(ctx, domain.NewCriteria(
+		[]domain.Filter{domain.NewFilter("alert", domain.NotEquals, nil)},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	script := NewEvaluateLogMetricAlertScript(
+		s.logMetricRepo,
+		s.logMetricRollupRepo,
+		s.logMetricAlertStateRepo,
+		s.notificationChannelRepo,
+		s.notifier,
+	)
+	resp := &EvaluateLogMetricAlertsResp{Changes: []domain.LogMetricAlertState{}}
+	for _, metric := range metrics {
+		if ctx.Err() != nil {
+			return resp, ctx.Err()
+		}
+
+		evaluation, err := script.Exec(ctx, EvaluateLogMetricAlertReq{Metric: metric})
+		if err != nil {
+			log.Printf("evaluating the alert of log metric %s: %v", metric.ID().Hex(), err)
+			continue
+		}
+		if evaluation.Evaluated {
+			resp.Evaluated++
+		}
+		resp.Changes = append(resp.Changes, evaluation.Changes...)
+	}
+
+	return resp, nil
+}
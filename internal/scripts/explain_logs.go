@@ -0,0 +1,118 @@
+package scripts
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+// explainScanThreshold is the most logs counted for the estimate of a search
+// when there is no limit to compare it with.
+const explainScanThreshold = 1000000
+
+// ExplainLogsResp is how a search would run, within which limits.
+type ExplainLogsResp struct {
+	// From is where the search starts, set from the limits when it was not
+	// given.
+	From   *time.Time         `json:"from"`
+	To     *time.Time         `json:"to"`
+	Limits domain.QueryLimits `json:"limits"`
+	// Plan is the winning plan of the database to list the logs.
+	Plan           any      `json:"plan"`
+	Indexes        []string `json:"indexes"`
+	CollectionScan bool     `json:"collectionScan"`
+	// Scans is set when the search term or the query cannot be answered
+	// from an index, so every log of the apps in the time range is read.
+	Scans bool `json:"scans"`
+	// EstimatedScan is how many logs of the apps are in the time range, nil
+	// when they could not be counted in time. It is a lower bound when
+	// EstimateExact is false.
+	EstimatedScan *int64 `json:"estimatedScan"`
+	EstimateExact bool   `json:"estimateExact"`
+	// Errors are the limits the search exceeds, it runs when there are none.
+	Errors []string `json:"errors"`
+}
+
+type ExplainLogsScript struct {
+	search  *SearchLogsScript
+	logRepo domain.LogRepo
+}
+
+func NewExplainLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, guard *QueryGuard) *ExplainLogsScript {
+	return &ExplainLogsScript{search: NewSearchLogsScript(appRepo, logRepo, guard), logRepo: logRepo}
+}
+
+// Exec explains a search without running it. Invalid searches are errors,
+// while exceeded limits are reported.
+func (s *ExplainLogsScript) Exec(ctx context.Context, req SearchLogsReq) (*ExplainLogsResp, error) {
+	limits, err := s.search.guard.Limits(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	req = limitedRange(req, limits)
+	search, err := s.search.build(ctx, req, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ExplainLogsResp{Limits: limits, Scans: search.scans, Errors: []string{}}
+	if !req.From.IsZero() {
+		from := req.From.UTC()
+		resp.From = &from
+	}
+	if !req.To.IsZero() {
+		to := req.To.UTC()
+		resp.To = &to
+	}
+
+	if err := search.checkRange(req.From, req.To); err != nil {
+		resp.Errors = append(resp.Errors, err.Error())
+	}
+
+	order := domain.Asc
+	if domain.SortOrder(req.SortOrder) == domain.Desc {
+		order = domain.Desc
+	}
+	sort := domain.NewSort("timestamp", order).ThenBy("_id", order)
+	if search.textSearch {
+		sort = domain.NewSort(domain.SortByRelevance, domain.Desc).ThenBy("timestamp", order).ThenBy("_id", order)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	plan, err := s.logRepo.ExplainLogs(ctx, search.criteria(domain.NewPagination(limit+1, max(req.Page-1, 0)*limit), sort))
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Plan = plan.Plan
+	resp.Indexes = plan.Indexes
+	resp.CollectionScan = plan.CollectionScan
+
+	threshold := int64(explainScanThreshold)
+	if limits.MaxScan > 0 {
+		threshold = limits.MaxScan + 1
+	}
+	count, err := s.search.estimateScan(ctx, search, threshold)
+	if err != nil && !mongo.IsTimeout(err) {
+		return nil, err
+	}
+	if err == nil {
+		resp.EstimatedScan = &count
+		resp.EstimateExact = count < threshold
+		if search.scans && limits.MaxScan > 0 && count > limits.MaxScan {
+			resp.Errors = append(resp.Errors, scanLimitError(limits).Error())
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,250 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/export"
+)
+
+var (
+	ErrExportLogsScriptInvalidExport = errors.New("invalid export")
+	ErrExportLogsScriptTooManyLogs   = errors.New("too many logs to export")
+)
+
+const (
+	// maxExportLogs is the most logs downloaded directly, larger exports
+	// are written by an export job.
+	maxExportLogs = 100000
+	// maxExportDataColumns is the most data fields exported by default.
+	maxExportDataColumns = 100
+)
+
+type ExportLogsReq struct {
+	SearchLogsReq
+	Format string `form:"format"`
+	// Columns are the log fields written to csv and parquet files, like
+	// level or data.request.path. By default, the log fields and the data
+	// fields found in the logs of the search.
+	Columns []string `form:"columns"`
+}
+
+// ExportLogsResp streams the file of the export when written to a writer.
+type ExportLogsResp struct {
+	Format   export.Format
+	FileName string
+
+	ctx     context.Context
+	export  *logExport
+	logRepo domain.LogRepo
+}
+
+func (r *ExportLogsResp) WriteTo(w io.Writer) (int64, error) {
+	return r.export.write(r.ctx, r.logRepo, w, nil)
+}
+
+type ExportLogsScript struct {
+	search        *SearchLogsScript
+	logRepo       domain.LogRepo
+	logSchemaRepo domain.LogSchemaRepo
+}
+
+func NewExportLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, logSchemaRepo domain.LogSchemaRepo, guard *QueryGuard) *ExportLogsScript {
+	return &ExportLogsScript{
+		search:        NewSearchLogsScript(appRepo, logRepo, guard),
+		logRepo:       logRepo,
+		logSchemaRepo: logSchemaRepo,
+	}
+}
+
+// Exec prepares the export of the logs of a search. The logs are read while
+// the response is written, so the response must be written before ctx is
+// done.
+func (s *ExportLogsScript) Exec(ctx context.Context, req ExportLogsReq) (*ExportLogsResp, error) {
+	logExport, err := prepareLogExport(ctx, s.search, s.logSchemaRepo, req.SearchLogsReq, req.Format, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.logRepo.CountLogs(ctx, logExport.criteria, maxExportLogs+1)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+	if count > maxExportLogs {
+		return nil, fmt.Errorf("%w: more than %d logs match the search, create an export job instead", ErrExportLogsScriptTooManyLogs, maxExportLogs)
+	}
+
+	return &ExportLogsResp{
+		Format:   logExport.format,
+		FileName: fmt.Sprintf("logs-%s.%s", Now().UTC().Format("20060102T150405Z"), logExport.format.Extension()),
+		ctx:      ctx,
+		export:   logExport,
+		logRepo:  s.logRepo,
+	}, nil
+}
+
+// logExport is what an export writes: the logs of a search, oldest first
+// unless sorted descending, in a format.
+type logExport struct {
+	format   export.Format
+	columns  []string
+	criteria domain.Criteria
+}
+
+func prepareLogExport(
+	ctx context.Context,
+	search *SearchLogsScript,
+	logSchemaRepo domain.LogSchemaRepo,
+	req SearchLogsReq,
+	format string,
+	columns []string,
+) (*logExport, error) {
+	exportFormat, err := export.ParseFormat(format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExportLogsScriptInvalidExport, err)
+	}
+
+	if err := export.ValidateColumns(columns); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExportLogsScriptInvalidExport, err)
+	}
+
+	logSearch, err := search.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := search.checkScan(ctx, logSearch); err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 && exportFormat != export.NDJSON {
+		columns, err = defaultExportColumns(ctx, logSchemaRepo, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	order := domain.Asc
+	if domain.SortOrder(req.SortOrder) == domain.Desc {
+		order = domain.Desc
+	}
+
+	return &logExport{
+		format:  exportFormat,
+		columns: columns,
+		criteria: logSearch.criteria(
+			domain.EmptyPagination,
+			domain.NewSort("timestamp", order).ThenBy("_id", order),
+		),
+	}, nil
+}
+
+// defaultExportColumns returns the log fields and the most common data
+// fields of the logs of the search.
+func defaultExportColumns(ctx context.Context, logSchemaRepo domain.LogSchemaRepo, req SearchLogsReq) ([]string, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var appIDs []domain.ID
+	if strings.TrimSpace(req.AppID) != "" {
+		appID, err := domain.NewID(req.AppID)
+		if err != nil {
+			return nil, err
+		}
+		appIDs = []domain.ID{appID}
+	}
+
+	var dateRange *domain.Range
+	if !req.From.IsZero() && !req.To.IsZero() {
+		dateRange = &domain.Range{From: req.From.UTC(), To: req.To.UTC()}
+	}
+
+	schema, err := logSchemaRepo.Get(ctx, userID, appIDs, dateRange)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(schema.Schema))
+	for field := range schema.Schema {
+		fields = append(fields, field)
+	}
+	slices.SortFunc(fields, func(a, b string) int {
+		return cmp.Or(cmp.Compare(schema.Schema[b], schema.Schema[a]), cmp.Compare(a, b))
+	})
+	if len(fields) > maxExportDataColumns {
+		fields = fields[:maxExportDataColumns]
+	}
+	slices.Sort(fields)
+
+	columns := slices.Clone(export.DefaultColumns)
+	for _, field := range fields {
+		columns = append(columns, "data."+field)
+	}
+	return columns, nil
+}
+
+// write writes the logs to w and returns the bytes written. progress, when
+// given, is called with the logs written so far every exportProgressEvery
+// logs.
+func (e *logExport) write(ctx context.Context, logRepo domain.LogRepo, w io.Writer, progress func(int64) error) (int64, error) {
+	counter := &countingWriter{writer: w}
+	writer, err := export.NewWriter(e.format, counter, e.columns)
+	if err != nil {
+		return counter.count, err
+	}
+
+	// The time limit of MongoDB covers the whole cursor, which is read for as
+	// long as the file is written, so exports are bounded by their number of
+	// logs and the scan limit instead.
+	criteria := e.criteria
+	criteria.MaxTime = 0
+
+	var processed int64
+	err = logRepo.StreamLogs(ctx, criteria, func(log domain.Log) error {
+		if err := writer.Write(log); err != nil {
+			return err
+		}
+
+		processed++
+		if progress != nil && processed%exportProgressEvery == 0 {
+			return progress(processed)
+		}
+		return nil
+	})
+	if err != nil {
+		return counter.count, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return counter.count, err
+	}
+
+	if progress != nil {
+		err = progress(processed)
+	}
+	return counter.count, err
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
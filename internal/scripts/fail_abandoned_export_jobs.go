@@ -0,0 +1,75 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrFailAbandonedExportJobsScriptInterrupted = errors.New("export was interrupted, the server writing it stopped")
+)
+
+type FailAbandonedExportJobsReq struct{}
+
+type FailAbandonedExportJobsResp struct {
+	// Failed is how many abandoned jobs were marked as failed.
+	Failed int
+}
+
+type FailAbandonedExportJobsScript struct {
+	exportJobRepo  domain.ExportJobRepo
+	exportFileRepo domain.ExportFileRepo
+}
+
+func NewFailAbandonedExportJobsScript(exportJobRepo domain.ExportJobRepo, exportFileRepo domain.ExportFileRepo) *FailAbandonedExportJobsScript {
+	return &FailAbandonedExportJobsScript{exportJobRepo: exportJobRepo, exportFileRepo: exportFileRepo}
+}
+
+// Exec marks the jobs whose lease expired as failed, since the server writing
+// them stopped. It runs every ExportJobLease on every server, and a job that
+// fails to update does not keep the others from being marked.
+func (s *FailAbandonedExportJobsScript) Exec(ctx context.Context, req FailAbandonedExportJobsReq) (*FailAbandonedExportJobsResp, error) {
+	before := Now().Add(-domain.ExportJobLease)
+	jobs, err := s.exportJobRepo.ListExportJobs(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("status", domain.In, []domain.ExportJobStatus{domain.ExportJobStatusPending, domain.ExportJobStatusRunning}),
+			domain.NewOrFilter(
+				domain.NewFilter("updatedAt", domain.LessThan, before),
+				domain.NewAndFilter(
+					domain.NewFilter("updatedAt", domain.Exists, false),
+					domain.NewFilter("createdAt", domain.LessThan, before),
+				),
+			),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &FailAbandonedExportJobsResp{}
+	for _, job := range jobs {
+		job.Fail(ErrFailAbandonedExportJobsScriptInterrupted, Now())
+		// The job is only failed if its lease is still expired, a server
+		// may have renewed it since it was listed.
+		failed, err := s.exportJobRepo.FailAbandonedExportJob(ctx, job, before)
+		if err != nil {
+			log.Printf("failing export job %s: %v", job.ID().Hex(), err)
+			continue
+		}
+		if !failed {
+			continue
+		}
+		resp.Failed++
+
+		if err := s.exportFileRepo.DeleteExportFile(ctx, job.ID()); err != nil {
+			log.Printf("deleting file of export job %s: %v", job.ID().Hex(), err)
+		}
+	}
+
+	return resp, nil
+}
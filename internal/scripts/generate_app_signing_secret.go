@@ -0,0 +1,51 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type GenerateAppSigningSecretReq struct {
+	UserID string `json:"-"`
+	AppID  string `json:"-"`
+}
+
+type GenerateAppSigningSecretResp struct {
+	App           domain.App `json:"app"`
+	SigningSecret string     `json:"signingSecret"`
+}
+
+type GenerateAppSigningSecretScript struct {
+	appRepo domain.AppRepo
+}
+
+func NewGenerateAppSigningSecretScript(appRepo domain.AppRepo) *GenerateAppSigningSecretScript {
+	return &GenerateAppSigningSecretScript{appRepo: appRepo}
+}
+
+// Exec replaces the signing secret of the app. Requests signed with the
+// previous secret stop being accepted right away.
+func (s *GenerateAppSigningSecretScript) Exec(ctx context.Context, req GenerateAppSigningSecretReq) (*GenerateAppSigningSecretResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSigningSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = app.ChangeSigningSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appRepo.UpdateApp(ctx, *app)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerateAppSigningSecretResp{App: *app, SigningSecret: secret}, nil
+}
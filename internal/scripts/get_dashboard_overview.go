@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"time"
 
+	"go.mongodb.org/mongo-driver/mongo"
+
 	"monitoring/internal/domain"
 )
 
@@ -35,10 +37,11 @@ type GetDashboardOverviewResp struct {
 
 type GetDashboardOverviewScript struct {
 	dashboardRepo domain.DashboardRepo
+	guard         *QueryGuard
 }
 
-func NewGetDashboardOverviewScript(dashboardRepo domain.DashboardRepo) *GetDashboardOverviewScript {
-	return &GetDashboardOverviewScript{dashboardRepo: dashboardRepo}
+func NewGetDashboardOverviewScript(dashboardRepo domain.DashboardRepo, guard *QueryGuard) *GetDashboardOverviewScript {
+	return &GetDashboardOverviewScript{dashboardRepo: dashboardRepo, guard: guard}
 }
 
 func (s *GetDashboardOverviewScript) Exec(ctx context.Context, req GetDashboardOverviewReq) (*GetDashboardOverviewResp, error) {
@@ -47,6 +50,21 @@ func (s *GetDashboardOverviewScript) Exec(ctx context.Context, req GetDashboardO
 		return nil, err
 	}
 
+	limits, err := s.guard.Limits(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like searches, an overview without from starts the longest range
+	// allowed before its end.
+	if req.From.IsZero() && limits.MaxRange > 0 {
+		req.To = searchEnd(req.To)
+		req.From = req.To.Add(-limits.MaxRange)
+	}
+	if err := checkQueryRange(limits, req.From, req.To); err != nil {
+		return nil, err
+	}
+
 	var dateRange *domain.Range
 	if !req.From.IsZero() || !req.To.IsZero() {
 		dateRange = &domain.Range{}
@@ -59,7 +77,10 @@ func (s *GetDashboardOverviewScript) Exec(ctx context.Context, req GetDashboardO
 		}
 	}
 
-	kpis, err := s.dashboardRepo.OverviewKPIs(ctx, userID, dateRange)
+	kpis, err := s.dashboardRepo.OverviewKPIs(ctx, userID, dateRange, limits.MaxTime)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
 	if err != nil {
 		return nil, err
 	}
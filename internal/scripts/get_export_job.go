@@ -0,0 +1,33 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type GetExportJobReq struct {
+	UserID      string `json:"-"`
+	ExportJobID string `json:"-"`
+}
+
+type GetExportJobResp struct {
+	domain.ExportJob
+}
+
+type GetExportJobScript struct {
+	exportJobRepo domain.ExportJobRepo
+}
+
+func NewGetExportJobScript(exportJobRepo domain.ExportJobRepo) *GetExportJobScript {
+	return &GetExportJobScript{exportJobRepo: exportJobRepo}
+}
+
+func (s *GetExportJobScript) Exec(ctx context.Context, req GetExportJobReq) (*GetExportJobResp, error) {
+	job, err := getUserExportJob(ctx, s.exportJobRepo, req.UserID, req.ExportJobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetExportJobResp{ExportJob: *job}, nil
+}
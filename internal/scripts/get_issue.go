@@ -0,0 +1,69 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+// issueLatestEventsLimit is how many events are shown when inspecting an issue.
+const issueLatestEventsLimit = 20
+
+type GetIssueReq struct {
+	UserID  string `json:"-"`
+	IssueID string `json:"-"`
+}
+
+type GetIssueResp struct {
+	Issue        domain.Issue `json:"issue"`
+	LatestEvents []domain.Log `json:"latestEvents"`
+}
+
+type GetIssueScript struct {
+	issueRepo domain.IssueRepo
+	appRepo   domain.AppRepo
+	logRepo   domain.LogRepo
+}
+
+func NewGetIssueScript(issueRepo domain.IssueRepo, appRepo domain.AppRepo, logRepo domain.LogRepo) *GetIssueScript {
+	return &GetIssueScript{issueRepo: issueRepo, appRepo: appRepo, logRepo: logRepo}
+}
+
+func (s *GetIssueScript) Exec(ctx context.Context, req GetIssueReq) (*GetIssueResp, error) {
+	issue, err := getUserIssue(ctx, s.issueRepo, req.UserID, req.IssueID)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := s.appRepo.ListApps(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("userId", domain.Equals, issue.UserID()),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]any, len(apps))
+	for i, app := range apps {
+		appIDs[i] = app.ID()
+	}
+
+	// Fingerprints are not unique across users, so events are also
+	// restricted to the apps of the issue owner.
+	logs, err := s.logRepo.ListLogs(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("fingerprint", domain.In, issue.Fingerprints()),
+			domain.NewFilter("appId", domain.In, appIDs),
+		},
+		domain.NewPagination(issueLatestEventsLimit, 0),
+		domain.NewSort("timestamp", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetIssueResp{Issue: *issue, LatestEvents: logs}, nil
+}
@@ -0,0 +1,34 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type GetLogReq struct {
+	UserID string `json:"-"`
+	LogID  string `json:"-"`
+}
+
+type GetLogResp struct {
+	domain.Log
+}
+
+type GetLogScript struct {
+	appRepo domain.AppRepo
+	logRepo domain.LogRepo
+}
+
+func NewGetLogScript(appRepo domain.AppRepo, logRepo domain.LogRepo) *GetLogScript {
+	return &GetLogScript{appRepo: appRepo, logRepo: logRepo}
+}
+
+func (s *GetLogScript) Exec(ctx context.Context, req GetLogReq) (*GetLogResp, error) {
+	log, err := getUserLog(ctx, s.appRepo, s.logRepo, req.UserID, req.LogID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetLogResp{Log: *log}, nil
+}
@@ -0,0 +1,147 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrGetLogContextScriptInvalidField = errors.New("invalid context field")
+)
+
+const (
+	defaultLogContextSize = 10
+	maxLogContextSize     = 500
+)
+
+type GetLogContextReq struct {
+	UserID string `json:"-"`
+	LogID  string `json:"-"`
+	// Before and After are how many logs are returned on each side.
+	Before int `form:"before"`
+	After  int `form:"after"`
+	// Same are fields whose value must be the same as in the log, like
+	// data.host or data.traceId.
+	Same []string `form:"same"`
+	// BeforeCursor and AfterCursor page outward from the logs of a previous
+	// response. When only one is given, only that side is returned.
+	BeforeCursor string `form:"beforeCursor"`
+	AfterCursor  string `form:"afterCursor"`
+}
+
+type GetLogContextResp struct {
+	Log domain.Log `json:"log"`
+	// Before and After are in chronological order.
+	Before       []domain.Log `json:"before"`
+	After        []domain.Log `json:"after"`
+	BeforeCursor string       `json:"beforeCursor,omitempty"`
+	AfterCursor  string       `json:"afterCursor,omitempty"`
+}
+
+type GetLogContextScript struct {
+	appRepo domain.AppRepo
+	logRepo domain.LogRepo
+}
+
+func NewGetLogContextScript(appRepo domain.AppRepo, logRepo domain.LogRepo) *GetLogContextScript {
+	return &GetLogContextScript{appRepo: appRepo, logRepo: logRepo}
+}
+
+// Exec returns the logs of the same app around a log, by timestamp.
+func (s *GetLogContextScript) Exec(ctx context.Context, req GetLogContextReq) (*GetLogContextResp, error) {
+	log, err := getUserLog(ctx, s.appRepo, s.logRepo, req.UserID, req.LogID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []domain.Filter{
+		domain.NewFilter("appId", domain.Equals, log.AppID()),
+	}
+	for _, field := range req.Same {
+		if !domain.IsQueryField(field) {
+			return nil, fmt.Errorf("%w: %s", ErrGetLogContextScriptInvalidField, field)
+		}
+
+		if value, ok := log.Field(field); ok && value != nil {
+			filters = append(filters, domain.NewFilter(field, domain.Equals, value))
+		} else {
+			filters = append(filters, domain.NewFilter(field, domain.Exists, false))
+		}
+	}
+
+	resp := &GetLogContextResp{Log: *log, Before: []domain.Log{}, After: []domain.Log{}}
+	paging := req.BeforeCursor != "" || req.AfterCursor != ""
+
+	if !paging || req.BeforeCursor != "" {
+		resp.Before, resp.BeforeCursor, err = s.side(ctx, *log, filters, req.BeforeCursor, req.Before, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !paging || req.AfterCursor != "" {
+		resp.After, resp.AfterCursor, err = s.side(ctx, *log, filters, req.AfterCursor, req.After, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// side reads the logs before or after the cursor, or the log when there is
+// no cursor, and returns them in chronological order with the cursor of the
+// next logs on that side when there are more.
+func (s *GetLogContextScript) side(
+	ctx context.Context,
+	log domain.Log,
+	filters []domain.Filter,
+	rawCursor string,
+	size int,
+	before bool,
+) ([]domain.Log, string, error) {
+	if size <= 0 {
+		size = defaultLogContextSize
+	}
+	size = min(size, maxLogContextSize)
+
+	cursor := &logCursor{Timestamp: log.Timestamp(), ID: log.ID().Hex(), Order: domain.Asc, Backward: before}
+	if rawCursor != "" {
+		var err error
+		cursor, err = decodeLogCursor(rawCursor)
+		if err != nil {
+			return nil, "", err
+		}
+		cursor.Order = domain.Asc
+		cursor.Backward = before
+	}
+
+	scanOrder := cursor.scanOrder()
+	logs, err := s.logRepo.ListLogs(ctx, domain.NewCriteria(
+		append(slices.Clone(filters), cursor.filter()),
+		domain.NewPagination(size+1, 0),
+		domain.NewSort("timestamp", scanOrder).ThenBy("_id", scanOrder),
+	))
+	if err != nil {
+		return nil, "", err
+	}
+
+	hasMore := len(logs) > size
+	if hasMore {
+		logs = logs[:size]
+	}
+
+	var next string
+	if hasMore {
+		next = newLogCursor(logs[len(logs)-1], domain.Asc, before)
+	}
+
+	if before {
+		slices.Reverse(logs)
+	}
+	return logs, next, nil
+}
@@ -0,0 +1,127 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrGetLogFieldValuesScriptInvalidField = errors.New("invalid field")
+)
+
+const (
+	defaultFieldValuesSize = 10
+	maxFieldValuesSize     = 100
+	// defaultFieldValuesScan is how many of the latest logs with the field
+	// are read by default, which keeps the values fast on large apps.
+	defaultFieldValuesScan = 10000
+	maxFieldValuesScan     = 1000000
+	maxFieldPrefixLength   = 256
+	// fieldValuesTimeBudget bounds the scan, which is mostly unindexed.
+	fieldValuesTimeBudget = 10 * time.Second
+)
+
+type GetLogFieldValuesReq struct {
+	SearchLogsReq
+	// Field is a field like level, appId or data.request.method.
+	Field string `form:"field"`
+	// Prefix only keeps the values that start with it, ignoring case.
+	Prefix string `form:"prefix"`
+	// Size is how many values are returned, 10 by default.
+	Size int `form:"size"`
+	// MaxScan is how many of the latest logs with the field are read, 10000
+	// by default.
+	MaxScan int `form:"maxScan"`
+}
+
+type GetLogFieldValuesValue struct {
+	Value any   `json:"value"`
+	Count int64 `json:"count"`
+}
+
+type GetLogFieldValuesResp struct {
+	Field  string                   `json:"field"`
+	Values []GetLogFieldValuesValue `json:"values"`
+	// Distinct is how many different values were found.
+	Distinct int64 `json:"distinct"`
+	// Scanned is how many logs were read, and Complete whether they are all
+	// the logs of the search with the field.
+	Scanned  int64    `json:"scanned"`
+	Complete bool     `json:"complete"`
+	Min      *float64 `json:"min"`
+	Max      *float64 `json:"max"`
+}
+
+type GetLogFieldValuesScript struct {
+	search  *SearchLogsScript
+	logRepo domain.LogRepo
+}
+
+func NewGetLogFieldValuesScript(appRepo domain.AppRepo, logRepo domain.LogRepo, guard *QueryGuard) *GetLogFieldValuesScript {
+	return &GetLogFieldValuesScript{search: NewSearchLogsScript(appRepo, logRepo, guard), logRepo: logRepo}
+}
+
+// Exec counts the values of a field among the latest logs of a search that
+// have it.
+func (s *GetLogFieldValuesScript) Exec(ctx context.Context, req GetLogFieldValuesReq) (*GetLogFieldValuesResp, error) {
+	if req.Field != "appId" && !domain.IsQueryField(req.Field) {
+		return nil, fmt.Errorf("%w: %q, use level, appId, fingerprint, patternId, data.* or stackTrace.*", ErrGetLogFieldValuesScriptInvalidField, req.Field)
+	}
+	if len(req.Prefix) > maxFieldPrefixLength {
+		return nil, fmt.Errorf("%w: prefix cannot be longer than %d characters", ErrGetLogFieldValuesScriptInvalidField, maxFieldPrefixLength)
+	}
+
+	size := req.Size
+	if size <= 0 {
+		size = defaultFieldValuesSize
+	}
+	size = min(size, maxFieldValuesSize)
+
+	maxScan := req.MaxScan
+	if maxScan <= 0 {
+		maxScan = defaultFieldValuesScan
+	}
+	maxScan = min(maxScan, maxFieldValuesScan)
+
+	search, err := s.search.prepare(ctx, req.SearchLogsReq)
+	if err != nil {
+		return nil, err
+	}
+
+	criteria := search.criteria(
+		domain.NewPagination(maxScan, 0),
+		domain.NewSort("timestamp", domain.Desc),
+		domain.NewFilter(req.Field, domain.Exists, true),
+	)
+	if criteria.MaxTime == 0 || criteria.MaxTime > fieldValuesTimeBudget {
+		criteria.MaxTime = fieldValuesTimeBudget
+	}
+
+	values, err := s.logRepo.FieldValues(ctx, criteria, req.Field, req.Prefix, size)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GetLogFieldValuesResp{
+		Field:    req.Field,
+		Values:   make([]GetLogFieldValuesValue, len(values.Values)),
+		Distinct: values.Distinct,
+		Scanned:  values.Scanned,
+		Complete: values.Scanned < int64(maxScan),
+		Min:      values.Min,
+		Max:      values.Max,
+	}
+	for i, value := range values.Values {
+		resp.Values[i] = GetLogFieldValuesValue{Value: value.Value, Count: value.Count}
+	}
+	return resp, nil
+}
@@ -0,0 +1,41 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type GetLogMetricReq struct {
+	UserID   string `json:"-"`
+	MetricID string `json:"-"`
+}
+
+type GetLogMetricResp struct {
+	domain.LogMetric
+	// AlertStates are whether the alert fires for each group evaluated.
+	AlertStates []domain.LogMetricAlertState `json:"alertStates"`
+}
+
+type GetLogMetricScript struct {
+	logMetricRepo           domain.LogMetricRepo
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo
+}
+
+func NewGetLogMetricScript(logMetricRepo domain.LogMetricRepo, logMetricAlertStateRepo domain.LogMetricAlertStateRepo) *GetLogMetricScript {
+	return &GetLogMetricScript{logMetricRepo: logMetricRepo, logMetricAlertStateRepo: logMetricAlertStateRepo}
+}
+
+func (s *GetLogMetricScript) Exec(ctx context.Context, req GetLogMetricReq) (*GetLogMetricResp, error) {
+	metric, err := getUserLogMetric(ctx, s.logMetricRepo, req.UserID, req.MetricID)
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.logMetricAlertStateRepo.ListAlertStates(ctx, metric.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetLogMetricResp{LogMetric: *metric, AlertStates: states}, nil
+}
@@ -0,0 +1,241 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrGetLogMetricSeriesScriptInvalidSeries = errors.New("invalid log metric series")
+)
+
+const (
+	defaultLogMetricSeriesRange = 24 * time.Hour
+	defaultLogMetricSeries      = 10
+	maxLogMetricSeries          = 100
+)
+
+type GetLogMetricSeriesReq struct {
+	UserID   string    `json:"-"`
+	MetricID string    `json:"-"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+	// Interval is the length of the points, from 1m, chosen from the time
+	// range when empty or auto.
+	Interval string `form:"interval"`
+	// Timezone is the IANA name of the zone the points start in, UTC by
+	// default.
+	Timezone string `form:"timezone"`
+	// Stats are the statistics of the points of a distribution, all by
+	// default. Counts only have count.
+	Stats []string `form:"stats"`
+	// Limit is how many groups are returned, those with the most logs.
+	Limit int `form:"limit"`
+}
+
+type LogMetricPoint struct {
+	Start  time.Time          `json:"start"`
+	Values map[string]float64 `json:"values"`
+}
+
+type LogMetricSeries struct {
+	// Group holds the value of each field the metric is grouped by.
+	Group  map[string]string `json:"group"`
+	Total  int64             `json:"total"`
+	Points []LogMetricPoint  `json:"points"`
+}
+
+type GetLogMetricSeriesResp struct {
+	Metric   domain.LogMetric  `json:"metric"`
+	Interval string            `json:"interval"`
+	Timezone string            `json:"timezone"`
+	From     time.Time         `json:"from"`
+	To       time.Time         `json:"to"`
+	Stats    []string          `json:"stats"`
+	Series   []LogMetricSeries `json:"series"`
+	// Groups is how many groups had logs in the range, Series holding the
+	// first ones.
+	Groups int `json:"groups"`
+}
+
+type GetLogMetricSeriesScript struct {
+	logMetricRepo       domain.LogMetricRepo
+	logMetricRollupRepo domain.LogMetricRollupRepo
+}
+
+func NewGetLogMetricSeriesScript(logMetricRepo domain.LogMetricRepo, logMetricRollupRepo domain.LogMetricRollupRepo) *GetLogMetricSeriesScript {
+	return &GetLogMetricSeriesScript{logMetricRepo: logMetricRepo, logMetricRollupRepo: logMetricRollupRepo}
+}
+
+// Exec returns the series of the groups of a metric with the most logs,
+// from its rollups.
+func (s *GetLogMetricSeriesScript) Exec(ctx context.Context, req GetLogMetricSeriesReq) (*GetLogMetricSeriesResp, error) {
+	metric, err := getUserLogMetric(ctx, s.logMetricRepo, req.UserID, req.MetricID)
+	if err != nil {
+		return nil, err
+	}
+
+	location := time.UTC
+	if strings.TrimSpace(req.Timezone) != "" {
+		location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %s", ErrGetLogMetricSeriesScriptInvalidSeries, req.Timezone)
+		}
+	}
+
+	to := req.To
+	if to.IsZero() {
+		to = Now()
+	}
+	from := req.From
+	if from.IsZero() {
+		from = to.Add(-defaultLogMetricSeriesRange)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrGetLogMetricSeriesScriptInvalidSeries)
+	}
+	from, to = from.In(location), to.In(location)
+
+	interval, err := logMetricInterval(req.Interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := logMetricStats(*metric, req.Stats)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLogMetricSeries
+	}
+	limit = min(limit, maxLogMetricSeries)
+
+	rollups, err := s.logMetricRollupRepo.ListRollups(ctx, metric.ID(), domain.Range{From: from.UTC(), To: to.UTC()}, interval, location)
+	if err != nil {
+		return nil, err
+	}
+
+	// The points step from the rollups found so they start at the same
+	// times.
+	start := interval.Truncate(from)
+	if len(rollups) > 0 {
+		start = rollups[0].Start.In(location)
+	}
+	for start.After(from) {
+		start = interval.Add(start, -1)
+	}
+
+	type group struct {
+		keys    []string
+		total   int64
+		rollups map[int64]domain.LogMetricRollup
+	}
+	groups := map[string]*group{}
+	for _, rollup := range rollups {
+		key := domain.LogMetricGroupKey(rollup.Keys)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{keys: rollup.Keys, rollups: map[int64]domain.LogMetricRollup{}}
+			groups[key] = g
+		}
+		g.total += rollup.Count
+		g.rollups[rollup.Start.Unix()] = rollup
+	}
+
+	top := slices.SortedFunc(maps.Values(groups), func(a, b *group) int {
+		return cmp.Or(cmp.Compare(b.total, a.total), slices.Compare(a.keys, b.keys))
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	series := make([]LogMetricSeries, len(top))
+	for i, g := range top {
+		named := make(map[string]string, len(g.keys))
+		for j, key := range g.keys {
+			if j < len(metric.GroupBy()) {
+				named[metric.GroupBy()[j]] = key
+			}
+		}
+
+		points := []LogMetricPoint{}
+		for point := start; point.Before(to); point = interval.Add(point, 1) {
+			rollup := g.rollups[point.Unix()]
+			values := make(map[string]float64, len(stats))
+			for _, stat := range stats {
+				if value, ok := rollup.Stat(stat); ok {
+					values[stat] = value
+				}
+			}
+			points = append(points, LogMetricPoint{Start: point, Values: values})
+		}
+		series[i] = LogMetricSeries{Group: named, Total: g.total, Points: points}
+	}
+
+	return &GetLogMetricSeriesResp{
+		Metric:   *metric,
+		Interval: interval.String(),
+		Timezone: location.String(),
+		From:     from,
+		To:       to,
+		Stats:    stats,
+		Series:   series,
+		Groups:   len(groups),
+	}, nil
+}
+
+// logMetricInterval parses the interval, from the resolution of the rollups,
+// or chooses the shortest one that gives about histogramTargetBuckets points
+// over the range.
+func logMetricInterval(value string, from time.Time, to time.Time) (domain.Interval, error) {
+	if value != "" && value != "auto" {
+		interval, err := domain.ParseInterval(value)
+		if err != nil {
+			return domain.Interval{}, fmt.Errorf("%w: %s", ErrGetLogMetricSeriesScriptInvalidSeries, err)
+		}
+		if interval.Duration() < domain.LogMetricResolution {
+			return domain.Interval{}, fmt.Errorf("%w: the interval must be at least 1m", ErrGetLogMetricSeriesScriptInvalidSeries)
+		}
+		if interval.Buckets(from, to) > maxHistogramBuckets {
+			return domain.Interval{}, fmt.Errorf("%w: more than %d points, use a longer interval or a shorter time range", ErrGetLogMetricSeriesScriptInvalidSeries, maxHistogramBuckets)
+		}
+		return interval, nil
+	}
+
+	for _, value := range histogramIntervals {
+		interval, _ := domain.ParseInterval(value)
+		if interval.Duration() >= domain.LogMetricResolution && to.Sub(from)/interval.Duration() <= histogramTargetBuckets {
+			return interval, nil
+		}
+	}
+	return domain.MaxInterval, nil
+}
+
+// logMetricStats checks the stats asked for the metric, all of them by
+// default.
+func logMetricStats(metric domain.LogMetric, stats []string) ([]string, error) {
+	available := domain.LogMetricStats
+	if metric.Type() == domain.LogMetricCount {
+		available = []string{"count"}
+	}
+	if len(stats) == 0 {
+		return available, nil
+	}
+
+	for _, stat := range stats {
+		if !slices.Contains(available, stat) {
+			return nil, fmt.Errorf("%w: %s is not a stat of the metric, use %s", ErrGetLogMetricSeriesScriptInvalidSeries, stat, strings.Join(available, ", "))
+		}
+	}
+	return stats, nil
+}
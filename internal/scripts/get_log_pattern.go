@@ -0,0 +1,71 @@
+package scripts
+
+import (
+	"context"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+const (
+	defaultLogPatternExamples = 10
+	maxLogPatternExamples     = 100
+)
+
+type GetLogPatternReq struct {
+	UserID    string `json:"-"`
+	PatternID string `json:"-"`
+	// From and To bound the example logs, which are the latest ones.
+	From  time.Time `form:"from"`
+	To    time.Time `form:"to"`
+	Limit int       `form:"limit"`
+}
+
+type GetLogPatternResp struct {
+	Pattern  domain.LogPattern `json:"pattern"`
+	Examples []domain.Log      `json:"examples"`
+}
+
+type GetLogPatternScript struct {
+	appRepo        domain.AppRepo
+	logRepo        domain.LogRepo
+	logPatternRepo domain.LogPatternRepo
+}
+
+func NewGetLogPatternScript(appRepo domain.AppRepo, logRepo domain.LogRepo, logPatternRepo domain.LogPatternRepo) *GetLogPatternScript {
+	return &GetLogPatternScript{appRepo: appRepo, logRepo: logRepo, logPatternRepo: logPatternRepo}
+}
+
+func (s *GetLogPatternScript) Exec(ctx context.Context, req GetLogPatternReq) (*GetLogPatternResp, error) {
+	pattern, err := getUserLogPattern(ctx, s.appRepo, s.logPatternRepo, req.UserID, req.PatternID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLogPatternExamples
+	}
+	limit = min(limit, maxLogPatternExamples)
+
+	filters := []domain.Filter{
+		domain.NewFilter("patternId", domain.Equals, pattern.ID().Hex()),
+	}
+	if !req.From.IsZero() {
+		filters = append(filters, domain.NewFilter("timestamp", domain.GreaterThanOrEqual, req.From.UTC()))
+	}
+	if !req.To.IsZero() {
+		filters = append(filters, domain.NewFilter("timestamp", domain.LessThanOrEqual, req.To.UTC()))
+	}
+
+	examples, err := s.logRepo.ListLogs(ctx, domain.NewCriteria(
+		filters,
+		domain.NewPagination(limit, 0),
+		domain.NewSort("timestamp", domain.Desc).ThenBy("_id", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetLogPatternResp{Pattern: *pattern, Examples: examples}, nil
+}
@@ -2,12 +2,18 @@ package scripts
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"monitoring/internal/domain"
 )
 
 type GetLogsSchemaReq struct {
 	UserID string `json:"-"`
+	// AppID limits the schema to an app of the user.
+	AppID string    `form:"appId"`
+	From  time.Time `form:"from"`
+	To    time.Time `form:"to"`
 }
 
 type GetLogsSchemaResp struct {
@@ -15,11 +21,12 @@ type GetLogsSchemaResp struct {
 }
 
 type GetLogsSchemaScript struct {
+	appRepo       domain.AppRepo
 	logSchemaRepo domain.LogSchemaRepo
 }
 
-func NewGetLogsSchemaScript(logSchemaRepo domain.LogSchemaRepo) *GetLogsSchemaScript {
-	return &GetLogsSchemaScript{logSchemaRepo: logSchemaRepo}
+func NewGetLogsSchemaScript(appRepo domain.AppRepo, logSchemaRepo domain.LogSchemaRepo) *GetLogsSchemaScript {
+	return &GetLogsSchemaScript{appRepo: appRepo, logSchemaRepo: logSchemaRepo}
 }
 
 func (s *GetLogsSchemaScript) Exec(ctx context.Context, req GetLogsSchemaReq) (*GetLogsSchemaResp, error) {
@@ -28,8 +35,21 @@ func (s *GetLogsSchemaScript) Exec(ctx context.Context, req GetLogsSchemaReq) (*
 		return nil, err
 	}
 
-	// TODO: add appIDs filter and [from, to] timestamp filter
-	result, err := s.logSchemaRepo.Get(ctx, userID, nil, nil)
+	var appIDs []domain.ID
+	if strings.TrimSpace(req.AppID) != "" {
+		app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+		if err != nil {
+			return nil, err
+		}
+		appIDs = []domain.ID{app.ID()}
+	}
+
+	var dateRange *domain.Range
+	if !req.From.IsZero() || !req.To.IsZero() {
+		dateRange = &domain.Range{From: req.From.UTC(), To: req.To.UTC()}
+	}
+
+	result, err := s.logSchemaRepo.Get(ctx, userID, appIDs, dateRange)
 	if err != nil {
 		return nil, err
 	}
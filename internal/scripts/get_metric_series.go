@@ -0,0 +1,277 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrGetMetricSeriesScriptInvalidSeries = errors.New("invalid metric series")
+)
+
+const (
+	defaultMetricSeriesRange = time.Hour
+	defaultMetricSeries      = 10
+	maxMetricSeries          = 100
+	// minMetricInterval is the flush interval of StatsD, shorter points
+	// mostly being empty.
+	minMetricInterval = 10 * time.Second
+)
+
+type GetMetricSeriesReq struct {
+	UserID string `json:"-"`
+	// AppIDs are the apps the series are of, all the apps of the user by
+	// default.
+	AppIDs []string `form:"appIds"`
+	Name   string   `form:"name"`
+	// Labels select the series by the values of their labels, like
+	// method=GET.
+	Labels []string  `form:"labels"`
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+	// Interval is the length of the points, from 10s, chosen from the time
+	// range when empty or auto.
+	Interval string `form:"interval"`
+	// Timezone is the IANA name of the zone the points start in, UTC by
+	// default.
+	Timezone string `form:"timezone"`
+	// Stats are the statistics of the points, all those of the kind of each
+	// series by default.
+	Stats []string `form:"stats"`
+	// Limit is how many series are returned, those with the most samples.
+	Limit int `form:"limit"`
+}
+
+type MetricPoint struct {
+	Start  time.Time          `json:"start"`
+	Values map[string]float64 `json:"values"`
+}
+
+type MetricSeriesPoints struct {
+	AppID  domain.ID         `json:"appId"`
+	Kind   domain.MetricKind `json:"kind"`
+	Labels map[string]string `json:"labels"`
+	Stats  []string          `json:"stats"`
+	Points []MetricPoint     `json:"points"`
+}
+
+type GetMetricSeriesResp struct {
+	Name     string               `json:"name"`
+	Interval string               `json:"interval"`
+	Timezone string               `json:"timezone"`
+	From     time.Time            `json:"from"`
+	To       time.Time            `json:"to"`
+	Series   []MetricSeriesPoints `json:"series"`
+	// Total is how many series had samples in the range, Series holding the
+	// first ones.
+	Total int `json:"total"`
+}
+
+type GetMetricSeriesScript struct {
+	appRepo    domain.AppRepo
+	metricRepo domain.MetricRepo
+}
+
+func NewGetMetricSeriesScript(appRepo domain.AppRepo, metricRepo domain.MetricRepo) *GetMetricSeriesScript {
+	return &GetMetricSeriesScript{appRepo: appRepo, metricRepo: metricRepo}
+}
+
+// Exec returns the points of the series of a metric in the apps of the user
+// with the most samples. Short intervals are read from the samples, and
+// longer ones from their rollups, which are kept longer.
+func (s *GetMetricSeriesScript) Exec(ctx context.Context, req GetMetricSeriesReq) (*GetMetricSeriesResp, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrGetMetricSeriesScriptInvalidSeries)
+	}
+
+	appIDs, err := getUserAppIDsOrAll(ctx, s.appRepo, req.UserID, req.AppIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(req.Labels))
+	for _, pair := range req.Labels {
+		label, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: label %q must be like name=value", ErrGetMetricSeriesScriptInvalidSeries, pair)
+		}
+		if err := domain.ValidateMetricLabel(label); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrGetMetricSeriesScriptInvalidSeries, err)
+		}
+		labels[label] = value
+	}
+
+	location := time.UTC
+	if strings.TrimSpace(req.Timezone) != "" {
+		location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %s", ErrGetMetricSeriesScriptInvalidSeries, req.Timezone)
+		}
+	}
+
+	to := req.To
+	if to.IsZero() {
+		to = Now()
+	}
+	from := req.From
+	if from.IsZero() {
+		from = to.Add(-defaultMetricSeriesRange)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrGetMetricSeriesScriptInvalidSeries)
+	}
+	from, to = from.In(location), to.In(location)
+
+	interval, err := metricInterval(req.Interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, stat := range req.Stats {
+		if !isMetricStat(stat) {
+			return nil, fmt.Errorf("%w: unknown stat %s", ErrGetMetricSeriesScriptInvalidSeries, stat)
+		}
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultMetricSeries
+	}
+	limit = min(limit, maxMetricSeries)
+
+	rollups, err := s.metricRepo.ListMetricRollups(ctx, domain.MetricQuery{
+		AppIDs:   appIDs,
+		Name:     req.Name,
+		Labels:   labels,
+		Range:    domain.Range{From: from.UTC(), To: to.UTC()},
+		Interval: interval,
+		Location: location,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The points step from the rollups found so they start at the same
+	// times.
+	start := interval.Truncate(from)
+	if len(rollups) > 0 {
+		start = rollups[0].Start.In(location)
+	}
+	for start.After(from) {
+		start = interval.Add(start, -1)
+	}
+
+	type series struct {
+		key     string
+		first   domain.MetricRollup
+		samples int64
+		rollups map[int64]domain.MetricRollup
+	}
+	bySeries := map[string]*series{}
+	for _, rollup := range rollups {
+		key := rollup.AppID.Hex() + domain.MetricSeriesKey(rollup.Name, rollup.Labels)
+		entry, ok := bySeries[key]
+		if !ok {
+			entry = &series{key: key, first: rollup, rollups: map[int64]domain.MetricRollup{}}
+			bySeries[key] = entry
+		}
+		entry.samples += rollup.Count
+		entry.rollups[rollup.Start.Unix()] = rollup
+	}
+
+	top := slices.SortedFunc(maps.Values(bySeries), func(a, b *series) int {
+		return cmp.Or(cmp.Compare(b.samples, a.samples), cmp.Compare(a.key, b.key))
+	})
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	points := make([]MetricSeriesPoints, len(top))
+	for i, entry := range top {
+		// The stats asked for that the kind of the series does not have are
+		// left out.
+		kind := entry.first.Kind
+		stats := domain.MetricStats[kind]
+		if len(req.Stats) > 0 {
+			stats = slices.DeleteFunc(slices.Clone(req.Stats), func(stat string) bool {
+				return !slices.Contains(domain.MetricStats[kind], stat)
+			})
+		}
+
+		seriesPoints := []MetricPoint{}
+		for point := start; point.Before(to); point = interval.Add(point, 1) {
+			rollup := entry.rollups[point.Unix()]
+			length := interval.Add(point, 1).Sub(point)
+			values := make(map[string]float64, len(stats))
+			for _, stat := range stats {
+				if value, ok := rollup.Stat(stat, length); ok {
+					values[stat] = value
+				}
+			}
+			seriesPoints = append(seriesPoints, MetricPoint{Start: point, Values: values})
+		}
+
+		points[i] = MetricSeriesPoints{
+			AppID:  entry.first.AppID,
+			Kind:   kind,
+			Labels: entry.first.Labels,
+			Stats:  stats,
+			Points: seriesPoints,
+		}
+	}
+
+	return &GetMetricSeriesResp{
+		Name:     req.Name,
+		Interval: interval.String(),
+		Timezone: location.String(),
+		From:     from,
+		To:       to,
+		Series:   points,
+		Total:    len(bySeries),
+	}, nil
+}
+
+// metricInterval parses the interval, from minMetricInterval, or chooses the
+// shortest one that gives about histogramTargetBuckets points over the
+// range.
+func metricInterval(value string, from time.Time, to time.Time) (domain.Interval, error) {
+	if value != "" && value != "auto" {
+		interval, err := domain.ParseInterval(value)
+		if err != nil {
+			return domain.Interval{}, fmt.Errorf("%w: %s", ErrGetMetricSeriesScriptInvalidSeries, err)
+		}
+		if interval.Duration() < minMetricInterval {
+			return domain.Interval{}, fmt.Errorf("%w: the interval must be at least %s", ErrGetMetricSeriesScriptInvalidSeries, domain.FormatLength(minMetricInterval))
+		}
+		if interval.Buckets(from, to) > maxHistogramBuckets {
+			return domain.Interval{}, fmt.Errorf("%w: more than %d points, use a longer interval or a shorter time range", ErrGetMetricSeriesScriptInvalidSeries, maxHistogramBuckets)
+		}
+		return interval, nil
+	}
+
+	for _, value := range histogramIntervals {
+		interval, _ := domain.ParseInterval(value)
+		if interval.Duration() >= minMetricInterval && to.Sub(from)/interval.Duration() <= histogramTargetBuckets {
+			return interval, nil
+		}
+	}
+	return domain.MaxInterval, nil
+}
+
+// isMetricStat tells whether the stat is a stat of any kind of metric.
+func isMetricStat(stat string) bool {
+	for _, stats := range domain.MetricStats {
+		if slices.Contains(stats, stat) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+type GetQueryPolicyReq struct {
+	UserID string `json:"-"`
+}
+
+type GetQueryPolicyResp struct {
+	// Server are the limits of every account.
+	Server domain.QueryLimits `json:"server"`
+	// Account is the policy of the account of the user, nil when it has
+	// none.
+	Account *domain.QueryPolicy `json:"account"`
+	// Effective are the limits of the searches of the user, the stricter of
+	// the server and the account ones.
+	Effective domain.QueryLimits `json:"effective"`
+}
+
+type GetQueryPolicyScript struct {
+	userRepo        domain.UserRepo
+	queryPolicyRepo domain.QueryPolicyRepo
+	guard           *QueryGuard
+}
+
+func NewGetQueryPolicyScript(userRepo domain.UserRepo, queryPolicyRepo domain.QueryPolicyRepo, guard *QueryGuard) *GetQueryPolicyScript {
+	return &GetQueryPolicyScript{userRepo: userRepo, queryPolicyRepo: queryPolicyRepo, guard: guard}
+}
+
+func (s *GetQueryPolicyScript) Exec(ctx context.Context, req GetQueryPolicyReq) (*GetQueryPolicyResp, error) {
+	_, accountID, err := getUserAccount(ctx, s.userRepo, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GetQueryPolicyResp{Server: s.guard.limits, Effective: s.guard.limits}
+	policy, err := s.queryPolicyRepo.GetQueryPolicy(ctx, accountID)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if err == nil {
+		resp.Account = policy
+		resp.Effective = s.guard.limits.Stricter(policy.Limits())
+	}
+	return resp, nil
+}
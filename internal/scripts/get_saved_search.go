@@ -0,0 +1,42 @@
+package scripts
+
+import (
+	"context"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+type GetSavedSearchReq struct {
+	UserID   string `json:"-"`
+	SearchID string `json:"-"`
+}
+
+type GetSavedSearchResp struct {
+	domain.SavedSearch
+	// From and To are the time range of the search if it ran now.
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+type GetSavedSearchScript struct {
+	userRepo        domain.UserRepo
+	savedSearchRepo domain.SavedSearchRepo
+}
+
+func NewGetSavedSearchScript(userRepo domain.UserRepo, savedSearchRepo domain.SavedSearchRepo) *GetSavedSearchScript {
+	return &GetSavedSearchScript{userRepo: userRepo, savedSearchRepo: savedSearchRepo}
+}
+
+func (s *GetSavedSearchScript) Exec(ctx context.Context, req GetSavedSearchReq) (*GetSavedSearchResp, error) {
+	search, err := getVisibleSavedSearch(ctx, s.userRepo, s.savedSearchRepo, req.UserID, req.SearchID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GetSavedSearchResp{SavedSearch: *search}
+	if dateRange := search.Range(Now().UTC()); dateRange != nil {
+		resp.From, resp.To = &dateRange.From, &dateRange.To
+	}
+	return resp, nil
+}
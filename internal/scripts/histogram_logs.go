@@ -0,0 +1,269 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrHistogramLogsScriptInvalidHistogram = errors.New("invalid histogram")
+)
+
+const (
+	// histogramTargetBuckets is about how many buckets an automatic interval
+	// gives.
+	histogramTargetBuckets = 100
+	maxHistogramBuckets    = 2000
+	// maxHistogramSeries is how many series are returned when split, the
+	// rest are added up in histogramOtherSeries.
+	maxHistogramSeries   = 10
+	histogramOtherSeries = "(other)"
+	histogramNoneSeries  = "(none)"
+)
+
+// histogramIntervals are the intervals chosen automatically.
+var histogramIntervals = []string{
+	"1s", "5s", "10s", "30s",
+	"1m", "5m", "10m", "30m",
+	"1h", "3h", "6h", "12h",
+	"1d", "1w", "1M",
+}
+
+type HistogramLogsReq struct {
+	SearchLogsReq
+	// Interval is the length of the buckets, like 30s, 5m, 1h, 1d, 1w or 1M.
+	// It is chosen from the time range when empty or auto.
+	Interval string `form:"interval"`
+	// SplitBy counts the logs of each value of a field apart: level, appId
+	// or a data field.
+	SplitBy string `form:"splitBy"`
+	// Timezone is the IANA name of the zone the buckets start in, UTC by
+	// default.
+	Timezone string `form:"timezone"`
+}
+
+type HistogramLogsBucket struct {
+	Start  time.Time        `json:"start"`
+	Count  int64            `json:"count"`
+	Series map[string]int64 `json:"series,omitempty"`
+}
+
+type HistogramLogsResp struct {
+	Interval string                `json:"interval"`
+	Timezone string                `json:"timezone"`
+	From     time.Time             `json:"from"`
+	To       time.Time             `json:"to"`
+	Series   []string              `json:"series"`
+	Buckets  []HistogramLogsBucket `json:"buckets"`
+}
+
+type HistogramLogsScript struct {
+	search  *SearchLogsScript
+	logRepo domain.LogRepo
+}
+
+func NewHistogramLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, guard *QueryGuard) *HistogramLogsScript {
+	return &HistogramLogsScript{search: NewSearchLogsScript(appRepo, logRepo, guard), logRepo: logRepo}
+}
+
+// Exec counts the logs of a search per time bucket.
+func (s *HistogramLogsScript) Exec(ctx context.Context, req HistogramLogsReq) (*HistogramLogsResp, error) {
+	location := time.UTC
+	if strings.TrimSpace(req.Timezone) != "" {
+		var err error
+		location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %s", ErrHistogramLogsScriptInvalidHistogram, req.Timezone)
+		}
+	}
+
+	if req.SplitBy != "" && req.SplitBy != "appId" && !domain.IsQueryField(req.SplitBy) {
+		return nil, fmt.Errorf("%w: cannot split by %s, use level, appId or a data field", ErrHistogramLogsScriptInvalidHistogram, req.SplitBy)
+	}
+
+	search, err := s.search.prepare(ctx, req.SearchLogsReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.search.checkScan(ctx, search); err != nil {
+		return nil, err
+	}
+
+	from, to, err := searchTimeRange(ctx, s.logRepo, search, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	if from.IsZero() {
+		return &HistogramLogsResp{Timezone: location.String(), Series: []string{}, Buckets: []HistogramLogsBucket{}}, nil
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrHistogramLogsScriptInvalidHistogram)
+	}
+
+	interval, err := histogramInterval(req.Interval, from.In(location), to.In(location))
+	if err != nil {
+		return nil, err
+	}
+
+	rawBuckets, err := s.logRepo.HistogramLogs(ctx, search.criteria(domain.EmptyPagination, domain.EmptySort), interval, location, req.SplitBy)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	series := []string{}
+	if req.SplitBy != "" {
+		series = topHistogramSeries(rawBuckets)
+	}
+
+	return &HistogramLogsResp{
+		Interval: interval.String(),
+		Timezone: location.String(),
+		From:     from.In(location),
+		To:       to.In(location),
+		Series:   series,
+		Buckets:  fillHistogramBuckets(rawBuckets, series, interval, from.In(location), to.In(location)),
+	}, nil
+}
+
+// searchTimeRange returns the range of the search, which goes from its first
+// log to now when not given. from is zero when no log matches.
+func searchTimeRange(ctx context.Context, logRepo domain.LogRepo, search *logSearch, from time.Time, to time.Time) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = Now()
+	}
+
+	if from.IsZero() {
+		logs, err := logRepo.ListLogs(ctx, search.criteria(domain.NewPagination(1, 0), domain.NewSort("timestamp", domain.Asc)))
+		if err != nil && mongo.IsTimeout(err) {
+			return time.Time{}, time.Time{}, ErrSearchLogsScriptTimeout
+		}
+		if err != nil || len(logs) == 0 {
+			return time.Time{}, time.Time{}, err
+		}
+		from = logs[0].Timestamp()
+	}
+
+	return from.UTC(), to.UTC(), nil
+}
+
+// histogramInterval parses the interval, or chooses the shortest one that
+// gives about histogramTargetBuckets over the range.
+func histogramInterval(value string, from time.Time, to time.Time) (domain.Interval, error) {
+	if value != "" && value != "auto" {
+		interval, err := domain.ParseInterval(value)
+		if err != nil {
+			return domain.Interval{}, fmt.Errorf("%w: %s", ErrHistogramLogsScriptInvalidHistogram, err)
+		}
+		if interval.Buckets(from, to) > maxHistogramBuckets {
+			return domain.Interval{}, fmt.Errorf("%w: more than %d buckets, use a longer interval or a shorter time range", ErrHistogramLogsScriptInvalidHistogram, maxHistogramBuckets)
+		}
+		return interval, nil
+	}
+
+	for _, value := range histogramIntervals {
+		interval, _ := domain.ParseInterval(value)
+		if to.Sub(from)/interval.Duration() <= histogramTargetBuckets {
+			return interval, nil
+		}
+	}
+	return domain.MaxInterval, nil
+}
+
+// topHistogramSeries returns the series with the most logs.
+func topHistogramSeries(buckets []domain.LogHistogramBucket) []string {
+	totals := map[string]int64{}
+	for _, bucket := range buckets {
+		totals[histogramSeriesName(bucket.Series)] += bucket.Count
+	}
+
+	series := make([]string, 0, len(totals))
+	for name := range totals {
+		series = append(series, name)
+	}
+	slices.SortFunc(series, func(a, b string) int {
+		return cmp.Or(cmp.Compare(totals[b], totals[a]), cmp.Compare(a, b))
+	})
+
+	if len(series) > maxHistogramSeries {
+		series = append(series[:maxHistogramSeries], histogramOtherSeries)
+	}
+	return series
+}
+
+func histogramSeriesName(series string) string {
+	if series == "" {
+		return histogramNoneSeries
+	}
+	return series
+}
+
+// fillHistogramBuckets adds up the buckets by start and adds the empty
+// buckets of the range, stepping from the buckets found so they start at the
+// same times.
+func fillHistogramBuckets(rawBuckets []domain.LogHistogramBucket, series []string, interval domain.Interval, from time.Time, to time.Time) []HistogramLogsBucket {
+	kept := make(map[string]bool, len(series))
+	for _, name := range series {
+		kept[name] = true
+	}
+
+	counts := map[int64]*HistogramLogsBucket{}
+	for _, raw := range rawBuckets {
+		start := raw.Start.In(from.Location())
+		bucket, ok := counts[start.Unix()]
+		if !ok {
+			bucket = newHistogramBucket(start, series)
+			counts[start.Unix()] = bucket
+		}
+
+		bucket.Count += raw.Count
+		if len(series) > 0 {
+			name := histogramSeriesName(raw.Series)
+			if !kept[name] {
+				name = histogramOtherSeries
+			}
+			bucket.Series[name] += raw.Count
+		}
+	}
+
+	start := interval.Truncate(from)
+	if len(rawBuckets) > 0 {
+		start = rawBuckets[0].Start.In(from.Location())
+	}
+	for start.After(from) {
+		start = interval.Add(start, -1)
+	}
+
+	buckets := []HistogramLogsBucket{}
+	for ; !start.After(to); start = interval.Add(start, 1) {
+		if bucket, ok := counts[start.Unix()]; ok {
+			buckets = append(buckets, *bucket)
+			continue
+		}
+		buckets = append(buckets, *newHistogramBucket(start, series))
+	}
+	return buckets
+}
+
+func newHistogramBucket(start time.Time, series []string) *HistogramLogsBucket {
+	bucket := &HistogramLogsBucket{Start: start}
+	if len(series) > 0 {
+		bucket.Series = make(map[string]int64, len(series))
+		for _, name := range series {
+			bucket.Series[name] = 0
+		}
+	}
+	return bucket
+}
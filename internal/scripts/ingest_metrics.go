@@ -0,0 +1,168 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrIngestMetricsScriptInvalidSample = errors.New("invalid metric sample")
+	ErrIngestMetricsScriptTooManySeries = errors.New("too many metric series")
+)
+
+const (
+	// maxMetricSeriesPerApp bounds the series of an app, since labels with
+	// unbounded values, like IDs, make a series per value.
+	maxMetricSeriesPerApp = 10000
+	// maxMetricSampleSkew is how far in the future the clock of a client can
+	// be, samples older than their retention being rejected too.
+	maxMetricSampleSkew = 10 * time.Minute
+	// maxMetricIngestErrors is how many reasons of rejected samples are
+	// returned.
+	maxMetricIngestErrors = 10
+)
+
+type IngestMetricsReq struct {
+	App     domain.App
+	Samples []domain.MetricSample
+	// Rejected are why the samples the parser left out were rejected.
+	Rejected []error
+}
+
+type IngestMetricsResp struct {
+	Accepted int `json:"accepted"`
+	Rejected int `json:"rejected"`
+	// Errors are why the first samples rejected were.
+	Errors []string `json:"errors"`
+}
+
+func (r *IngestMetricsResp) reject(err error) {
+	r.Rejected++
+	if len(r.Errors) < maxMetricIngestErrors {
+		r.Errors = append(r.Errors, err.Error())
+	}
+}
+
+type IngestMetricsScript struct {
+	metricRepo       domain.MetricRepo
+	metricSeriesRepo domain.MetricSeriesRepo
+}
+
+func NewIngestMetricsScript(metricRepo domain.MetricRepo, metricSeriesRepo domain.MetricSeriesRepo) *IngestMetricsScript {
+	return &IngestMetricsScript{metricRepo: metricRepo, metricSeriesRepo: metricSeriesRepo}
+}
+
+// Exec records the samples of an app and adds them to the rollups of their
+// series. Cumulative samples are recorded as what they counted since the
+// previous total of their series, the first total only starting the count.
+// Concurrent batches of totals of the same series may count some of them
+// twice, which exporters avoid by sending a series from one place.
+func (s *IngestMetricsScript) Exec(ctx context.Context, req IngestMetricsReq) (*IngestMetricsResp, error) {
+	resp := &IngestMetricsResp{Errors: []string{}}
+	for _, err := range req.Rejected {
+		resp.reject(err)
+	}
+
+	now := Now().UTC()
+	keys := []string{}
+	bySeries := map[string][]domain.MetricSample{}
+	for _, sample := range req.Samples {
+		if sample.Timestamp.IsZero() {
+			sample.Timestamp = now
+		}
+		if sample.Timestamp.Before(now.Add(-domain.MetricSampleRetention)) || sample.Timestamp.After(now.Add(maxMetricSampleSkew)) {
+			resp.reject(fmt.Errorf("%w: %s is too old or too far in the future", ErrIngestMetricsScriptInvalidSample, sample.Series()))
+			continue
+		}
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			resp.reject(fmt.Errorf("%w: %s is not a number", ErrIngestMetricsScriptInvalidSample, sample.Series()))
+			continue
+		}
+
+		sample.AppID = req.App.ID()
+		key := sample.Series()
+		if _, ok := bySeries[key]; !ok {
+			keys = append(keys, key)
+		}
+		bySeries[key] = append(bySeries[key], sample)
+	}
+	if len(keys) == 0 {
+		return resp, nil
+	}
+
+	known, err := s.metricSeriesRepo.ListMetricSeriesByKeys(ctx, req.App.ID(), keys)
+	if err != nil {
+		return nil, err
+	}
+	catalog := make(map[string]domain.MetricSeries, len(known))
+	for _, series := range known {
+		catalog[series.Key] = series
+	}
+
+	var total int64
+	if len(known) < len(keys) {
+		total, err = s.metricSeriesRepo.CountMetricSeries(ctx, req.App.ID())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recorded := []domain.MetricSample{}
+	changed := []domain.MetricSeries{}
+	for _, key := range keys {
+		samples := bySeries[key]
+		series, ok := catalog[key]
+		if !ok {
+			if total >= maxMetricSeriesPerApp {
+				for range samples {
+					resp.reject(fmt.Errorf("%w: the app has %d series, %s is not recorded", ErrIngestMetricsScriptTooManySeries, maxMetricSeriesPerApp, key))
+				}
+				continue
+			}
+			total++
+			series = domain.NewMetricSeries(samples[0])
+		}
+
+		slices.SortStableFunc(samples, func(a, b domain.MetricSample) int {
+			return a.Timestamp.Compare(b.Timestamp)
+		})
+		accepted := 0
+		for _, sample := range samples {
+			if sample.Kind != series.Kind {
+				resp.reject(fmt.Errorf("%w: %s is a %s, not a %s", ErrIngestMetricsScriptInvalidSample, key, series.Kind, sample.Kind))
+				continue
+			}
+			accepted++
+			if delta, ok := series.Accumulate(sample); ok {
+				recorded = append(recorded, delta)
+			}
+		}
+		resp.Accepted += accepted
+		if accepted > 0 {
+			changed = append(changed, series)
+		}
+	}
+
+	if err := s.metricRepo.SaveMetricSamples(ctx, recorded); err != nil {
+		return nil, err
+	}
+
+	for resolution := range domain.MetricRollupRetentions {
+		if err := s.metricRepo.RecordMetricRollups(ctx, resolution, domain.RollUpMetricSamples(recorded, resolution)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The totals move on once what they counted is recorded.
+	if err := s.metricSeriesRepo.SaveMetricSeries(ctx, changed); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
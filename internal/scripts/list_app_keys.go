@@ -0,0 +1,45 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type ListAppKeysReq struct {
+	UserID string `json:"-"`
+	AppID  string `json:"-"`
+}
+
+type ListAppKeysResp struct {
+	Keys []domain.AppKey `json:"keys"`
+}
+
+type ListAppKeysScript struct {
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
+}
+
+func NewListAppKeysScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *ListAppKeysScript {
+	return &ListAppKeysScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
+}
+
+func (s *ListAppKeysScript) Exec(ctx context.Context, req ListAppKeysReq) (*ListAppKeysResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.appKeyRepo.ListAppKeys(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("appId", domain.Equals, app.ID()),
+		},
+		domain.EmptyPagination,
+		domain.NewSort("createdAt", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListAppKeysResp{Keys: keys}, nil
+}
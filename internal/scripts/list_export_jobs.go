@@ -0,0 +1,46 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type ListExportJobsReq struct {
+	UserID string `json:"-"`
+	Page   int    `form:"page"`
+	Limit  int    `form:"limit"`
+}
+
+type ListExportJobsResp struct {
+	Data []domain.ExportJob `json:"data"`
+}
+
+type ListExportJobsScript struct {
+	exportJobRepo domain.ExportJobRepo
+}
+
+func NewListExportJobsScript(exportJobRepo domain.ExportJobRepo) *ListExportJobsScript {
+	return &ListExportJobsScript{exportJobRepo: exportJobRepo}
+}
+
+// Exec lists the export jobs of the user, the newest first.
+func (s *ListExportJobsScript) Exec(ctx context.Context, req ListExportJobsReq) (*ListExportJobsResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := s.exportJobRepo.ListExportJobs(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("userId", domain.Equals, userID),
+		},
+		domain.NewPagination(req.Limit, (req.Page-1)*req.Limit),
+		domain.NewSort("createdAt", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListExportJobsResp{Data: jobs}, nil
+}
@@ -0,0 +1,74 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+type ListIssuesReq struct {
+	UserID     string `json:"-"`
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+	SortOrder  string `form:"sortOrder"`
+	Status     string `form:"status"`
+	AppID      string `form:"appId"`
+	SearchTerm string `form:"searchTerm"`
+}
+
+type ListIssuesResp struct {
+	Data []domain.Issue `json:"data"`
+}
+
+type ListIssuesScript struct {
+	issueRepo domain.IssueRepo
+}
+
+func NewListIssuesScript(issueRepo domain.IssueRepo) *ListIssuesScript {
+	return &ListIssuesScript{issueRepo: issueRepo}
+}
+
+// Exec lists the issues of the user, the most recently seen first.
+func (s *ListIssuesScript) Exec(ctx context.Context, req ListIssuesReq) (*ListIssuesResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []domain.Filter{
+		domain.NewFilter("userId", domain.Equals, userID),
+	}
+
+	if strings.TrimSpace(req.Status) != "" {
+		filters = append(filters, domain.NewFilter("status", domain.Equals, req.Status))
+	}
+
+	if strings.TrimSpace(req.AppID) != "" {
+		appID, err := domain.NewID(req.AppID)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, domain.NewFilter("appIds", domain.Equals, appID))
+	}
+
+	if strings.TrimSpace(req.SearchTerm) != "" {
+		filters = append(filters, domain.NewFilter("title", domain.Like, req.SearchTerm))
+	}
+
+	sortOrder := domain.SortOrder(req.SortOrder)
+	if sortOrder == "" {
+		sortOrder = domain.Desc
+	}
+
+	issues, err := s.issueRepo.ListIssues(ctx, domain.NewCriteria(
+		filters,
+		domain.NewPagination(req.Limit, (req.Page-1)*req.Limit),
+		domain.NewSort("lastSeen", sortOrder),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListIssuesResp{Data: issues}, nil
+}
@@ -0,0 +1,49 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+type ListLogMetricsReq struct {
+	UserID     string `json:"-"`
+	SearchTerm string `form:"searchTerm"`
+}
+
+type ListLogMetricsResp struct {
+	Data []domain.LogMetric `json:"data"`
+}
+
+type ListLogMetricsScript struct {
+	logMetricRepo domain.LogMetricRepo
+}
+
+func NewListLogMetricsScript(logMetricRepo domain.LogMetricRepo) *ListLogMetricsScript {
+	return &ListLogMetricsScript{logMetricRepo: logMetricRepo}
+}
+
+// Exec lists the metrics of the user by name.
+func (s *ListLogMetricsScript) Exec(ctx context.Context, req ListLogMetricsReq) (*ListLogMetricsResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []domain.Filter{domain.NewFilter("userId", domain.Equals, userID)}
+	if strings.TrimSpace(req.SearchTerm) != "" {
+		filters = append(filters, domain.NewFilter("name", domain.Like, req.SearchTerm))
+	}
+
+	metrics, err := s.logMetricRepo.ListLogMetrics(ctx, domain.NewCriteria(
+		filters,
+		domain.EmptyPagination,
+		domain.NewSort("name", domain.Asc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListLogMetricsResp{Data: metrics}, nil
+}
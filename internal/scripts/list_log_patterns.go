@@ -0,0 +1,296 @@
+package scripts
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrListLogPatternsScriptInvalidList = errors.New("invalid pattern list")
+)
+
+const (
+	defaultLogPatternsLimit = 50
+	maxLogPatternsLimit     = 500
+	// defaultLogPatternsRange is the time range listed when from is not
+	// given.
+	defaultLogPatternsRange = 24 * time.Hour
+	// logPatternTrendBuckets is about how many counts the trend of a pattern
+	// has.
+	logPatternTrendBuckets = 24
+)
+
+// LogPatternsSort is the order patterns are listed in.
+type LogPatternsSort string
+
+const (
+	// LogPatternsSortCount lists the patterns with the most logs first.
+	LogPatternsSortCount LogPatternsSort = "count"
+	// LogPatternsSortNew lists the patterns first seen in the range first.
+	LogPatternsSortNew LogPatternsSort = "new"
+	// LogPatternsSortChange lists the patterns that grew the most since the
+	// previous range first.
+	LogPatternsSortChange LogPatternsSort = "change"
+)
+
+type ListLogPatternsReq struct {
+	SearchLogsReq
+	// Sort is count, new or change, count by default.
+	Sort LogPatternsSort `form:"sort"`
+}
+
+// LogPatternStats are the logs of a pattern in the time range, compared with
+// the previous range of the same length.
+type LogPatternStats struct {
+	Pattern       domain.LogPattern `json:"pattern"`
+	Count         int64             `json:"count"`
+	PreviousCount int64             `json:"previousCount"`
+	// Change is the relative change of the count, nil when the pattern had
+	// no logs in the previous range.
+	Change *float64 `json:"change"`
+	// New is set when the pattern was first seen in the range.
+	New bool `json:"new"`
+	// Trend are the counts of the pattern from each of TrendStarts.
+	Trend []int64 `json:"trend"`
+}
+
+type ListLogPatternsResp struct {
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	TrendInterval string            `json:"trendInterval"`
+	TrendStarts   []time.Time       `json:"trendStarts"`
+	Total         int               `json:"total"`
+	Patterns      []LogPatternStats `json:"patterns"`
+}
+
+type ListLogPatternsScript struct {
+	search         *SearchLogsScript
+	logRepo        domain.LogRepo
+	logPatternRepo domain.LogPatternRepo
+}
+
+func NewListLogPatternsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, logPatternRepo domain.LogPatternRepo, guard *QueryGuard) *ListLogPatternsScript {
+	return &ListLogPatternsScript{
+		search:         NewSearchLogsScript(appRepo, logRepo, guard),
+		logRepo:        logRepo,
+		logPatternRepo: logPatternRepo,
+	}
+}
+
+// Exec counts the logs of each pattern among the logs of a search, in its
+// time range and in the range before it.
+func (s *ListLogPatternsScript) Exec(ctx context.Context, req ListLogPatternsReq) (*ListLogPatternsResp, error) {
+	sort := cmp.Or(req.Sort, LogPatternsSortCount)
+	if !slices.Contains([]LogPatternsSort{LogPatternsSortCount, LogPatternsSortNew, LogPatternsSortChange}, sort) {
+		return nil, fmt.Errorf("%w: sort must be count, new or change", ErrListLogPatternsScriptInvalidList)
+	}
+
+	to := req.To.UTC()
+	if req.To.IsZero() {
+		to = Now().UTC()
+	}
+	from := req.From.UTC()
+	if req.From.IsZero() {
+		from = to.Add(-defaultLogPatternsRange)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrListLogPatternsScriptInvalidList)
+	}
+
+	// The time range is applied apart, to count the previous range too.
+	previous := domain.Range{From: from.Add(-to.Sub(from)), To: from}
+	search, err := s.search.prepareWindows(ctx, req.SearchLogsReq, domain.Range{From: from, To: to}, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeFilters := []domain.Filter{
+		domain.NewFilter("timestamp", domain.GreaterThanOrEqual, from),
+		domain.NewFilter("timestamp", domain.LessThanOrEqual, to),
+	}
+	previousFilters := []domain.Filter{
+		domain.NewFilter("timestamp", domain.GreaterThanOrEqual, previous.From),
+		domain.NewFilter("timestamp", domain.LessThan, previous.To),
+	}
+	for _, filters := range [][]domain.Filter{rangeFilters, previousFilters} {
+		if err := s.search.checkScan(ctx, search, filters...); err != nil {
+			return nil, err
+		}
+	}
+
+	counts, err := s.countLogs(ctx, search, rangeFilters...)
+	if err != nil {
+		return nil, err
+	}
+
+	previousCounts, err := s.countLogs(ctx, search, previousFilters...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Patterns that stopped are listed too, with no logs in the range.
+	ids := make([]any, 0, len(counts)+len(previousCounts))
+	for hexID := range counts {
+		if id, err := domain.NewID(hexID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	for hexID := range previousCounts {
+		if _, ok := counts[hexID]; ok {
+			continue
+		}
+		if id, err := domain.NewID(hexID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	patterns, err := s.logPatternRepo.ListLogPatterns(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("_id", domain.In, ids),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]LogPatternStats, len(patterns))
+	for i, pattern := range patterns {
+		stats[i] = LogPatternStats{
+			Pattern:       pattern,
+			Count:         counts[pattern.ID().Hex()],
+			PreviousCount: previousCounts[pattern.ID().Hex()],
+			New:           !pattern.FirstSeen().Before(from),
+		}
+		if stats[i].PreviousCount > 0 {
+			change := float64(stats[i].Count-stats[i].PreviousCount) / float64(stats[i].PreviousCount)
+			stats[i].Change = &change
+		}
+	}
+	sortLogPatternStats(stats, sort)
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultLogPatternsLimit
+	}
+	limit = min(limit, maxLogPatternsLimit)
+	offset := min(max(req.Page-1, 0)*limit, len(stats))
+	page := stats[offset:min(offset+limit, len(stats))]
+
+	interval := logPatternTrendInterval(to.Sub(from))
+	starts, err := s.trends(ctx, search, page, interval, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListLogPatternsResp{
+		From:          from,
+		To:            to,
+		TrendInterval: interval.String(),
+		TrendStarts:   starts,
+		Total:         len(stats),
+		Patterns:      page,
+	}, nil
+}
+
+// countLogs counts the logs of the search per pattern.
+func (s *ListLogPatternsScript) countLogs(ctx context.Context, search *logSearch, filters ...domain.Filter) (map[string]int64, error) {
+	filters = append(filters, domain.NewFilter("patternId", domain.Exists, true))
+	rows, _, err := s.logRepo.AggregateLogs(ctx, search.criteria(domain.EmptyPagination, domain.EmptySort, filters...), domain.LogAggregation{
+		Groups:  []domain.AggregationGroup{{Field: "patternId"}},
+		MaxRows: maxAggregationRows,
+	})
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		if id, ok := row.Keys[0].(string); ok && id != "" {
+			counts[id] = row.Count
+		}
+	}
+	return counts, nil
+}
+
+// trends fills the trend of the patterns and returns the starts of its
+// buckets.
+func (s *ListLogPatternsScript) trends(ctx context.Context, search *logSearch, stats []LogPatternStats, interval domain.Interval, from time.Time, to time.Time) ([]time.Time, error) {
+	ids := make([]string, len(stats))
+	for i, stat := range stats {
+		ids[i] = stat.Pattern.ID().Hex()
+	}
+
+	var rawBuckets []domain.LogHistogramBucket
+	if len(ids) > 0 {
+		criteria := search.criteria(domain.EmptyPagination, domain.EmptySort,
+			domain.NewFilter("timestamp", domain.GreaterThanOrEqual, from),
+			domain.NewFilter("timestamp", domain.LessThanOrEqual, to),
+			domain.NewFilter("patternId", domain.In, ids),
+		)
+
+		var err error
+		rawBuckets, err = s.logRepo.HistogramLogs(ctx, criteria, interval, time.UTC, "patternId")
+		if err != nil && mongo.IsTimeout(err) {
+			return nil, ErrSearchLogsScriptTimeout
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buckets := fillHistogramBuckets(rawBuckets, ids, interval, from, to)
+	starts := make([]time.Time, len(buckets))
+	for i, bucket := range buckets {
+		starts[i] = bucket.Start
+	}
+	for i := range stats {
+		stats[i].Trend = make([]int64, len(buckets))
+		for j, bucket := range buckets {
+			stats[i].Trend[j] = bucket.Series[ids[i]]
+		}
+	}
+	return starts, nil
+}
+
+func sortLogPatternStats(stats []LogPatternStats, sort LogPatternsSort) {
+	slices.SortFunc(stats, func(a, b LogPatternStats) int {
+		byCount := cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Pattern.Template(), b.Pattern.Template()))
+		switch sort {
+		case LogPatternsSortNew:
+			if a.New != b.New {
+				if a.New {
+					return -1
+				}
+				return 1
+			}
+		case LogPatternsSortChange:
+			return cmp.Or(cmp.Compare(b.Count-b.PreviousCount, a.Count-a.PreviousCount), byCount)
+		}
+		return byCount
+	})
+}
+
+// logPatternTrendInterval is the shortest interval that gives about
+// logPatternTrendBuckets over the range.
+func logPatternTrendInterval(timeRange time.Duration) domain.Interval {
+	for _, value := range histogramIntervals {
+		interval, _ := domain.ParseInterval(value)
+		if timeRange/interval.Duration() <= logPatternTrendBuckets {
+			return interval
+		}
+	}
+	return domain.MaxInterval
+}
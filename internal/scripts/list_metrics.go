@@ -0,0 +1,48 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+// maxMetricNames bounds the metrics listed, a search narrowing them.
+const maxMetricNames = 1000
+
+type ListMetricsReq struct {
+	UserID string `json:"-"`
+	// AppIDs are the apps the metrics are of, all the apps of the user by
+	// default.
+	AppIDs     []string `form:"appIds"`
+	SearchTerm string   `form:"searchTerm"`
+}
+
+type ListMetricsResp struct {
+	Data []domain.MetricName `json:"data"`
+}
+
+type ListMetricsScript struct {
+	appRepo          domain.AppRepo
+	metricSeriesRepo domain.MetricSeriesRepo
+}
+
+func NewListMetricsScript(appRepo domain.AppRepo, metricSeriesRepo domain.MetricSeriesRepo) *ListMetricsScript {
+	return &ListMetricsScript{appRepo: appRepo, metricSeriesRepo: metricSeriesRepo}
+}
+
+// Exec lists the metrics the apps of the user reported, by name, with their
+// kinds and the names of their labels.
+func (s *ListMetricsScript) Exec(ctx context.Context, req ListMetricsReq) (*ListMetricsResp, error) {
+	appIDs, err := getUserAppIDsOrAll(ctx, s.appRepo, req.UserID, req.AppIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := s.metricSeriesRepo.ListMetricNames(ctx, appIDs, strings.TrimSpace(req.SearchTerm), maxMetricNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListMetricsResp{Data: names}, nil
+}
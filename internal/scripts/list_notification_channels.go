@@ -0,0 +1,44 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type ListNotificationChannelsReq struct {
+	UserID string `json:"-"`
+}
+
+type ListNotificationChannelsResp struct {
+	Data []domain.NotificationChannel `json:"data"`
+}
+
+type ListNotificationChannelsScript struct {
+	notificationChannelRepo domain.NotificationChannelRepo
+}
+
+func NewListNotificationChannelsScript(notificationChannelRepo domain.NotificationChannelRepo) *ListNotificationChannelsScript {
+	return &ListNotificationChannelsScript{notificationChannelRepo: notificationChannelRepo}
+}
+
+// Exec lists the notification channels of the user, the newest first.
+func (s *ListNotificationChannelsScript) Exec(ctx context.Context, req ListNotificationChannelsReq) (*ListNotificationChannelsResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := s.notificationChannelRepo.ListNotificationChannels(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("userId", domain.Equals, userID),
+		},
+		domain.EmptyPagination,
+		domain.NewSort("createdAt", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListNotificationChannelsResp{Data: channels}, nil
+}
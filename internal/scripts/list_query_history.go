@@ -0,0 +1,57 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+type ListQueryHistoryReq struct {
+	UserID     string `json:"-"`
+	Page       int    `form:"page"`
+	Limit      int    `form:"limit"`
+	SearchTerm string `form:"searchTerm"`
+}
+
+type ListQueryHistoryResp struct {
+	Data []domain.QueryHistoryEntry `json:"data"`
+}
+
+type ListQueryHistoryScript struct {
+	queryHistoryRepo domain.QueryHistoryRepo
+}
+
+func NewListQueryHistoryScript(queryHistoryRepo domain.QueryHistoryRepo) *ListQueryHistoryScript {
+	return &ListQueryHistoryScript{queryHistoryRepo: queryHistoryRepo}
+}
+
+// Exec lists the latest searches of the user, the last run first.
+func (s *ListQueryHistoryScript) Exec(ctx context.Context, req ListQueryHistoryReq) (*ListQueryHistoryResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []domain.Filter{
+		domain.NewFilter("userId", domain.Equals, userID),
+	}
+
+	if strings.TrimSpace(req.SearchTerm) != "" {
+		filters = append(filters, domain.NewOrFilter(
+			domain.NewFilter("query", domain.Like, req.SearchTerm),
+			domain.NewFilter("searchTerm", domain.Like, req.SearchTerm),
+		))
+	}
+
+	entries, err := s.queryHistoryRepo.ListQueryHistory(ctx, domain.NewCriteria(
+		filters,
+		domain.NewPagination(req.Limit, (req.Page-1)*req.Limit),
+		domain.NewSort("lastRunAt", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListQueryHistoryResp{Data: entries}, nil
+}
@@ -0,0 +1,67 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+type ListSavedSearchesReq struct {
+	UserID     string `json:"-"`
+	SearchTerm string `form:"searchTerm"`
+	// Shared lists only the searches shared with the account when true, or
+	// only the private ones of the user when false.
+	Shared *bool `form:"shared"`
+}
+
+type ListSavedSearchesResp struct {
+	Data []domain.SavedSearch `json:"data"`
+}
+
+type ListSavedSearchesScript struct {
+	userRepo        domain.UserRepo
+	savedSearchRepo domain.SavedSearchRepo
+}
+
+func NewListSavedSearchesScript(userRepo domain.UserRepo, savedSearchRepo domain.SavedSearchRepo) *ListSavedSearchesScript {
+	return &ListSavedSearchesScript{userRepo: userRepo, savedSearchRepo: savedSearchRepo}
+}
+
+// Exec lists the searches saved by the user and those shared with its
+// account, by name.
+func (s *ListSavedSearchesScript) Exec(ctx context.Context, req ListSavedSearchesReq) (*ListSavedSearchesResp, error) {
+	user, accountID, err := getUserAccount(ctx, s.userRepo, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []domain.Filter{
+		domain.NewOrFilter(
+			domain.NewFilter("userId", domain.Equals, user.ID()),
+			domain.NewAndFilter(
+				domain.NewFilter("accountId", domain.Equals, accountID),
+				domain.NewFilter("shared", domain.Equals, true),
+			),
+		),
+	}
+
+	if req.Shared != nil {
+		filters = append(filters, domain.NewFilter("shared", domain.Equals, *req.Shared))
+	}
+
+	if strings.TrimSpace(req.SearchTerm) != "" {
+		filters = append(filters, domain.NewFilter("name", domain.Like, req.SearchTerm))
+	}
+
+	searches, err := s.savedSearchRepo.ListSavedSearches(ctx, domain.NewCriteria(
+		filters,
+		domain.EmptyPagination,
+		domain.NewSort("name", domain.Asc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListSavedSearchesResp{Data: searches}, nil
+}
@@ -0,0 +1,80 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+type ListSchemaDriftEventsReq struct {
+	UserID string    `json:"-"`
+	Page   int       `form:"page"`
+	Limit  int       `form:"limit"`
+	AppID  string    `form:"appId"`
+	Kind   string    `form:"kind"`
+	Path   string    `form:"path"`
+	From   time.Time `form:"from"`
+	To     time.Time `form:"to"`
+}
+
+type ListSchemaDriftEventsResp struct {
+	Data []domain.SchemaDriftEvent `json:"data"`
+}
+
+type ListSchemaDriftEventsScript struct {
+	schemaDriftEventRepo domain.SchemaDriftEventRepo
+}
+
+func NewListSchemaDriftEventsScript(schemaDriftEventRepo domain.SchemaDriftEventRepo) *ListSchemaDriftEventsScript {
+	return &ListSchemaDriftEventsScript{schemaDriftEventRepo: schemaDriftEventRepo}
+}
+
+// Exec lists the schema drift events of the apps of the user, the latest
+// detected first.
+func (s *ListSchemaDriftEventsScript) Exec(ctx context.Context, req ListSchemaDriftEventsReq) (*ListSchemaDriftEventsResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := []domain.Filter{
+		domain.NewFilter("userId", domain.Equals, userID),
+	}
+
+	if strings.TrimSpace(req.AppID) != "" {
+		appID, err := domain.NewID(req.AppID)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, domain.NewFilter("appId", domain.Equals, appID))
+	}
+
+	if strings.TrimSpace(req.Kind) != "" {
+		filters = append(filters, domain.NewFilter("kind", domain.Equals, req.Kind))
+	}
+
+	if strings.TrimSpace(req.Path) != "" {
+		filters = append(filters, domain.NewFilter("path", domain.StartsWith, req.Path))
+	}
+
+	if !req.From.IsZero() {
+		filters = append(filters, domain.NewFilter("detectedAt", domain.GreaterThanOrEqual, req.From.UTC()))
+	}
+
+	if !req.To.IsZero() {
+		filters = append(filters, domain.NewFilter("detectedAt", domain.LessThanOrEqual, req.To.UTC()))
+	}
+
+	events, err := s.schemaDriftEventRepo.ListSchemaDriftEvents(ctx, domain.NewCriteria(
+		filters,
+		domain.NewPagination(req.Limit, (req.Page-1)*req.Limit),
+		domain.NewSort("detectedAt", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListSchemaDriftEventsResp{Data: events}, nil
+}
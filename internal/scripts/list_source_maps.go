@@ -0,0 +1,47 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type ListSourceMapsReq struct {
+	UserID  string `json:"-"`
+	AppID   string `json:"-"`
+	Release string `json:"-"`
+}
+
+type ListSourceMapsResp struct {
+	SourceMaps []domain.SourceMap `json:"sourceMaps"`
+}
+
+type ListSourceMapsScript struct {
+	appRepo       domain.AppRepo
+	sourceMapRepo domain.SourceMapRepo
+}
+
+func NewListSourceMapsScript(appRepo domain.AppRepo, sourceMapRepo domain.SourceMapRepo) *ListSourceMapsScript {
+	return &ListSourceMapsScript{appRepo: appRepo, sourceMapRepo: sourceMapRepo}
+}
+
+func (s *ListSourceMapsScript) Exec(ctx context.Context, req ListSourceMapsReq) (*ListSourceMapsResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceMaps, err := s.sourceMapRepo.ListSourceMaps(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("appId", domain.Equals, app.ID()),
+			domain.NewFilter("release", domain.Equals, req.Release),
+		},
+		domain.EmptyPagination,
+		domain.NewSort("file", domain.Asc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListSourceMapsResp{SourceMaps: sourceMaps}, nil
+}
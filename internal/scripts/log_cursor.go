@@ -0,0 +1,82 @@
+package scripts
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrSearchLogsScriptInvalidCursor = errors.New("invalid cursor")
+)
+
+// logCursor is the position of a log in a search sorted by timestamp and
+// then by ID, which keeps logs with the same timestamp in a stable order.
+// Logs ingested while paging are never skipped nor repeated.
+type logCursor struct {
+	Timestamp time.Time        `json:"t"`
+	ID        string           `json:"id"`
+	Order     domain.SortOrder `json:"o"`
+	// Backward is set on the cursor of the previous page.
+	Backward bool `json:"b,omitempty"`
+}
+
+func newLogCursor(log domain.Log, order domain.SortOrder, backward bool) string {
+	content, _ := json.Marshal(logCursor{
+		Timestamp: log.Timestamp(),
+		ID:        log.ID().Hex(),
+		Order:     order,
+		Backward:  backward,
+	})
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+func decodeLogCursor(value string) (*logCursor, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrSearchLogsScriptInvalidCursor
+	}
+
+	var cursor logCursor
+	if err := json.Unmarshal(content, &cursor); err != nil {
+		return nil, ErrSearchLogsScriptInvalidCursor
+	}
+
+	if _, err := domain.NewID(cursor.ID); err != nil || cursor.Timestamp.IsZero() {
+		return nil, ErrSearchLogsScriptInvalidCursor
+	}
+
+	if cursor.Order != domain.Desc {
+		cursor.Order = domain.Asc
+	}
+	return &cursor, nil
+}
+
+// scanOrder is the order logs are read in, the previous page is read
+// backwards from the cursor.
+func (c *logCursor) scanOrder() domain.SortOrder {
+	if c.Backward == (c.Order == domain.Desc) {
+		return domain.Asc
+	}
+	return domain.Desc
+}
+
+// filter matches the logs after the cursor in the scan order.
+func (c *logCursor) filter() domain.Filter {
+	id, _ := domain.NewID(c.ID)
+	comparison := domain.GreaterThan
+	if c.scanOrder() == domain.Desc {
+		comparison = domain.LessThan
+	}
+
+	return domain.NewOrFilter(
+		domain.NewFilter("timestamp", comparison, c.Timestamp),
+		domain.NewAndFilter(
+			domain.NewFilter("timestamp", domain.Equals, c.Timestamp),
+			domain.NewFilter("_id", comparison, id),
+		),
+	)
+}
@@ -0,0 +1,68 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"monitoring/internal/domain"
+)
+
+type MergeIssuesReq struct {
+	UserID   string   `json:"-"`
+	IssueID  string   `json:"-"`
+	IssueIDs []string `json:"issueIds"`
+}
+
+type MergeIssuesResp struct {
+	Issue domain.Issue `json:"issue"`
+}
+
+type MergeIssuesScript struct {
+	issueRepo domain.IssueRepo
+}
+
+func NewMergeIssuesScript(issueRepo domain.IssueRepo) *MergeIssuesScript {
+	return &MergeIssuesScript{issueRepo: issueRepo}
+}
+
+// Exec merges the given issues into the target one and deletes them. Their
+// future events are grouped into the target issue.
+func (s *MergeIssuesScript) Exec(ctx context.Context, req MergeIssuesReq) (*MergeIssuesResp, error) {
+	if len(req.IssueIDs) == 0 {
+		return nil, errors.New("at least one issue to merge is required")
+	}
+
+	target, err := getUserIssue(ctx, s.issueRepo, req.UserID, req.IssueID)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make([]domain.Issue, 0, len(req.IssueIDs))
+	for _, issueID := range req.IssueIDs {
+		if slices.ContainsFunc(merged, func(issue domain.Issue) bool { return issue.ID().Hex() == issueID }) {
+			continue
+		}
+
+		issue, err := getUserIssue(ctx, s.issueRepo, req.UserID, issueID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = target.CheckMerge(*issue)
+		if err != nil {
+			return nil, err
+		}
+
+		merged = append(merged, *issue)
+	}
+
+	for _, issue := range merged {
+		target, err = s.issueRepo.MergeIssue(ctx, target.ID(), issue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &MergeIssuesResp{Issue: *target}, nil
+}
@@ -0,0 +1,229 @@
+package scripts
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/drain"
+)
+
+const (
+	// maxLogPatterns is how many patterns an app can have. Past it, logs
+	// that do not fit a known pattern are left without one.
+	maxLogPatterns = 1000
+	// logPatternTreeTTL is how long the patterns of an app are mined in
+	// memory before being loaded again, with those other servers learned.
+	logPatternTreeTTL = time.Minute
+)
+
+// logPatternTrees are the patterns of the apps that sent logs lately, so
+// batches are not matched against patterns loaded on every request.
+var logPatternTrees = &patternTrees{trees: map[domain.ID]*patternTree{}}
+
+type patternTrees struct {
+	mu    sync.Mutex
+	trees map[domain.ID]*patternTree
+}
+
+// patternTree is the tree of the patterns of an app, which the batches of
+// the app match one at a time, since matching changes it.
+type patternTree struct {
+	mu       sync.Mutex
+	tree     *drain.Tree
+	loadedAt time.Time
+}
+
+// get returns the tree of the app, dropping those of the apps that sent no
+// logs for a while.
+func (t *patternTrees) get(appID domain.ID, now time.Time) *patternTree {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, tree := range t.trees {
+		if id != appID && tree.mu.TryLock() {
+			if now.Sub(tree.loadedAt) > logPatternTreeTTL {
+				delete(t.trees, id)
+			}
+			tree.mu.Unlock()
+		}
+	}
+
+	tree, ok := t.trees[appID]
+	if !ok {
+		tree = &patternTree{}
+		t.trees[appID] = tree
+	}
+	return tree
+}
+
+type MineLogPatternsReq struct {
+	App  domain.App
+	Logs []domain.Log
+}
+
+type MineLogPatternsResp struct {
+	// Logs are the logs of each pattern, to add to it once they are saved.
+	Logs []domain.LogPatternLogs
+}
+
+type MineLogPatternsScript struct {
+	logPatternRepo domain.LogPatternRepo
+}
+
+func NewMineLogPatternsScript(logPatternRepo domain.LogPatternRepo) *MineLogPatternsScript {
+	return &MineLogPatternsScript{logPatternRepo: logPatternRepo}
+}
+
+// patternLogs are the logs of a batch that share a pattern.
+type patternLogs struct {
+	cluster   *drain.Cluster
+	change    drain.Change
+	logs      []*domain.Log
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// Exec matches the messages of the logs against the patterns of the app,
+// learning new patterns or generalizing known ones, and tags each log with
+// the ID of its pattern. The logs are not counted, so those that fail to be
+// saved are not. On error, the logs are left without patterns.
+func (s *MineLogPatternsScript) Exec(ctx context.Context, req MineLogPatternsReq) (*MineLogPatternsResp, error) {
+	now := Now()
+	entry := logPatternTrees.get(req.App.ID(), now)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	resp, err := s.mine(ctx, entry, now, req)
+	if err != nil {
+		// The tree may hold what was not recorded.
+		entry.tree = nil
+		for i := range req.Logs {
+			req.Logs[i].ChangePatternID("")
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *MineLogPatternsScript) mine(ctx context.Context, entry *patternTree, now time.Time, req MineLogPatternsReq) (*MineLogPatternsResp, error) {
+	if entry.tree == nil || now.Sub(entry.loadedAt) > logPatternTreeTTL {
+		tree, err := s.load(ctx, req.App.ID())
+		if err != nil {
+			return nil, err
+		}
+		entry.tree, entry.loadedAt = tree, now
+	}
+	tree := entry.tree
+
+	groups := make([]*patternLogs, 0)
+	byCluster := make(map[*drain.Cluster]*patternLogs)
+	for i := range req.Logs {
+		log := &req.Logs[i]
+		tokens := drain.Tokenize(logMessage(log.Data(), log.Raw(), log.StackTrace()))
+		if len(tokens) == 0 {
+			continue
+		}
+
+		cluster, change := tree.Match(tokens, tree.Len() < maxLogPatterns)
+		if cluster == nil {
+			continue
+		}
+		if change == drain.Created {
+			cluster.ID = domain.NewAutoID().Hex()
+		}
+
+		group, ok := byCluster[cluster]
+		if !ok {
+			group = &patternLogs{cluster: cluster, firstSeen: log.Timestamp()}
+			byCluster[cluster] = group
+			groups = append(groups, group)
+		}
+
+		if change == drain.Created {
+			group.change = drain.Created
+		}
+		group.logs = append(group.logs, log)
+		if log.Timestamp().Before(group.firstSeen) {
+			group.firstSeen = log.Timestamp()
+		}
+		if log.Timestamp().After(group.lastSeen) {
+			group.lastSeen = log.Timestamp()
+		}
+	}
+
+	resp := &MineLogPatternsResp{Logs: make([]domain.LogPatternLogs, 0, len(groups))}
+	for _, group := range groups {
+		id, err := domain.NewID(group.cluster.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		logs := domain.LogPatternLogs{
+			PatternID: id,
+			AppID:     req.App.ID(),
+			New:       group.change == drain.Created,
+			Template:  group.cluster.Template(),
+			Count:     int64(len(group.logs)),
+			FirstSeen: group.firstSeen,
+			LastSeen:  group.lastSeen,
+		}
+		pattern, err := s.logPatternRepo.RecordLogPattern(ctx, logs)
+		if err != nil {
+			return nil, err
+		}
+
+		// A pattern learned by another batch at once has the ID it was
+		// recorded with.
+		logs.PatternID = pattern.ID()
+		group.cluster.ID = pattern.ID().Hex()
+		for _, log := range group.logs {
+			log.ChangePatternID(group.cluster.ID)
+		}
+		resp.Logs = append(resp.Logs, logs)
+	}
+
+	return resp, nil
+}
+
+func (s *MineLogPatternsScript) load(ctx context.Context, appID domain.ID) (*drain.Tree, error) {
+	known, err := s.logPatternRepo.ListLogPatterns(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("appId", domain.Equals, appID),
+		},
+		domain.NewPagination(maxLogPatterns, 0),
+		domain.NewSort("lastSeen", domain.Desc),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tree := drain.NewTree()
+	for _, pattern := range known {
+		tree.Add(&drain.Cluster{ID: pattern.ID().Hex(), Tokens: strings.Split(pattern.Template(), " ")})
+	}
+	return tree, nil
+}
+
+// mineLogPatterns tags the logs with their patterns before they are saved,
+// and returns the logs of each pattern to pass to countLogPatterns once they
+// are. Patterns are secondary, so logs are saved without them when mining
+// fails.
+func mineLogPatterns(ctx context.Context, logPatternRepo domain.LogPatternRepo, app domain.App, logs []domain.Log) []domain.LogPatternLogs {
+	resp, err := NewMineLogPatternsScript(logPatternRepo).Exec(ctx, MineLogPatternsReq{App: app, Logs: logs})
+	if err != nil {
+		log.Printf("mining the log patterns of app %s: %v", app.ID().Hex(), err)
+		return nil
+	}
+	return resp.Logs
+}
+
+// countLogPatterns adds the saved logs to their patterns.
+func countLogPatterns(ctx context.Context, logPatternRepo domain.LogPatternRepo, app domain.App, logs []domain.LogPatternLogs) {
+	if err := logPatternRepo.AddLogPatternLogs(ctx, logs); err != nil {
+		log.Printf("counting the log patterns of app %s: %v", app.ID().Hex(), err)
+	}
+}
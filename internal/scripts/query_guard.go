@@ -0,0 +1,44 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+)
+
+// QueryGuard holds the server-wide limits of the searches of the logs, which
+// the policy of the account of a user can narrow.
+type QueryGuard struct {
+	limits          domain.QueryLimits
+	userRepo        domain.UserRepo
+	queryPolicyRepo domain.QueryPolicyRepo
+}
+
+func NewQueryGuard(limits domain.QueryLimits, userRepo domain.UserRepo, queryPolicyRepo domain.QueryPolicyRepo) *QueryGuard {
+	return &QueryGuard{limits: limits, userRepo: userRepo, queryPolicyRepo: queryPolicyRepo}
+}
+
+// Limits returns the limits of the searches of the user. A nil guard has no
+// limits.
+func (g *QueryGuard) Limits(ctx context.Context, userID string) (domain.QueryLimits, error) {
+	if g == nil {
+		return domain.QueryLimits{}, nil
+	}
+
+	_, accountID, err := getUserAccount(ctx, g.userRepo, userID)
+	if err != nil {
+		return domain.QueryLimits{}, err
+	}
+
+	policy, err := g.queryPolicyRepo.GetQueryPolicy(ctx, accountID)
+	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
+		return g.limits, nil
+	}
+	if err != nil {
+		return domain.QueryLimits{}, err
+	}
+	return g.limits.Stricter(policy.Limits()), nil
+}
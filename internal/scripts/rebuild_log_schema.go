@@ -0,0 +1,110 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+// rebuildLogSchemaBatch is how many logs are walked before adding them to
+// the catalog, like a batch of ingested logs.
+const rebuildLogSchemaBatch = 1000
+
+type RebuildLogSchemaReq struct {
+	// AppID is the app whose catalog is rebuilt, all apps when empty.
+	AppID string
+}
+
+type RebuildLogSchemaResp struct {
+	Apps int
+	Logs int64
+}
+
+type RebuildLogSchemaScript struct {
+	appRepo       domain.AppRepo
+	logRepo       domain.LogRepo
+	logSchemaRepo domain.LogSchemaRepo
+}
+
+func NewRebuildLogSchemaScript(appRepo domain.AppRepo, logRepo domain.LogRepo, logSchemaRepo domain.LogSchemaRepo) *RebuildLogSchemaScript {
+	return &RebuildLogSchemaScript{appRepo: appRepo, logRepo: logRepo, logSchemaRepo: logSchemaRepo}
+}
+
+// Exec empties the catalogs and walks all the logs of the apps again. Schema
+// drift is not detected while a catalog is rebuilt, and the logs ingested
+// once it started are added by the ingestion rather than walked, so only
+// those of the second it starts can be counted twice. A catalog whose
+// rebuild fails stays marked as rebuilding until it is run again.
+func (s *RebuildLogSchemaScript) Exec(ctx context.Context, req RebuildLogSchemaReq) (*RebuildLogSchemaResp, error) {
+	filters := []domain.Filter{}
+	if strings.TrimSpace(req.AppID) != "" {
+		appID, err := domain.NewID(req.AppID)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, domain.NewFilter("_id", domain.Equals, appID))
+	}
+
+	apps, err := s.appRepo.ListApps(ctx, domain.NewCriteria(filters, domain.EmptyPagination, domain.EmptySort))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RebuildLogSchemaResp{}
+	for _, app := range apps {
+		logs, err := s.rebuild(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+		resp.Apps++
+		resp.Logs += logs
+	}
+	return resp, nil
+}
+
+func (s *RebuildLogSchemaScript) rebuild(ctx context.Context, app domain.App) (int64, error) {
+	// The IDs of logs start with the second they were received at, those
+	// received from now on are added by the ingestion.
+	before := domain.NewIDFromTime(Now().Add(time.Second))
+	if err := s.logSchemaRepo.StartRebuild(ctx, app.ID()); err != nil {
+		return 0, err
+	}
+
+	var logs int64
+	builder := domain.NewLogSchemaBuilder()
+	criteria := domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("appId", domain.Equals, app.ID()),
+			domain.NewFilter("_id", domain.LessThan, before),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	)
+	err := s.logRepo.StreamLogs(ctx, criteria, func(log domain.Log) error {
+		builder.Add(log.Timestamp(), log.Data())
+		logs++
+		if logs%rebuildLogSchemaBatch != 0 {
+			return nil
+		}
+
+		schema := builder.Build()
+		builder = domain.NewLogSchemaBuilder()
+		return s.logSchemaRepo.Record(ctx, app.ID(), schema)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if logs%rebuildLogSchemaBatch != 0 {
+		if err := s.logSchemaRepo.Record(ctx, app.ID(), builder.Build()); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.logSchemaRepo.FinishRebuild(ctx, app.ID()); err != nil {
+		return 0, err
+	}
+	return logs, nil
+}
@@ -0,0 +1,321 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/sourcemap"
+	"monitoring/internal/stacktrace"
+)
+
+var (
+	ErrReceiveBrowserErrorsScriptOriginNotAllowed = errors.New("origin not allowed for the app key")
+)
+
+const (
+	maxBrowserErrorsPerRequest = 50
+	maxBrowserErrorStackSize   = 64 * 1024
+)
+
+type BrowserError struct {
+	Type      string         `json:"type"`
+	Message   string         `json:"message"`
+	Stack     string         `json:"stack"`
+	URL       string         `json:"url"`
+	UserAgent string         `json:"userAgent"`
+	Timestamp *time.Time     `json:"timestamp"`
+	Extra     map[string]any `json:"extra"`
+}
+
+type ReceiveBrowserErrorsReq struct {
+	AppKey      string         `json:"-"`
+	Origin      string         `json:"-"`
+	Release     string         `json:"release"`
+	Environment string         `json:"environment"`
+	Errors      []BrowserError `json:"errors"`
+}
+
+type ReceiveBrowserErrorsResp struct {
+	Message string `json:"message"`
+}
+
+type ReceiveBrowserErrorsScript struct {
+	logRepo                 domain.LogRepo
+	appRepo                 domain.AppRepo
+	appKeyRepo              domain.AppKeyRepo
+	sourceMapRepo           domain.SourceMapRepo
+	issueRepo               domain.IssueRepo
+	logPatternRepo          domain.LogPatternRepo
+	logSchemaRepo           domain.LogSchemaRepo
+	schemaDriftEventRepo    domain.SchemaDriftEventRepo
+	logMetricRepo           domain.LogMetricRepo
+	logMetricRollupRepo     domain.LogMetricRollupRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+	logBroker               domain.LogBroker
+	notifier                domain.Notifier
+}
+
+func NewReceiveBrowserErrorsScript(
+	logRepo domain.LogRepo,
+	appRepo domain.AppRepo,
+	appKeyRepo domain.AppKeyRepo,
+	sourceMapRepo domain.SourceMapRepo,
+	issueRepo domain.IssueRepo,
+	logPatternRepo domain.LogPatternRepo,
+	logSchemaRepo domain.LogSchemaRepo,
+	schemaDriftEventRepo domain.SchemaDriftEventRepo,
+	logMetricRepo domain.LogMetricRepo,
+	logMetricRollupRepo domain.LogMetricRollupRepo,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	logBroker domain.LogBroker,
+	notifier domain.Notifier,
+) *ReceiveBrowserErrorsScript {
+	return &ReceiveBrowserErrorsScript{
+		logRepo:                 logRepo,
+		appRepo:                 appRepo,
+		appKeyRepo:              appKeyRepo,
+		sourceMapRepo:           sourceMapRepo,
+		issueRepo:               issueRepo,
+		logPatternRepo:          logPatternRepo,
+		logSchemaRepo:           logSchemaRepo,
+		schemaDriftEventRepo:    schemaDriftEventRepo,
+		logMetricRepo:           logMetricRepo,
+		logMetricRollupRepo:     logMetricRollupRepo,
+		notificationChannelRepo: notificationChannelRepo,
+		logBroker:               logBroker,
+		notifier:                notifier,
+	}
+}
+
+func (s *ReceiveBrowserErrorsScript) Exec(ctx context.Context, req ReceiveBrowserErrorsReq) (*ReceiveBrowserErrorsResp, error) {
+	auth, err := NewAuthenticateAppKeyScript(s.appRepo, s.appKeyRepo).Exec(ctx, AuthenticateAppKeyReq{
+		AppKey: req.AppKey,
+		Scope:  domain.AppKeyScopeBrowser,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !auth.AppKey.IsOriginAllowed(req.Origin) {
+		return nil, ErrReceiveBrowserErrorsScriptOriginNotAllowed
+	}
+
+	if len(req.Errors) == 0 {
+		return nil, errors.New("at least one error is required")
+	}
+
+	if len(req.Errors) > maxBrowserErrorsPerRequest {
+		return nil, fmt.Errorf("at most %d errors can be sent at once", maxBrowserErrorsPerRequest)
+	}
+
+	stacks := make([][]stacktrace.Frame, len(req.Errors))
+	var files []string
+	for i, browserErr := range req.Errors {
+		stack := browserErr.Stack
+		if len(stack) > maxBrowserErrorStackSize {
+			stack = stack[:maxBrowserErrorStackSize]
+		}
+
+		stacks[i] = stacktrace.ParseJavaScript(stack)
+		for _, frame := range stacks[i] {
+			files = append(files, frame.File)
+		}
+	}
+
+	symbolicator, err := s.newSymbolicator(ctx, auth.App.ID(), req.Release, files)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]domain.Log, len(req.Errors))
+	for i, browserErr := range req.Errors {
+		frames := stacks[i]
+		rawStack := make([]string, len(frames))
+		for j, frame := range frames {
+			frames[j] = symbolicator.resolve(ctx, frame)
+			rawStack[j] = formatJavaScriptFrame(frames[j])
+		}
+
+		data := map[string]any{
+			"source":      "browser",
+			"type":        browserErr.Type,
+			"message":     browserErr.Message,
+			"url":         browserErr.URL,
+			"userAgent":   browserErr.UserAgent,
+			"release":     req.Release,
+			"environment": req.Environment,
+		}
+		if browserErr.Timestamp != nil {
+			data["clientTimestamp"] = browserErr.Timestamp.UTC()
+		}
+		if len(browserErr.Extra) > 0 {
+			data["extra"] = browserErr.Extra
+		}
+
+		raw := strings.TrimSpace(browserErr.Type + ": " + browserErr.Message)
+		if len(rawStack) > 0 {
+			raw += "\n" + strings.Join(rawStack, "\n")
+		}
+
+		stackTrace := stackTraceFromTrace(&stacktrace.Trace{
+			Language:      stacktrace.JavaScript,
+			ExceptionType: browserErr.Type,
+			Message:       browserErr.Message,
+			Frames:        frames,
+		})
+		log, err := domain.NewLog(
+			domain.NewAutoID(),
+			auth.App.ID(),
+			Now().UTC(),
+			data,
+			raw,
+			"ERROR",
+			stackTrace,
+			fingerprintLog("ERROR", data, raw, stackTrace),
+			"",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		logs[i] = *log
+	}
+
+	patternLogs := mineLogPatterns(ctx, s.logPatternRepo, auth.App, logs)
+
+	err = s.logRepo.SaveLogs(ctx, logs)
+	if err != nil {
+		return nil, err
+	}
+	s.logBroker.Publish(logs)
+
+	// The logs are saved, so the steps after saving them log their errors
+	// instead of failing the request, which a client would retry, saving
+	// the logs twice.
+	countLogPatterns(ctx, s.logPatternRepo, auth.App, patternLogs)
+
+	_, err = NewTrackIssuesScript(s.issueRepo).Exec(ctx, TrackIssuesReq{App: auth.App, Logs: logs})
+	if err != nil {
+		log.Printf("tracking issues of app %s: %v", auth.App.ID().Hex(), err)
+	}
+
+	_, err = NewRecordLogSchemaScript(
+		s.logSchemaRepo,
+		s.schemaDriftEventRepo,
+		s.notificationChannelRepo,
+		s.notifier,
+	).Exec(ctx, RecordLogSchemaReq{App: auth.App, Logs: logs})
+	if err != nil {
+		log.Printf("recording the schema of app %s: %v", auth.App.ID().Hex(), err)
+	}
+
+	_, err = NewRecordLogMetricsScript(s.logMetricRepo, s.logMetricRollupRepo).Exec(ctx, RecordLogMetricsReq{App: auth.App, Logs: logs})
+	if err != nil {
+		log.Printf("recording the log metrics of app %s: %v", auth.App.ID().Hex(), err)
+	}
+
+	return &ReceiveBrowserErrorsResp{Message: "Errors received"}, nil
+}
+
+// newSymbolicator lists the maps of the release that match the files of the
+// frames, leaving their content to be loaded once a frame needs it.
+func (s *ReceiveBrowserErrorsScript) newSymbolicator(
+	ctx context.Context,
+	appID domain.ID,
+	release string,
+	files []string,
+) (*symbolicator, error) {
+	sym := &symbolicator{sourceMapRepo: s.sourceMapRepo, parsed: make(map[domain.ID]*sourcemap.Map)}
+	if strings.TrimSpace(release) == "" || len(files) == 0 {
+		return sym, nil
+	}
+
+	candidates := make(map[string]struct{})
+	for _, file := range files {
+		for _, candidate := range domain.SourceMapFiles(file) {
+			candidates[candidate] = struct{}{}
+		}
+	}
+	candidateFiles := make([]string, 0, len(candidates))
+	for candidate := range candidates {
+		candidateFiles = append(candidateFiles, candidate)
+	}
+
+	sourceMaps, err := s.sourceMapRepo.ListSourceMaps(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("appId", domain.Equals, appID),
+			domain.NewFilter("release", domain.Equals, release),
+			domain.NewFilter("file", domain.In, candidateFiles),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	sym.sourceMaps = sourceMaps
+	return sym, nil
+}
+
+// symbolicator maps minified frames to their original position with the
+// source maps of a release, loading and parsing each map at most once.
+type symbolicator struct {
+	sourceMapRepo domain.SourceMapRepo
+	sourceMaps    []domain.SourceMap
+	parsed        map[domain.ID]*sourcemap.Map
+}
+
+func (s *symbolicator) resolve(ctx context.Context, frame stacktrace.Frame) stacktrace.Frame {
+	m := s.mapFor(ctx, frame.File)
+	if m == nil {
+		return frame
+	}
+
+	pos, ok := m.Resolve(frame.Line, frame.Column)
+	if !ok {
+		return frame
+	}
+
+	frame.File = pos.Source
+	frame.Line = pos.Line
+	frame.Column = pos.Column
+	frame.InApp = !strings.Contains(pos.Source, "node_modules")
+	if pos.Name != "" {
+		frame.Function = pos.Name
+	}
+	return frame
+}
+
+func (s *symbolicator) mapFor(ctx context.Context, file string) *sourcemap.Map {
+	for _, sourceMap := range s.sourceMaps {
+		if !sourceMap.Matches(file) {
+			continue
+		}
+
+		m, ok := s.parsed[sourceMap.ID()]
+		if !ok {
+			// A map that was deleted since it was listed or no longer parses
+			// is skipped, leaving the frame minified.
+			if withContent, err := s.sourceMapRepo.GetSourceMapByID(ctx, sourceMap.ID()); err == nil {
+				m, _ = sourcemap.Parse([]byte(withContent.Content()))
+			}
+			s.parsed[sourceMap.ID()] = m
+		}
+		return m
+	}
+	return nil
+}
+
+func formatJavaScriptFrame(frame stacktrace.Frame) string {
+	location := fmt.Sprintf("%s:%d:%d", frame.File, frame.Line, frame.Column)
+	if frame.Function != "" {
+		return fmt.Sprintf("    at %s (%s)", frame.Function, location)
+	}
+	return "    at " + location
+}
@@ -2,20 +2,39 @@ package scripts
 
 import (
 	"context"
+	"crypto/hmac"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"monitoring/internal/domain"
+	"monitoring/internal/stacktrace"
 )
 
+var (
+	ErrReceiveLogsScriptSignatureRequired = errors.New("request signature required")
+	ErrReceiveLogsScriptInvalidSignature  = errors.New("invalid request signature")
+	ErrReceiveLogsScriptStaleSignature    = errors.New("request signature timestamp is too old or too far in the future")
+)
+
+// signatureTolerance is how far the signed timestamp can be from the server
+// clock. Signatures are remembered for domain.RequestSignatureRetention, twice
+// this window, to reject replays.
+const signatureTolerance = 5 * time.Minute
+
 type ReceiveLogsReq struct {
-	AppKey  string   `json:"-"`
-	Logs    []string `json:"logs"`
-	LogType *string  `json:"logType"`
+	AppKey             string   `json:"-"`
+	Signature          string   `json:"-"`
+	SignatureTimestamp string   `json:"-"`
+	Body               []byte   `json:"-"`
+	Logs               []string `json:"logs"`
+	LogType            *string  `json:"logType"`
 }
 
 type ReceiveLogsResp struct {
@@ -23,19 +42,69 @@ type ReceiveLogsResp struct {
 }
 
 type ReceiveLogsScript struct {
-	logRepo domain.LogRepo
-	appRepo domain.AppRepo
+	logRepo                 domain.LogRepo
+	appRepo                 domain.AppRepo
+	appKeyRepo              domain.AppKeyRepo
+	requestSignatureRepo    domain.RequestSignatureRepo
+	issueRepo               domain.IssueRepo
+	logPatternRepo          domain.LogPatternRepo
+	logSchemaRepo           domain.LogSchemaRepo
+	schemaDriftEventRepo    domain.SchemaDriftEventRepo
+	logMetricRepo           domain.LogMetricRepo
+	logMetricRollupRepo     domain.LogMetricRollupRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+	logBroker               domain.LogBroker
+	notifier                domain.Notifier
 }
 
-func NewReceiveLogsScript(logRepo domain.LogRepo, appRepo domain.AppRepo) *ReceiveLogsScript {
-	return &ReceiveLogsScript{logRepo: logRepo, appRepo: appRepo}
+func NewReceiveLogsScript(
+	logRepo domain.LogRepo,
+	appRepo domain.AppRepo,
+	appKeyRepo domain.AppKeyRepo,
+	requestSignatureRepo domain.RequestSignatureRepo,
+	issueRepo domain.IssueRepo,
+	logPatternRepo domain.LogPatternRepo,
+	logSchemaRepo domain.LogSchemaRepo,
+	schemaDriftEventRepo domain.SchemaDriftEventRepo,
+	logMetricRepo domain.LogMetricRepo,
+	logMetricRollupRepo domain.LogMetricRollupRepo,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	logBroker domain.LogBroker,
+	notifier domain.Notifier,
+) *ReceiveLogsScript {
+	return &ReceiveLogsScript{
+		logRepo:                 logRepo,
+		appRepo:                 appRepo,
+		appKeyRepo:              appKeyRepo,
+		requestSignatureRepo:    requestSignatureRepo,
+		issueRepo:               issueRepo,
+		logPatternRepo:          logPatternRepo,
+		logSchemaRepo:           logSchemaRepo,
+		schemaDriftEventRepo:    schemaDriftEventRepo,
+		logMetricRepo:           logMetricRepo,
+		logMetricRollupRepo:     logMetricRollupRepo,
+		notificationChannelRepo: notificationChannelRepo,
+		logBroker:               logBroker,
+		notifier:                notifier,
+	}
 }
 
 func (s *ReceiveLogsScript) Exec(ctx context.Context, req ReceiveLogsReq) (*ReceiveLogsResp, error) {
-	app, err := s.appRepo.GetAppByKey(ctx, req.AppKey)
+	auth, err := NewAuthenticateAppKeyScript(s.appRepo, s.appKeyRepo).Exec(ctx, AuthenticateAppKeyReq{
+		AppKey: req.AppKey,
+		Scope:  domain.AppKeyScopeIngest,
+	})
 	if err != nil {
 		return nil, err
 	}
+	app := auth.App
+
+	if app.RequireSignature() || req.Signature != "" {
+		err = s.verifySignature(ctx, app, req)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	logType := "json"
 	if req.LogType != nil {
@@ -45,13 +114,18 @@ func (s *ReceiveLogsScript) Exec(ctx context.Context, req ReceiveLogsReq) (*Rece
 	logs := make([]domain.Log, len(req.Logs))
 	for i, rawLog := range req.Logs {
 		data := s.parse(rawLog, logType)
+		level := extractLogLevel(rawLog)
+		stackTrace := extractStackTrace(rawLog, data)
 		log, err := domain.NewLog(
 			domain.NewAutoID(),
 			app.ID(),
 			Now().UTC(),
 			data,
 			rawLog,
-			extractLogLevel(rawLog),
+			level,
+			stackTrace,
+			fingerprintLog(level, data, rawLog, stackTrace),
+			"",
 		)
 		if err != nil {
 			return nil, err
@@ -60,14 +134,70 @@ func (s *ReceiveLogsScript) Exec(ctx context.Context, req ReceiveLogsReq) (*Rece
 		logs[i] = *log
 	}
 
+	patternLogs := mineLogPatterns(ctx, s.logPatternRepo, app, logs)
+
 	err = s.logRepo.SaveLogs(ctx, logs)
 	if err != nil {
 		return nil, err
 	}
+	s.logBroker.Publish(logs)
+
+	// The logs are saved, so the steps after saving them log their errors
+	// instead of failing the request, which a client would retry, saving
+	// the logs twice.
+	countLogPatterns(ctx, s.logPatternRepo, app, patternLogs)
+
+	_, err = NewTrackIssuesScript(s.issueRepo).Exec(ctx, TrackIssuesReq{App: app, Logs: logs})
+	if err != nil {
+		log.Printf("tracking issues of app %s: %v", app.ID().Hex(), err)
+	}
+
+	_, err = NewRecordLogSchemaScript(
+		s.logSchemaRepo,
+		s.schemaDriftEventRepo,
+		s.notificationChannelRepo,
+		s.notifier,
+	).Exec(ctx, RecordLogSchemaReq{App: app, Logs: logs})
+	if err != nil {
+		log.Printf("recording the schema of app %s: %v", app.ID().Hex(), err)
+	}
+
+	_, err = NewRecordLogMetricsScript(s.logMetricRepo, s.logMetricRollupRepo).Exec(ctx, RecordLogMetricsReq{App: app, Logs: logs})
+	if err != nil {
+		log.Printf("recording the log metrics of app %s: %v", app.ID().Hex(), err)
+	}
 
 	return &ReceiveLogsResp{Message: "Logs received"}, nil
 }
 
+func (s *ReceiveLogsScript) verifySignature(ctx context.Context, app domain.App, req ReceiveLogsReq) error {
+	if req.Signature == "" || req.SignatureTimestamp == "" {
+		return ErrReceiveLogsScriptSignatureRequired
+	}
+
+	if app.SigningSecret() == "" {
+		return ErrReceiveLogsScriptInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(req.SignatureTimestamp, 10, 64)
+	if err != nil {
+		return ErrReceiveLogsScriptInvalidSignature
+	}
+
+	now := Now().UTC()
+	signedAt := time.Unix(unix, 0).UTC()
+	if signedAt.Before(now.Add(-signatureTolerance)) || signedAt.After(now.Add(signatureTolerance)) {
+		return ErrReceiveLogsScriptStaleSignature
+	}
+
+	expected := signRequest(app.SigningSecret(), req.SignatureTimestamp, req.Body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(req.Signature))) {
+		return ErrReceiveLogsScriptInvalidSignature
+	}
+
+	return s.requestSignatureRepo.SaveRequestSignature(ctx, app.ID(), expected, now)
+}
+
 func (s *ReceiveLogsScript) parse(rawLog string, logType string) map[string]any {
 	switch strings.ToLower(logType) {
 	case "json":
@@ -187,6 +317,48 @@ func nodeToMap(n xmlNode) map[string]any {
 	return m
 }
 
+// extractStackTrace looks for a stack trace in the stack or error fields of
+// the data, then in its message and finally in the raw log.
+func extractStackTrace(raw string, data map[string]any) *domain.StackTrace {
+	candidates := []any{data["stack"], data["error"]}
+	if errData, ok := data["error"].(map[string]any); ok {
+		candidates = append(candidates, errData["stack"])
+	}
+	candidates = append(candidates, data["message"], raw)
+
+	for _, candidate := range candidates {
+		text, ok := candidate.(string)
+		if !ok {
+			continue
+		}
+
+		if trace, ok := stacktrace.Parse(text); ok {
+			return stackTraceFromTrace(trace)
+		}
+	}
+	return nil
+}
+
+func stackTraceFromTrace(trace *stacktrace.Trace) *domain.StackTrace {
+	frames := make([]domain.StackFrame, len(trace.Frames))
+	for i, frame := range trace.Frames {
+		frames[i] = domain.StackFrame{
+			File:     frame.File,
+			Function: frame.Function,
+			Line:     frame.Line,
+			Column:   frame.Column,
+			InApp:    frame.InApp,
+		}
+	}
+
+	return &domain.StackTrace{
+		Language:      string(trace.Language),
+		ExceptionType: trace.ExceptionType,
+		Message:       trace.Message,
+		Frames:        frames,
+	}
+}
+
 func extractLogLevel(raw string) string {
 	var levelRegex = regexp.MustCompile(`(?i)\b(?:trace|debug|info|warn|warning|error|fatal)\b`)
 
@@ -0,0 +1,93 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/otlp"
+	"monitoring/internal/promwrite"
+)
+
+var (
+	ErrReceiveMetricsScriptInvalidMetrics = errors.New("invalid metrics")
+)
+
+// MetricFormat is the format of the body of the metrics received.
+type MetricFormat string
+
+const (
+	// MetricFormatOTLP is the JSON encoding of OTLP/HTTP.
+	MetricFormatOTLP MetricFormat = "otlp"
+	// MetricFormatPrometheus is Prometheus remote write.
+	MetricFormatPrometheus MetricFormat = "prometheus"
+)
+
+type ReceiveMetricsReq struct {
+	AppKey string
+	Format MetricFormat
+	Body   []byte
+}
+
+type ReceiveMetricsResp struct {
+	IngestMetricsResp
+}
+
+type ReceiveMetricsScript struct {
+	appRepo          domain.AppRepo
+	appKeyRepo       domain.AppKeyRepo
+	metricRepo       domain.MetricRepo
+	metricSeriesRepo domain.MetricSeriesRepo
+}
+
+func NewReceiveMetricsScript(
+	appRepo domain.AppRepo,
+	appKeyRepo domain.AppKeyRepo,
+	metricRepo domain.MetricRepo,
+	metricSeriesRepo domain.MetricSeriesRepo,
+) *ReceiveMetricsScript {
+	return &ReceiveMetricsScript{
+		appRepo:          appRepo,
+		appKeyRepo:       appKeyRepo,
+		metricRepo:       metricRepo,
+		metricSeriesRepo: metricSeriesRepo,
+	}
+}
+
+// Exec ingests the metrics of the app of the key, the samples that are
+// invalid being rejected one by one.
+func (s *ReceiveMetricsScript) Exec(ctx context.Context, req ReceiveMetricsReq) (*ReceiveMetricsResp, error) {
+	auth, err := NewAuthenticateAppKeyScript(s.appRepo, s.appKeyRepo).Exec(ctx, AuthenticateAppKeyReq{
+		AppKey: req.AppKey,
+		Scope:  domain.AppKeyScopeIngest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []domain.MetricSample
+	var rejected []error
+	switch req.Format {
+	case MetricFormatOTLP:
+		samples, rejected, err = otlp.Parse(req.Body)
+	case MetricFormatPrometheus:
+		samples, rejected, err = promwrite.Decode(req.Body)
+	default:
+		err = fmt.Errorf("unknown format %s", req.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrReceiveMetricsScriptInvalidMetrics, err)
+	}
+
+	resp, err := NewIngestMetricsScript(s.metricRepo, s.metricSeriesRepo).Exec(ctx, IngestMetricsReq{
+		App:      auth.App,
+		Samples:  samples,
+		Rejected: rejected,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReceiveMetricsResp{IngestMetricsResp: *resp}, nil
+}
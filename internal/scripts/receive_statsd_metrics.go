@@ -0,0 +1,119 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+// statsdAppKeyCacheTTL is how long the app of a key, or why the key was
+// refused, is remembered, StatsD clients sending their key with every line.
+const statsdAppKeyCacheTTL = time.Minute
+
+type ReceiveStatsDMetricsReq struct {
+	AppKey  string
+	Samples []domain.MetricSample
+}
+
+type ReceiveStatsDMetricsResp struct {
+	IngestMetricsResp
+}
+
+type statsdAppKey struct {
+	app       domain.App
+	err       error
+	expiresAt time.Time
+}
+
+// ReceiveStatsDMetricsScript lives as long as the StatsD listener, unlike
+// the other scripts, to remember the apps of the keys between flushes.
+type ReceiveStatsDMetricsScript struct {
+	appRepo          domain.AppRepo
+	appKeyRepo       domain.AppKeyRepo
+	metricRepo       domain.MetricRepo
+	metricSeriesRepo domain.MetricSeriesRepo
+
+	mu sync.Mutex
+	// keys are by hash of the key.
+	keys map[string]statsdAppKey
+}
+
+func NewReceiveStatsDMetricsScript(
+	appRepo domain.AppRepo,
+	appKeyRepo domain.AppKeyRepo,
+	metricRepo domain.MetricRepo,
+	metricSeriesRepo domain.MetricSeriesRepo,
+) *ReceiveStatsDMetricsScript {
+	return &ReceiveStatsDMetricsScript{
+		appRepo:          appRepo,
+		appKeyRepo:       appKeyRepo,
+		metricRepo:       metricRepo,
+		metricSeriesRepo: metricSeriesRepo,
+		keys:             map[string]statsdAppKey{},
+	}
+}
+
+// Exec ingests the samples a flush added up for an app key.
+func (s *ReceiveStatsDMetricsScript) Exec(ctx context.Context, req ReceiveStatsDMetricsReq) (*ReceiveStatsDMetricsResp, error) {
+	app, err := s.authenticate(ctx, req.AppKey)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := NewIngestMetricsScript(s.metricRepo, s.metricSeriesRepo).Exec(ctx, IngestMetricsReq{
+		App:     *app,
+		Samples: req.Samples,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReceiveStatsDMetricsResp{IngestMetricsResp: *resp}, nil
+}
+
+// authenticate returns the app of the key, remembering it, and the keys
+// refused, for statsdAppKeyCacheTTL.
+func (s *ReceiveStatsDMetricsScript) authenticate(ctx context.Context, appKey string) (*domain.App, error) {
+	now := Now()
+	hash := hashAppKeySecret(appKey)
+
+	s.mu.Lock()
+	for key, cached := range s.keys {
+		if now.After(cached.expiresAt) {
+			delete(s.keys, key)
+		}
+	}
+	cached, ok := s.keys[hash]
+	s.mu.Unlock()
+	if ok && cached.err != nil {
+		return nil, cached.err
+	}
+	if ok {
+		return &cached.app, nil
+	}
+
+	auth, err := NewAuthenticateAppKeyScript(s.appRepo, s.appKeyRepo).Exec(ctx, AuthenticateAppKeyReq{
+		AppKey: appKey,
+		Scope:  domain.AppKeyScopeIngest,
+	})
+	refused := errors.Is(err, ErrAuthenticateAppKeyScriptInvalidKey) || errors.Is(err, ErrAuthenticateAppKeyScriptScopeNotAllowed)
+	if err != nil && !refused {
+		return nil, err
+	}
+
+	cached = statsdAppKey{err: err, expiresAt: now.Add(statsdAppKeyCacheTTL)}
+	if auth != nil {
+		cached.app = auth.App
+	}
+	s.mu.Lock()
+	s.keys[hash] = cached
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	return &cached.app, nil
+}
@@ -0,0 +1,80 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type RecordLogMetricsReq struct {
+	App  domain.App
+	Logs []domain.Log
+}
+
+type RecordLogMetricsResp struct {
+	// Rollups are what the logs added to the metrics of the user.
+	Rollups []domain.LogMetricRollup
+}
+
+type RecordLogMetricsScript struct {
+	logMetricRepo       domain.LogMetricRepo
+	logMetricRollupRepo domain.LogMetricRollupRepo
+}
+
+func NewRecordLogMetricsScript(logMetricRepo domain.LogMetricRepo, logMetricRollupRepo domain.LogMetricRollupRepo) *RecordLogMetricsScript {
+	return &RecordLogMetricsScript{logMetricRepo: logMetricRepo, logMetricRollupRepo: logMetricRollupRepo}
+}
+
+// Exec adds the logs ingested to the rollups of the metrics of the user of
+// the app, those of the groups past the limit of a metric to its other
+// group. Their alerts are evaluated apart, by EvaluateLogMetricAlerts.
+func (s *RecordLogMetricsScript) Exec(ctx context.Context, req RecordLogMetricsReq) (*RecordLogMetricsResp, error) {
+	resp := &RecordLogMetricsResp{Rollups: []domain.LogMetricRollup{}}
+	if len(req.Logs) == 0 {
+		return resp, nil
+	}
+
+	metrics, err := s.logMetricRepo.ListLogMetrics(ctx, domain.NewCriteria(
+		[]domain.Filter{domain.NewFilter("userId", domain.Equals, req.App.UserID())},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, metric := range metrics {
+		rollups := domain.RollUpLogs(metric, req.Logs)
+		if len(metric.GroupBy()) > 0 && len(rollups) > 0 {
+			rollups, err = s.limitGroups(ctx, metric, rollups)
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp.Rollups = append(resp.Rollups, rollups...)
+	}
+
+	if err := s.logMetricRollupRepo.RecordRollups(ctx, resp.Rollups); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (s *RecordLogMetricsScript) limitGroups(ctx context.Context, metric domain.LogMetric, rollups []domain.LogMetricRollup) ([]domain.LogMetricRollup, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, rollup := range rollups {
+		key := domain.LogMetricGroupKey(rollup.Keys)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	allowed, err := s.logMetricRollupRepo.ClaimGroups(ctx, metric.ID(), keys, domain.LogMetricGroupLimit)
+	if err != nil {
+		return nil, err
+	}
+	return domain.LimitLogMetricGroups(rollups, allowed), nil
+}
@@ -0,0 +1,66 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type RecordLogSchemaReq struct {
+	App  domain.App
+	Logs []domain.Log
+}
+
+type RecordLogSchemaResp struct {
+	// Schema is the schema of the logs recorded.
+	Schema domain.LogSchema
+	// DriftEvents are the changes of the schema the logs brought.
+	DriftEvents []domain.SchemaDriftEvent
+}
+
+type RecordLogSchemaScript struct {
+	logSchemaRepo           domain.LogSchemaRepo
+	schemaDriftEventRepo    domain.SchemaDriftEventRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+	notifier                domain.Notifier
+}
+
+func NewRecordLogSchemaScript(
+	logSchemaRepo domain.LogSchemaRepo,
+	schemaDriftEventRepo domain.SchemaDriftEventRepo,
+	notificationChannelRepo domain.NotificationChannelRepo,
+	notifier domain.Notifier,
+) *RecordLogSchemaScript {
+	return &RecordLogSchemaScript{
+		logSchemaRepo:           logSchemaRepo,
+		schemaDriftEventRepo:    schemaDriftEventRepo,
+		notificationChannelRepo: notificationChannelRepo,
+		notifier:                notifier,
+	}
+}
+
+// Exec adds the fields of the data of the logs to the catalog of the app,
+// detecting first how they change it.
+func (s *RecordLogSchemaScript) Exec(ctx context.Context, req RecordLogSchemaReq) (*RecordLogSchemaResp, error) {
+	builder := domain.NewLogSchemaBuilder()
+	for _, log := range req.Logs {
+		builder.Add(log.Timestamp(), log.Data())
+	}
+	schema := builder.Build()
+
+	drift, err := NewDetectSchemaDriftScript(
+		s.logSchemaRepo,
+		s.schemaDriftEventRepo,
+		s.notificationChannelRepo,
+		s.notifier,
+	).Exec(ctx, DetectSchemaDriftReq{App: req.App, Schema: schema})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.logSchemaRepo.Record(ctx, req.App.ID(), schema); err != nil {
+		return nil, err
+	}
+
+	return &RecordLogSchemaResp{Schema: schema, DriftEvents: drift.Events}, nil
+}
@@ -0,0 +1,79 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+// maxQueryHistoryEntries is how many of the latest searches of a user are
+// kept.
+const maxQueryHistoryEntries = 100
+
+type RecordQueryHistoryReq struct {
+	Search SearchLogsReq
+}
+
+type RecordQueryHistoryResp struct {
+	// Recorded is false for searches that are not kept, like the next pages
+	// of a search.
+	Recorded bool
+}
+
+type RecordQueryHistoryScript struct {
+	queryHistoryRepo domain.QueryHistoryRepo
+}
+
+func NewRecordQueryHistoryScript(queryHistoryRepo domain.QueryHistoryRepo) *RecordQueryHistoryScript {
+	return &RecordQueryHistoryScript{queryHistoryRepo: queryHistoryRepo}
+}
+
+// Exec adds a search to the history of the user. Only the first page of
+// searches with a query, a search term or a level is kept.
+func (s *RecordQueryHistoryScript) Exec(ctx context.Context, req RecordQueryHistoryReq) (*RecordQueryHistoryResp, error) {
+	search := req.Search
+	if search.Cursor != "" || search.Page > 1 {
+		return &RecordQueryHistoryResp{}, nil
+	}
+
+	if strings.TrimSpace(search.Query) == "" && strings.TrimSpace(search.SearchTerm) == "" && strings.TrimSpace(search.LogLevel) == "" {
+		return &RecordQueryHistoryResp{}, nil
+	}
+
+	userID, err := domain.NewID(search.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := domain.NewQueryHistoryEntry(
+		domain.NewAutoID(),
+		userID,
+		search.Query,
+		search.SearchTerm,
+		search.LogLevel,
+		search.AppID,
+		optionalTime(search.From),
+		optionalTime(search.To),
+		1,
+		Now().UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.queryHistoryRepo.RecordQueryHistoryEntry(ctx, *entry, maxQueryHistoryEntries); err != nil {
+		return nil, err
+	}
+
+	return &RecordQueryHistoryResp{Recorded: true}, nil
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,50 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type RevokeAppKeyReq struct {
+	UserID string `json:"-"`
+	AppID  string `json:"-"`
+	KeyID  string `json:"-"`
+}
+
+type RevokeAppKeyResp struct {
+	domain.AppKey
+}
+
+type RevokeAppKeyScript struct {
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
+}
+
+func NewRevokeAppKeyScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *RevokeAppKeyScript {
+	return &RevokeAppKeyScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
+}
+
+func (s *RevokeAppKeyScript) Exec(ctx context.Context, req RevokeAppKeyReq) (*RevokeAppKeyResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := getAppKey(ctx, s.appKeyRepo, app.ID(), req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = key.Revoke(Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appKeyRepo.UpdateAppKey(ctx, *key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevokeAppKeyResp{AppKey: *key}, nil
+}
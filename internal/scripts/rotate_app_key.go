@@ -0,0 +1,97 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+const (
+	defaultAppKeyRotationOverlap = 24 * time.Hour
+	maxAppKeyRotationOverlap     = 30 * 24 * time.Hour
+)
+
+type RotateAppKeyReq struct {
+	UserID         string     `json:"-"`
+	AppID          string     `json:"-"`
+	KeyID          string     `json:"-"`
+	OverlapSeconds *int       `json:"overlapSeconds"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+}
+
+type RotateAppKeyResp struct {
+	PreviousKey domain.AppKey `json:"previousKey"`
+	Key         domain.AppKey `json:"key"`
+	Secret      string        `json:"secret"`
+}
+
+type RotateAppKeyScript struct {
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
+}
+
+func NewRotateAppKeyScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo) *RotateAppKeyScript {
+	return &RotateAppKeyScript{appRepo: appRepo, appKeyRepo: appKeyRepo}
+}
+
+// Exec issues a new key with the same name and scopes, and keeps the previous
+// one working until the overlap window ends so shippers can switch without
+// downtime.
+func (s *RotateAppKeyScript) Exec(ctx context.Context, req RotateAppKeyReq) (*RotateAppKeyResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := getAppKey(ctx, s.appKeyRepo, app.ID(), req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := Now().UTC()
+	if !previous.IsActive(now) {
+		return nil, errors.New("only active keys can be rotated")
+	}
+
+	overlap := defaultAppKeyRotationOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+	if overlap < 0 || overlap > maxAppKeyRotationOverlap {
+		return nil, errors.New("overlap must be between 0 and 30 days")
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		utc := req.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	key, secret, err := newAppKey(app.ID(), previous.Name(), previous.Scopes(), previous.AllowedOrigins(), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous key is expired first, so a key revoked meanwhile is not
+	// replaced.
+	previous, err = s.appKeyRepo.ExpireAppKey(ctx, previous.ID(), now.Add(overlap))
+	if errors.Is(err, domain.ErrAppKeyRevoked) {
+		return nil, errors.New("only active keys can be rotated")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.appKeyRepo.SaveAppKey(ctx, *key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RotateAppKeyResp{
+		PreviousKey: *previous,
+		Key:         *key,
+		Secret:      secret,
+	}, nil
+}
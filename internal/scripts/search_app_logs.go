@@ -0,0 +1,39 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+type SearchAppLogsReq struct {
+	AppKey string `json:"-"`
+	SearchLogsReq
+}
+
+type SearchAppLogsScript struct {
+	appRepo    domain.AppRepo
+	appKeyRepo domain.AppKeyRepo
+	logRepo    domain.LogRepo
+	guard      *QueryGuard
+}
+
+func NewSearchAppLogsScript(appRepo domain.AppRepo, appKeyRepo domain.AppKeyRepo, logRepo domain.LogRepo, guard *QueryGuard) *SearchAppLogsScript {
+	return &SearchAppLogsScript{appRepo: appRepo, appKeyRepo: appKeyRepo, logRepo: logRepo, guard: guard}
+}
+
+func (s *SearchAppLogsScript) Exec(ctx context.Context, req SearchAppLogsReq) (*SearchLogsResp, error) {
+	auth, err := NewAuthenticateAppKeyScript(s.appRepo, s.appKeyRepo).Exec(ctx, AuthenticateAppKeyReq{
+		AppKey: req.AppKey,
+		Scope:  domain.AppKeyScopeRead,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	searchReq := req.SearchLogsReq
+	searchReq.UserID = auth.App.UserID().Hex()
+	searchReq.AppID = auth.App.ID().Hex()
+
+	return NewSearchLogsScript(s.appRepo, s.logRepo, s.guard).Exec(ctx, searchReq)
+}
@@ -2,44 +2,273 @@ package scripts
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
-	"github.com/openai/openai-go"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	"monitoring/internal/domain"
 )
 
+var (
+	ErrSearchLogsScriptInvalidSearchMode = errors.New("search mode must be literal, regex or text")
+	ErrSearchLogsScriptInvalidRegex      = errors.New("invalid regex")
+	ErrSearchLogsScriptTimeout           = errors.New("search took too long, narrow the time range or the search term")
+	ErrSearchLogsScriptLimitExceeded     = errors.New("query limit exceeded")
+)
+
+// SearchMode is how the search term is matched against the logs.
+type SearchMode string
+
+const (
+	// SearchModeLiteral finds the term as a substring of the raw log.
+	SearchModeLiteral SearchMode = "literal"
+	// SearchModeRegex matches the raw log with the term as a regex.
+	SearchModeRegex SearchMode = "regex"
+	// SearchModeText is a full-text search over the raw log and the message
+	// fields, ranked by relevance.
+	SearchModeText SearchMode = "text"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+	// searchTotalThreshold is the most logs counted for the total, past it
+	// the total is a lower bound.
+	searchTotalThreshold = 10000
+)
+
+const (
+	maxSearchRegexLength = 512
+	// searchRegexTimeBudget bounds regex searches, which cannot use an index.
+	searchRegexTimeBudget = 10 * time.Second
+	// scanEstimateTimeBudget bounds the count of the logs a search would
+	// read. Past it the search runs, bounded by its time limit.
+	scanEstimateTimeBudget = 5 * time.Second
+)
+
 type SearchLogsReq struct {
-	UserID     string    `json:"-"`
-	Page       int       `form:"page"`
-	Limit      int       `form:"limit"`
-	SortOrder  string    `form:"sortOrder"`
-	SearchTerm string    `form:"searchTerm"`
-	LogLevel   string    `form:"logLevel"`
-	From       time.Time `form:"from"`
-	To         time.Time `form:"to"`
-	AppID      string    `form:"appId"`
+	UserID        string     `json:"-"`
+	Page          int        `json:"page" form:"page"`
+	Limit         int        `json:"limit" form:"limit"`
+	SortOrder     string     `json:"sortOrder" form:"sortOrder"`
+	SearchTerm    string     `json:"searchTerm" form:"searchTerm"`
+	SearchMode    SearchMode `json:"searchMode" form:"searchMode"`
+	LogLevel      string     `json:"logLevel" form:"logLevel"`
+	From          time.Time  `json:"from" form:"from"`
+	To            time.Time  `json:"to" form:"to"`
+	AppID         string     `json:"appId" form:"appId"`
+	ExceptionType string     `json:"exceptionType" form:"exceptionType"`
+	File          string     `json:"file" form:"file"`
+	// Query is written in the query language of domain.ParseQuery, like
+	// level:ERROR AND data.status>=500.
+	Query string `json:"query" form:"query"`
+	// Cursor is the nextCursor or prevCursor of a previous response. It
+	// replaces Page and keeps the sort order of the first page.
+	Cursor       string `json:"cursor" form:"cursor"`
+	IncludeTotal bool   `json:"includeTotal" form:"includeTotal"`
 }
 
 type SearchLogsResp struct {
-	Data []domain.Log `json:"data"`
+	Data       []domain.Log     `json:"data"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+	PrevCursor string           `json:"prevCursor,omitempty"`
+	Total      *SearchLogsTotal `json:"total,omitempty"`
+}
+
+// SearchLogsTotal is the number of logs that match the search. When Exact is
+// false there are at least Value logs.
+type SearchLogsTotal struct {
+	Value int64 `json:"value"`
+	Exact bool  `json:"exact"`
 }
 
 type SearchLogsScript struct {
-	openaiClient *openai.Client
-	appRepo      domain.AppRepo
-	logRepo      domain.LogRepo
+	appRepo domain.AppRepo
+	logRepo domain.LogRepo
+	guard   *QueryGuard
+}
+
+func NewSearchLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo, guard *QueryGuard) *SearchLogsScript {
+	return &SearchLogsScript{appRepo: appRepo, logRepo: logRepo, guard: guard}
+}
+
+// logSearch is what matches the logs of a search, apart from its paging.
+type logSearch struct {
+	filters []domain.Filter
+	query   domain.QueryNode
+	// textSearch is set when the logs are ranked by relevance.
+	textSearch bool
+	maxTime    time.Duration
+	limits     domain.QueryLimits
+	// scope are the filters of the apps and the time range, which the
+	// indexes of the logs narrow.
+	scope []domain.Filter
+	// scans is set when the search term, the file or the query cannot be
+	// answered from an index, so every log of the scope is read.
+	scans bool
 }
 
-func NewSearchLogsScript(appRepo domain.AppRepo, logRepo domain.LogRepo) *SearchLogsScript {
-	// TODO: add pipeline generation using openai client
-	client := openai.NewClient(openai.DefaultClientOptions()...)
-	return &SearchLogsScript{openaiClient: &client, appRepo: appRepo, logRepo: logRepo}
+func (l *logSearch) criteria(pagination domain.Pagination, sort domain.Sort, extra ...domain.Filter) domain.Criteria {
+	filters := append(slices.Clone(l.filters), extra...)
+	criteria := domain.NewCriteria(filters, pagination, sort)
+	criteria.Query = l.query
+	criteria.MaxTime = l.maxTime
+	return criteria
 }
 
 func (s *SearchLogsScript) Exec(ctx context.Context, req SearchLogsReq) (*SearchLogsResp, error) {
+	search, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if search.scans {
+		if err := s.checkScan(ctx, search); err != nil {
+			return nil, err
+		}
+	}
+
+	order := domain.Asc
+	if domain.SortOrder(req.SortOrder) == domain.Desc {
+		order = domain.Desc
+	}
+
+	var cursor *logCursor
+	if req.Cursor != "" {
+		if search.textSearch {
+			return nil, fmt.Errorf("%w: cursors cannot be used with the text search mode", ErrSearchLogsScriptInvalidCursor)
+		}
+
+		cursor, err = decodeLogCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		order = cursor.Order
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	limit = min(limit, maxSearchLimit)
+
+	var total *SearchLogsTotal
+	if req.IncludeTotal {
+		count, err := s.logRepo.CountLogs(ctx, search.criteria(domain.EmptyPagination, domain.EmptySort), searchTotalThreshold+1)
+		if err != nil && mongo.IsTimeout(err) {
+			return nil, ErrSearchLogsScriptTimeout
+		}
+		if err != nil {
+			return nil, err
+		}
+		total = &SearchLogsTotal{Value: min(count, searchTotalThreshold), Exact: count <= searchTotalThreshold}
+	}
+
+	// One more log than the limit is read to know whether there is a next page.
+	pagination := domain.NewPagination(limit+1, max(req.Page-1, 0)*limit)
+	scanOrder := order
+	var extra []domain.Filter
+	if cursor != nil {
+		extra = append(extra, cursor.filter())
+		pagination = domain.NewPagination(limit+1, 0)
+		scanOrder = cursor.scanOrder()
+	}
+
+	sort := domain.NewSort("timestamp", scanOrder).ThenBy("_id", scanOrder)
+	if search.textSearch {
+		sort = domain.NewSort(domain.SortByRelevance, domain.Desc).ThenBy("timestamp", order).ThenBy("_id", order)
+	}
+
+	logs, err := s.logRepo.ListLogs(ctx, search.criteria(pagination, sort, extra...))
+	if err != nil && mongo.IsTimeout(err) {
+		return nil, ErrSearchLogsScriptTimeout
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+
+	backward := cursor != nil && cursor.Backward
+	if backward {
+		slices.Reverse(logs)
+	}
+
+	resp := &SearchLogsResp{Data: logs, Total: total}
+	if search.textSearch || len(logs) == 0 {
+		return resp, nil
+	}
+
+	if hasMore || backward {
+		resp.NextCursor = newLogCursor(logs[len(logs)-1], order, false)
+	}
+	if (backward && hasMore) || (!backward && (cursor != nil || req.Page > 1)) {
+		resp.PrevCursor = newLogCursor(logs[0], order, true)
+	}
+
+	return resp, nil
+}
+
+// prepare validates the search and scopes it to the apps of the user,
+// within the limits of the user.
+func (s *SearchLogsScript) prepare(ctx context.Context, req SearchLogsReq) (*logSearch, error) {
+	limits, err := s.guard.Limits(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	req = limitedRange(req, limits)
+	search, err := s.build(ctx, req, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := search.checkRange(req.From, req.To); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// limitedRange starts a search without from the longest range allowed
+// before its end.
+func limitedRange(req SearchLogsReq, limits domain.QueryLimits) SearchLogsReq {
+	if req.From.IsZero() && limits.MaxRange > 0 {
+		req.From = searchEnd(req.To).Add(-limits.MaxRange)
+	}
+	return req
+}
+
+// prepareWindows prepares a search read in several windows instead of its
+// time range, each window being within the limits of the user.
+func (s *SearchLogsScript) prepareWindows(ctx context.Context, req SearchLogsReq, windows ...domain.Range) (*logSearch, error) {
+	limits, err := s.guard.Limits(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	req.From, req.To = time.Time{}, time.Time{}
+	search, err := s.build(ctx, req, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, window := range windows {
+		if err := search.checkRange(window.From, window.To); err != nil {
+			return nil, err
+		}
+	}
+	return search, nil
+}
+
+func (s *SearchLogsScript) build(ctx context.Context, req SearchLogsReq, limits domain.QueryLimits) (*logSearch, error) {
 	userID, err := domain.NewID(req.UserID)
 	if err != nil {
 		return nil, err
@@ -58,20 +287,33 @@ func (s *SearchLogsScript) Exec(ctx context.Context, req SearchLogsReq) (*Search
 
 	filters := []domain.Filter{}
 
-	if strings.TrimSpace(req.SearchTerm) != "" {
-		filters = append(filters, domain.NewFilter("raw", domain.Like, req.SearchTerm))
+	searchFilter, err := s.searchTermFilter(req.SearchMode, req.SearchTerm)
+	if err != nil {
+		return nil, err
+	}
+	if searchFilter != nil {
+		filters = append(filters, *searchFilter)
 	}
 
 	if strings.TrimSpace(req.LogLevel) != "" {
 		filters = append(filters, domain.NewFilter("level", domain.Equals, req.LogLevel))
 	}
 
+	if strings.TrimSpace(req.ExceptionType) != "" {
+		filters = append(filters, domain.NewFilter("stackTrace.exceptionType", domain.Equals, req.ExceptionType))
+	}
+
+	if strings.TrimSpace(req.File) != "" {
+		filters = append(filters, domain.NewFilter("stackTrace.frames.file", domain.Like, req.File))
+	}
+
+	scope := []domain.Filter{}
 	if !req.From.IsZero() {
-		filters = append(filters, domain.NewFilter("timestamp", domain.GreaterThanOrEqual, req.From.UTC()))
+		scope = append(scope, domain.NewFilter("timestamp", domain.GreaterThanOrEqual, req.From.UTC()))
 	}
 
 	if !req.To.IsZero() {
-		filters = append(filters, domain.NewFilter("timestamp", domain.LessThanOrEqual, req.To.UTC()))
+		scope = append(scope, domain.NewFilter("timestamp", domain.LessThanOrEqual, req.To.UTC()))
 	}
 
 	appsIDs := make([]any, len(apps))
@@ -99,18 +341,109 @@ func (s *SearchLogsScript) Exec(ctx context.Context, req SearchLogsReq) (*Search
 		appsIDs = []any{appID}
 	}
 
-	filters = append(filters, domain.NewFilter("appId", domain.In, appsIDs))
-
-	criteria := domain.NewCriteria(
-		filters,
-		domain.NewPagination(req.Limit, (req.Page-1)*req.Limit),
-		domain.NewSort("timestamp", domain.SortOrder(req.SortOrder)),
-	)
+	scope = append(scope, domain.NewFilter("appId", domain.In, appsIDs))
+	filters = append(filters, scope...)
 
-	logs, err := s.logRepo.ListLogs(ctx, criteria)
+	query, err := domain.ParseQuery(req.Query)
 	if err != nil {
 		return nil, err
 	}
 
-	return &SearchLogsResp{Data: logs}, nil
+	search := &logSearch{
+		filters:    filters,
+		query:      query,
+		textSearch: req.SearchMode == SearchModeText && searchFilter != nil,
+		maxTime:    limits.MaxTime,
+		limits:     limits,
+		scope:      scope,
+		scans:      (searchFilter != nil && req.SearchMode != SearchModeText) || strings.TrimSpace(req.File) != "" || query != nil,
+	}
+	if req.SearchMode == SearchModeRegex && (search.maxTime == 0 || search.maxTime > searchRegexTimeBudget) {
+		search.maxTime = searchRegexTimeBudget
+	}
+	return search, nil
+}
+
+// searchEnd is the end of a time range, now when it has none.
+func searchEnd(to time.Time) time.Time {
+	if to.IsZero() {
+		return Now().UTC()
+	}
+	return to.UTC()
+}
+
+func (l *logSearch) checkRange(from time.Time, to time.Time) error {
+	return checkQueryRange(l.limits, from, to)
+}
+
+// checkQueryRange checks the time range is not longer than the limit. A
+// range without start is only allowed when there is no limit.
+func checkQueryRange(limits domain.QueryLimits, from time.Time, to time.Time) error {
+	if limits.MaxRange == 0 {
+		return nil
+	}
+	if from.IsZero() || searchEnd(to).Sub(from) > limits.MaxRange {
+		return fmt.Errorf("%w: the time range is longer than the %s allowed, narrow it", ErrSearchLogsScriptLimitExceeded, domain.FormatLength(limits.MaxRange))
+	}
+	return nil
+}
+
+// estimateScan counts the logs of the apps in the time range of the search,
+// narrowed by the extra filters, up to max.
+func (s *SearchLogsScript) estimateScan(ctx context.Context, search *logSearch, max int64, extra ...domain.Filter) (int64, error) {
+	criteria := domain.NewCriteria(append(slices.Clone(search.scope), extra...), domain.EmptyPagination, domain.EmptySort)
+	criteria.MaxTime = scanEstimateTimeBudget
+	return s.logRepo.CountLogs(ctx, criteria, max)
+}
+
+// checkScan checks the search would not read more logs than the limit. When
+// they cannot be counted in time, the search runs bounded by its time limit.
+func (s *SearchLogsScript) checkScan(ctx context.Context, search *logSearch, extra ...domain.Filter) error {
+	if search.limits.MaxScan == 0 {
+		return nil
+	}
+
+	count, err := s.estimateScan(ctx, search, search.limits.MaxScan+1, extra...)
+	if err != nil && mongo.IsTimeout(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if count > search.limits.MaxScan {
+		return scanLimitError(search.limits)
+	}
+	return nil
+}
+
+func scanLimitError(limits domain.QueryLimits) error {
+	return fmt.Errorf("%w: the search would read more than %d logs, narrow the time range or the apps", ErrSearchLogsScriptLimitExceeded, limits.MaxScan)
+}
+
+// searchTermFilter returns the filter of the search term in the given mode,
+// or nil when there is no term.
+func (s *SearchLogsScript) searchTermFilter(mode SearchMode, term string) (*domain.Filter, error) {
+	if strings.TrimSpace(term) == "" {
+		return nil, nil
+	}
+
+	var filter domain.Filter
+	switch mode {
+	case "", SearchModeLiteral:
+		filter = domain.NewFilter("raw", domain.Like, term)
+	case SearchModeRegex:
+		if len(term) > maxSearchRegexLength {
+			return nil, fmt.Errorf("%w: cannot be longer than %d characters", ErrSearchLogsScriptInvalidRegex, maxSearchRegexLength)
+		}
+		if _, err := regexp.Compile(term); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrSearchLogsScriptInvalidRegex, err)
+		}
+		filter = domain.NewFilter("raw", domain.Regex, term)
+	case SearchModeText:
+		filter = domain.NewFilter("", domain.Text, term)
+	default:
+		return nil, ErrSearchLogsScriptInvalidSearchMode
+	}
+	return &filter, nil
 }
@@ -0,0 +1,125 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"monitoring/internal/domain"
+)
+
+const (
+	// defaultTailRate and maxTailRate are in logs per second.
+	defaultTailRate = 50
+	maxTailRate     = 500
+)
+
+type TailLogsReq struct {
+	UserID string `json:"-"`
+	AppID  string `form:"appId"`
+	// Query is written in the query language of domain.ParseQuery.
+	Query string `form:"query"`
+	// Cursor is the id of the last event received, to resume the tail
+	// without missing logs.
+	Cursor string `form:"cursor"`
+	// MaxRate is the most logs sent per second, the rest wait for their turn.
+	MaxRate int `form:"maxRate"`
+}
+
+// TailLogsResp follows the logs ingested for the apps of the user that match
+// the query. It must be closed.
+type TailLogsResp struct {
+	subscription domain.LogSubscription
+	query        domain.QueryNode
+	limiter      *rate.Limiter
+}
+
+// Next returns the next matching log or gap, or nil when none arrived in
+// about a second.
+func (r *TailLogsResp) Next(ctx context.Context) (*domain.LogEvent, error) {
+	start := time.Now()
+	for time.Since(start) < time.Second {
+		event, err := r.subscription.Next(ctx)
+		if err != nil || event == nil {
+			return nil, err
+		}
+
+		if event.Log != nil && !domain.MatchQuery(r.query, *event.Log) {
+			continue
+		}
+
+		// Logs are read from the broker no faster than the limit, so a
+		// consumer that cannot keep up falls behind instead of getting more.
+		if err := r.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return event, nil
+	}
+	return nil, nil
+}
+
+func (r *TailLogsResp) Close(ctx context.Context) error {
+	return r.subscription.Close(ctx)
+}
+
+type TailLogsScript struct {
+	appRepo   domain.AppRepo
+	logBroker domain.LogBroker
+}
+
+func NewTailLogsScript(appRepo domain.AppRepo, logBroker domain.LogBroker) *TailLogsScript {
+	return &TailLogsScript{appRepo: appRepo, logBroker: logBroker}
+}
+
+func (s *TailLogsScript) Exec(ctx context.Context, req TailLogsReq) (*TailLogsResp, error) {
+	userID, err := domain.NewID(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := domain.ParseQuery(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	apps, err := s.appRepo.ListApps(ctx, domain.NewCriteria(
+		[]domain.Filter{
+			domain.NewFilter("userId", domain.Equals, userID),
+		},
+		domain.EmptyPagination,
+		domain.EmptySort,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := make([]domain.ID, 0, len(apps))
+	for _, app := range apps {
+		if strings.TrimSpace(req.AppID) == "" || app.ID().Hex() == req.AppID {
+			appIDs = append(appIDs, app.ID())
+		}
+	}
+	if strings.TrimSpace(req.AppID) != "" && len(appIDs) == 0 {
+		return nil, fmt.Errorf("app with ID %s does not exist for the user", req.AppID)
+	}
+
+	maxRate := req.MaxRate
+	if maxRate <= 0 {
+		maxRate = defaultTailRate
+	}
+	maxRate = min(maxRate, maxTailRate)
+
+	subscription, err := s.logBroker.Subscribe(ctx, appIDs, req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TailLogsResp{
+		subscription: subscription,
+		query:        query,
+		limiter:      rate.NewLimiter(rate.Limit(maxRate), maxRate),
+	}, nil
+}
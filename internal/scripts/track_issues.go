@@ -0,0 +1,138 @@
+package scripts
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/fingerprint"
+)
+
+// issueLevels are the levels whose logs are grouped into issues.
+var issueLevels = map[string]bool{"ERROR": true, "FATAL": true}
+
+type TrackIssuesReq struct {
+	App  domain.App
+	Logs []domain.Log
+}
+
+type TrackIssuesResp struct {
+	Issues []domain.Issue
+}
+
+type TrackIssuesScript struct {
+	issueRepo domain.IssueRepo
+}
+
+func NewTrackIssuesScript(issueRepo domain.IssueRepo) *TrackIssuesScript {
+	return &TrackIssuesScript{issueRepo: issueRepo}
+}
+
+// issueEvents are the logs of a batch that share a fingerprint.
+type issueEvents struct {
+	fingerprint string
+	title       string
+	level       string
+	count       int64
+	firstSeen   time.Time
+	lastSeen    time.Time
+}
+
+// Exec adds the fingerprinted logs to the issues of the app owner, opening
+// an issue for each new fingerprint.
+func (s *TrackIssuesScript) Exec(ctx context.Context, req TrackIssuesReq) (*TrackIssuesResp, error) {
+	groups := make([]*issueEvents, 0)
+	byFingerprint := make(map[string]*issueEvents)
+	for _, log := range req.Logs {
+		if log.Fingerprint() == "" {
+			continue
+		}
+
+		group, ok := byFingerprint[log.Fingerprint()]
+		if !ok {
+			group = &issueEvents{
+				fingerprint: log.Fingerprint(),
+				title:       fingerprint.Template(logMessage(log.Data(), log.Raw(), log.StackTrace())),
+				firstSeen:   log.Timestamp(),
+			}
+			byFingerprint[log.Fingerprint()] = group
+			groups = append(groups, group)
+		}
+
+		group.count++
+		group.level = log.Level()
+		if log.Timestamp().Before(group.firstSeen) {
+			group.firstSeen = log.Timestamp()
+		}
+		if log.Timestamp().After(group.lastSeen) {
+			group.lastSeen = log.Timestamp()
+		}
+	}
+
+	issues := make([]domain.Issue, 0, len(groups))
+	for _, group := range groups {
+		issue, err := s.track(ctx, req.App, *group)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, *issue)
+	}
+
+	return &TrackIssuesResp{Issues: issues}, nil
+}
+
+func (s *TrackIssuesScript) track(ctx context.Context, app domain.App, group issueEvents) (*domain.Issue, error) {
+	title := group.title
+	if title == "" {
+		title = "Unknown error"
+	}
+
+	return s.issueRepo.RecordIssueEvents(ctx, app.UserID(), domain.IssueEvents{
+		AppID:       app.ID(),
+		Fingerprint: group.fingerprint,
+		Title:       title,
+		Level:       group.level,
+		Count:       group.count,
+		FirstSeen:   group.firstSeen,
+		LastSeen:    group.lastSeen,
+	})
+}
+
+// fingerprintLog returns the fingerprint of an error log, or an empty string
+// for the levels that are not grouped into issues.
+func fingerprintLog(level string, data map[string]any, raw string, stackTrace *domain.StackTrace) string {
+	if !issueLevels[level] {
+		return ""
+	}
+
+	var frames []fingerprint.Frame
+	if stackTrace != nil {
+		frames = make([]fingerprint.Frame, len(stackTrace.Frames))
+		for i, frame := range stackTrace.Frames {
+			frames[i] = fingerprint.Frame{
+				File:     frame.File,
+				Function: frame.Function,
+				InApp:    frame.InApp,
+			}
+		}
+	}
+
+	return fingerprint.Compute(fingerprint.Template(logMessage(data, raw, stackTrace)), frames)
+}
+
+// logMessage is the text that describes what went wrong: the exception of
+// the stack trace, then the message fields of the data and then the raw log.
+func logMessage(data map[string]any, raw string, stackTrace *domain.StackTrace) string {
+	if stackTrace != nil && (stackTrace.ExceptionType != "" || stackTrace.Message != "") {
+		return strings.TrimPrefix(stackTrace.ExceptionType+": "+stackTrace.Message, ": ")
+	}
+
+	for _, key := range []string{"message", "msg", "error"} {
+		if message, ok := data[key].(string); ok && strings.TrimSpace(message) != "" {
+			return message
+		}
+	}
+
+	return raw
+}
@@ -7,9 +7,10 @@ import (
 )
 
 type UpdateAppReq struct {
-	ID     string `json:"id"`
-	Name   string `json:"name"`
-	AppKey string `json:"appKey"`
+	UserID           string `json:"-"`
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	RequireSignature *bool  `json:"requireSignature"`
 }
 
 type UpdateAppResp struct {
@@ -25,12 +26,7 @@ func NewUpdateAppScript(appRepo domain.AppRepo) *UpdateAppScript {
 }
 
 func (s *UpdateAppScript) Exec(ctx context.Context, req UpdateAppReq) (*UpdateAppResp, error) {
-	id, err := domain.NewID(req.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	app, err := s.appRepo.GetAppByID(ctx, id)
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -40,9 +36,11 @@ func (s *UpdateAppScript) Exec(ctx context.Context, req UpdateAppReq) (*UpdateAp
 		return nil, err
 	}
 
-	err = app.ChangeAppKey(req.AppKey)
-	if err != nil {
-		return nil, err
+	if req.RequireSignature != nil {
+		err = app.ChangeRequireSignature(*req.RequireSignature)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	err = s.appRepo.UpdateApp(ctx, *app)
@@ -0,0 +1,127 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+// UpdateLogMetricReq changes the fields that are set. RemoveAlert removes
+// the alert.
+type UpdateLogMetricReq struct {
+	UserID      string             `json:"-"`
+	MetricID    string             `json:"-"`
+	Name        *string            `json:"name"`
+	Type        *string            `json:"type"`
+	Query       *string            `json:"query"`
+	AppIDs      *[]string          `json:"appIds"`
+	Field       *string            `json:"field"`
+	GroupBy     *[]string          `json:"groupBy"`
+	Alert       *LogMetricAlertReq `json:"alert"`
+	RemoveAlert bool               `json:"removeAlert"`
+}
+
+type UpdateLogMetricResp struct {
+	domain.LogMetric
+}
+
+type UpdateLogMetricScript struct {
+	appRepo                 domain.AppRepo
+	logMetricRepo           domain.LogMetricRepo
+	logMetricRollupRepo     domain.LogMetricRollupRepo
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo
+}
+
+func NewUpdateLogMetricScript(
+	appRepo domain.AppRepo,
+	logMetricRepo domain.LogMetricRepo,
+	logMetricRollupRepo domain.LogMetricRollupRepo,
+	logMetricAlertStateRepo domain.LogMetricAlertStateRepo,
+) *UpdateLogMetricScript {
+	return &UpdateLogMetricScript{
+		appRepo:                 appRepo,
+		logMetricRepo:           logMetricRepo,
+		logMetricRollupRepo:     logMetricRollupRepo,
+		logMetricAlertStateRepo: logMetricAlertStateRepo,
+	}
+}
+
+// Exec changes a metric of the user. Changing what it counts deletes its
+// series, which no longer match it, and changing its alert resets the state
+// of the alert.
+func (s *UpdateLogMetricScript) Exec(ctx context.Context, req UpdateLogMetricReq) (*UpdateLogMetricResp, error) {
+	metric, err := getUserLogMetric(ctx, s.logMetricRepo, req.UserID, req.MetricID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if err := metric.ChangeName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	realerted := req.RemoveAlert || req.Alert != nil
+	alert, err := req.Alert.alert()
+	if err != nil {
+		return nil, err
+	}
+	// The new alert replaces the old one before the definition changes, so it
+	// is the one checked against the new definition.
+	if realerted {
+		if err := metric.ChangeAlert(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	redefined := req.Type != nil || req.Query != nil || req.AppIDs != nil || req.Field != nil || req.GroupBy != nil
+	if redefined {
+		metricType, query, appIDs, field, groupBy := metric.Type(), metric.Query(), metric.AppIDs(), metric.Field(), metric.GroupBy()
+		if req.Type != nil {
+			metricType = domain.LogMetricType(*req.Type)
+		}
+		if req.Query != nil {
+			query = *req.Query
+		}
+		if req.AppIDs != nil {
+			appIDs, err = getUserAppIDs(ctx, s.appRepo, req.UserID, *req.AppIDs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if req.Field != nil {
+			field = *req.Field
+		}
+		if req.GroupBy != nil {
+			groupBy = *req.GroupBy
+		}
+		if err := metric.ChangeDefinition(metricType, query, appIDs, field, groupBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if realerted {
+		if err := metric.ChangeAlert(alert); err != nil {
+			return nil, err
+		}
+	}
+
+	metric.MarkUpdated(Now().UTC())
+	if err := s.logMetricRepo.UpdateLogMetric(ctx, *metric); err != nil {
+		return nil, err
+	}
+
+	if redefined {
+		if err := s.logMetricRollupRepo.DeleteRollups(ctx, metric.ID()); err != nil {
+			return nil, err
+		}
+	}
+
+	if redefined || realerted {
+		if err := s.logMetricAlertStateRepo.DeleteAlertStates(ctx, metric.ID()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &UpdateLogMetricResp{LogMetric: *metric}, nil
+}
@@ -0,0 +1,79 @@
+package scripts
+
+import (
+	"context"
+
+	"monitoring/internal/domain"
+)
+
+// UpdateNotificationChannelReq changes the fields that are set.
+type UpdateNotificationChannelReq struct {
+	UserID    string    `json:"-"`
+	ChannelID string    `json:"-"`
+	Name      *string   `json:"name"`
+	Type      *string   `json:"type"`
+	Target    *string   `json:"target"`
+	Events    *[]string `json:"events"`
+	AppIDs    *[]string `json:"appIds"`
+}
+
+type UpdateNotificationChannelResp struct {
+	domain.NotificationChannel
+}
+
+type UpdateNotificationChannelScript struct {
+	appRepo                 domain.AppRepo
+	notificationChannelRepo domain.NotificationChannelRepo
+}
+
+func NewUpdateNotificationChannelScript(appRepo domain.AppRepo, notificationChannelRepo domain.NotificationChannelRepo) *UpdateNotificationChannelScript {
+	return &UpdateNotificationChannelScript{appRepo: appRepo, notificationChannelRepo: notificationChannelRepo}
+}
+
+func (s *UpdateNotificationChannelScript) Exec(ctx context.Context, req UpdateNotificationChannelReq) (*UpdateNotificationChannelResp, error) {
+	channel, err := getUserNotificationChannel(ctx, s.notificationChannelRepo, req.UserID, req.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if err := channel.ChangeName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Type != nil || req.Target != nil {
+		channelType, target := channel.Type(), channel.Target()
+		if req.Type != nil {
+			channelType = domain.NotificationChannelType(*req.Type)
+		}
+		if req.Target != nil {
+			target = *req.Target
+		}
+		if err := channel.ChangeTarget(channelType, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Events != nil || req.AppIDs != nil {
+		events, appIDs := channel.Events(), channel.AppIDs()
+		if req.Events != nil {
+			events = notificationEvents(*req.Events)
+		}
+		if req.AppIDs != nil {
+			appIDs, err = getUserAppIDs(ctx, s.appRepo, req.UserID, *req.AppIDs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := channel.ChangeSubscription(events, appIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.notificationChannelRepo.UpdateNotificationChannel(ctx, *channel); err != nil {
+		return nil, err
+	}
+
+	return &UpdateNotificationChannelResp{NotificationChannel: *channel}, nil
+}
@@ -0,0 +1,57 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrUpdateQueryPolicyScriptNotRoot = errors.New("only the root user of the account can change its query policy")
+)
+
+// UpdateQueryPolicyReq replaces the limits of the account. They can only
+// narrow the server ones, empty or 0 leaving them as they are.
+type UpdateQueryPolicyReq struct {
+	UserID string `json:"-"`
+	// MaxRange is a length like 12h, 7d or 3M.
+	MaxRange  string `json:"maxRange"`
+	MaxScan   int64  `json:"maxScan"`
+	MaxTimeMS int64  `json:"maxTimeMs"`
+}
+
+type UpdateQueryPolicyScript struct {
+	userRepo        domain.UserRepo
+	queryPolicyRepo domain.QueryPolicyRepo
+}
+
+func NewUpdateQueryPolicyScript(userRepo domain.UserRepo, queryPolicyRepo domain.QueryPolicyRepo) *UpdateQueryPolicyScript {
+	return &UpdateQueryPolicyScript{userRepo: userRepo, queryPolicyRepo: queryPolicyRepo}
+}
+
+func (s *UpdateQueryPolicyScript) Exec(ctx context.Context, req UpdateQueryPolicyReq) (*domain.QueryPolicy, error) {
+	user, accountID, err := getUserAccount(ctx, s.userRepo, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsRoot() {
+		return nil, ErrUpdateQueryPolicyScriptNotRoot
+	}
+
+	limits, err := domain.NewQueryLimits(req.MaxRange, req.MaxScan, req.MaxTimeMS)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := domain.NewQueryPolicy(accountID, limits, user.ID(), Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.queryPolicyRepo.SaveQueryPolicy(ctx, *policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
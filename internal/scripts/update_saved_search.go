@@ -0,0 +1,85 @@
+package scripts
+
+import (
+	"context"
+	"fmt"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/export"
+)
+
+// UpdateSavedSearchReq changes the fields that are set.
+type UpdateSavedSearchReq struct {
+	UserID    string    `json:"-"`
+	SearchID  string    `json:"-"`
+	Name      *string   `json:"name"`
+	Query     *string   `json:"query"`
+	AppIDs    *[]string `json:"appIds"`
+	TimeRange *string   `json:"timeRange"`
+	Columns   *[]string `json:"columns"`
+	Shared    *bool     `json:"shared"`
+}
+
+type UpdateSavedSearchResp struct {
+	domain.SavedSearch
+}
+
+type UpdateSavedSearchScript struct {
+	appRepo         domain.AppRepo
+	savedSearchRepo domain.SavedSearchRepo
+}
+
+func NewUpdateSavedSearchScript(appRepo domain.AppRepo, savedSearchRepo domain.SavedSearchRepo) *UpdateSavedSearchScript {
+	return &UpdateSavedSearchScript{appRepo: appRepo, savedSearchRepo: savedSearchRepo}
+}
+
+// Exec changes a saved search of the user. Searches shared by other users of
+// the account can be used but not changed.
+func (s *UpdateSavedSearchScript) Exec(ctx context.Context, req UpdateSavedSearchReq) (*UpdateSavedSearchResp, error) {
+	search, err := getUserSavedSearch(ctx, s.savedSearchRepo, req.UserID, req.SearchID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if err := search.ChangeName(*req.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Query != nil || req.AppIDs != nil || req.TimeRange != nil || req.Columns != nil {
+		query, appIDs, timeRange, columns := search.Query(), search.AppIDs(), search.TimeRange(), search.Columns()
+		if req.Query != nil {
+			query = *req.Query
+		}
+		if req.AppIDs != nil {
+			appIDs, err = getUserAppIDs(ctx, s.appRepo, req.UserID, *req.AppIDs)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if req.TimeRange != nil {
+			timeRange = *req.TimeRange
+		}
+		if req.Columns != nil {
+			if err := export.ValidateColumns(*req.Columns); err != nil {
+				return nil, fmt.Errorf("%w: %s", domain.ErrSavedSearch, err)
+			}
+			columns = *req.Columns
+		}
+		if err := search.ChangeSearch(query, appIDs, timeRange, columns); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Shared != nil {
+		search.ChangeShared(*req.Shared)
+	}
+
+	search.MarkUpdated(Now().UTC())
+	if err := s.savedSearchRepo.UpdateSavedSearch(ctx, *search); err != nil {
+		return nil, err
+	}
+
+	return &UpdateSavedSearchResp{SavedSearch: *search}, nil
+}
@@ -0,0 +1,61 @@
+package scripts
+
+import (
+	"context"
+	"encoding/json"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/sourcemap"
+)
+
+type UploadSourceMapReq struct {
+	UserID    string          `json:"-"`
+	AppID     string          `json:"-"`
+	Release   string          `json:"-"`
+	File      string          `json:"file"`
+	SourceMap json.RawMessage `json:"sourceMap"`
+}
+
+type UploadSourceMapResp struct {
+	domain.SourceMap
+}
+
+type UploadSourceMapScript struct {
+	appRepo       domain.AppRepo
+	sourceMapRepo domain.SourceMapRepo
+}
+
+func NewUploadSourceMapScript(appRepo domain.AppRepo, sourceMapRepo domain.SourceMapRepo) *UploadSourceMapScript {
+	return &UploadSourceMapScript{appRepo: appRepo, sourceMapRepo: sourceMapRepo}
+}
+
+func (s *UploadSourceMapScript) Exec(ctx context.Context, req UploadSourceMapReq) (*UploadSourceMapResp, error) {
+	app, err := getUserApp(ctx, s.appRepo, req.UserID, req.AppID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = sourcemap.Parse(req.SourceMap)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceMap, err := domain.NewSourceMap(
+		domain.NewAutoID(),
+		app.ID(),
+		req.Release,
+		req.File,
+		string(req.SourceMap),
+		Now().UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.sourceMapRepo.SaveSourceMap(ctx, *sourceMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadSourceMapResp{SourceMap: *sourceMap}, nil
+}
@@ -0,0 +1,201 @@
+// Package sourcemap decodes version 3 source maps and maps generated
+// positions back to their original source.
+package sourcemap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidSourceMap = errors.New("invalid source map")
+)
+
+// Position is a place in the original source. Line and Column are 1-based,
+// like the ones found in JavaScript stack traces.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+	Name   string
+}
+
+type segment struct {
+	generatedColumn int
+	source          int
+	originalLine    int
+	originalColumn  int
+	name            int
+}
+
+type Map struct {
+	sources []string
+	names   []string
+	lines   [][]segment
+}
+
+type rawMap struct {
+	Version    int             `json:"version"`
+	SourceRoot string          `json:"sourceRoot"`
+	Sources    []string        `json:"sources"`
+	Names      []string        `json:"names"`
+	Mappings   string          `json:"mappings"`
+	Sections   json.RawMessage `json:"sections"`
+}
+
+func Parse(content []byte) (*Map, error) {
+	var raw rawMap
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSourceMap, err)
+	}
+
+	if raw.Version != 3 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSourceMap, raw.Version)
+	}
+
+	if len(raw.Sections) > 0 {
+		return nil, fmt.Errorf("%w: index maps are not supported", ErrInvalidSourceMap)
+	}
+
+	sources := make([]string, len(raw.Sources))
+	for i, source := range raw.Sources {
+		if raw.SourceRoot != "" && !strings.Contains(source, "://") {
+			source = strings.TrimRight(raw.SourceRoot, "/") + "/" + source
+		}
+		sources[i] = source
+	}
+
+	lines, err := decodeMappings(raw.Mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Map{sources: sources, names: raw.Names, lines: lines}, nil
+}
+
+// Resolve returns the original position of a 1-based generated line and
+// column.
+func (m *Map) Resolve(line, column int) (Position, bool) {
+	if line < 1 || line > len(m.lines) {
+		return Position{}, false
+	}
+
+	segments := m.lines[line-1]
+	col := column - 1
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].generatedColumn > col
+	}) - 1
+	if i < 0 {
+		return Position{}, false
+	}
+
+	seg := segments[i]
+	if seg.source < 0 || seg.source >= len(m.sources) {
+		return Position{}, false
+	}
+
+	pos := Position{
+		Source: m.sources[seg.source],
+		Line:   seg.originalLine + 1,
+		Column: seg.originalColumn + 1,
+	}
+	if seg.name >= 0 && seg.name < len(m.names) {
+		pos.Name = m.names[seg.name]
+	}
+	return pos, true
+}
+
+func decodeMappings(mappings string) ([][]segment, error) {
+	var (
+		lines          [][]segment
+		source         int
+		originalLine   int
+		originalColumn int
+		name           int
+	)
+
+	for _, line := range strings.Split(mappings, ";") {
+		var segments []segment
+		generatedColumn := 0
+
+		for _, field := range strings.Split(line, ",") {
+			if field == "" {
+				continue
+			}
+
+			values, err := decodeVLQ(field)
+			if err != nil {
+				return nil, err
+			}
+
+			switch len(values) {
+			case 1, 4, 5:
+			default:
+				return nil, fmt.Errorf("%w: segment with %d fields", ErrInvalidSourceMap, len(values))
+			}
+
+			generatedColumn += values[0]
+			seg := segment{generatedColumn: generatedColumn, source: -1, name: -1}
+			if len(values) >= 4 {
+				source += values[1]
+				originalLine += values[2]
+				originalColumn += values[3]
+				seg.source = source
+				seg.originalLine = originalLine
+				seg.originalColumn = originalColumn
+			}
+			if len(values) == 5 {
+				name += values[4]
+				seg.name = name
+			}
+			segments = append(segments, seg)
+		}
+
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].generatedColumn < segments[j].generatedColumn
+		})
+		lines = append(lines, segments)
+	}
+
+	return lines, nil
+}
+
+const base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+func decodeVLQ(field string) ([]int, error) {
+	var (
+		values []int
+		value  int
+		shift  uint
+	)
+
+	for i := 0; i < len(field); i++ {
+		digit := strings.IndexByte(base64Chars, field[i])
+		if digit < 0 {
+			return nil, fmt.Errorf("%w: invalid base64 character %q", ErrInvalidSourceMap, field[i])
+		}
+
+		value += (digit & 31) << shift
+		if digit&32 != 0 {
+			shift += 5
+			continue
+		}
+
+		if value&1 == 1 {
+			values = append(values, -(value >> 1))
+		} else {
+			values = append(values, value>>1)
+		}
+		value = 0
+		shift = 0
+	}
+
+	if shift != 0 {
+		return nil, fmt.Errorf("%w: truncated segment", ErrInvalidSourceMap)
+	}
+
+	return values, nil
+}
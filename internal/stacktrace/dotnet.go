@@ -0,0 +1,55 @@
+package stacktrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	dotNetFrameRegex     = regexp.MustCompile(`^\s+at ([\w.<>` + "`" + `+\[\], ]+?)\((.*?)\)(?: in (.+):line (\d+))?$`)
+	dotNetExceptionRegex = regexp.MustCompile(`^([A-Z][\w]*(?:\.[\w]+)*(?:Exception|Error))(?::\s*(.*))?$`)
+)
+
+// parseDotNet parses .NET stack traces:
+//
+//	System.NullReferenceException: Object reference not set to an instance of an object.
+//	   at MyApp.Program.Main(String[] args) in C:\src\MyApp\Program.cs:line 12
+func parseDotNet(lines []string) *Trace {
+	trace := &Trace{Language: DotNet}
+	isDotNet := false
+	for _, line := range lines {
+		matches := dotNetFrameRegex.FindStringSubmatch(line)
+		if matches == nil {
+			if trace.ExceptionType == "" {
+				if exception := dotNetExceptionRegex.FindStringSubmatch(strings.TrimSpace(line)); exception != nil {
+					trace.ExceptionType = exception[1]
+					trace.Message = strings.TrimSpace(exception[2])
+				}
+			}
+			if strings.Contains(line, "--- End of inner exception stack trace ---") && len(trace.Frames) > 0 {
+				break
+			}
+			continue
+		}
+
+		// Without a file, .NET and Java frames look alike. The parameters
+		// tell them apart: .NET lists typed parameters, Java lists a location.
+		if matches[3] != "" || matches[2] == "" || strings.Contains(matches[2], " ") {
+			isDotNet = true
+		}
+
+		line, _ := strconv.Atoi(matches[4])
+		trace.Frames = append(trace.Frames, Frame{
+			File:     matches[3],
+			Function: matches[1],
+			Line:     line,
+			InApp:    !hasAnyPrefix(matches[1], "System.", "Microsoft.", "Newtonsoft."),
+		})
+	}
+
+	if !isDotNet || trace.ExceptionType == "" {
+		return nil
+	}
+	return trace
+}
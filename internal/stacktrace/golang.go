@@ -0,0 +1,95 @@
+package stacktrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	goFunctionRegex = regexp.MustCompile(`^(?:created by )?(\S.*?)(?:\([^()]*\))?(?: in goroutine \d+)?$`)
+	goLocationRegex = regexp.MustCompile(`^\t(.+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// parseGo parses panics and goroutine dumps:
+//
+//	panic: runtime error: index out of range [3] with length 3
+//
+//	goroutine 1 [running]:
+//	main.main()
+//		/app/main.go:8 +0x25
+func parseGo(lines []string) *Trace {
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(line, "]:") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	trace := &Trace{Language: Go, ExceptionType: "panic"}
+	for _, line := range lines[:start] {
+		if message, ok := strings.CutPrefix(line, "panic: "); ok {
+			trace.Message = strings.TrimSpace(message)
+			break
+		}
+		if message, ok := strings.CutPrefix(line, "fatal error: "); ok {
+			trace.ExceptionType = "fatal error"
+			trace.Message = strings.TrimSpace(message)
+			break
+		}
+	}
+
+	for i := start + 1; i+1 < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "goroutine ") {
+			// Only the first goroutine, the one that failed, is kept.
+			break
+		}
+
+		function := goFunctionRegex.FindStringSubmatch(lines[i])
+		location := goLocationRegex.FindStringSubmatch(lines[i+1])
+		if function == nil || location == nil {
+			continue
+		}
+
+		line, _ := strconv.Atoi(location[2])
+		trace.Frames = append(trace.Frames, Frame{
+			File:     location[1],
+			Function: function[1],
+			Line:     line,
+			InApp:    isGoInApp(function[1], location[1]),
+		})
+		i++
+	}
+
+	return trace
+}
+
+// goStdPackages are the top level packages of the standard library.
+var goStdPackages = map[string]bool{
+	"archive": true, "bufio": true, "bytes": true, "cmp": true, "compress": true,
+	"container": true, "context": true, "crypto": true, "database": true, "debug": true,
+	"embed": true, "encoding": true, "errors": true, "expvar": true, "flag": true,
+	"fmt": true, "go": true, "hash": true, "html": true, "image": true, "index": true,
+	"internal": true, "io": true, "iter": true, "log": true, "maps": true, "math": true,
+	"mime": true, "net": true, "os": true, "path": true, "plugin": true, "reflect": true,
+	"regexp": true, "runtime": true, "slices": true, "sort": true, "strconv": true,
+	"strings": true, "sync": true, "syscall": true, "testing": true, "text": true,
+	"time": true, "unicode": true, "unique": true, "unsafe": true, "weak": true,
+}
+
+func isGoInApp(function string, file string) bool {
+	if strings.Contains(file, "/pkg/mod/") || strings.Contains(file, "/vendor/") {
+		return false
+	}
+
+	// The first element of "net/http.(*conn).serve" or "runtime.gopanic".
+	pkg := function
+	if idx := strings.IndexAny(pkg, "/."); idx >= 0 {
+		pkg = pkg[:idx]
+	}
+	return !goStdPackages[pkg]
+}
@@ -0,0 +1,63 @@
+package stacktrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	javaFrameRegex     = regexp.MustCompile(`^\s+at (?:[\w.$-]+/)*([\w$.<>]+)\((.*)\)$`)
+	javaLocationRegex  = regexp.MustCompile(`^([\w$.-]+\.(?:java|kt|kts|scala|groovy|clj))(?::(\d+))?$`)
+	javaExceptionRegex = regexp.MustCompile(`^(?:Exception in thread "[^"]*" )?([a-zA-Z_$][\w$]*(?:\.[\w$]+)+)(?::\s*(.*))?$`)
+)
+
+// parseJava parses JVM stack traces:
+//
+//	java.lang.IllegalStateException: boom
+//		at com.example.Service.run(Service.java:42)
+//		at java.base/java.lang.Thread.run(Thread.java:833)
+func parseJava(lines []string) *Trace {
+	trace := &Trace{Language: Java}
+	for _, line := range lines {
+		matches := javaFrameRegex.FindStringSubmatch(line)
+		if matches == nil {
+			if trace.ExceptionType == "" {
+				if exception := javaExceptionRegex.FindStringSubmatch(strings.TrimSpace(line)); exception != nil {
+					trace.ExceptionType = exception[1]
+					trace.Message = strings.TrimSpace(exception[2])
+				}
+			}
+			if strings.HasPrefix(line, "Caused by: ") && len(trace.Frames) > 0 {
+				// The frames of the causes repeat the ones already parsed.
+				break
+			}
+			continue
+		}
+
+		location := matches[2]
+		if location != "Native Method" && location != "Unknown Source" && !javaLocationRegex.MatchString(location) {
+			continue
+		}
+
+		frame := Frame{Function: matches[1], File: location, InApp: isJavaInApp(matches[1])}
+		if loc := javaLocationRegex.FindStringSubmatch(location); loc != nil {
+			frame.File = loc[1]
+			frame.Line, _ = strconv.Atoi(loc[2])
+		}
+		trace.Frames = append(trace.Frames, frame)
+	}
+
+	if trace.ExceptionType == "" {
+		return nil
+	}
+	return trace
+}
+
+func isJavaInApp(function string) bool {
+	return !hasAnyPrefix(function,
+		"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin.", "kotlinx.", "scala.",
+		"org.springframework.", "org.apache.", "org.hibernate.", "io.netty.", "reactor.",
+		"com.fasterxml.", "org.eclipse.", "org.junit.",
+	)
+}
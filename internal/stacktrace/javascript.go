@@ -0,0 +1,86 @@
+package stacktrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// "    at fn (https://host/app.js:10:20)" or "    at https://host/app.js:10:20"
+	v8FrameRegex = regexp.MustCompile(`^\s*at (?:(.+?) \()?(.+?):(\d+):(\d+)\)?$`)
+	// "fn@https://host/app.js:10:20", used by Firefox and Safari
+	geckoFrameRegex = regexp.MustCompile(`^\s*(.*?)@(.+?):(\d+):(\d+)$`)
+	jsErrorRegex    = regexp.MustCompile(`^(?:Uncaught )?([A-Z]\w*(?:Error|Exception)|Error)(?:\s*\[[\w_]+\])?(?::\s*(.*))?$`)
+)
+
+// ParseJavaScript parses stacks produced by V8 (Chrome, Edge, Node) and by
+// Gecko and WebKit (Firefox, Safari). Lines that are not frames are skipped.
+func ParseJavaScript(stack string) []Frame {
+	var frames []Frame
+	for _, line := range strings.Split(stack, "\n") {
+		if frame, ok := parseJavaScriptFrame(strings.TrimRight(line, "\r")); ok {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+func parseJavaScript(lines []string) *Trace {
+	trace := &Trace{Language: JavaScript}
+	for _, line := range lines {
+		if frame, ok := parseJavaScriptFrame(line); ok {
+			trace.Frames = append(trace.Frames, frame)
+			continue
+		}
+
+		if trace.ExceptionType == "" && len(trace.Frames) == 0 {
+			if exception := jsErrorRegex.FindStringSubmatch(strings.TrimSpace(line)); exception != nil {
+				trace.ExceptionType = exception[1]
+				trace.Message = strings.TrimSpace(exception[2])
+			}
+		}
+	}
+
+	// Gecko frames have no header and can be mistaken for other text, so
+	// only V8 style traces are detected without an exception.
+	if trace.ExceptionType == "" && !v8FrameRegex.MatchString(firstFrameLine(lines)) {
+		return nil
+	}
+	return trace
+}
+
+func parseJavaScriptFrame(line string) (Frame, bool) {
+	matches := v8FrameRegex.FindStringSubmatch(line)
+	if matches == nil {
+		matches = geckoFrameRegex.FindStringSubmatch(line)
+	}
+	if matches == nil {
+		return Frame{}, false
+	}
+
+	lineNumber, _ := strconv.Atoi(matches[3])
+	column, _ := strconv.Atoi(matches[4])
+	return Frame{
+		File:     matches[2],
+		Function: strings.TrimPrefix(matches[1], "async "),
+		Line:     lineNumber,
+		Column:   column,
+		InApp:    isJavaScriptInApp(matches[2]),
+	}, true
+}
+
+func firstFrameLine(lines []string) string {
+	for _, line := range lines {
+		if _, ok := parseJavaScriptFrame(line); ok {
+			return line
+		}
+	}
+	return ""
+}
+
+func isJavaScriptInApp(file string) bool {
+	return !strings.Contains(file, "node_modules") &&
+		!strings.HasPrefix(file, "node:") &&
+		!strings.HasPrefix(file, "internal/")
+}
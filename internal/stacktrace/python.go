@@ -0,0 +1,70 @@
+package stacktrace
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	pythonFrameRegex     = regexp.MustCompile(`^\s+File "(.+)", line (\d+)(?:, in (.+))?$`)
+	pythonExceptionRegex = regexp.MustCompile(`^([a-zA-Z_][\w.]*)(?::\s*(.*))?$`)
+)
+
+// parsePython parses tracebacks:
+//
+//	Traceback (most recent call last):
+//	  File "/app/main.py", line 10, in <module>
+//	    main()
+//	ValueError: bad value
+//
+// Frames are reversed so the innermost call comes first, like in the other
+// languages.
+func parsePython(lines []string) *Trace {
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "Traceback (most recent call last):") {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	trace := &Trace{Language: Python}
+	var frames []Frame
+	for _, line := range lines[start+1:] {
+		if matches := pythonFrameRegex.FindStringSubmatch(line); matches != nil {
+			lineNumber, _ := strconv.Atoi(matches[2])
+			frames = append(frames, Frame{
+				File:     matches[1],
+				Function: matches[3],
+				Line:     lineNumber,
+				InApp:    isPythonInApp(matches[1]),
+			})
+			continue
+		}
+
+		if strings.HasPrefix(line, " ") || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if exception := pythonExceptionRegex.FindStringSubmatch(line); exception != nil {
+			trace.ExceptionType = exception[1]
+			trace.Message = strings.TrimSpace(exception[2])
+			break
+		}
+	}
+
+	for i := len(frames) - 1; i >= 0; i-- {
+		trace.Frames = append(trace.Frames, frames[i])
+	}
+	return trace
+}
+
+func isPythonInApp(file string) bool {
+	return !strings.Contains(file, "site-packages") &&
+		!strings.Contains(file, "dist-packages") &&
+		!strings.Contains(file, "/lib/python") &&
+		!strings.HasPrefix(file, "<frozen ")
+}
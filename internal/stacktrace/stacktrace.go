@@ -0,0 +1,73 @@
+// Package stacktrace detects stack traces in log messages and parses them
+// into an exception and its frames.
+package stacktrace
+
+import (
+	"strings"
+)
+
+// maxFrames bounds the frames kept from very deep stacks.
+const maxFrames = 100
+
+type Language string
+
+const (
+	Go         Language = "go"
+	Java       Language = "java"
+	Python     Language = "python"
+	JavaScript Language = "javascript"
+	DotNet     Language = "dotnet"
+)
+
+type Frame struct {
+	File     string
+	Function string
+	Line     int
+	Column   int
+	// InApp is false for frames of the runtime, the standard library or
+	// third party dependencies.
+	InApp bool
+}
+
+type Trace struct {
+	Language      Language
+	ExceptionType string
+	Message       string
+	Frames        []Frame
+}
+
+// Parse detects the language of a stack trace and parses it. It returns
+// false when the text does not contain a stack trace.
+func Parse(text string) (*Trace, bool) {
+	if !strings.Contains(text, "\n") {
+		return nil, false
+	}
+
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for _, parse := range []func([]string) *Trace{
+		parsePython,
+		parseGo,
+		parseDotNet,
+		parseJava,
+		parseJavaScript,
+	} {
+		trace := parse(lines)
+		if trace != nil && len(trace.Frames) > 0 {
+			if len(trace.Frames) > maxFrames {
+				trace.Frames = trace.Frames[:maxFrames]
+			}
+			return trace, true
+		}
+	}
+
+	return nil, false
+}
+
+func hasAnyPrefix(value string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
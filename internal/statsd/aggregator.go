@@ -0,0 +1,131 @@
+package statsd
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"monitoring/internal/domain"
+)
+
+const (
+	// maxSeries bounds the series added up between two flushes.
+	maxSeries = 100000
+	// gaugeRetention is how long the value of a gauge that is not updated is
+	// kept for the delta gauges that change it.
+	gaugeRetention = time.Hour
+)
+
+var kinds = map[Type]domain.MetricKind{
+	Counter:      domain.MetricCounter,
+	Gauge:        domain.MetricGauge,
+	Timer:        domain.MetricHistogram,
+	Histogram:    domain.MetricHistogram,
+	Distribution: domain.MetricHistogram,
+	Set:          domain.MetricGauge,
+}
+
+type seriesKey struct {
+	appKey string
+	series string
+}
+
+type aggregate struct {
+	sample       domain.MetricSample
+	distribution domain.Distribution
+	members      map[string]bool
+}
+
+type gauge struct {
+	value     float64
+	updatedAt time.Time
+}
+
+// Aggregator adds up the lines of each app key between flushes: counters
+// are summed, gauges keep their latest value, timers and histograms observe
+// theirs, and sets count their distinct members as a gauge.
+type Aggregator struct {
+	mu     sync.Mutex
+	series map[seriesKey]*aggregate
+	gauges map[seriesKey]gauge
+}
+
+func NewAggregator() *Aggregator {
+	return &Aggregator{series: map[seriesKey]*aggregate{}, gauges: map[seriesKey]gauge{}}
+}
+
+// Add adds up a line with the others of its series.
+func (a *Aggregator) Add(line Line) error {
+	kind := kinds[line.Type]
+	sample, err := domain.NewMetricSample(line.Name, kind, line.Labels, time.Time{})
+	if err != nil {
+		return err
+	}
+	key := seriesKey{appKey: line.AppKey, series: sample.Series()}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	series, ok := a.series[key]
+	if !ok {
+		if len(a.series) >= maxSeries {
+			return fmt.Errorf("%w: more than %d series since the last flush", ErrInvalidLine, maxSeries)
+		}
+		series = &aggregate{sample: *sample}
+		a.series[key] = series
+	}
+	if series.sample.Kind != kind {
+		return fmt.Errorf("%w: %s is a %s", ErrInvalidLine, series.sample.Series(), series.sample.Kind)
+	}
+
+	switch line.Type {
+	case Counter:
+		series.sample.Value += line.Value / line.SampleRate
+	case Gauge:
+		value := line.Value
+		if line.Delta {
+			value += a.gauges[key].value
+		}
+		if _, ok := a.gauges[key]; ok || len(a.gauges) < maxSeries {
+			a.gauges[key] = gauge{value: value, updatedAt: time.Now()}
+		}
+		series.sample.Value = value
+	case Timer, Histogram, Distribution:
+		series.distribution.AddN(line.Value, int64(math.Round(1/line.SampleRate)))
+	case Set:
+		if series.members == nil {
+			series.members = map[string]bool{}
+		}
+		series.members[line.Member] = true
+		series.sample.Value = float64(len(series.members))
+	}
+	return nil
+}
+
+// Flush returns the samples of the series added up since the previous
+// flush, at the time, by app key.
+func (a *Aggregator) Flush(at time.Time) map[string][]domain.MetricSample {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	samples := map[string][]domain.MetricSample{}
+	for key, series := range a.series {
+		sample := series.sample
+		sample.Timestamp = at.UTC()
+		if sample.Kind == domain.MetricHistogram {
+			distribution := series.distribution
+			sample.Distribution = &distribution
+		}
+		samples[key.appKey] = append(samples[key.appKey], sample)
+	}
+	a.series = map[seriesKey]*aggregate{}
+
+	for key, gauge := range a.gauges {
+		if at.Sub(gauge.updatedAt) > gaugeRetention {
+			delete(a.gauges, key)
+		}
+	}
+
+	return samples
+}
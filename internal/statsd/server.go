@@ -0,0 +1,113 @@
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPacketSize is the largest UDP packet.
+const maxPacketSize = 65535
+
+// Server receives lines over UDP and ingests their samples every flush
+// interval.
+type Server struct {
+	addr          string
+	flushInterval time.Duration
+	ingest        Ingest
+	aggregator    *Aggregator
+
+	mu sync.Mutex
+	// dropped counts the lines dropped since the last flush, lastErr being
+	// why the last one was.
+	dropped int
+	lastErr error
+}
+
+func NewServer(addr string, flushInterval time.Duration, ingest Ingest) *Server {
+	return &Server{addr: addr, flushInterval: flushInterval, ingest: ingest, aggregator: NewAggregator()}
+}
+
+// ListenAndServe receives lines until the context is done. The lines without
+// an app key are dropped.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	conn, err := net.ListenPacket("udp", s.addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	go s.flushEvery(ctx)
+
+	packet := make([]byte, maxPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(packet)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		s.receive(string(packet[:n]))
+	}
+}
+
+func (s *Server) receive(packet string) {
+	for _, text := range strings.Split(packet, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+
+		line, err := ParseLine(text)
+		if err == nil && line.AppKey == "" {
+			err = fmt.Errorf("%w: %q has no %s tag", ErrInvalidLine, text, AppKeyTag)
+		}
+		if err == nil {
+			err = s.aggregator.Add(line)
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.dropped++
+			s.lastErr = err
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Server) flushEvery(ctx context.Context) {
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case at := <-ticker.C:
+			s.flush(ctx, at)
+		}
+	}
+}
+
+// flush ingests the samples of each app key, the lines dropped being logged
+// once per flush.
+func (s *Server) flush(ctx context.Context, at time.Time) {
+	s.mu.Lock()
+	dropped, lastErr := s.dropped, s.lastErr
+	s.dropped, s.lastErr = 0, nil
+	s.mu.Unlock()
+	if dropped > 0 {
+		log.Printf("statsd: dropped %d lines, the last one: %v", dropped, lastErr)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.flushInterval)
+	defer cancel()
+	for appKey, samples := range s.aggregator.Flush(at) {
+		s.ingest(ctx, appKey, samples)
+	}
+}
@@ -0,0 +1,108 @@
+// Package statsd receives StatsD lines over UDP, with the tags of DogStatsD,
+// and adds them up into samples between flushes.
+package statsd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"monitoring/internal/domain"
+)
+
+var (
+	ErrInvalidLine = errors.New("invalid statsd line")
+)
+
+// AppKeyTag is the tag of the lines that holds the app key, which is not a
+// label.
+const AppKeyTag = "appKey"
+
+// Type is the type of a line.
+type Type string
+
+const (
+	Counter   Type = "c"
+	Gauge     Type = "g"
+	Timer     Type = "ms"
+	Histogram Type = "h"
+	// Distribution is the histogram of DogStatsD.
+	Distribution Type = "d"
+	Set          Type = "s"
+)
+
+// Line is a line like requests:1|c|@0.5|#appKey:secret,method:GET.
+type Line struct {
+	Name  string
+	Type  Type
+	Value float64
+	// Delta gauges change the gauge by their value, written with a sign.
+	Delta bool
+	// Member is the value of a set.
+	Member string
+	// SampleRate is the fraction of the events the client sent, so each
+	// line counts for 1/SampleRate of them.
+	SampleRate float64
+	AppKey     string
+	Labels     map[string]string
+}
+
+// Ingest receives the samples of an app key.
+type Ingest func(ctx context.Context, appKey string, samples []domain.MetricSample)
+
+// ParseLine parses a line. Tags without a value are labels with an empty
+// value.
+func ParseLine(text string) (Line, error) {
+	name, rest, ok := strings.Cut(text, ":")
+	if !ok || name == "" {
+		return Line{}, fmt.Errorf("%w: %q has no name", ErrInvalidLine, text)
+	}
+
+	parts := strings.Split(rest, "|")
+	if len(parts) < 2 {
+		return Line{}, fmt.Errorf("%w: %q has no type", ErrInvalidLine, text)
+	}
+
+	line := Line{Name: name, Type: Type(parts[1]), SampleRate: 1, Labels: map[string]string{}}
+	value := parts[0]
+	switch line.Type {
+	case Set:
+		line.Member = value
+	case Counter, Gauge, Timer, Histogram, Distribution:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return Line{}, fmt.Errorf("%w: %q has an invalid value", ErrInvalidLine, text)
+		}
+		line.Value = number
+		line.Delta = line.Type == Gauge && (strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"))
+	default:
+		return Line{}, fmt.Errorf("%w: %q has an unknown type", ErrInvalidLine, text)
+	}
+
+	for _, part := range parts[2:] {
+		switch {
+		case strings.HasPrefix(part, "@"):
+			rate, err := strconv.ParseFloat(part[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return Line{}, fmt.Errorf("%w: %q has an invalid sample rate", ErrInvalidLine, text)
+			}
+			line.SampleRate = rate
+		case strings.HasPrefix(part, "#"):
+			for _, tag := range strings.Split(part[1:], ",") {
+				key, value, _ := strings.Cut(tag, ":")
+				if key == AppKeyTag {
+					line.AppKey = value
+					continue
+				}
+				if key != "" {
+					line.Labels[domain.SanitizeMetricLabel(key)] = value
+				}
+			}
+		}
+	}
+
+	return line, nil
+}
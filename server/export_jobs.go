@@ -0,0 +1,37 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// FailAbandonedExportJobs marks the export jobs whose server stopped writing
+// them as failed, at start and every lease until the context is done, so
+// they can be deleted.
+func FailAbandonedExportJobs(ctx context.Context, db *mongo.Database) {
+	script := scripts.NewFailAbandonedExportJobsScript(
+		persistence.NewExportJobRepo(db),
+		persistence.NewExportFileRepo(db),
+	)
+
+	ticker := time.NewTicker(domain.ExportJobLease)
+	defer ticker.Stop()
+	for {
+		if _, err := script.Exec(ctx, scripts.FailAbandonedExportJobsReq{}); err != nil {
+			log.Printf("failing abandoned export jobs: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
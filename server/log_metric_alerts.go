@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/config"
+	"monitoring/internal/mail"
+	"monitoring/internal/notify"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+)
+
+// EvaluateLogMetricAlerts evaluates the alerts of the log metrics every
+// interval until the context is done, so they fire and resolve when logs
+// stop coming too.
+func EvaluateLogMetricAlerts(ctx context.Context, cfg config.Config, db *mongo.Database) {
+	script := scripts.NewEvaluateLogMetricAlertsScript(
+		persistence.NewLogMetricRepo(db),
+		persistence.NewLogMetricRollupRepo(db),
+		persistence.NewLogMetricAlertStateRepo(db),
+		persistence.NewNotificationChannelRepo(db),
+		notify.NewNotifier(mail.NewMailSender(cfg.MailFromEmail, cfg.MailAppPassword)),
+	)
+
+	ticker := time.NewTicker(scripts.LogMetricAlertEvaluationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		evaluateCtx, cancel := context.WithTimeout(ctx, scripts.LogMetricAlertEvaluationInterval)
+		if _, err := script.Exec(evaluateCtx, scripts.EvaluateLogMetricAlertsReq{}); err != nil {
+			log.Printf("evaluating log metric alerts: %v", err)
+		}
+		cancel()
+	}
+}
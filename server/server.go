@@ -15,8 +15,14 @@ import (
 	"golang.org/x/oauth2/google"
 
 	"monitoring/config"
+	"monitoring/internal/broker"
+	"monitoring/internal/domain"
 	"monitoring/internal/handlers"
+	"monitoring/internal/llm"
+	"monitoring/internal/mail"
 	"monitoring/internal/middlewares"
+	"monitoring/internal/notify"
+	"monitoring/internal/persistence"
 )
 
 //go:embed static
@@ -42,6 +48,10 @@ func New(cfg config.Config, db *mongo.Database) *gin.Engine {
 		Endpoint:     google.Endpoint,
 	}
 
+	logBroker := newLogBroker(cfg, db)
+	queryGenerator := llm.NewOpenAIQueryGenerator(cfg.OpenAIModel)
+	notifier := notify.NewNotifier(mail.NewMailSender(cfg.MailFromEmail, cfg.MailAppPassword))
+
 	backoffice := router.Group("/api/v1/backoffice")
 	{
 		backoffice.POST("/register", handlers.Register(db, cfg))
@@ -59,9 +69,61 @@ func New(cfg config.Config, db *mongo.Database) *gin.Engine {
 			backoffice.POST("/apps", handlers.CreateApp(db))
 			backoffice.PATCH("/apps/:appID", handlers.UpdateApp(db))
 			backoffice.DELETE("/apps/:appID", handlers.DeleteApp(db))
-			backoffice.GET("/logs", handlers.SearchLogs(db))
-			backoffice.GET("/dashboard/overview", handlers.GetDashboardOverview(db))
+			backoffice.POST("/apps/:appID/signing-secret", handlers.GenerateAppSigningSecret(db))
+			backoffice.GET("/apps/:appID/keys", handlers.ListAppKeys(db))
+			backoffice.POST("/apps/:appID/keys", handlers.CreateAppKey(db))
+			backoffice.POST("/apps/:appID/keys/:keyID/rotate", handlers.RotateAppKey(db))
+			backoffice.DELETE("/apps/:appID/keys/:keyID", handlers.RevokeAppKey(db))
+			backoffice.GET("/apps/:appID/releases/:release/source-maps", handlers.ListSourceMaps(db))
+			backoffice.POST("/apps/:appID/releases/:release/source-maps", handlers.UploadSourceMap(db))
+			backoffice.DELETE("/apps/:appID/source-maps/:sourceMapID", handlers.DeleteSourceMap(db))
+			backoffice.GET("/logs", handlers.SearchLogs(db, cfg.QueryLimits))
+			backoffice.GET("/logs/export", handlers.ExportLogs(db, cfg.QueryLimits))
+			backoffice.GET("/logs/tail", handlers.TailLogs(db, logBroker))
+			backoffice.GET("/logs/histogram", handlers.HistogramLogs(db, cfg.QueryLimits))
+			backoffice.POST("/logs/aggregate", handlers.AggregateLogs(db, cfg.QueryLimits))
+			backoffice.POST("/logs/compare", handlers.CompareLogs(db, cfg.QueryLimits))
+			backoffice.GET("/logs/explain", handlers.ExplainLogs(db, cfg.QueryLimits))
+			backoffice.POST("/logs/ask", handlers.AskLogs(db, queryGenerator, cfg.QueryLimits))
+			backoffice.GET("/logs/patterns", handlers.ListLogPatterns(db, cfg.QueryLimits))
+			backoffice.GET("/logs/patterns/:patternID", handlers.GetLogPattern(db))
+			backoffice.GET("/logs/fields/values", handlers.GetLogFieldValues(db, cfg.QueryLimits))
+			backoffice.GET("/logs/:logID", handlers.GetLog(db))
+			backoffice.GET("/logs/:logID/context", handlers.GetLogContext(db))
+			backoffice.GET("/exports", handlers.ListExportJobs(db))
+			backoffice.POST("/exports", handlers.CreateExportJob(db, cfg))
+			backoffice.GET("/exports/:exportID", handlers.GetExportJob(db))
+			backoffice.GET("/exports/:exportID/download", handlers.DownloadExportJob(db))
+			backoffice.DELETE("/exports/:exportID", handlers.DeleteExportJob(db))
+			backoffice.GET("/issues", handlers.ListIssues(db))
+			backoffice.GET("/issues/:issueID", handlers.GetIssue(db))
+			backoffice.PUT("/issues/:issueID/status", handlers.ChangeIssueStatus(db))
+			backoffice.POST("/issues/:issueID/merge", handlers.MergeIssues(db))
+			backoffice.GET("/dashboard/overview", handlers.GetDashboardOverview(db, cfg.QueryLimits))
 			backoffice.GET("/logs/schema", handlers.GetLogsSchema(db))
+			backoffice.GET("/logs/schema/drift", handlers.ListSchemaDriftEvents(db))
+			backoffice.GET("/saved-searches", handlers.ListSavedSearches(db))
+			backoffice.POST("/saved-searches", handlers.CreateSavedSearch(db))
+			backoffice.GET("/saved-searches/:searchID", handlers.GetSavedSearch(db))
+			backoffice.PATCH("/saved-searches/:searchID", handlers.UpdateSavedSearch(db))
+			backoffice.DELETE("/saved-searches/:searchID", handlers.DeleteSavedSearch(db))
+			backoffice.GET("/query-history", handlers.ListQueryHistory(db))
+			backoffice.DELETE("/query-history", handlers.DeleteQueryHistory(db))
+			backoffice.DELETE("/query-history/:entryID", handlers.DeleteQueryHistory(db))
+			backoffice.GET("/query-policy", handlers.GetQueryPolicy(db, cfg.QueryLimits))
+			backoffice.PUT("/query-policy", handlers.UpdateQueryPolicy(db))
+			backoffice.GET("/log-metrics", handlers.ListLogMetrics(db))
+			backoffice.POST("/log-metrics", handlers.CreateLogMetric(db))
+			backoffice.GET("/log-metrics/:metricID", handlers.GetLogMetric(db))
+			backoffice.GET("/log-metrics/:metricID/series", handlers.GetLogMetricSeries(db))
+			backoffice.PATCH("/log-metrics/:metricID", handlers.UpdateLogMetric(db))
+			backoffice.DELETE("/log-metrics/:metricID", handlers.DeleteLogMetric(db))
+			backoffice.GET("/metrics", handlers.ListMetrics(db))
+			backoffice.GET("/metrics/series", handlers.GetMetricSeries(db))
+			backoffice.GET("/notification-channels", handlers.ListNotificationChannels(db))
+			backoffice.POST("/notification-channels", handlers.CreateNotificationChannel(db))
+			backoffice.PATCH("/notification-channels/:channelID", handlers.UpdateNotificationChannel(db))
+			backoffice.DELETE("/notification-channels/:channelID", handlers.DeleteNotificationChannel(db))
 			backoffice.PATCH("/users/me", handlers.UpdateUser(db))
 			backoffice.PUT("/users/me/password", handlers.UpdateUserPassword(db))
 			backoffice.POST("/users", handlers.CreateNoRootUser(db, cfg))
@@ -72,7 +134,21 @@ func New(cfg config.Config, db *mongo.Database) *gin.Engine {
 
 	appsGroup := router.Group("/api/v1/apps")
 	{
-		appsGroup.POST("/logs", handlers.ReceiveLogs(db))
+		appsGroup.POST("/logs", handlers.ReceiveLogs(db, logBroker, notifier))
+		appsGroup.GET("/logs", handlers.SearchAppLogs(db, cfg.QueryLimits))
+		appsGroup.POST("/metrics/otlp", handlers.ReceiveOTLPMetrics(db))
+		appsGroup.POST("/metrics/prometheus", handlers.ReceivePrometheusMetrics(db))
+	}
+
+	browserGroup := router.Group("/api/v1/browser")
+	{
+		browserGroup.POST("/errors", handlers.ReceiveBrowserErrors(db, logBroker, notifier))
+	}
+
+	cronGroup := router.Group("/api/v1/cron")
+	cronGroup.Use(middlewares.HasCronSecret(cfg.CronSecret))
+	{
+		cronGroup.GET("/log-metric-alerts", handlers.EvaluateLogMetricAlerts(db, notifier))
 	}
 
 	subFS, err := fs.Sub(staticFiles, "static")
@@ -85,3 +161,14 @@ func New(cfg config.Config, db *mongo.Database) *gin.Engine {
 
 	return router
 }
+
+// memoryLogBrokerCapacity is how many logs a live tail can fall behind before
+// missing some.
+const memoryLogBrokerCapacity = 10000
+
+func newLogBroker(cfg config.Config, db *mongo.Database) domain.LogBroker {
+	if cfg.LogBroker == "mongo" {
+		return persistence.NewLogChangeStreamBroker(db)
+	}
+	return broker.NewMemoryLogBroker(memoryLogBrokerCapacity)
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"monitoring/config"
+	"monitoring/internal/domain"
+	"monitoring/internal/persistence"
+	"monitoring/internal/scripts"
+	"monitoring/internal/statsd"
+)
+
+// statsdFlushInterval is how often the StatsD lines received are added up
+// into samples.
+const statsdFlushInterval = 10 * time.Second
+
+// ListenStatsD receives StatsD metrics on the address of the config until
+// the context is done.
+func ListenStatsD(ctx context.Context, cfg config.Config, db *mongo.Database) error {
+	script := scripts.NewReceiveStatsDMetricsScript(
+		persistence.NewAppRepo(db),
+		persistence.NewAppKeyRepo(db),
+		persistence.NewMetricRepo(db),
+		persistence.NewMetricSeriesRepo(db),
+	)
+	ingest := func(ctx context.Context, appKey string, samples []domain.MetricSample) {
+		resp, err := script.Exec(ctx, scripts.ReceiveStatsDMetricsReq{AppKey: appKey, Samples: samples})
+		if err != nil {
+			log.Printf("statsd: ingesting %d samples: %v", len(samples), err)
+			return
+		}
+		if resp.Rejected > 0 {
+			log.Printf("statsd: rejected %d samples: %v", resp.Rejected, resp.Errors)
+		}
+	}
+
+	log.Printf("statsd: listening on %s", cfg.StatsDAddr)
+	return statsd.NewServer(cfg.StatsDAddr, statsdFlushInterval, ingest).ListenAndServe(ctx)
+}